@@ -53,11 +53,13 @@ func main() {
 		panic(fmt.Sprintf("failed to init docs: %v", err))
 	}
 
-	driveFileService := service.NewDriveFileService(driveRepository, docsRepository)
+	bandRepository := repository.NewBandRepository(mongoClient)
+	driveFileService := service.NewDriveFileService(driveRepository, docsRepository, bandRepository)
 	songRepository := repository.NewSongRepository(mongoClient)
 	voiceRepository := repository.NewVoiceRepository(mongoClient)
-	bandRepository := repository.NewBandRepository(mongoClient)
-	songService := service.NewSongService(songRepository, voiceRepository, bandRepository, driveRepository, driveFileService)
+	eventRepository := repository.NewEventRepository(mongoClient)
+	songSearchIndexRepository := repository.NewSongSearchIndexRepository(mongoClient)
+	songService := service.NewSongService(songRepository, voiceRepository, bandRepository, eventRepository, songSearchIndexRepository, driveRepository, driveFileService)
 
 	songs, err := songService.FindAll()
 	if err != nil {
@@ -30,15 +30,18 @@ import (
 )
 
 type BotController struct {
-	UserService        *service.UserService
-	DriveFileService   *service.DriveFileService
-	SongService        *service.SongService
-	VoiceService       *service.VoiceService
-	BandService        *service.BandService
-	MembershipService  *service.MembershipService
-	EventService       *service.EventService
-	RoleService        *service.RoleService
-	JoinRequestService *service.JoinRequestService
+	UserService           *service.UserService
+	DriveFileService      *service.DriveFileService
+	SongService           *service.SongService
+	VoiceService          *service.VoiceService
+	BandService           *service.BandService
+	MembershipService     *service.MembershipService
+	EventService          *service.EventService
+	RoleService           *service.RoleService
+	JoinRequestService    *service.JoinRequestService
+	SongImportService     *service.SongImportService
+	AudioJobService       *service.AudioJobService
+	ProcessedAudioService *service.ProcessedAudioService
 	// OldHandler        *myhandlers.Handler
 }
 
@@ -58,6 +61,10 @@ func (c *BotController) ChooseHandlerOrSearch(bot *gotgbot.Bot, ctx *ext.Context
 		return c.searchSetlist(user.State.Index)(bot, ctx)
 	case state.SongVoices_CreateVoice:
 		return c.SongVoices_CreateVoice(user.State.Index)(bot, ctx)
+	case state.VoiceSegmentCreate:
+		return c.VoiceSegmentCreate(user.State.Index)(bot, ctx)
+	case state.SongVoiceEdit:
+		return c.SongVoiceEdit(user.State.Index)(bot, ctx)
 	case state.RoleCreate_ChoosePosition:
 		return c.RoleCreate_ChoosePosition(bot, ctx)
 	}
@@ -151,6 +158,15 @@ func (c *BotController) UpdateUser(bot *gotgbot.Bot, ctx *ext.Context) error {
 	return err
 }
 
+// answerOutdatedButton answers the callback which payload is malformed, e.g. of the button made by the older version.
+func answerOutdatedButton(bot *gotgbot.Bot, ctx *ext.Context) error {
+	_, err := ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text:      txt.Get("text.outdatedButton", ctx.EffectiveUser.LanguageCode),
+		ShowAlert: true,
+	})
+	return err
+}
+
 func (c *BotController) search(index int) handlers.Response {
 	return func(bot *gotgbot.Bot, ctx *ext.Context) error {
 		user := ctx.Data["user"].(*entity.User)
@@ -194,6 +210,7 @@ func (c *BotController) search(index int) handlers.Response {
 					user.Cache.NextPageToken = nil
 				}
 
+				infoQuery := strings.TrimSpace(query)
 				query = util.CleanUpText(query)
 				songNames := util.SplitTextByNewlines(query)
 
@@ -217,7 +234,7 @@ func (c *BotController) search(index int) handlers.Response {
 				case txt.Get("button.globalSearch", ctx.EffectiveUser.LanguageCode):
 					driveFiles, nextPageToken, err = c.DriveFileService.FindSomeByFullTextAndFolderID(query, []string{}, user.Cache.NextPageToken.GetValue())
 				default:
-					driveFiles, nextPageToken, err = c.DriveFileService.FindSomeByFullTextAndFolderID(query, []string{user.Band.DriveFolderID, user.Band.ArchiveFolderID}, user.Cache.NextPageToken.GetValue())
+					driveFiles, nextPageToken, err = c.SongService.SearchDriveFiles(user.Band, query, user.Cache.NextPageToken.GetValue())
 				}
 				if err != nil {
 					return err
@@ -252,6 +269,15 @@ func (c *BotController) search(index int) handlers.Response {
 					}
 				}
 
+				// Authors, copyright, CCLI number and language are stored only in the database, so they are searched separately.
+				if user.Cache.Filter != txt.Get("button.globalSearch", ctx.EffectiveUser.LanguageCode) && user.Cache.NextPageToken.GetValue() == "" {
+					infoDriveFiles, infoErr := c.SongService.FindDriveFilesByInfo(user.BandID, infoQuery)
+					if infoErr != nil {
+						log.Error().Err(infoErr).Msg("Error finding songs by info")
+					}
+					driveFiles = mergeDriveFiles(infoDriveFiles, driveFiles)
+				}
+
 				user.Cache.NextPageToken = &entity.NextPageToken{
 					Value: nextPageToken,
 					Prev:  user.Cache.NextPageToken,
@@ -373,6 +399,20 @@ func (c *BotController) search(index int) handlers.Response {
 	}
 }
 
+// mergeDriveFiles appends files from second to first skipping nil files and files that are already in first.
+func mergeDriveFiles(first, second []*drive.File) []*drive.File {
+	merged := make([]*drive.File, 0, len(first)+len(second))
+	seen := make(map[string]bool)
+	for _, driveFile := range append(append([]*drive.File{}, first...), second...) {
+		if driveFile == nil || seen[driveFile.Id] {
+			continue
+		}
+		seen[driveFile.Id] = true
+		merged = append(merged, driveFile)
+	}
+	return merged
+}
+
 func (c *BotController) searchSetlist(index int) handlers.Response {
 	return func(bot *gotgbot.Bot, ctx *ext.Context) error {
 		user := ctx.Data["user"].(*entity.User)
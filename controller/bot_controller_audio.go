@@ -4,23 +4,27 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
-	"github.com/joeyave/scala-bot/controller/mysemaphore"
 	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/joeyave/scala-bot/service"
 	"github.com/joeyave/scala-bot/state"
 	"github.com/joeyave/scala-bot/txt"
 	"github.com/joeyave/scala-bot/util"
-	ffmpeg "github.com/u2takey/ffmpeg-go"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 var ffmpegAudioExt = "mp3"
@@ -37,6 +41,9 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 	// skipClipping := false
 	fine := false
 	more := false
+	tempo := 100
+	// asAudio sends the result of the voice message as the audio file.
+	asAudio := false
 	if ctx.CallbackQuery != nil {
 		payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
 		split := strings.Split(payload, ":")
@@ -59,11 +66,28 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 			}
 			more = _more
 		}
+
+		if len(split) > 3 {
+			_tempo, err := strconv.Atoi(split[3])
+			if err != nil {
+				return err
+			}
+			tempo = _tempo
+		}
+
+		if len(split) > 4 {
+			_asAudio, err := strconv.ParseBool(split[4])
+			if err != nil {
+				return err
+			}
+			asAudio = _asAudio
+		}
 	} else if ctx.EffectiveMessage.Audio != nil {
 		audio := ctx.EffectiveMessage.Audio
 		// todo: remove what's not needed.
 		user.CallbackCache.IsVoice = false
 		user.CallbackCache.AudioFileId = audio.FileId
+		user.CallbackCache.AudioFileUniqueId = audio.FileUniqueId
 		user.CallbackCache.AudioDuration = audio.Duration
 		user.CallbackCache.AudioPerformer = audio.Performer
 		user.CallbackCache.AudioTitle = audio.Title
@@ -83,15 +107,82 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 		// todo: remove what's not needed.
 		user.CallbackCache.IsVoice = true
 		user.CallbackCache.AudioFileId = voice.FileId
+		user.CallbackCache.AudioFileUniqueId = voice.FileUniqueId
 		user.CallbackCache.AudioDuration = voice.Duration
 		user.CallbackCache.AudioMimeType = voice.MimeType
 		user.CallbackCache.AudioFileSize = voice.FileSize
 	}
 
+	lang := ctx.EffectiveUser.LanguageCode
+
+	// The key and the tempo that aren't known yet are detected in the background and sent as a reply,
+	// so the settings chosen meanwhile aren't reset.
+	analysisText := ""
+	var voice *entity.Voice
+	var song *entity.Song
+	detect := false
+	if ctx.CallbackQuery == nil {
+		var analysis *service.AudioAnalysis
+		analysis, voice, song = c.knownAudioAnalysis(user)
+		detect = analysis == nil
+		if detect {
+			if song != nil && song.PDF.BPM != "" {
+				user.CallbackCache.AudioBPM = song.PDF.BPM
+			}
+		} else {
+			analysisText, semitones = c.applyAudioAnalysis(user, song, analysis, lang)
+		}
+	}
+
+	text, markup := transposeAudioMessage(user, lang, semitones, fine, more, tempo, asAudio, analysisText)
+
+	if ctx.CallbackQuery != nil {
+		// ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		//	ReplyMarkup:           *markup,
+		//	ParseMode:             "HTML",
+		//	LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+		//IsDisabled:
+		//	true,
+		// },
+		//})
+		_, _, err := ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+			ReplyMarkup: *markup,
+		})
+		if err != nil {
+			return err
+		}
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	} else {
+		msg, err := ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ReplyMarkup: markup,
+			ParseMode:   "HTML",
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+				IsDisabled: true,
+			},
+			ReplyParameters: &gotgbot.ReplyParameters{
+				MessageId: ctx.EffectiveMessage.MessageId,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if detect {
+			go c.detectAudioForTransposition(bot, msg, user.CallbackCache.AudioFileId, voice, song, lang)
+		}
+	}
+
+	return nil
+}
+
+// transposeAudioMessage builds the text and the keyboard of the transposition settings.
+func transposeAudioMessage(user *entity.User, lang string, semitones int, fine, more bool, tempo int, asAudio bool, analysisText string) (string, *gotgbot.InlineKeyboardMarkup) {
+	bpm, hasBPM := service.ParseBPM(user.CallbackCache.AudioBPM)
+
 	markup := &gotgbot.InlineKeyboardMarkup{}
 
-	buttonText1 := txt.Get("button.fast", ctx.EffectiveUser.LanguageCode)
-	buttonText2 := txt.Get("button.fine", ctx.EffectiveUser.LanguageCode)
+	buttonText1 := txt.Get("button.fast", lang)
+	buttonText2 := txt.Get("button.fine", lang)
 
 	if !fine {
 		buttonText1 = fmt.Sprintf("〔%s〕", buttonText1)
@@ -100,10 +191,23 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 	}
 
 	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
-		{Text: buttonText1, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t", semitones, false))},
-		{Text: buttonText2, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t", semitones, true))},
+		{Text: buttonText1, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", semitones, false, false, tempo, asAudio))},
+		{Text: buttonText2, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", semitones, true, false, tempo, asAudio))},
 	})
 
+	tempoButtons := make([]gotgbot.InlineKeyboardButton, 0, len(service.AudioTempoPresets))
+	for _, preset := range service.AudioTempoPresets {
+		buttonText := fmt.Sprintf("%d%%", preset)
+		if hasBPM {
+			buttonText = fmt.Sprintf("%d BPM", entity.TempoBPM(bpm, preset))
+		}
+		if tempo == preset {
+			buttonText = fmt.Sprintf("〔%s〕", buttonText)
+		}
+		tempoButtons = append(tempoButtons, gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", semitones, fine, more, preset, asAudio))})
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, tempoButtons)
+
 	limit := 4
 	if more {
 		limit = 12
@@ -116,7 +220,7 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 		if semitones == i-1 {
 			buttonText = fmt.Sprintf("〔%s〕", buttonText)
 		}
-		markup.InlineKeyboard[len(markup.InlineKeyboard)-1] = append(markup.InlineKeyboard[len(markup.InlineKeyboard)-1], gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t", i-1, fine, more))})
+		markup.InlineKeyboard[len(markup.InlineKeyboard)-1] = append(markup.InlineKeyboard[len(markup.InlineKeyboard)-1], gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", i-1, fine, more, tempo, asAudio))})
 	}
 	for i := range limit {
 		if i%4 == 0 || i == 0 {
@@ -127,210 +231,817 @@ func (c *BotController) TransposeAudio_AskForSemitonesNumber(bot *gotgbot.Bot, c
 		if semitones == i+1 {
 			buttonText = fmt.Sprintf("〔%s〕", buttonText)
 		}
-		markup.InlineKeyboard[len(markup.InlineKeyboard)-1] = append(markup.InlineKeyboard[len(markup.InlineKeyboard)-1], gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t", i+1, fine, more))})
+		markup.InlineKeyboard[len(markup.InlineKeyboard)-1] = append(markup.InlineKeyboard[len(markup.InlineKeyboard)-1], gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", i+1, fine, more, tempo, asAudio))})
 	}
 	buttonText := "▿"
 	if more {
 		buttonText = "△"
 	}
-	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t", semitones, fine, !more))}})
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", semitones, fine, !more, tempo, asAudio))}})
 
-	s := strconv.Itoa(semitones)
-	if !strings.HasPrefix(s, "-") {
-		s = "+" + s
+	if user.CallbackCache.IsVoice {
+		buttonText := txt.Get("button.sendAsAudioFile", lang)
+		if asAudio {
+			buttonText = fmt.Sprintf("〔%s〕", buttonText)
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.TransposeAudio_AskForSemitonesNumber, fmt.Sprintf("%d:%t:%t:%d:%t", semitones, fine, more, tempo, !asAudio))}})
 	}
 
-	text := user.CallbackCache.AddToText(txt.Get("text.sendSemitones", ctx.EffectiveUser.LanguageCode))
-	// text := user.CallbackCache.AddToText(txt.Get("text.sendSemitones", ctx.EffectiveUser.LanguageCode, user.CallbackCache.AudioFileName, s))
-
-	if ctx.CallbackQuery != nil {
-		if semitones != 0 {
-			markup.InlineKeyboard = append(markup.InlineKeyboard,
-				[]gotgbot.InlineKeyboardButton{
-					{Text: txt.Get("button.continue", ctx.EffectiveUser.LanguageCode, s), CallbackData: util.CallbackData(state.TransposeAudio, fmt.Sprintf("%d:%t", semitones, fine))},
-				})
+	// The continue button shows the changes, e.g. "+2, 90%", the tempo alone if the key isn't changed.
+	var changes []string
+	if semitones != 0 {
+		changes = append(changes, fmt.Sprintf("%+d", semitones))
+	}
+	if tempo != 100 {
+		if hasBPM {
+			changes = append(changes, fmt.Sprintf("%d BPM", entity.TempoBPM(bpm, tempo)))
+		} else {
+			changes = append(changes, fmt.Sprintf("%d%%", tempo))
 		}
+	}
+	continueText := txt.Get("button.continue", lang)
+	if len(changes) > 0 {
+		continueText = txt.Get("button.continueWithChanges", lang, strings.Join(changes, ", "))
+	}
 
-		// ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
-		//	ReplyMarkup:           *markup,
-		//	ParseMode:             "HTML",
-		//	LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
-		//IsDisabled:
-		//	true,
-		// },
-		//})
-		_, _, err := ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
-			ReplyMarkup: *markup,
-		})
+	text := txt.Get("text.sendSemitones", lang)
+	if analysisText != "" {
+		text += "\n\n" + analysisText
+	}
+	if hasBPM {
+		text += "\n\n" + txt.Get("text.sendTempoBPM", lang, user.CallbackCache.AudioBPM)
+	} else {
+		text += "\n\n" + txt.Get("text.sendTempo", lang)
+	}
+	text += "\n\n" + txt.Get("text.audioOutput", lang, audioOutputText(user.AudioOutput, lang))
+	text = user.CallbackCache.AddToText(text)
+	// text := user.CallbackCache.AddToText(txt.Get("text.sendSemitones", lang, user.CallbackCache.AudioFileName, s))
+
+	// Voice messages may be sent as the audio file without other changes.
+	if semitones != 0 || tempo != 100 || asAudio {
+		markup.InlineKeyboard = append(markup.InlineKeyboard,
+			[]gotgbot.InlineKeyboardButton{
+				{Text: continueText, CallbackData: util.CallbackData(state.TransposeAudio, fmt.Sprintf("%d:%t:%d:%t", semitones, fine, tempo, asAudio))},
+			})
+	}
+
+	return text, markup
+}
+
+// TransposeAudio puts transposition of the chosen audio to the job queue.
+// The message with the semitones keyboard becomes the progress message of the job.
+func (c *BotController) TransposeAudio(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	split := strings.Split(payload, ":")
+	semitones, err := strconv.Atoi(split[0])
+	if err != nil {
+		return err
+	}
+	fine, err := strconv.ParseBool(split[1])
+	if err != nil {
+		return err
+	}
+	tempo := 100
+	if len(split) > 2 {
+		tempo, err = strconv.Atoi(split[2])
 		if err != nil {
 			return err
 		}
-		_, _ = ctx.CallbackQuery.Answer(bot, nil)
-	} else {
-		_, err := ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
-			ReplyMarkup: markup,
-			ParseMode:   "HTML",
-			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
-				IsDisabled: true,
-			},
-			ReplyParameters: &gotgbot.ReplyParameters{
-				MessageId: ctx.EffectiveMessage.MessageId,
-			},
-		})
+	}
+	if !slices.Contains(service.AudioTempoPresets, tempo) {
+		return fmt.Errorf("%w: unsupported tempo %d%%", service.ErrInvalidOperation, tempo)
+	}
+	asAudio := false
+	if len(split) > 3 {
+		asAudio, err = strconv.ParseBool(split[3])
 		if err != nil {
 			return err
 		}
 	}
+	bpm, _ := service.ParseBPM(user.CallbackCache.AudioBPM)
 
-	return nil
-}
+	job := entity.AudioJob{
+		UserID:       user.ID,
+		BandID:       user.BandID,
+		ChatID:       ctx.EffectiveChat.Id,
+		MessageID:    ctx.EffectiveMessage.MessageId,
+		LanguageCode: lang,
+		Output:       user.AudioOutput,
+		Audio: entity.AudioJobFile{
+			FileID:       user.CallbackCache.AudioFileId,
+			FileUniqueID: user.CallbackCache.AudioFileUniqueId,
+			FileName:     user.CallbackCache.AudioFileName,
+			MimeType:     user.CallbackCache.AudioMimeType,
+			Title:        user.CallbackCache.AudioTitle,
+			Performer:    user.CallbackCache.AudioPerformer,
+			Duration:     user.CallbackCache.AudioDuration,
+			FileSize:     user.CallbackCache.AudioFileSize,
+			IsVoice:      user.CallbackCache.IsVoice,
+		},
+		Semitones:    semitones,
+		Fine:         fine,
+		Tempo:        tempo,
+		BPM:          bpm,
+		ConvertVoice: asAudio && user.CallbackCache.IsVoice,
+	}
+	// Voice messages have no file name.
+	if job.ConvertVoice && job.Audio.FileName == "" {
+		job.Audio.FileName = fmt.Sprintf("voice_%s.ogg", time.Now().Format("2006-01-02_15-04-05"))
+	}
 
-var sem = mysemaphore.NewWeighted(100)
+	// The same audio was already processed with these parameters, no need to wait in the queue.
+	if c.sendProcessedAudio(bot, &job) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		_, _ = ctx.EffectiveMessage.Delete(bot, nil)
+		return nil
+	}
 
-func (c *BotController) TransposeAudio(bot *gotgbot.Bot, ctx *ext.Context) error {
-	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	_, err = c.enqueueAudioJob(bot, ctx, job)
+	return err
+}
 
+// EventVoices lists voices of the event songs which key was changed for the event.
+func (c *BotController) EventVoices(bot *gotgbot.Bot, ctx *ext.Context) error {
 	user := ctx.Data["user"].(*entity.User)
-	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
-	split := strings.Split(payload, ":")
-	semitones := split[0]
-	fine, err := strconv.ParseBool(split[1])
+	lang := ctx.EffectiveUser.LanguageCode
+
+	eventID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
 	if err != nil {
 		return err
 	}
 
-	lang := ctx.EffectiveUser.LanguageCode
-	processingMsg, _, err := ctx.EffectiveMessage.EditText(bot, txt.Get("text.audioStarting", lang), &gotgbot.EditMessageTextOpts{})
+	event, err := c.EventService.FindOneByID(eventID)
 	if err != nil {
 		return err
 	}
 
-	ctxWithCancel, stopSendingQueueMessages := context.WithCancel(context.Background())
+	markup := gotgbot.InlineKeyboardMarkup{}
+	for _, song := range event.Songs {
+		override := event.GetSongOverride(song.ID)
+		if override == nil || override.EventKey == "" {
+			continue
+		}
 
-	go func(id int64, ctx context.Context) {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-		prevPos := 0
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				pos := sem.Position(id) + 1
-				if pos != prevPos {
-					_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioQueuePosition", lang, pos), nil)
-				}
-				prevPos = pos
+		for _, voice := range song.Voices {
+			key := voiceKey(voice, song)
+			if semitones, ok := service.SuggestSemitones(key, override.EventKey); !ok || semitones == 0 {
+				continue
 			}
+			buttonText := fmt.Sprintf("%s · %s (%s → %s)", song.PDF.Name, voice.Name, key, override.EventKey)
+			markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.VoiceTransposeToEventKey, event.ID.Hex()+":"+voice.ID.Hex())}})
 		}
-	}(ctx.EffectiveMessage.MessageId, ctxWithCancel)
+	}
 
-	weight := int64(1)
-	if !user.CallbackCache.IsVoice {
-		mb := user.CallbackCache.AudioFileSize / 1000000
-		coef := int64(2)
-		if fine {
-			coef = 5
-		}
-		weight = mb * coef
+	if len(markup.InlineKeyboard) == 0 {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.noEventKeyVoices", lang),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.EventCB, event.ID.Hex()+":init")}})
+
+	text := fmt.Sprintf("<b>%s</b>\n\n%s", event.Alias(lang), txt.Get("text.chooseVoiceToTranspose", lang))
+	text = user.CallbackCache.AddToText(text)
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode: "HTML",
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// VoiceTransposeToEventKey transposes the voice from the key of the song to the key of the song for the event.
+// The result is sent to the private chat with the user, so it works from inline messages too.
+func (c *BotController) VoiceTransposeToEventKey(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	split := strings.Split(util.ParseCallbackPayload(ctx.CallbackQuery.Data), ":")
+	if len(split) < 2 {
+		return answerOutdatedButton(bot, ctx)
+	}
+	eventID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+	voiceID, err := bson.ObjectIDFromHex(split[1])
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+	voice, err := c.VoiceService.FindOneByID(voiceID)
+	if err != nil {
+		return err
+	}
+	song, err := c.SongService.FindOneByID(voice.SongID)
+	if err != nil {
+		return err
 	}
 
-	converted, newFileBytes, err := c.transposeAudio(bot, ctx, stopSendingQueueMessages, sem, weight, user.CallbackCache.AudioMimeType, user.CallbackCache.AudioFileId, semitones, fine, processingMsg, lang)
+	targetKey := song.PDF.Key
+	if override := event.GetSongOverride(song.ID); override != nil && override.EventKey != "" {
+		targetKey = override.EventKey
+	}
+
+	semitones, ok := service.SuggestSemitones(voiceKey(voice, song), targetKey)
+	if !ok || semitones == 0 {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.voiceAlreadyInEventKey", lang, targetKey),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	audio, err := voiceAudioJobFile(bot, voice, song)
 	if err != nil {
-		_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioError", lang, err), nil)
+		return err
+	}
 
+	job := entity.AudioJob{
+		UserID:       user.ID,
+		BandID:       user.BandID,
+		ChatID:       ctx.EffectiveUser.Id,
+		LanguageCode: lang,
+		Output:       user.AudioOutput,
+		Audio:        audio,
+		Semitones:    semitones,
+		// The voices are listened to for practice, so the quality matters more than the speed.
+		Fine: true,
+	}
+
+	// The voice is cached by its file and the shift, so the members of the event get it instantly.
+	if c.sendProcessedAudio(bot, &job) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		return nil
+	}
+
+	msg, err := bot.SendMessage(job.ChatID, txt.Get("text.audioTransposingToKey", lang, voice.Name, targetKey), nil)
+	if err != nil {
 		return err
 	}
+	job.MessageID = msg.MessageId
 
-	_, _ = ctx.EffectiveChat.SendAction(bot, "upload_document", nil)
+	enqueued, err := c.enqueueAudioJob(bot, ctx, job)
+	if !enqueued {
+		_, _ = msg.Delete(bot, nil)
+	}
+	return err
+}
 
-	s := split[0]
-	if !strings.HasPrefix(s, "-") {
-		s = "+" + s
+// voiceKey returns the key detected from the voice recording, or the key of the song if it wasn't detected.
+func voiceKey(voice *entity.Voice, song *entity.Song) entity.Key {
+	if voice.DetectedKey != "" {
+		return voice.DetectedKey
 	}
+	return song.PDF.Key
+}
 
-	if user.CallbackCache.IsVoice {
+// enqueueAudioJob puts the job to the queue and answers the callback query.
+// Returns false if the user has too many active jobs, the user is alerted about it.
+func (c *BotController) enqueueAudioJob(bot *gotgbot.Bot, ctx *ext.Context, job entity.AudioJob) (bool, error) {
+	_, err := c.AudioJobService.Enqueue(job)
+	if errors.Is(err, service.ErrLimitExceeded) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.audioJobsLimit", job.LanguageCode, service.MaxActiveAudioJobsPerUser),
+			ShowAlert: true,
+		})
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	c.updateAudioQueuePositions(bot)
+	return true, nil
+}
+
+// voiceAudioJobFile describes the file of the song voice for the audio job.
+func voiceAudioJobFile(bot *gotgbot.Bot, voice *entity.Voice, song *entity.Song) (entity.AudioJobFile, error) {
+	fileID := voice.AudioFileID
+	if fileID == "" {
+		fileID = voice.FileID
+	}
+
+	f, err := bot.GetFile(fileID, nil)
+	if err != nil {
+		return entity.AudioJobFile{}, err
+	}
+
+	extension := filepath.Ext(f.FilePath)
+	mimeType := ""
+	switch strings.ToLower(extension) {
+	case ".m4a", ".mp4", ".aac":
+		// Such files are converted before the transposition.
+		mimeType = "audio/mp4"
+	}
+
+	return entity.AudioJobFile{
+		FileID:       fileID,
+		FileUniqueID: f.FileUniqueId,
+		FileName:     voice.Name + extension,
+		MimeType:     mimeType,
+		Title:        voice.Name,
+		Performer:    song.PDF.Name,
+		FileSize:     f.FileSize,
+	}, nil
+}
+
+// AudioJobCancel cancels the queued or running audio job from its progress message.
+func (c *BotController) AudioJobCancel(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	jobID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AudioJobService.Cancel(jobID, user.ID)
+	if errors.Is(err, service.ErrForbidden) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text: txt.Get("text.audioJobForbidden", lang),
+		})
+		return nil
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text: txt.Get("text.audioJobNotCancelable", lang),
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, txt.Get("text.audioCanceled", lang), nil)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	c.updateAudioQueuePositions(bot)
+	return nil
+}
+
+// RunAudioJobs starts processing of the audio job queue, including jobs that were interrupted by restart.
+func (c *BotController) RunAudioJobs(bot *gotgbot.Bot) {
+	err := c.AudioJobService.Run(func(ctx context.Context, job *entity.AudioJob) error {
+		return c.processAudioJob(ctx, bot, job)
+	}, func() {
+		c.updateAudioQueuePositions(bot)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to run audio jobs")
+	}
+}
+
+// updateAudioQueuePositions shows the queue position on progress messages of the queued jobs.
+func (c *BotController) updateAudioQueuePositions(bot *gotgbot.Bot) {
+	jobs, err := c.AudioJobService.FindManyQueued()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find queued audio jobs")
+		return
+	}
+
+	for i, job := range jobs {
+		editAudioJobMessage(context.Background(), bot, job, txt.Get("text.audioQueuePosition", job.LanguageCode, i+1))
+	}
+}
+
+func (c *BotController) processAudioJob(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob) error {
+	lang := job.LanguageCode
+
+	// The same job could be queued a few times before the first one was done.
+	if c.sendProcessedAudio(bot, job) {
+		_, _ = bot.DeleteMessage(job.ChatID, job.MessageID, nil)
+		return nil
+	}
+
+	converted, newFileBytes, err := transposeAudio(ctx, bot, job)
+	if err == nil && job.NeedsEncoding() {
+		newFileBytes, err = encodeAudio(ctx, bot, job, newFileBytes)
+		converted = true
+	}
+	if err != nil {
+		// Canceled jobs already have the message about it.
+		if ctx.Err() == nil {
+			_, _, _ = bot.EditMessageText(txt.Get("text.audioError", lang, err), &gotgbot.EditMessageTextOpts{
+				ChatId:    job.ChatID,
+				MessageId: job.MessageID,
+			})
+		}
+		return err
+	}
+
+	_, _ = bot.SendChatAction(job.ChatID, "upload_document", nil)
+
+	if job.SendsVoice() {
 		opts := &gotgbot.SendVoiceOpts{
-			Duration: user.CallbackCache.AudioDuration,
+			Duration: job.ResultDuration(),
 		}
-		_, err := bot.SendVoice(ctx.EffectiveChat.Id, gotgbot.InputFileByReader(user.CallbackCache.AudioFileName, bytes.NewReader(newFileBytes)), opts)
+		msg, err := bot.SendVoice(job.ChatID, gotgbot.InputFileByReader(job.Audio.FileName, bytes.NewReader(newFileBytes)), opts)
 		if err != nil {
 			return err
 		}
+		c.saveProcessedAudio(job, msg)
+	} else {
+		title, fileName := audioJobResultNames(job, converted)
+		file := gotgbot.InputFileByReader(fileName, bytes.NewReader(newFileBytes))
+
+		var msg *gotgbot.Message
+		if isAudioFileName(fileName) {
+			msg, err = bot.SendAudio(job.ChatID, file, &gotgbot.SendAudioOpts{
+				Caption:   audioJobCaption(job),
+				Duration:  job.ResultDuration(),
+				Performer: job.Audio.Performer,
+				Title:     title,
+			})
+		} else {
+			msg, err = bot.SendDocument(job.ChatID, file, &gotgbot.SendDocumentOpts{
+				Caption: audioJobCaption(job),
+			})
+		}
+		if err != nil {
+			return err
+		}
+		c.saveProcessedAudio(job, msg)
+	}
+
+	_, _ = bot.DeleteMessage(job.ChatID, job.MessageID, nil)
+
+	return nil
+}
+
+// sendProcessedAudio sends the cached result of the job if the same audio was already processed with the same parameters.
+// Returns false if there is no such result and the job has to be processed.
+func (c *BotController) sendProcessedAudio(bot *gotgbot.Bot, job *entity.AudioJob) bool {
+	audio, err := c.ProcessedAudioService.FindOneByJob(job)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Error().Err(err).Msg("failed to find processed audio")
+		}
+		return false
+	}
+
+	if audio.IsVoice {
+		_, err = bot.SendVoice(job.ChatID, gotgbot.InputFileByID(audio.FileID), &gotgbot.SendVoiceOpts{
+			Duration: job.ResultDuration(),
+		})
+	} else if audio.IsDocument {
+		_, err = bot.SendDocument(job.ChatID, gotgbot.InputFileByID(audio.FileID), &gotgbot.SendDocumentOpts{
+			Caption: audioJobCaption(job),
+		})
 	} else {
-		opts := &gotgbot.SendAudioOpts{
-			Duration:  user.CallbackCache.AudioDuration,
-			Performer: user.CallbackCache.AudioPerformer,
-			// Thumbnail: user.CallbackCache.AudioThumbFileId,
+		title, _ := audioJobResultNames(job, false)
+		_, err = bot.SendAudio(job.ChatID, gotgbot.InputFileByID(audio.FileID), &gotgbot.SendAudioOpts{
+			Caption:   audioJobCaption(job),
+			Duration:  job.ResultDuration(),
+			Performer: job.Audio.Performer,
+			Title:     title,
+		})
+	}
+	if err != nil {
+		// The file is not available anymore, process the job again.
+		log.Error().Err(err).Str("processedAudioID", audio.ID.Hex()).Msg("failed to send processed audio")
+		if err := c.ProcessedAudioService.DeleteOne(audio.ID); err != nil {
+			log.Error().Err(err).Str("processedAudioID", audio.ID.Hex()).Msg("failed to delete processed audio")
 		}
-		if user.CallbackCache.AudioTitle != "" {
-			opts.Title = fmt.Sprintf("%s (%s)", user.CallbackCache.AudioTitle, s)
+		return false
+	}
+
+	if err := c.ProcessedAudioService.Hit(audio.ID); err != nil {
+		log.Error().Err(err).Str("processedAudioID", audio.ID.Hex()).Msg("failed to count processed audio hit")
+	}
+	return true
+}
+
+// saveProcessedAudio caches the result sent in the message. Telegram may return the audio as the document.
+func (c *BotController) saveProcessedAudio(job *entity.AudioJob, msg *gotgbot.Message) {
+	var fileID string
+	var fileSize int64
+	isDocument := false
+	switch {
+	case msg.Voice != nil:
+		fileID, fileSize = msg.Voice.FileId, msg.Voice.FileSize
+	case msg.Audio != nil:
+		fileID, fileSize = msg.Audio.FileId, msg.Audio.FileSize
+	case msg.Document != nil:
+		fileID, fileSize, isDocument = msg.Document.FileId, msg.Document.FileSize, true
+	default:
+		return
+	}
+
+	_, err := c.ProcessedAudioService.SaveJobResult(job, fileID, fileSize, isDocument)
+	if err != nil {
+		log.Error().Err(err).Str("audioJobID", job.ID.Hex()).Msg("failed to save processed audio")
+	}
+}
+
+// isAudioFileName reports whether Telegram shows the file as audio. Other formats are sent as files.
+func isAudioFileName(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".mp3", ".m4a":
+		return true
+	default:
+		return false
+	}
+}
+
+// audioJobResultNames returns the title and the file name of the processed audio with the shift and the tempo in them.
+func audioJobResultNames(job *entity.AudioJob, converted bool) (string, string) {
+	var changes []string
+	// The shift is always shown for transpositions, mixes, parts and converted voice messages may be made without it.
+	if job.Semitones != 0 || (job.TempoPercent() == 100 && !job.IsMix() && job.Trim == nil && !job.ConvertVoice) {
+		semitones := strconv.Itoa(job.Semitones)
+		if !strings.HasPrefix(semitones, "-") {
+			semitones = "+" + semitones
+		}
+		changes = append(changes, semitones)
+	}
+	if job.TempoPercent() != 100 {
+		changes = append(changes, fmt.Sprintf("%d%%", job.TempoPercent()))
+		if bpm, ok := job.TargetBPM(); ok {
+			changes = append(changes, fmt.Sprintf("%d BPM", bpm))
 		}
+	}
+	s := ""
+	if len(changes) > 0 {
+		s = fmt.Sprintf(" (%s)", strings.Join(changes, ", "))
+	}
+
+	title := ""
+	if job.Audio.Title != "" {
+		title = job.Audio.Title + s
+	}
 
-		extension := filepath.Ext(user.CallbackCache.AudioFileName)
-		fileName := strings.TrimSuffix(user.CallbackCache.AudioFileName, extension)
-		if converted {
-			extension = "." + ffmpegAudioExt
+	extension := filepath.Ext(job.Audio.FileName)
+	fileName := strings.TrimSuffix(job.Audio.FileName, extension)
+	if converted {
+		extension = "." + job.Output.Format.Extension()
+	}
+
+	return title, fileName + s + extension
+}
+
+// knownAudioAnalysis returns the key and the tempo of the audio from the callback cache detected earlier
+// for the voice of the song, and the voice and the song themselves if there are such.
+// The analysis is nil if the key and the tempo have to be detected.
+func (c *BotController) knownAudioAnalysis(user *entity.User) (*service.AudioAnalysis, *entity.Voice, *entity.Song) {
+	voice, err := c.VoiceService.FindOneByAnyFileID(user.CallbackCache.AudioFileId)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	song, err := c.SongService.FindOneByID(voice.SongID)
+	if err != nil {
+		song = nil
+	}
+
+	if voice.DetectedKey == "" && voice.DetectedBPM == 0 {
+		return nil, voice, song
+	}
+	return &service.AudioAnalysis{Key: voice.DetectedKey, BPM: voice.DetectedBPM}, voice, song
+}
+
+// applyAudioAnalysis puts the BPM of the song or the detected one to the callback cache.
+// Returns the text about the detected key and tempo and the suggested shift to the key of the song.
+func (c *BotController) applyAudioAnalysis(user *entity.User, song *entity.Song, analysis *service.AudioAnalysis, lang string) (string, int) {
+	if song != nil && song.PDF.BPM != "" {
+		user.CallbackCache.AudioBPM = song.PDF.BPM
+	} else if analysis.BPM > 0 {
+		user.CallbackCache.AudioBPM = strconv.Itoa(analysis.BPM)
+	}
+
+	targetKey := entity.Key("")
+	if song != nil {
+		targetKey = c.songTargetKey(song)
+	}
+	return audioAnalysisText(analysis, targetKey, lang)
+}
+
+// detectAudioForTransposition detects the key and the tempo of the audio and shows them in a reply
+// to the transposition settings message. It's run in the background,
+// the download and the analysis take too long for the update handler.
+func (c *BotController) detectAudioForTransposition(bot *gotgbot.Bot, msg *gotgbot.Message, fileID string, voice *entity.Voice, song *entity.Song, lang string) {
+	reply, err := msg.Reply(bot, txt.Get("text.audioAnalyzing", lang), nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to send audio analysis message")
+		return
+	}
+
+	analysis := analyzeAudio(bot, fileID)
+	if analysis != nil && voice != nil {
+		c.saveVoiceAnalysis(voice.ID, analysis)
+	}
+
+	analysisText := ""
+	if analysis != nil {
+		targetKey := entity.Key("")
+		if song != nil {
+			targetKey = c.songTargetKey(song)
 		}
+		analysisText, _ = audioAnalysisText(analysis, targetKey, lang)
+	}
 
-		file := gotgbot.InputFileByReader(fmt.Sprintf("%s (%s)%s", fileName, s, extension), bytes.NewReader(newFileBytes))
+	if analysisText == "" {
+		_, err = reply.Delete(bot, nil)
+	} else {
+		_, _, err = reply.EditText(bot, analysisText, nil)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to show detected key and BPM")
+	}
+}
 
-		_, err = bot.SendAudio(ctx.EffectiveChat.Id, file, opts)
+// detectVoiceAnalysis detects the key and the tempo of the new voice in the background and sends them to the chat.
+func (c *BotController) detectVoiceAnalysis(bot *gotgbot.Bot, chatID int64, voice *entity.Voice, lang string) {
+	analysis := analyzeAudio(bot, voice.FileID)
+	if analysis == nil {
+		return
+	}
+	c.saveVoiceAnalysis(voice.ID, analysis)
+
+	targetKey := entity.Key("")
+	if song, err := c.SongService.FindOneByID(voice.SongID); err == nil {
+		targetKey = c.songTargetKey(song)
+	}
+	if analysisText, _ := audioAnalysisText(analysis, targetKey, lang); analysisText != "" {
+		_, err := bot.SendMessage(chatID, analysisText, nil)
 		if err != nil {
-			return err
+			log.Error().Err(err).Msg("failed to send detected key and BPM")
 		}
 	}
+}
 
-	_, _ = processingMsg.Delete(bot, nil)
+func (c *BotController) saveVoiceAnalysis(voiceID bson.ObjectID, analysis *service.AudioAnalysis) {
+	if err := c.VoiceService.SaveAnalysis(voiceID, analysis.Key, analysis.BPM); err != nil {
+		log.Error().Err(err).Str("voiceID", voiceID.Hex()).Msg("failed to save detected key and BPM")
+	}
+}
 
-	return nil
+// analyzeAudio downloads the audio and estimates its key and tempo. Returns nil if the analysis failed.
+func analyzeAudio(bot *gotgbot.Bot, fileID string) *service.AudioAnalysis {
+	f, err := bot.GetFile(fileID, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get audio to analyze")
+		return nil
+	}
+
+	reader, err := util.File(bot, f)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to download audio to analyze")
+		return nil
+	}
+	defer reader.Close()
+
+	analysis, err := service.AnalyzeAudio(context.Background(), reader)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to analyze audio")
+		return nil
+	}
+	return analysis
 }
 
-func (c *BotController) transposeAudio(bot *gotgbot.Bot, ctx *ext.Context, stopQueueMessages context.CancelFunc, sem *mysemaphore.Weighted, weight int64, mimeType, audioFileID, semitones string, fine bool, processingMsg *gotgbot.Message, lang string) (bool, []byte, error) {
-	err := sem.Acquire(context.TODO(), weight, ctx.EffectiveMessage.MessageId)
+// songTargetKey returns the key of the song for the nearest upcoming event with it, or the key of the song.
+func (c *BotController) songTargetKey(song *entity.Song) entity.Key {
+	if event, key := c.songUpcomingEventKey(song); event != nil {
+		return key
+	}
+	return song.PDF.Key
+}
+
+// songUpcomingEventKey returns the nearest upcoming event with the song and the key of the song for it.
+// The event is nil if the song is not in the upcoming events or its key wasn't changed for the nearest one.
+func (c *BotController) songUpcomingEventKey(song *entity.Song) (*entity.Event, entity.Key) {
+	band, err := c.BandService.FindOneByID(song.BandID)
 	if err != nil {
-		sem.Release(weight)
-		stopQueueMessages()
-		return false, nil, err
+		return nil, ""
 	}
-	defer sem.Release(weight)
-	stopQueueMessages()
 
-	_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioDownloading", lang), nil)
+	events, err := c.EventService.FindManyFromTodayByBandID(song.BandID, band.GetLocation())
+	if err != nil {
+		return nil, ""
+	}
 
-	f, err := bot.GetFile(audioFileID, nil)
+	for _, event := range events {
+		if !slices.Contains(event.SongIDs, song.ID) {
+			continue
+		}
+		if override := event.GetSongOverride(song.ID); override != nil && override.EventKey != "" {
+			return event, override.EventKey
+		}
+		break
+	}
+	return nil, ""
+}
+
+// audioAnalysisText describes the detected key and tempo and suggests the shift to the target key.
+// Returns the text and the suggested shift in semitones.
+func audioAnalysisText(analysis *service.AudioAnalysis, targetKey entity.Key, lang string) (string, int) {
+	var lines []string
+	if analysis.Key != "" {
+		lines = append(lines, txt.Get("text.audioDetectedKey", lang, analysis.Key))
+	}
+	if analysis.BPM > 0 {
+		lines = append(lines, txt.Get("text.audioDetectedBPM", lang, analysis.BPM))
+	}
+
+	suggested := 0
+	if analysis.Key != "" && targetKey != "" {
+		if semitones, ok := service.SuggestSemitones(analysis.Key, targetKey); ok {
+			suggested = semitones
+			if semitones == 0 {
+				lines = append(lines, txt.Get("text.audioSameKey", lang, targetKey))
+			} else {
+				lines = append(lines, txt.Get("text.audioSuggestedShift", lang, targetKey, fmt.Sprintf("%+d", semitones)))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), suggested
+}
+
+// editAudioJobMessage shows the text on the progress message of the job with the cancel button.
+// Nothing is shown once the job is canceled, so the progress doesn't overwrite the message about it.
+func editAudioJobMessage(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob, text string) {
+	if ctx.Err() != nil {
+		return
+	}
+	_, _, _ = bot.EditMessageText(text, &gotgbot.EditMessageTextOpts{
+		ChatId:    job.ChatID,
+		MessageId: job.MessageID,
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{
+			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+				{{Text: txt.Get("button.cancel", job.LanguageCode), CallbackData: util.CallbackData(state.AudioJobCancel, job.ID.Hex())}},
+			},
+		},
+	})
+}
+
+func transposeAudio(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob) (bool, []byte, error) {
+	lang := job.LanguageCode
+
+	editAudioJobMessage(ctx, bot, job, txt.Get("text.audioDownloading", lang))
+
+	inputTmpFile, err := os.CreateTemp("", "input_audio_*")
 	if err != nil {
 		return false, nil, err
 	}
+	defer os.Remove(inputTmpFile.Name())
 
-	originalFileBytes, err := util.File(bot, f)
+	if job.IsMix() {
+		if err := inputTmpFile.Close(); err != nil {
+			return false, nil, err
+		}
+		if err := mixAudioStems(ctx, bot, job, inputTmpFile.Name()); err != nil {
+			return false, nil, err
+		}
+		// The mix is already an mp3 file, it's transposed only if needed.
+		if job.Semitones == 0 && job.TempoPercent() == 100 {
+			newFileBytes, err := os.ReadFile(inputTmpFile.Name())
+			return true, newFileBytes, err
+		}
+		return rubberbandAudio(ctx, bot, job, inputTmpFile.Name(), true)
+	}
+
+	f, err := bot.GetFile(job.Audio.FileID, nil)
 	if err != nil {
 		return false, nil, err
 	}
-	defer originalFileBytes.Close()
 
-	inputTmpFile, err := os.CreateTemp("", "input_audio_*")
+	originalFileBytes, err := util.File(bot, f)
 	if err != nil {
 		return false, nil, err
 	}
-	defer os.Remove(inputTmpFile.Name())
+	defer originalFileBytes.Close()
 
 	converted := false
-	if mimeType == "audio/mp4" {
-		_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioConverting", lang), nil)
+	if job.Audio.MimeType == "audio/mp4" {
+		editAudioJobMessage(ctx, bot, job, txt.Get("text.audioConverting", lang))
 		converted = true
 		if err := inputTmpFile.Close(); err != nil {
 			return false, nil, err
 		}
 
-		err := ffmpeg.
-			Input("pipe:").
-			Output(inputTmpFile.Name(), ffmpeg.KwArgs{"f": ffmpegAudioExt, "c:v": "copy", "c:a": "libmp3lame", "q:a": "4"}).
-			WithInput(originalFileBytes).
-			OverWriteOutput().
-			// ErrorToStdOut().
-			Run()
-		if err != nil {
+		if err := service.ConvertAudioToMP3(ctx, originalFileBytes, inputTmpFile.Name()); err != nil {
 			return false, nil, err
 		}
 	} else {
@@ -342,7 +1053,57 @@ func (c *BotController) transposeAudio(bot *gotgbot.Bot, ctx *ext.Context, stopQ
 		}
 	}
 
-	_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioProcessing", lang), nil)
+	if job.Trim != nil {
+		return trimAudio(ctx, bot, job, inputTmpFile.Name())
+	}
+
+	// The voice message is only converted to the audio file.
+	if job.Semitones == 0 && job.TempoPercent() == 100 {
+		newFileBytes, err := os.ReadFile(inputTmpFile.Name())
+		return converted, newFileBytes, err
+	}
+
+	return rubberbandAudio(ctx, bot, job, inputTmpFile.Name(), converted)
+}
+
+// trimAudio cuts and repeats the part of the input file and transposes it if needed.
+// The result is always converted to mp3.
+func trimAudio(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob, input string) (bool, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	editAudioJobMessage(ctx, bot, job, txt.Get("text.audioTrimming", job.LanguageCode))
+
+	trimmedTmpFile, err := os.CreateTemp("", "trimmed_audio_*")
+	if err != nil {
+		return false, nil, err
+	}
+	defer os.Remove(trimmedTmpFile.Name())
+
+	if err := trimmedTmpFile.Close(); err != nil {
+		return false, nil, err
+	}
+
+	if err := service.TrimAudio(ctx, input, *job.Trim, trimmedTmpFile.Name()); err != nil {
+		return false, nil, err
+	}
+
+	if job.Semitones == 0 && job.TempoPercent() == 100 {
+		newFileBytes, err := os.ReadFile(trimmedTmpFile.Name())
+		return true, newFileBytes, err
+	}
+	return rubberbandAudio(ctx, bot, job, trimmedTmpFile.Name(), true)
+}
+
+// rubberbandAudio changes the pitch and the tempo of the input file.
+// converted is returned as it is, it tells if the input was converted to mp3.
+func rubberbandAudio(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob, input string, converted bool) (bool, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		return false, nil, err
+	}
+
+	editAudioJobMessage(ctx, bot, job, txt.Get("text.audioProcessing", job.LanguageCode))
 
 	outTmpFile, err := os.CreateTemp("", "output_audio_*")
 	if err != nil {
@@ -354,18 +1115,21 @@ func (c *BotController) transposeAudio(bot *gotgbot.Bot, ctx *ext.Context, stopQ
 		return false, nil, err
 	}
 
-	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	args := []string{"-p", semitones}
+	args := []string{"-p", strconv.Itoa(job.Semitones)}
+	if job.TempoPercent() != 100 {
+		args = append(args, "--tempo", strconv.FormatFloat(float64(job.TempoPercent())/100, 'f', -1, 64))
+	}
 
-	if fine {
+	if job.Fine {
 		args = append(args, "-3")
 	} else {
 		args = append(args, "-2", "--ignore-clipping")
 	}
 
-	args = append(args, inputTmpFile.Name(), outTmpFile.Name())
+	args = append(args, input, outTmpFile.Name())
 
 	cmd := exec.CommandContext(ctxWithTimeout, "rubberband", args...)
 	stderr, err := cmd.StderrPipe()
@@ -376,9 +1140,12 @@ func (c *BotController) transposeAudio(bot *gotgbot.Bot, ctx *ext.Context, stopQ
 		return false, nil, err
 	}
 
-	go sendProgressToUser(stderr, bot, processingMsg, lang)
+	go sendProgressToUser(ctx, stderr, bot, job)
 
 	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return false, nil, ctx.Err()
+		}
 		return false, nil, err
 	}
 
@@ -389,7 +1156,45 @@ func (c *BotController) transposeAudio(bot *gotgbot.Bot, ctx *ext.Context, stopQ
 	return converted, newFileBytes, nil
 }
 
-func sendProgressToUser(stderr io.Reader, bot *gotgbot.Bot, processingMsg *gotgbot.Message, lang string) {
+// encodeAudio encodes the result of the job to the output format chosen by the user.
+func encodeAudio(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob, fileBytes []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	editAudioJobMessage(ctx, bot, job, txt.Get("text.audioEncoding", job.LanguageCode))
+
+	inputTmpFile, err := os.CreateTemp("", "encode_input_audio_*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(inputTmpFile.Name())
+
+	if _, err := inputTmpFile.Write(fileBytes); err != nil {
+		_ = inputTmpFile.Close()
+		return nil, err
+	}
+	if err := inputTmpFile.Close(); err != nil {
+		return nil, err
+	}
+
+	outTmpFile, err := os.CreateTemp("", "encoded_audio_*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(outTmpFile.Name())
+
+	if err := outTmpFile.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := service.EncodeAudio(ctx, inputTmpFile.Name(), job.Output, job.SendsVoice(), outTmpFile.Name()); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(outTmpFile.Name())
+}
+
+func sendProgressToUser(ctx context.Context, stderr io.Reader, bot *gotgbot.Bot, job *entity.AudioJob) {
 	scanner := bufio.NewScanner(stderr)
 	scanner.Split(bufio.ScanWords)
 
@@ -418,7 +1223,7 @@ func sendProgressToUser(stderr io.Reader, bot *gotgbot.Bot, processingMsg *gotgb
 
 			select {
 			case <-ticker.C:
-				_, _, _ = processingMsg.EditText(bot, txt.Get("text.audioProcessingProgress", lang, scanner.Text()), nil)
+				editAudioJobMessage(ctx, bot, job, txt.Get("text.audioProcessingProgress", job.LanguageCode, scanner.Text()))
 				// fmt.Println(wordsScanner.Text())
 			default:
 			}
@@ -0,0 +1,478 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// audioMixMuted is the gain of the muted voice in the mixer settings.
+const audioMixMuted = "m"
+
+// maxAudioMixSemitones limits the pitch shift of the practice track.
+const maxAudioMixSemitones = 12
+
+// audioMixStem is the setting of the song voice in the mixer.
+// Voices without settings are mixed at 0 dB in the center.
+type audioMixStem struct {
+	Gain  float64
+	Pan   float64
+	Muted bool
+}
+
+// parseAudioMix parses the mixer settings "voiceID:gain:pan,..." from the callback cache.
+// Broken entries are skipped.
+func parseAudioMix(s string) map[bson.ObjectID]audioMixStem {
+	settings := make(map[bson.ObjectID]audioMixStem)
+	for entry := range strings.SplitSeq(s, ",") {
+		split := strings.Split(entry, ":")
+		if len(split) != 3 {
+			continue
+		}
+		voiceID, err := bson.ObjectIDFromHex(split[0])
+		if err != nil {
+			continue
+		}
+		pan, err := strconv.ParseFloat(split[2], 64)
+		if err != nil {
+			continue
+		}
+
+		stem := audioMixStem{Pan: pan}
+		if split[1] == audioMixMuted {
+			stem.Muted = true
+		} else if stem.Gain, err = strconv.ParseFloat(split[1], 64); err != nil {
+			continue
+		}
+		settings[voiceID] = stem
+	}
+	return settings
+}
+
+// formatAudioMix formats the settings of the voices for the callback cache. Default settings are omitted.
+func formatAudioMix(settings map[bson.ObjectID]audioMixStem, voices []*entity.Voice) string {
+	var entries []string
+	for _, voice := range voices {
+		stem := settings[voice.ID]
+		if stem == (audioMixStem{}) {
+			continue
+		}
+		gain := strconv.FormatFloat(stem.Gain, 'f', -1, 64)
+		if stem.Muted {
+			gain = audioMixMuted
+		}
+		entries = append(entries, fmt.Sprintf("%s:%s:%s", voice.ID.Hex(), gain, strconv.FormatFloat(stem.Pan, 'f', -1, 64)))
+	}
+	return strings.Join(entries, ",")
+}
+
+// nextGain cycles the voice through the gain presets and then mutes it.
+func (s audioMixStem) nextGain() audioMixStem {
+	if s.Muted {
+		s.Muted = false
+		s.Gain = service.AudioMixGains[0]
+		return s
+	}
+
+	i := slices.Index(service.AudioMixGains, s.Gain)
+	if i == len(service.AudioMixGains)-1 {
+		s.Muted = true
+		s.Gain = 0
+		return s
+	}
+	s.Gain = service.AudioMixGains[i+1]
+	return s
+}
+
+// nextPan cycles the voice from the center to the left and to the right.
+func (s audioMixStem) nextPan() audioMixStem {
+	switch {
+	case s.Pan == 0:
+		s.Pan = -1
+	case s.Pan < 0:
+		s.Pan = 1
+	default:
+		s.Pan = 0
+	}
+	return s
+}
+
+func (s audioMixStem) gainText() string {
+	if s.Muted {
+		return "🔇"
+	}
+	if s.Gain == 0 {
+		return "0 dB"
+	}
+	return fmt.Sprintf("%s dB", strconv.FormatFloat(s.Gain, 'f', -1, 64))
+}
+
+func (s audioMixStem) panText() string {
+	switch {
+	case s.Pan < 0:
+		return "⬅️ L"
+	case s.Pan > 0:
+		return "R ➡️"
+	default:
+		return "↔️ C"
+	}
+}
+
+// SongVoicesMix shows the mixer of the song voices. Payload is songID[:action:arg],
+// where the action is "g" or "p" to change the gain or the pan of the voice by its index,
+// "s" to set the pitch shift and "r" to reset the settings.
+// The settings are kept in the callback cache of the mixer message.
+func (c *BotController) SongVoicesMix(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	split := strings.Split(util.ParseCallbackPayload(ctx.CallbackQuery.Data), ":")
+	songID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+	entity.SortVoices(song.Voices)
+
+	action := ""
+	arg := 0
+	if len(split) > 2 {
+		action = split[1]
+		arg, err = strconv.Atoi(split[2])
+		if err != nil {
+			return err
+		}
+	}
+
+	settings := parseAudioMix(user.CallbackCache.AudioMix)
+	switch action {
+	case "":
+		// The mixer is opened from the song, start with the key of the nearest event.
+		settings = make(map[bson.ObjectID]audioMixStem)
+		user.CallbackCache.AudioSemitones = 0
+		if event, eventKey := c.songUpcomingEventKey(song); event != nil {
+			if semitones, ok := service.SuggestSemitones(song.PDF.Key, eventKey); ok {
+				user.CallbackCache.AudioSemitones = semitones
+			}
+		}
+	case "g", "p":
+		if arg < 0 || arg >= len(song.Voices) {
+			_, _ = ctx.CallbackQuery.Answer(bot, nil)
+			return nil
+		}
+		voiceID := song.Voices[arg].ID
+		if action == "g" {
+			settings[voiceID] = settings[voiceID].nextGain()
+		} else {
+			settings[voiceID] = settings[voiceID].nextPan()
+		}
+	case "s":
+		user.CallbackCache.AudioSemitones = max(-maxAudioMixSemitones, min(arg, maxAudioMixSemitones))
+	case "r":
+		settings = make(map[bson.ObjectID]audioMixStem)
+		user.CallbackCache.AudioSemitones = 0
+	}
+	user.CallbackCache.AudioMix = formatAudioMix(settings, song.Voices)
+
+	markup := audioMixMarkup(song, settings, user.CallbackCache.AudioSemitones, lang)
+
+	text := fmt.Sprintf("<b>%s</b>\n\n%s", html.EscapeString(song.PDF.Name), txt.Get("text.audioMixer", lang))
+	if event, eventKey := c.songUpcomingEventKey(song); event != nil {
+		text += "\n\n" + txt.Get("text.audioMixEventKey", lang, event.Alias(lang), eventKey)
+	}
+	text = user.CallbackCache.AddToText(text)
+
+	if action == "" {
+		_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ParseMode: "HTML",
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+				IsDisabled: true,
+			},
+			ReplyMarkup: markup,
+		})
+	} else {
+		_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+			ParseMode: "HTML",
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+				IsDisabled: true,
+			},
+			ReplyMarkup: markup,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+func audioMixMarkup(song *entity.Song, settings map[bson.ObjectID]audioMixStem, semitones int, lang string) gotgbot.InlineKeyboardMarkup {
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	for i, voice := range song.Voices {
+		stem := settings[voice.ID]
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s · %s", voice.Name, stem.gainText()), CallbackData: util.CallbackData(state.SongVoicesMix, fmt.Sprintf("%s:g:%d", song.ID.Hex(), i))},
+			{Text: stem.panText(), CallbackData: util.CallbackData(state.SongVoicesMix, fmt.Sprintf("%s:p:%d", song.ID.Hex(), i))},
+		})
+	}
+
+	pitchText := fmt.Sprintf("%+d", semitones)
+	if song.PDF.Key != "" {
+		pitchText = fmt.Sprintf("%s (%s)", pitchText, song.PDF.Key)
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+		{Text: "−1", CallbackData: util.CallbackData(state.SongVoicesMix, fmt.Sprintf("%s:s:%d", song.ID.Hex(), semitones-1))},
+		{Text: pitchText, CallbackData: util.CallbackData(state.SongVoicesMix, fmt.Sprintf("%s:s:%d", song.ID.Hex(), 0))},
+		{Text: "+1", CallbackData: util.CallbackData(state.SongVoicesMix, fmt.Sprintf("%s:s:%d", song.ID.Hex(), semitones+1))},
+	})
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+		{Text: txt.Get("button.reset", lang), CallbackData: util.CallbackData(state.SongVoicesMix, song.ID.Hex()+":r:0")},
+		{Text: txt.Get("button.mix", lang), CallbackData: util.CallbackData(state.SongVoicesMixRun, song.ID.Hex())},
+	})
+
+	return markup
+}
+
+// SongVoicesMixRun puts mixing of the voices with the settings from the mixer to the job queue.
+// The mixer message becomes the progress message of the job.
+func (c *BotController) SongVoicesMixRun(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	songID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+	entity.SortVoices(song.Voices)
+
+	settings := parseAudioMix(user.CallbackCache.AudioMix)
+
+	var stems []entity.AudioJobStem
+	var duration int64
+	for _, voice := range song.Voices {
+		stem := settings[voice.ID]
+		if stem.Muted {
+			continue
+		}
+
+		audio, err := voiceAudioJobFile(bot, voice, song)
+		if err != nil {
+			return err
+		}
+		stems = append(stems, entity.AudioJobStem{Audio: audio, Gain: stem.Gain, Pan: stem.Pan})
+		duration = max(duration, voice.Duration)
+	}
+
+	if len(stems) == 0 {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.audioMixEmpty", lang),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	job := entity.AudioJob{
+		UserID:       user.ID,
+		BandID:       user.BandID,
+		ChatID:       ctx.EffectiveChat.Id,
+		MessageID:    ctx.EffectiveMessage.MessageId,
+		LanguageCode: lang,
+		Output:       user.AudioOutput,
+		Audio: entity.AudioJobFile{
+			FileName:  song.PDF.Name + "." + ffmpegAudioExt,
+			Title:     txt.Get("text.audioMixTitle", lang),
+			Performer: song.PDF.Name,
+			Duration:  duration,
+		},
+		Stems:     stems,
+		Semitones: max(-maxAudioMixSemitones, min(user.CallbackCache.AudioSemitones, maxAudioMixSemitones)),
+		// The mix is listened to for practice, so the quality matters more than the speed.
+		Fine: true,
+	}
+
+	// The same mix was already made, no need to wait in the queue.
+	if c.sendProcessedAudio(bot, &job) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		_, _ = ctx.EffectiveMessage.Delete(bot, nil)
+		return nil
+	}
+
+	_, err = c.enqueueAudioJob(bot, ctx, job)
+	return err
+}
+
+// audioJobCaption describes the stems and the click of the mix. It's empty for other jobs.
+func audioJobCaption(job *entity.AudioJob) string {
+	lines := make([]string, 0, len(job.Stems))
+	for _, stem := range job.Stems {
+		s := audioMixStem{Gain: stem.Gain, Pan: stem.Pan}
+		line := fmt.Sprintf("%s · %s", stem.Audio.Title, s.gainText())
+		if stem.Pan != 0 {
+			line += " · " + s.panText()
+		}
+		lines = append(lines, line)
+	}
+	if job.Click != nil {
+		lines = append(lines, txt.Get("text.clickTrackCaption", job.LanguageCode, job.Click.BPM, job.Click.BeatsPerBar, job.Click.CountInBars))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mixAudioStems downloads the stems of the job and mixes them into the output file.
+func mixAudioStems(ctx context.Context, bot *gotgbot.Bot, job *entity.AudioJob, output string) error {
+	inputs := make([]string, 0, len(job.Stems))
+	defer func() {
+		for _, input := range inputs {
+			_ = os.Remove(input)
+		}
+	}()
+
+	for _, stem := range job.Stems {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		input, err := downloadAudioJobFile(bot, stem.Audio)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, input)
+	}
+
+	stems := slices.Clone(job.Stems)
+	click := ""
+	if job.Click != nil {
+		var offset time.Duration
+		// The click over the voice is aligned to it, so the count-in ends on its first beat.
+		if len(stems) == 1 {
+			stems[0].Delay, offset = alignClickTrack(ctx, job.Click, inputs[0])
+		}
+
+		input, err := writeClickTrack(job, offset)
+		if err != nil {
+			return err
+		}
+
+		// The click alone is mixed as the only stem.
+		if len(stems) == 0 {
+			inputs = append(inputs, input)
+			stems = append(stems, entity.AudioJobStem{})
+		} else {
+			click = input
+			defer os.Remove(click)
+		}
+	}
+
+	if len(job.Stems) > 0 {
+		editAudioJobMessage(ctx, bot, job, txt.Get("text.audioMixing", job.LanguageCode))
+	} else {
+		editAudioJobMessage(ctx, bot, job, txt.Get("text.audioProcessing", job.LanguageCode))
+	}
+
+	return service.MixAudio(ctx, inputs, stems, click, output)
+}
+
+// alignClickTrack returns the delay of the voice in milliseconds and the offset of the click,
+// so the count-in ends on the first beat of the voice. The voice starts after the count-in if its beat isn't found.
+func alignClickTrack(ctx context.Context, click *entity.AudioJobClick, voiceInput string) (int64, time.Duration) {
+	countIn := click.CountInDuration()
+
+	firstBeat, ok, err := service.DetectAudioFirstBeat(ctx, voiceInput, click.BPM)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to detect the first beat of the voice")
+	}
+	if err != nil || !ok {
+		return countIn.Milliseconds(), 0
+	}
+
+	if firstBeat <= countIn {
+		return (countIn - firstBeat).Milliseconds(), 0
+	}
+	// The intro of the voice is longer than the count-in, the click waits for it.
+	return 0, firstBeat - countIn
+}
+
+// writeClickTrack saves the click of the job to a temporary WAV file and returns its name.
+// The click is as long as the result, or service.DefaultClickTrackDuration if the duration is unknown.
+// The click mixed over the voice is cut when the voice ends.
+func writeClickTrack(job *entity.AudioJob, offset time.Duration) (string, error) {
+	duration := time.Duration(job.Audio.Duration) * time.Second
+	if duration <= 0 {
+		duration = service.DefaultClickTrackDuration
+	}
+	duration += offset
+
+	tmpFile, err := os.CreateTemp("", "click_audio_*.wav")
+	if err != nil {
+		return "", err
+	}
+
+	if err := service.WriteClickTrack(tmpFile, *job.Click, offset, duration); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
+
+// downloadAudioJobFile saves the file to a temporary file and returns its name.
+func downloadAudioJobFile(bot *gotgbot.Bot, audio entity.AudioJobFile) (string, error) {
+	f, err := bot.GetFile(audio.FileID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := util.File(bot, f)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	tmpFile, err := os.CreateTemp("", "stem_audio_*")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := io.Copy(tmpFile, reader); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return tmpFile.Name(), nil
+}
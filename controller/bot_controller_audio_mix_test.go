@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestAudioMixSettings(t *testing.T) {
+	voices := []*entity.Voice{{ID: bson.NewObjectID()}, {ID: bson.NewObjectID()}, {ID: bson.NewObjectID()}}
+
+	settings := map[bson.ObjectID]audioMixStem{
+		voices[0].ID: {},
+		voices[1].ID: {Gain: -10, Pan: -1},
+		voices[2].ID: {Muted: true},
+	}
+
+	got := parseAudioMix(formatAudioMix(settings, voices))
+	if len(got) != 2 || got[voices[1].ID] != settings[voices[1].ID] || got[voices[2].ID] != settings[voices[2].ID] {
+		t.Fatalf("got %+v, want %+v without defaults", got, settings)
+	}
+
+	if got := parseAudioMix("broken,:,x:0:0"); len(got) != 0 {
+		t.Fatalf("broken settings must be skipped, got %+v", got)
+	}
+}
+
+func TestAudioMixStemNextGain(t *testing.T) {
+	stem := audioMixStem{Pan: 1}
+	var gains []string
+	for range 6 {
+		stem = stem.nextGain()
+		gains = append(gains, stem.gainText())
+	}
+
+	want := []string{"-6 dB", "-10 dB", "-20 dB", "🔇", "0 dB", "-6 dB"}
+	for i := range want {
+		if gains[i] != want[i] {
+			t.Fatalf("got %v, want %v", gains, want)
+		}
+	}
+	if stem.Pan != 1 {
+		t.Fatalf("gain change must keep the pan")
+	}
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+)
+
+var audioFormatNames = map[entity.AudioFormat]string{
+	entity.AudioFormatMP3_128: "MP3 128 kbps",
+	entity.AudioFormatMP3_192: "MP3 192 kbps",
+	entity.AudioFormatMP3_320: "MP3 320 kbps",
+	entity.AudioFormatOpus:    "OGG/Opus",
+	entity.AudioFormatM4A:     "M4A (AAC)",
+	entity.AudioFormatFLAC:    "FLAC",
+	entity.AudioFormatWAV:     "WAV",
+}
+
+// audioOutputText describes the format of the result, e.g. "FLAC, EBU R128".
+func audioOutputText(output entity.AudioOutput, lang string) string {
+	text, ok := audioFormatNames[output.Format]
+	if !ok {
+		text = txt.Get("text.audioFormatOriginal", lang)
+	}
+	if output.Normalize {
+		text += ", EBU R128"
+	}
+	return text
+}
+
+// AudioSettings shows and changes the format the processed audio is sent in.
+// The callback payload is "f:format" to choose the format or "n" to toggle the normalization.
+func (c *BotController) AudioSettings(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	if ctx.CallbackQuery != nil {
+		payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+		switch {
+		case payload == "n":
+			user.AudioOutput.Normalize = !user.AudioOutput.Normalize
+		case strings.HasPrefix(payload, "f:"):
+			format := entity.AudioFormat(strings.TrimPrefix(payload, "f:"))
+			if !slices.Contains(entity.AudioFormats, format) {
+				return fmt.Errorf("%w: unsupported audio format %q", service.ErrInvalidOperation, format)
+			}
+			user.AudioOutput.Format = format
+		}
+
+		// Callbacks don't reach UpdateUser.
+		_, err := c.UserService.UpdateOne(*user)
+		if err != nil {
+			return err
+		}
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	var row []gotgbot.InlineKeyboardButton
+	for _, format := range entity.AudioFormats {
+		buttonText, ok := audioFormatNames[format]
+		if !ok {
+			buttonText = txt.Get("button.audioFormatOriginal", lang)
+		}
+		if format == user.AudioOutput.Format {
+			buttonText = fmt.Sprintf("〔%s〕", buttonText)
+		}
+		row = append(row, gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.AudioSettings, "f:"+string(format))})
+		// The original format has its own row, the rest are two in a row.
+		if format == entity.AudioFormatOriginal || len(row) == 2 {
+			markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+
+	buttonText := txt.Get("button.normalizeLoudness", lang)
+	if user.AudioOutput.Normalize {
+		buttonText = fmt.Sprintf("〔%s〕", buttonText)
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.AudioSettings, "n")}})
+
+	text := txt.Get("text.audioSettings", lang, txt.Get("button.sendAsAudioFile", lang))
+
+	if ctx.CallbackQuery == nil {
+		_, err := ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ReplyMarkup: markup,
+		})
+		return err
+	}
+
+	_, _, err := ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+)
+
+func TestAudioJobResultNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		job          entity.AudioJob
+		converted    bool
+		wantTitle    string
+		wantFileName string
+	}{
+		{
+			name:         "up",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "song.mp3", Title: "Song"}, Semitones: 2},
+			wantTitle:    "Song (+2)",
+			wantFileName: "song (+2).mp3",
+		},
+		{
+			name:         "down converted",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "song.m4a"}, Semitones: -3},
+			converted:    true,
+			wantFileName: "song (-3).mp3",
+		},
+		{
+			name:         "slower",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "song.mp3", Title: "Song"}, Tempo: 75},
+			wantTitle:    "Song (75%)",
+			wantFileName: "song (75%).mp3",
+		},
+		{
+			name:         "up and slower with bpm",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "song.mp3", Title: "Song"}, Semitones: 2, Tempo: 90, BPM: 120},
+			wantTitle:    "Song (+2, 90%, 108 BPM)",
+			wantFileName: "song (+2, 90%, 108 BPM).mp3",
+		},
+		{
+			name:         "mix",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "Song.mp3", Title: "Mix"}, Stems: []entity.AudioJobStem{{}, {}}},
+			converted:    true,
+			wantTitle:    "Mix",
+			wantFileName: "Song.mp3",
+		},
+		{
+			name:         "mix up",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "Song.mp3", Title: "Mix"}, Stems: []entity.AudioJobStem{{}, {}}, Semitones: 1},
+			converted:    true,
+			wantTitle:    "Mix (+1)",
+			wantFileName: "Song (+1).mp3",
+		},
+		{
+			name:         "flac",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "song.mp3", Title: "Song"}, Semitones: 2, Output: entity.AudioOutput{Format: entity.AudioFormatFLAC}},
+			converted:    true,
+			wantTitle:    "Song (+2)",
+			wantFileName: "song (+2).flac",
+		},
+		{
+			name:         "converted voice",
+			job:          entity.AudioJob{Audio: entity.AudioJobFile{FileName: "voice.ogg", IsVoice: true}, ConvertVoice: true},
+			converted:    true,
+			wantFileName: "voice.mp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, fileName := audioJobResultNames(&tt.job, tt.converted)
+			if title != tt.wantTitle || fileName != tt.wantFileName {
+				t.Fatalf("got %q, %q, want %q, %q", title, fileName, tt.wantTitle, tt.wantFileName)
+			}
+		})
+	}
+}
+
+func TestIsAudioFileName(t *testing.T) {
+	for fileName, want := range map[string]bool{
+		"song (+2).mp3":  true,
+		"song (+2).M4A":  true,
+		"song (+2).flac": false,
+		"song (+2).wav":  false,
+		"song (+2).ogg":  false,
+	} {
+		if got := isAudioFileName(fileName); got != want {
+			t.Errorf("isAudioFileName(%q) = %t, want %t", fileName, got, want)
+		}
+	}
+}
+
+func TestAudioAnalysisText(t *testing.T) {
+	tests := []struct {
+		name          string
+		analysis      service.AudioAnalysis
+		targetKey     entity.Key
+		wantSuggested int
+		wantLines     int
+	}{
+		{name: "shift to target", analysis: service.AudioAnalysis{Key: "A", BPM: 96}, targetKey: "B", wantSuggested: 2, wantLines: 3},
+		{name: "relative key", analysis: service.AudioAnalysis{Key: "Em"}, targetKey: "G", wantSuggested: 0, wantLines: 2},
+		{name: "no target", analysis: service.AudioAnalysis{Key: "C", BPM: 120}, wantLines: 2},
+		{name: "nothing detected", wantLines: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, suggested := audioAnalysisText(&tt.analysis, tt.targetKey, "ru")
+			lines := 0
+			if text != "" {
+				lines = len(strings.Split(text, "\n"))
+			}
+			if suggested != tt.wantSuggested || lines != tt.wantLines {
+				t.Fatalf("got %d, %q, want %d and %d lines", suggested, text, tt.wantSuggested, tt.wantLines)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/joeyave/scala-bot/state"
 	"github.com/joeyave/scala-bot/txt"
 	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 func (c *BotController) RoleCreate_AskForName(bot *gotgbot.Bot, ctx *ext.Context) error {
@@ -102,3 +103,62 @@ func (c *BotController) RoleCreate(bot *gotgbot.Bot, ctx *ext.Context) error {
 
 	return c.Menu(bot, ctx)
 }
+
+// BandRoles shows the roles of the band for the admin to mark the ones that sing.
+// The callback payload is the ID of the role to toggle.
+func (c *BotController) BandRoles(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		if ctx.CallbackQuery != nil {
+			_, err := ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+				Text:      txt.Get("text.bandRolesInsufficientRights", lang),
+				ShowAlert: true,
+			})
+			return err
+		}
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.bandRolesInsufficientRights", lang), nil)
+		return err
+	}
+
+	if ctx.CallbackQuery != nil {
+		roleID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+		if err != nil {
+			return answerOutdatedButton(bot, ctx)
+		}
+		for _, role := range user.Band.Roles {
+			if role.ID == roleID {
+				role.Vocal = !role.Vocal
+				if _, err := c.RoleService.UpdateOne(*role); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+	for _, role := range user.Band.Roles {
+		buttonText := role.Name
+		if role.Vocal {
+			buttonText = fmt.Sprintf("〔🎤 %s〕", buttonText)
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.BandRoles, role.ID.Hex())}})
+	}
+
+	if ctx.CallbackQuery == nil {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.bandRoles", lang), &gotgbot.SendMessageOpts{
+			ReplyMarkup: markup,
+		})
+		return err
+	}
+
+	_, _, err := ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
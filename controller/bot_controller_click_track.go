@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defaultBeatsPerBar is used if the time signature of the song is unknown.
+const defaultBeatsPerBar = 4
+
+// clickTrackSettings are the click settings from the callback payload "songID:countInBars:voiceIndex".
+// The voice index is -1 if the click is not mixed over a voice.
+type clickTrackSettings struct {
+	SongID      bson.ObjectID
+	CountInBars int
+	VoiceIndex  int
+}
+
+func parseClickTrackSettings(payload string) (clickTrackSettings, error) {
+	settings := clickTrackSettings{CountInBars: 1, VoiceIndex: -1}
+
+	split := strings.Split(payload, ":")
+	songID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return settings, err
+	}
+	settings.SongID = songID
+
+	if len(split) > 2 {
+		settings.CountInBars, err = strconv.Atoi(split[1])
+		if err != nil {
+			return settings, err
+		}
+		settings.VoiceIndex, err = strconv.Atoi(split[2])
+		if err != nil {
+			return settings, err
+		}
+	}
+	return settings, nil
+}
+
+func (s clickTrackSettings) payload(countInBars, voiceIndex int) string {
+	return fmt.Sprintf("%s:%d:%d", s.SongID.Hex(), countInBars, voiceIndex)
+}
+
+// clickTrackBPM returns the BPM of the song, or the detected tempo of the voice if the song has none.
+func clickTrackBPM(song *entity.Song, voice *entity.Voice) (float64, bool) {
+	if bpm, ok := service.ParseBPM(song.EffectiveBPM()); ok {
+		return bpm, true
+	}
+	if voice != nil && voice.DetectedBPM > 0 {
+		return float64(voice.DetectedBPM), true
+	}
+	for _, v := range song.Voices {
+		if v.DetectedBPM > 0 {
+			return float64(v.DetectedBPM), true
+		}
+	}
+	return 0, false
+}
+
+// clickTrackBeatsPerBar returns the beats per bar of the song time signature.
+func clickTrackBeatsPerBar(song *entity.Song) int {
+	if beats, ok := service.ParseTimeSignature(song.EffectiveTime()); ok {
+		return beats
+	}
+	return defaultBeatsPerBar
+}
+
+// SongClickTrack shows the settings of the click for the song: the count-in and the voice to mix the click over.
+func (c *BotController) SongClickTrack(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	settings, err := parseClickTrackSettings(payload)
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(settings.SongID)
+	if err != nil {
+		return err
+	}
+	entity.SortVoices(song.Voices)
+
+	var voice *entity.Voice
+	if settings.VoiceIndex >= 0 && settings.VoiceIndex < len(song.Voices) {
+		voice = song.Voices[settings.VoiceIndex]
+	}
+
+	bpm, ok := clickTrackBPM(song, voice)
+	if !ok {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.clickTrackNoBPM", lang),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	var countInRow []gotgbot.InlineKeyboardButton
+	for _, bars := range service.ClickCountInPresets {
+		buttonText := txt.Get("button.countIn", lang, bars)
+		if bars == settings.CountInBars {
+			buttonText = fmt.Sprintf("〔%s〕", buttonText)
+		}
+		countInRow = append(countInRow, gotgbot.InlineKeyboardButton{Text: buttonText, CallbackData: util.CallbackData(state.SongClickTrack, settings.payload(bars, settings.VoiceIndex))})
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, countInRow)
+
+	buttonText := txt.Get("button.clickOnly", lang)
+	if voice == nil {
+		buttonText = fmt.Sprintf("〔%s〕", buttonText)
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.SongClickTrack, settings.payload(settings.CountInBars, -1))}})
+	for i, v := range song.Voices {
+		buttonText := v.Name
+		if v == voice {
+			buttonText = fmt.Sprintf("〔%s〕", buttonText)
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: buttonText, CallbackData: util.CallbackData(state.SongClickTrack, settings.payload(settings.CountInBars, i))}})
+	}
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.makeClickTrack", lang), CallbackData: util.CallbackData(state.SongClickTrackRun, settings.payload(settings.CountInBars, settings.VoiceIndex))}})
+
+	text := fmt.Sprintf("<b>%s</b>\n\n%s", html.EscapeString(song.PDF.Name), txt.Get("text.clickTrack", lang, bpm, clickTrackBeatsPerBar(song)))
+
+	// The settings are opened from the song, the message with them is the new one.
+	if !strings.Contains(payload, ":") {
+		_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	} else {
+		_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// SongClickTrackRun puts generation of the click to the job queue.
+// The message with the settings becomes the progress message of the job.
+func (c *BotController) SongClickTrackRun(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	settings, err := parseClickTrackSettings(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(service.ClickCountInPresets, settings.CountInBars) {
+		return fmt.Errorf("%w: unsupported count-in %d", service.ErrInvalidOperation, settings.CountInBars)
+	}
+
+	song, err := c.SongService.FindOneByID(settings.SongID)
+	if err != nil {
+		return err
+	}
+	entity.SortVoices(song.Voices)
+
+	var voice *entity.Voice
+	if settings.VoiceIndex >= 0 && settings.VoiceIndex < len(song.Voices) {
+		voice = song.Voices[settings.VoiceIndex]
+	}
+
+	bpm, ok := clickTrackBPM(song, voice)
+	if !ok {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.clickTrackNoBPM", lang),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	click := &entity.AudioJobClick{
+		BPM:         bpm,
+		BeatsPerBar: clickTrackBeatsPerBar(song),
+		CountInBars: settings.CountInBars,
+	}
+
+	job := entity.AudioJob{
+		UserID:       user.ID,
+		BandID:       user.BandID,
+		ChatID:       ctx.EffectiveChat.Id,
+		MessageID:    ctx.EffectiveMessage.MessageId,
+		LanguageCode: lang,
+		Output:       user.AudioOutput,
+		Audio: entity.AudioJobFile{
+			FileName:  song.PDF.Name + "." + ffmpegAudioExt,
+			Title:     txt.Get("text.clickTrackTitle", lang, bpm),
+			Performer: song.PDF.Name,
+			Duration:  int64(service.DefaultClickTrackDuration.Seconds()),
+		},
+		Click: click,
+	}
+
+	if voice != nil {
+		audio, err := voiceAudioJobFile(bot, voice, song)
+		if err != nil {
+			return err
+		}
+		// The voice starts after the count-in. The job moves it, so the count-in ends on the first beat of the voice.
+		job.Stems = []entity.AudioJobStem{{Audio: audio, Delay: click.CountInDuration().Milliseconds()}}
+		job.Audio.Title = fmt.Sprintf("%s · %s", voice.Name, job.Audio.Title)
+		job.Audio.Duration = 0
+		if voice.Duration > 0 {
+			job.Audio.Duration = voice.Duration + int64(math.Ceil(click.CountInDuration().Seconds()))
+		}
+	}
+
+	// The same click was already made, no need to wait in the queue.
+	if c.sendProcessedAudio(bot, &job) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		_, _ = ctx.EffectiveMessage.Delete(bot, nil)
+		return nil
+	}
+
+	_, err = c.enqueueAudioJob(bot, ctx, job)
+	return err
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestClickTrackBPM(t *testing.T) {
+	voice := &entity.Voice{DetectedBPM: 96}
+	song := &entity.Song{Voices: []*entity.Voice{{DetectedBPM: 100}, voice}}
+
+	if bpm, ok := clickTrackBPM(song, voice); !ok || bpm != 96 {
+		t.Fatalf("got %g, %v, want the tempo of the voice", bpm, ok)
+	}
+	if bpm, ok := clickTrackBPM(song, nil); !ok || bpm != 100 {
+		t.Fatalf("got %g, %v, want the tempo of the first voice", bpm, ok)
+	}
+
+	song.PDF.BPM = "72"
+	if bpm, ok := clickTrackBPM(song, voice); !ok || bpm != 72 {
+		t.Fatalf("got %g, %v, want the song BPM", bpm, ok)
+	}
+
+	if _, ok := clickTrackBPM(&entity.Song{}, nil); ok {
+		t.Fatalf("song without BPM must fail")
+	}
+}
+
+func TestParseClickTrackSettings(t *testing.T) {
+	settings, err := parseClickTrackSettings("64b7f0c2e4b0a1a2b3c4d5e6")
+	if err != nil || settings.CountInBars != 1 || settings.VoiceIndex != -1 {
+		t.Fatalf("got %+v, %v, want defaults", settings, err)
+	}
+
+	settings, err = parseClickTrackSettings(settings.payload(2, 3))
+	if err != nil || settings.CountInBars != 2 || settings.VoiceIndex != 3 {
+		t.Fatalf("got %+v, %v", settings, err)
+	}
+}
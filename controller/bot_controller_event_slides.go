@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (c *BotController) EventSlides(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+
+	eventID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: txt.Get("button.slidesOpenLyrics", lang), CallbackData: util.CallbackData(state.EventSlidesExport, hex+":"+string(service.SlidesFormatOpenLyrics))}},
+			{{Text: txt.Get("button.slidesText", lang), CallbackData: util.CallbackData(state.EventSlidesExport, hex+":"+string(service.SlidesFormatText))}},
+			{{Text: txt.Get("button.slidesPDF", lang), CallbackData: util.CallbackData(state.EventSlidesExport, hex+":"+string(service.SlidesFormatPDF))}},
+			{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.EventCB, hex+":edit")}},
+		},
+	}
+
+	text := user.CallbackCache.AddToText("<b>" + event.Alias(lang) + "</b>\n\n" + txt.Get("text.chooseSlidesFormat", lang))
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode: "HTML",
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// EventSlidesExport sends lyrics slides of the event setlist in the chosen format.
+// Slides are split by the rules from the band settings.
+func (c *BotController) EventSlidesExport(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	split := strings.Split(payload, ":")
+	if len(split) < 2 {
+		return answerOutdatedButton(bot, ctx)
+	}
+
+	eventID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	format := service.SlidesFormat(split[1])
+	if !format.IsValid() {
+		return service.ErrInvalidOperation
+	}
+
+	event, err := c.EventService.GetEventWithSongs(eventID)
+	if err != nil {
+		return err
+	}
+
+	if len(event.Songs) == 0 {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text: txt.Get("text.noSongs", lang),
+		})
+		return nil
+	}
+
+	_, _ = ctx.EffectiveChat.SendAction(bot, "upload_document", nil)
+
+	band, err := c.BandService.FindOneByID(event.BandID)
+	if err != nil {
+		return err
+	}
+
+	setlist, err := c.DriveFileService.GetSetlistSlides(event, event.Songs, band.GetSlideRules())
+	if err != nil {
+		return err
+	}
+
+	name := event.Alias(lang)
+	caption := txt.Get("text.slidesCaption", lang, name, len(setlist))
+
+	if format == service.SlidesFormatPDF {
+		bandTempFolderID, err := c.getBandTempFolderID(band.ID)
+		if err != nil {
+			return err
+		}
+
+		driveFile, err := c.DriveFileService.CreateSlidesDoc(name, setlist, bandTempFolderID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			go func() {
+				_ = c.DriveFileService.DeleteOne(driveFile.Id)
+			}()
+		}()
+
+		reader, err := c.DriveFileService.DownloadOneByID(driveFile.Id)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		_, err = bot.SendDocument(ctx.EffectiveChat.Id, gotgbot.InputFileByReader(format.FileName(name), reader), &gotgbot.SendDocumentOpts{
+			Caption: caption,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		var buf bytes.Buffer
+		err = service.WriteSlidesArchive(&buf, setlist, format, time.Now())
+		if err != nil {
+			return err
+		}
+
+		_, err = bot.SendDocument(ctx.EffectiveChat.Id, gotgbot.InputFileByReader(format.FileName(name), &buf), &gotgbot.SendDocumentOpts{
+			Caption: caption,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -103,12 +104,9 @@ func (c *BotController) CreateEvent(bot *gotgbot.Bot, ctx *ext.Context) error {
 		}
 		newEvent.SongIDs = append(newEvent.SongIDs, hex)
 
-		override := data.GetSongOverride(id)
-		if override != nil && override.EventKey != "" {
-			newEvent.SongOverrides = append(newEvent.SongOverrides, entity.SongOverride{
-				SongID:   hex,
-				EventKey: override.EventKey,
-			})
+		override := data.GetSongOverride(id).ToEntity(hex)
+		if override != nil {
+			newEvent.SongOverrides = append(newEvent.SongOverrides, *override)
 		}
 	}
 
@@ -1017,3 +1015,148 @@ func (c *BotController) notifyDeleted(bot *gotgbot.Bot, user *entity.User, membe
 		}
 	}
 }
+
+// EventMyVoices sends voices of the event songs recorded for the roles of the user in the event.
+func (c *BotController) EventMyVoices(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	eventID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	sent, err := c.sendMyVoices(bot, ctx.EffectiveUser.Id, user.ID, event, lang)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.noMyVoices", lang),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// MyVoices sends the voices for the nearest upcoming event of the user, e.g. all the parts to learn for next Sunday.
+func (c *BotController) MyVoices(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	events, err := c.EventService.FindManyFromTodayByBandIDAndUserID(user.BandID, user.Band.GetLocation(), user.ID, 0)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && len(events) == 0) {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.noMyUpcomingEvents", lang), nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	sent, err := c.sendMyVoices(bot, ctx.EffectiveChat.Id, user.ID, events[0], lang)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.noMyVoices", lang), nil)
+		return err
+	}
+	return nil
+}
+
+type eventVoice struct {
+	Song  *entity.Song
+	Voice *entity.Voice
+}
+
+// myEventVoices returns voices of the event songs recorded for the roles of the user in the event.
+func myEventVoices(event *entity.Event, userID int64) []eventVoice {
+	var roleIDs []bson.ObjectID
+	for _, membership := range event.Memberships {
+		if membership.UserID == userID {
+			roleIDs = append(roleIDs, membership.RoleID)
+		}
+	}
+
+	var voices []eventVoice
+	if len(roleIDs) == 0 {
+		return voices
+	}
+
+	for _, song := range event.Songs {
+		entity.SortVoices(song.Voices)
+		for _, voice := range song.Voices {
+			if !voice.RoleID.IsZero() && slices.Contains(roleIDs, voice.RoleID) {
+				voices = append(voices, eventVoice{Song: song, Voice: voice})
+			}
+		}
+	}
+	return voices
+}
+
+// sendMyVoices sends voices of the event songs for the roles of the user to the chat.
+// Returns false if there are no such voices.
+func (c *BotController) sendMyVoices(bot *gotgbot.Bot, chatID, userID int64, event *entity.Event, lang string) (bool, error) {
+	voices := myEventVoices(event, userID)
+	if len(voices) == 0 {
+		return false, nil
+	}
+
+	_, err := bot.SendMessage(chatID, txt.Get("text.myVoices", lang, event.Alias(lang)), &gotgbot.SendMessageOpts{
+		ParseMode: "HTML",
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range voices {
+		caption := fmt.Sprintf("<b>%s</b>\n%s", v.Song.PDF.Name, voiceButtonText(v.Voice, lang))
+
+		var markup gotgbot.InlineKeyboardMarkup
+		if override := event.GetSongOverride(v.Song.ID); override != nil && override.EventKey != "" {
+			if semitones, ok := service.SuggestSemitones(voiceKey(v.Voice, v.Song), override.EventKey); ok && semitones != 0 {
+				markup.InlineKeyboard = [][]gotgbot.InlineKeyboardButton{
+					{{Text: txt.Get("button.transposeToEventKey", lang, override.EventKey), CallbackData: util.CallbackData(state.VoiceTransposeToEventKey, event.ID.Hex()+":"+v.Voice.ID.Hex())}},
+				}
+			}
+		}
+
+		if err := sendVoiceFile(bot, chatID, v.Voice, caption, markup); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// sendVoiceFile sends the recording of the voice. The recording may be an audio or a voice message.
+func sendVoiceFile(bot *gotgbot.Bot, chatID int64, voice *entity.Voice, caption string, markup gotgbot.InlineKeyboardMarkup) error {
+	fileID := voice.AudioFileID
+	if fileID == "" {
+		fileID = voice.FileID
+	}
+
+	_, err := bot.SendAudio(chatID, gotgbot.InputFileByID(fileID), &gotgbot.SendAudioOpts{
+		Caption:     caption,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	if err == nil || voice.AudioFileID != "" {
+		return err
+	}
+
+	// Voice messages can't be sent as audio.
+	_, err = bot.SendVoice(chatID, gotgbot.InputFileByID(fileID), &gotgbot.SendVoiceOpts{
+		Caption:     caption,
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	return err
+}
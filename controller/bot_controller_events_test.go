@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestMyEventVoices(t *testing.T) {
+	soprano, tenor := bson.NewObjectID(), bson.NewObjectID()
+
+	song := &entity.Song{
+		Voices: []*entity.Voice{
+			{Name: "Tenor", RoleID: tenor, Position: 1},
+			{Name: "Soprano 2", RoleID: soprano, Position: 3},
+			{Name: "Full mix"},
+			{Name: "Soprano 1", RoleID: soprano, Position: 2},
+		},
+	}
+	event := &entity.Event{
+		Memberships: []*entity.Membership{
+			{UserID: 1, RoleID: soprano},
+			{UserID: 2, RoleID: tenor},
+		},
+		Songs: []*entity.Song{song},
+	}
+
+	voices := myEventVoices(event, 1)
+	if len(voices) != 2 || voices[0].Voice.Name != "Soprano 1" || voices[1].Voice.Name != "Soprano 2" {
+		t.Fatalf("myEventVoices() = %+v, want soprano voices in order", voices)
+	}
+
+	if voices := myEventVoices(event, 3); len(voices) != 0 {
+		t.Fatalf("myEventVoices() for a non-member = %+v, want none", voices)
+	}
+}
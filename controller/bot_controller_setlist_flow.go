@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (c *BotController) EventSetlistFlow(bot *gotgbot.Bot, ctx *ext.Context) error {
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+
+	eventID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	err = c.eventSetlistFlow(bot, ctx, event)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+func (c *BotController) EventSetlistFlowReorder(bot *gotgbot.Bot, ctx *ext.Context) error {
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+
+	eventID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	flow, err := c.SongService.AnalyzeSetlist(event)
+	if err != nil {
+		return err
+	}
+
+	// The setlist could be changed since the order was suggested. Don't lose songs that were not found.
+	if flow.SuggestedOrder == nil || len(flow.Songs) != len(event.SongIDs) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		return nil
+	}
+
+	songIDs := make([]bson.ObjectID, 0, len(flow.SuggestedOrder))
+	for _, i := range flow.SuggestedOrder {
+		songIDs = append(songIDs, flow.Songs[i].ID)
+	}
+	event.SongIDs = songIDs
+
+	event, err = c.EventService.UpdateOne(*event)
+	if err != nil {
+		return err
+	}
+
+	err = c.eventSetlistFlow(bot, ctx, event)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.setlistOrderApplied", ctx.EffectiveUser.LanguageCode),
+	})
+	return nil
+}
+
+func (c *BotController) eventSetlistFlow(bot *gotgbot.Bot, ctx *ext.Context, event *entity.Event) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	flow, err := c.SongService.AnalyzeSetlist(event)
+	if err != nil {
+		return err
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b>\n\n", event.Alias(lang))
+
+	if len(flow.Transitions) == 0 {
+		b.WriteString(txt.Get("text.setlistFlowEmpty", lang))
+	} else {
+		b.WriteString(txt.Get("text.setlistFlow", lang))
+		b.WriteString("\n")
+
+		hasIssues := false
+		for i, song := range flow.Songs {
+			fmt.Fprintf(&b, "\n%d. %s (%s)", i+1, html.EscapeString(song.NameWithArrangement()), html.EscapeString(song.Meta()))
+
+			if i == 0 {
+				continue
+			}
+			transition := flow.Transitions[i-1]
+			if len(transition.Issues) == 0 {
+				continue
+			}
+			hasIssues = true
+
+			var issues []string
+			for _, issue := range transition.Issues {
+				switch issue {
+				case entity.SetlistTransitionIssueKeyJump:
+					text := txt.Get("text.setlistFlowKeyJump", lang, transition.FromKey, transition.ToKey)
+					if transition.SuggestedKey != "" {
+						text += ", " + txt.Get("text.setlistFlowSuggestedKey", lang, transition.SuggestedKey)
+					}
+					issues = append(issues, text)
+				case entity.SetlistTransitionIssueTempoJump:
+					issues = append(issues, txt.Get("text.setlistFlowTempoJump", lang, transition.FromBPM, transition.ToBPM))
+				}
+			}
+			fmt.Fprintf(&b, "\n    ⚠️ <i>%s</i>", html.EscapeString(strings.Join(issues, "; ")))
+		}
+
+		if !hasIssues {
+			fmt.Fprintf(&b, "\n\n%s", txt.Get("text.setlistFlowSmooth", lang))
+		}
+
+		if flow.SuggestedOrder != nil {
+			fmt.Fprintf(&b, "\n\n%s", txt.Get("text.setlistFlowSuggestedOrder", lang))
+			for i, index := range flow.SuggestedOrder {
+				fmt.Fprintf(&b, "\n%d. %s", i+1, html.EscapeString(flow.Songs[index].NameWithArrangement()))
+			}
+			markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+				{Text: txt.Get("button.applySetlistOrder", lang), CallbackData: util.CallbackData(state.EventSetlistFlowReorder, event.ID.Hex())},
+			})
+		}
+	}
+
+	if flow.DurationSec > 0 {
+		fmt.Fprintf(&b, "\n\n%s", txt.Get("text.setlistDuration", lang, (flow.DurationSec+30)/60))
+		if flow.SongsWithoutDuration > 0 {
+			fmt.Fprintf(&b, " %s", txt.Get("text.setlistFlowUnknownDuration", lang, flow.SongsWithoutDuration))
+		}
+	}
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.EventCB, event.ID.Hex()+":edit")}})
+
+	text := user.CallbackCache.AddToText(b.String())
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode: "HTML",
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: markup,
+	})
+	return err
+}
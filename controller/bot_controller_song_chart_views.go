@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/keyboard"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (c *BotController) SongChartViews(bot *gotgbot.Bot, ctx *ext.Context) error {
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	songID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+
+	var capoKeys []entity.Key
+	for capo := 1; capo <= service.MaxCapo; capo++ {
+		capoKey, err := service.CapoKey(song.PDF.Key, capo)
+		if err != nil {
+			break
+		}
+		capoKeys = append(capoKeys, capoKey)
+	}
+
+	if len(capoKeys) == 0 {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text: txt.Get("text.chartViewUnknownKey", ctx.EffectiveUser.LanguageCode),
+		})
+		return nil
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+	markup.InlineKeyboard = keyboard.SongChartViews(song, capoKeys, ctx.EffectiveUser.LanguageCode)
+
+	_, _, err = ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.chooseChartView", ctx.EffectiveUser.LanguageCode),
+	})
+	return nil
+}
+
+// SongChartView sends the PDF of the song rendered with capo shapes or Nashville numbers.
+// The chart is rendered in a temporary copy of the doc, which is deleted after sending.
+func (c *BotController) SongChartView(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	split := strings.Split(payload, ":")
+	if len(split) < 2 {
+		return answerOutdatedButton(bot, ctx)
+	}
+
+	songID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	var view service.ChartView
+	if split[1] == "n" {
+		view.Nashville = true
+	} else {
+		view.Capo, err = strconv.Atoi(split[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+
+	chartKey, err := view.TargetKey(song.PDF.Key)
+	if errors.Is(err, service.ErrInvalidOperation) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text: txt.Get("text.chartViewUnknownKey", lang),
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.EffectiveChat.SendAction(bot, "upload_document", nil)
+
+	bandTempFolderID, err := c.getBandTempFolderID(song.BandID)
+	if err != nil {
+		return err
+	}
+
+	driveFile, err := c.DriveFileService.CopyAndRenderChartView(song.DriveFileID, song.PDF.Name, song.PDF.Key, view, bandTempFolderID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		go func() {
+			_ = c.DriveFileService.DeleteOne(driveFile.Id)
+		}()
+	}()
+
+	reader, err := c.DriveFileService.DownloadOneByID(driveFile.Id)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	caption := txt.Get("text.nashvilleCaption", lang, song.PDF.Key)
+	if !view.Nashville {
+		caption = txt.Get("text.capoCaption", lang, song.PDF.Key, view.Capo, chartKey)
+	}
+
+	_, err = bot.SendDocument(ctx.EffectiveChat.Id, gotgbot.InputFileByReader(fmt.Sprintf("%s.pdf", view.Title(song.PDF.Name)), reader), &gotgbot.SendDocumentOpts{
+		Caption: caption,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
@@ -0,0 +1,192 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const songDuplicatesLimit = 25
+
+func (c *BotController) SongDuplicates(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songDuplicatesInsufficientRights", ctx.EffectiveUser.LanguageCode), nil)
+		return err
+	}
+
+	msg, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songDuplicatesSearching", ctx.EffectiveUser.LanguageCode), nil)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.EffectiveChat.SendAction(bot, "typing", nil)
+
+	duplicates, err := c.SongService.FindDuplicates(user.BandID)
+	if err != nil {
+		return err
+	}
+
+	if len(duplicates) == 0 {
+		_, _, err = msg.EditText(bot, txt.Get("text.songDuplicatesNotFound", ctx.EffectiveUser.LanguageCode), nil)
+		return err
+	}
+
+	if len(duplicates) > songDuplicatesLimit {
+		duplicates = duplicates[:songDuplicatesLimit]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(txt.Get("text.songDuplicatesFound", ctx.EffectiveUser.LanguageCode))
+	sb.WriteString("\n")
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+	for i, d := range duplicates {
+		similarity := fmt.Sprintf("%.0f%%", d.NameSimilarity*100)
+		if d.LyricsSimilarity >= 0 {
+			similarity += fmt.Sprintf(", %s %.0f%%", txt.Get("text.songDuplicatesLyrics", ctx.EffectiveUser.LanguageCode), d.LyricsSimilarity*100)
+		}
+
+		fmt.Fprintf(&sb, "\n%d. %s ⟵ %s (%s)", i+1, html.EscapeString(d.Song.PDF.Name), html.EscapeString(d.Duplicate.PDF.Name), similarity)
+
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%d", i+1), CallbackData: util.CallbackData(state.SongMergeConfirm, d.Song.ID.Hex()+":"+d.Duplicate.ID.Hex())},
+		})
+	}
+	markup.InlineKeyboard = util.SplitInlineKeyboardToColumns(markup.InlineKeyboard, 5)
+
+	_, _, err = msg.EditText(bot, sb.String(), &gotgbot.EditMessageTextOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+func (c *BotController) SongMergeConfirm(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.songDuplicatesInsufficientRights", ctx.EffectiveUser.LanguageCode),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	payload, isSwap := strings.CutSuffix(payload, ":swap")
+
+	songID, duplicateID, err := parseSongMergePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+
+	duplicate, err := c.SongService.FindOneByID(duplicateID)
+	if err != nil {
+		return err
+	}
+
+	text := txt.Get("text.songMergeConfirm", ctx.EffectiveUser.LanguageCode,
+		html.EscapeString(song.PDF.Name), html.EscapeString(duplicate.PDF.Name), html.EscapeString(duplicate.PDF.Name))
+
+	markup := gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{
+				{Text: txt.Get("button.songMergeSwap", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongMergeConfirm, duplicateID.Hex()+":"+songID.Hex()+":swap")},
+			},
+			{
+				{Text: txt.Get("button.cancel", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongMerge, "cancel")},
+				{Text: txt.Get("button.yes", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongMerge, songID.Hex()+":"+duplicateID.Hex())},
+			},
+		},
+	}
+
+	// The list of duplicates is kept, so another pair can be merged without searching again.
+	if isSwap {
+		_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	} else {
+		_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+func (c *BotController) SongMerge(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	if payload == "cancel" {
+		_, err := ctx.EffectiveMessage.Delete(bot, nil)
+		return err
+	}
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.songDuplicatesInsufficientRights", ctx.EffectiveUser.LanguageCode),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	songID, duplicateID, err := parseSongMergePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+
+	song, err := c.SongService.Merge(user.BandID, songID, duplicateID)
+	if errors.Is(err, service.ErrInvalidOperation) || errors.Is(err, service.ErrForbidden) {
+		_, _, err = ctx.EffectiveMessage.EditText(bot, txt.Get("text.songMergeInvalid", ctx.EffectiveUser.LanguageCode), nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, txt.Get("text.songMerged", ctx.EffectiveUser.LanguageCode, html.EscapeString(song.PDF.Name)), &gotgbot.EditMessageTextOpts{
+		ParseMode: "HTML",
+	})
+	return err
+}
+
+func parseSongMergePayload(payload string) (songID, duplicateID bson.ObjectID, err error) {
+	split := strings.Split(payload, ":")
+	if len(split) != 2 {
+		return songID, duplicateID, fmt.Errorf("invalid merge payload: %s", payload)
+	}
+
+	songID, err = bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return songID, duplicateID, err
+	}
+
+	duplicateID, err = bson.ObjectIDFromHex(split[1])
+	return songID, duplicateID, err
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/rs/zerolog/log"
+)
+
+// SongImport imports all docs of the band Drive folder into the song library.
+// The import runs in the background, progress is shown in the sent message.
+func (c *BotController) SongImport(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songImportInsufficientRights", lang), nil)
+		return err
+	}
+
+	msg, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songImportStarted", lang), nil)
+	if err != nil {
+		return err
+	}
+
+	songImport, err := c.SongImportService.Start(user.Band, user.ID, ctx.EffectiveChat.Id, msg.MessageId, lang)
+	if errors.Is(err, service.ErrAlreadyExists) {
+		_, _, err = msg.EditText(bot, txt.Get("text.songImportAlreadyRunning", lang), nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	go c.runSongImport(bot, songImport)
+	return nil
+}
+
+// ResumeSongImports continues imports that were interrupted by restart.
+func (c *BotController) ResumeSongImports(bot *gotgbot.Bot) {
+	imports, err := c.SongImportService.FindManyRunning()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find running song imports")
+		return
+	}
+
+	for _, songImport := range imports {
+		log.Info().Str("songImportID", songImport.ID.Hex()).Msg("resuming song import")
+		go c.runSongImport(bot, songImport)
+	}
+}
+
+func (c *BotController) runSongImport(bot *gotgbot.Bot, songImport *entity.SongImport) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Msgf("song import panic: %v", r)
+		}
+	}()
+
+	err := c.SongImportService.Run(songImport, func(songImport *entity.SongImport) {
+		_, _, err := bot.EditMessageText(songImportText(songImport), &gotgbot.EditMessageTextOpts{
+			ChatId:    songImport.ChatID,
+			MessageId: songImport.MessageID,
+			ParseMode: "HTML",
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+				IsDisabled: true,
+			},
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("songImportID", songImport.ID.Hex()).Msg("failed to report song import progress")
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Str("songImportID", songImport.ID.Hex()).Msg("song import failed")
+	}
+}
+
+func songImportText(songImport *entity.SongImport) string {
+	lang := songImport.LanguageCode
+
+	var b strings.Builder
+	switch songImport.Status {
+	case entity.SongImportDone:
+		b.WriteString(txt.Get("text.songImportDone", lang, songImport.Processed, songImport.Created, songImport.Failed))
+	case entity.SongImportFailed:
+		b.WriteString(txt.Get("text.songImportFailed", lang, songImport.Processed, songImport.Created, songImport.Failed))
+	default:
+		b.WriteString(txt.Get("text.songImportProgress", lang, songImport.Processed, songImport.Created, songImport.Failed))
+	}
+
+	if songImport.Status != entity.SongImportRunning && len(songImport.FailedFileIDs) > 0 {
+		fmt.Fprintf(&b, "\n\n%s", txt.Get("text.songImportFailedFiles", lang))
+		for i, fileID := range songImport.FailedFileIDs {
+			fmt.Fprintf(&b, "\n<a href=\"https://docs.google.com/document/d/%s/edit\">%d</a>", fileID, i+1)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/keyboard"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func (c *BotController) SongKeyPreference(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	songID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+	markup.InlineKeyboard = keyboard.SongKeyPreference(song, user, ctx.EffectiveUser.LanguageCode)
+
+	_, _, err = ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.chooseKeyPreference", ctx.EffectiveUser.LanguageCode),
+	})
+	return nil
+}
+
+func (c *BotController) SongKeyPreferenceSet(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	split := strings.Split(payload, ":")
+	if len(split) < 2 {
+		return answerOutdatedButton(bot, ctx)
+	}
+
+	songID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	var answer string
+	if split[1] == "-" {
+		err = c.SongService.RemoveKeyPreference(songID, user.ID)
+		answer = txt.Get("text.keyPreferenceRemoved", ctx.EffectiveUser.LanguageCode)
+	} else {
+		err = c.SongService.SetKeyPreference(songID, user, entity.Key(split[1]))
+		answer = txt.Get("text.keyPreferenceSaved", ctx.EffectiveUser.LanguageCode, split[1])
+	}
+	if err != nil {
+		return err
+	}
+
+	song, err := c.SongService.FindOneByID(songID)
+	if err != nil {
+		return err
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+	markup.InlineKeyboard = keyboard.SongInit(song, user, user.CallbackCache.ChatID, user.CallbackCache.MessageID, ctx.EffectiveUser.LanguageCode)
+
+	_, _, err = ctx.EffectiveMessage.EditReplyMarkup(bot, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: answer,
+	})
+	return nil
+}
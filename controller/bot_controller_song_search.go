@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/txt"
+)
+
+// SongSearchReindex rebuilds search index of all band songs. Useful after the first deploy or if Drive was edited directly.
+func (c *BotController) SongSearchReindex(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	if !c.BandService.IsUserAdmin(user, user.Band) {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songSearchReindexInsufficientRights", ctx.EffectiveUser.LanguageCode), nil)
+		return err
+	}
+
+	msg, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songSearchReindexing", ctx.EffectiveUser.LanguageCode), nil)
+	if err != nil {
+		return err
+	}
+
+	indexed, err := c.SongService.ReindexBand(user.BandID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = msg.EditText(bot, txt.Get("text.songSearchReindexed", ctx.EffectiveUser.LanguageCode, indexed), nil)
+	return err
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const eventSongSuggestionsLimit = 8
+
+func (c *BotController) EventSongSuggestions(bot *gotgbot.Bot, ctx *ext.Context) error {
+	hex := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+
+	eventID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	err = c.eventSongSuggestions(bot, ctx, event)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+func (c *BotController) EventSongSuggestionAdd(bot *gotgbot.Bot, ctx *ext.Context) error {
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	split := strings.Split(payload, ":")
+	if len(split) < 2 {
+		return answerOutdatedButton(bot, ctx)
+	}
+
+	eventID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	songID, err := bson.ObjectIDFromHex(split[1])
+	if err != nil {
+		return err
+	}
+
+	err = c.EventService.PushSongID(eventID, songID)
+	if err != nil {
+		return err
+	}
+
+	event, err := c.EventService.FindOneByID(eventID)
+	if err != nil {
+		return err
+	}
+
+	err = c.eventSongSuggestions(bot, ctx, event)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.songSuggestionAdded", ctx.EffectiveUser.LanguageCode),
+	})
+	return nil
+}
+
+func (c *BotController) eventSongSuggestions(bot *gotgbot.Bot, ctx *ext.Context, event *entity.Event) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	suggestions, err := c.SongService.SuggestSongsForEvent(event, eventSongSuggestionsLimit)
+	if err != nil {
+		return err
+	}
+
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>%s</b>\n\n", event.Alias(lang))
+
+	if len(suggestions) == 0 {
+		b.WriteString(txt.Get("text.noSongSuggestions", lang))
+	} else {
+		b.WriteString(txt.Get("text.songSuggestions", lang))
+		b.WriteString("\n")
+	}
+
+	for i, suggestion := range suggestions {
+		song := suggestion.Song
+
+		fmt.Fprintf(&b, "\n%d. <b>%s</b>", i+1, html.EscapeString(song.PDF.Name))
+		if info := songSuggestionInfo(song); info != "" {
+			fmt.Fprintf(&b, " (%s)", html.EscapeString(info))
+		}
+
+		reasons := make([]string, 0, len(suggestion.Reasons))
+		for _, reason := range suggestion.Reasons {
+			reasons = append(reasons, txt.Get("text.songSuggestionReason."+string(reason), lang))
+		}
+		if len(reasons) > 0 {
+			fmt.Fprintf(&b, " — %s", strings.Join(reasons, ", "))
+		}
+
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+			{Text: "➕ " + song.PDF.Name, CallbackData: util.CallbackData(state.EventSongSuggestionAdd, event.ID.Hex()+":"+song.ID.Hex())},
+		})
+	}
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.EventCB, event.ID.Hex()+":edit")}})
+
+	text := user.CallbackCache.AddToText(b.String())
+
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode: "HTML",
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{
+			IsDisabled: true,
+		},
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+func songSuggestionInfo(song *entity.Song) string {
+	var info []string
+	if song.PDF.Key != "" {
+		info = append(info, string(song.PDF.Key))
+	}
+	if song.PDF.BPM != "" {
+		info = append(info, song.PDF.BPM)
+	}
+	return strings.Join(info, ", ")
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/txt"
+)
+
+// SongUsageReport sends CSV with songs performed in a period: /report [from to].
+func (c *BotController) SongUsageReport(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	loc := user.Band.GetLocation()
+	from, to, ok := parseSongUsageReportPeriod(strings.Fields(ctx.EffectiveMessage.Text)[1:], user.Band.GetNowTime(), loc)
+	if !ok {
+		_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songUsageReportUsage", ctx.EffectiveUser.LanguageCode), &gotgbot.SendMessageOpts{
+			ParseMode: "HTML",
+		})
+		return err
+	}
+
+	_, _ = ctx.EffectiveChat.SendAction(bot, "upload_document", nil)
+
+	usages, err := c.SongService.GetUsageReport(user.BandID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	period := from.Format("02.01.2006") + " – " + to.Format("02.01.2006")
+
+	if len(usages) == 0 {
+		_, err = ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songUsageReportEmpty", ctx.EffectiveUser.LanguageCode, period), nil)
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = service.WriteUsageReportCSV(&buf, usages, loc)
+	if err != nil {
+		return err
+	}
+
+	performances := 0
+	for _, usage := range usages {
+		performances += usage.Count
+	}
+
+	_, err = bot.SendDocument(ctx.EffectiveChat.Id, gotgbot.InputFileByReader(songUsageReportFileName(from, to), &buf), &gotgbot.SendDocumentOpts{
+		Caption: txt.Get("text.songUsageReport", ctx.EffectiveUser.LanguageCode, period, len(usages), performances),
+	})
+	return err
+}
+
+// parseSongUsageReportPeriod parses inclusive "from" and "to" dates of the report.
+// Without args the period is the last six months.
+func parseSongUsageReportPeriod(args []string, now time.Time, loc *time.Location) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	switch len(args) {
+	case 0:
+		return statisticsDefaultFromDate(now, loc), today, true
+	case 1, 2:
+		from, ok := parseSongUsageReportDate(args[0], loc)
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+
+		to := today
+		if len(args) == 2 {
+			to, ok = parseSongUsageReportDate(args[1], loc)
+			if !ok {
+				return time.Time{}, time.Time{}, false
+			}
+		}
+
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, false
+		}
+		return from, to, true
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+}
+
+func parseSongUsageReportDate(raw string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006", "2006-01-02"} {
+		parsed, err := time.ParseInLocation(layout, raw, loc)
+		if err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSongUsageReportPeriod(t *testing.T) {
+	loc := time.FixedZone("EET", 2*60*60)
+	now := time.Date(2026, 7, 15, 10, 30, 0, 0, loc)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantFrom string
+		wantTo   string
+		wantOK   bool
+	}{
+		{name: "default", args: nil, wantFrom: "2026-01-15", wantTo: "2026-07-15", wantOK: true},
+		{name: "from only", args: []string{"01.01.2026"}, wantFrom: "2026-01-01", wantTo: "2026-07-15", wantOK: true},
+		{name: "from and to", args: []string{"2026-01-01", "30.06.2026"}, wantFrom: "2026-01-01", wantTo: "2026-06-30", wantOK: true},
+		{name: "to before from", args: []string{"30.06.2026", "01.01.2026"}},
+		{name: "invalid date", args: []string{"yesterday"}},
+		{name: "too many args", args: []string{"01.01.2026", "30.06.2026", "31.12.2026"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, ok := parseSongUsageReportPeriod(tt.args, now, loc)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := from.Format("2006-01-02"); got != tt.wantFrom {
+				t.Fatalf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := to.Format("2006-01-02"); got != tt.wantTo {
+				t.Fatalf("to = %s, want %s", got, tt.wantTo)
+			}
+			if from.Location() != loc || to.Location() != loc {
+				t.Fatalf("dates must be in band location")
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"net/url"
 	"os"
 	"slices"
@@ -17,6 +18,7 @@ import (
 	"github.com/joeyave/scala-bot/entity"
 	"github.com/joeyave/scala-bot/helpers"
 	"github.com/joeyave/scala-bot/keyboard"
+	"github.com/joeyave/scala-bot/service"
 	"github.com/joeyave/scala-bot/state"
 	"github.com/joeyave/scala-bot/txt"
 	"github.com/joeyave/scala-bot/util"
@@ -278,7 +280,8 @@ func (c *BotController) GetSongs(index int) handlers.Response {
 				case txt.Get("button.next", ctx.EffectiveUser.LanguageCode), txt.Get("button.prev", ctx.EffectiveUser.LanguageCode):
 					return c.GetSongs(0)(bot, ctx)
 
-				case txt.Get("button.like", ctx.EffectiveUser.LanguageCode), txt.Get("button.calendar", ctx.EffectiveUser.LanguageCode), txt.Get("button.numbers", ctx.EffectiveUser.LanguageCode), txt.Get("button.tag", ctx.EffectiveUser.LanguageCode):
+				case txt.Get("button.like", ctx.EffectiveUser.LanguageCode), txt.Get("button.calendar", ctx.EffectiveUser.LanguageCode), txt.Get("button.numbers", ctx.EffectiveUser.LanguageCode), txt.Get("button.tag", ctx.EffectiveUser.LanguageCode),
+					txt.Get("button.songFilter", ctx.EffectiveUser.LanguageCode):
 					return c.filterSongs(0)(bot, ctx)
 				}
 
@@ -333,6 +336,11 @@ func (c *BotController) filterSongs(index int) handlers.Response {
 				case txt.Get("button.tag", ctx.EffectiveUser.LanguageCode):
 					user.Cache.Filter = ctx.EffectiveMessage.Text
 					return c.filterSongs(2)(bot, ctx)
+
+				case txt.Get("button.songFilter", ctx.EffectiveUser.LanguageCode):
+					user.Cache.Filter = ctx.EffectiveMessage.Text
+					user.Cache.Query = ""
+					return c.filterSongs(7)(bot, ctx)
 				}
 
 				var (
@@ -358,6 +366,15 @@ func (c *BotController) filterSongs(index int) handlers.Response {
 						user.Cache.Query = ctx.EffectiveMessage.Text
 					}
 					songs, err = c.SongService.FindManyExtraByTag(user.Cache.Query, user.BandID, statsPeriodStartDate, user.Cache.PageIndex)
+				case txt.Get("button.songFilter", ctx.EffectiveUser.LanguageCode):
+					if keyboard.IsSelectedButton(ctx.EffectiveMessage.Text) {
+						return c.GetSongs(0)(bot, ctx)
+					}
+					if user.Cache.Query == "" {
+						user.Cache.Query = ctx.EffectiveMessage.Text
+					}
+					filter, query, _ := service.ParseSongFilter(user.Cache.Query, user.Band.GetNowTime())
+					songs, err = c.SongService.FindManyExtraByFilter(user.BandID, filter, query, statsPeriodStartDate, user.Cache.PageIndex)
 				}
 				if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 					return err
@@ -412,7 +429,8 @@ func (c *BotController) filterSongs(index int) handlers.Response {
 			{
 				switch ctx.EffectiveMessage.Text {
 				case txt.Get("button.like", ctx.EffectiveUser.LanguageCode), txt.Get("button.calendar", ctx.EffectiveUser.LanguageCode),
-					txt.Get("button.numbers", ctx.EffectiveUser.LanguageCode), txt.Get("button.tag", ctx.EffectiveUser.LanguageCode):
+					txt.Get("button.numbers", ctx.EffectiveUser.LanguageCode), txt.Get("button.tag", ctx.EffectiveUser.LanguageCode),
+					txt.Get("button.songFilter", ctx.EffectiveUser.LanguageCode):
 					user.Cache.PageIndex = 0
 					return c.filterSongs(0)(bot, ctx)
 				case txt.Get("button.next", ctx.EffectiveUser.LanguageCode):
@@ -574,6 +592,33 @@ func (c *BotController) filterSongs(index int) handlers.Response {
 			statsSorting := keyboard.GetStatsSortingByButtonText(ctx.EffectiveMessage.Text, ctx.EffectiveUser.LanguageCode)
 			user.Cache.StatsSorting = statsSorting
 			return c.filterSongs(0)(bot, ctx)
+		case 7:
+			{
+				markup := &gotgbot.ReplyKeyboardMarkup{
+					ResizeKeyboard:        true,
+					InputFieldPlaceholder: "key:G bpm:120-140 #tag",
+				}
+
+				filterButtons := keyboard.GetSongsStateFilterButtons(ctx.EffectiveUser.LanguageCode)
+				for i := range filterButtons {
+					if filterButtons[i].Text == user.Cache.Filter {
+						filterButtons[i] = keyboard.SelectedButton(filterButtons[i].Text)
+						break
+					}
+				}
+				markup.Keyboard = append(markup.Keyboard, filterButtons)
+
+				_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.songFilterHelp", ctx.EffectiveUser.LanguageCode), &gotgbot.SendMessageOpts{
+					ParseMode:   "HTML",
+					ReplyMarkup: markup,
+				})
+				if err != nil {
+					return err
+				}
+
+				user.State.Index = 0
+				return nil
+			}
 		}
 
 		return c.Menu(bot, ctx)
@@ -753,19 +798,18 @@ func (c *BotController) songVoices(bot *gotgbot.Bot, ctx *ext.Context, songID bs
 
 	markup := gotgbot.InlineKeyboardMarkup{}
 
-	slices.SortStableFunc(song.Voices, func(v1, v2 *entity.Voice) int {
-		if v1.Name < v2.Name {
-			return -1
-		} else if v1.Name > v2.Name {
-			return 1
-		}
-		return 0
-	})
+	entity.SortVoices(song.Voices)
 	for _, voice := range song.Voices {
-		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: voice.Name, CallbackData: util.CallbackData(state.SongVoice, song.ID.Hex()+":"+voice.ID.Hex())}})
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: voiceButtonText(voice, ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongVoice, song.ID.Hex()+":"+voice.ID.Hex())}})
 	}
 
 	if ctx.EffectiveMessage != nil {
+		// The mixer and the click send their own messages, so they're not available in inline messages.
+		row := []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.metronome", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongClickTrack, song.ID.Hex())}}
+		if len(song.Voices) > 1 {
+			row = append(row, gotgbot.InlineKeyboardButton{Text: txt.Get("button.voiceMixer", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongVoicesMix, song.ID.Hex())})
+		}
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
 		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.addVoice", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongVoicesCreateVoiceAskForAudio, song.ID.Hex())}})
 	} else {
 		deeplink, err := url.Parse(fmt.Sprintf("https://t.me/%s?start=addVoice%s", bot.Username, song.ID.Hex()))
@@ -854,21 +898,25 @@ func (c *BotController) SongVoices_CreateVoice(index int) handlers.Response {
 		switch index {
 		case 0:
 			{
-				_, _ = ctx.EffectiveChat.SendAction(bot, "typing", nil)
-
 				fileID := ""
 				if ctx.EffectiveMessage.Voice != nil {
 					fileID = ctx.EffectiveMessage.Voice.FileId
+					user.Cache.Voice.Duration = ctx.EffectiveMessage.Voice.Duration
 				} else {
 					fileID = ctx.EffectiveMessage.Audio.FileId
+					user.Cache.Voice.Duration = ctx.EffectiveMessage.Audio.Duration
 				}
 				user.Cache.Voice.FileID = fileID
+				user.Cache.Voice.UploaderID = user.ID
+				user.Cache.Voice.CreatedAt = time.Now()
+
+				text := txt.Get("text.sendVoiceName", ctx.EffectiveUser.LanguageCode)
 
 				markup := &gotgbot.ReplyKeyboardMarkup{
 					Keyboard:       [][]gotgbot.KeyboardButton{{{Text: txt.Get("button.menu", ctx.EffectiveUser.LanguageCode)}}},
 					ResizeKeyboard: true,
 				}
-				_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.sendVoiceName", ctx.EffectiveUser.LanguageCode), &gotgbot.SendMessageOpts{
+				_, err := ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
 					ReplyMarkup: markup,
 				})
 				if err != nil {
@@ -882,7 +930,60 @@ func (c *BotController) SongVoices_CreateVoice(index int) handlers.Response {
 			{
 				user.Cache.Voice.Name = ctx.EffectiveMessage.Text
 
-				_, err := c.VoiceService.UpdateOne(*user.Cache.Voice)
+				user.State.Index = 2
+				return askForVoicePart(bot, ctx)
+			}
+		case 2:
+			{
+				lang := ctx.EffectiveUser.LanguageCode
+				if ctx.EffectiveMessage.Text != txt.Get("button.skip", lang) {
+					part, ok := voicePartByText(ctx.EffectiveMessage.Text, lang)
+					if !ok {
+						return askForVoicePart(bot, ctx)
+					}
+					user.Cache.Voice.Part = part
+				}
+
+				if user.Band == nil || len(user.Band.Roles) == 0 {
+					user.State.Index = 4
+					return askForVoiceDescription(bot, ctx)
+				}
+
+				user.State.Index = 3
+				return askForVoiceRole(bot, ctx, user.Band.Roles)
+			}
+		case 3:
+			{
+				lang := ctx.EffectiveUser.LanguageCode
+				if ctx.EffectiveMessage.Text != txt.Get("button.skip", lang) {
+					i := slices.IndexFunc(user.Band.Roles, func(role *entity.Role) bool {
+						return role.Name == ctx.EffectiveMessage.Text
+					})
+					if i == -1 {
+						return askForVoiceRole(bot, ctx, user.Band.Roles)
+					}
+					user.Cache.Voice.RoleID = user.Band.Roles[i].ID
+				}
+
+				user.State.Index = 4
+				return askForVoiceDescription(bot, ctx)
+			}
+		case 4:
+			{
+				if ctx.EffectiveMessage.Text != txt.Get("button.skip", ctx.EffectiveUser.LanguageCode) {
+					user.Cache.Voice.Description = ctx.EffectiveMessage.Text
+				}
+
+				// New voices go to the end of the list.
+				voices, err := c.VoiceService.FindManyBySongID(user.Cache.Voice.SongID)
+				if err != nil {
+					return err
+				}
+				for _, voice := range voices {
+					user.Cache.Voice.Position = max(user.Cache.Voice.Position, voice.Position+1)
+				}
+
+				voice, err := c.VoiceService.UpdateOne(*user.Cache.Voice)
 				if err != nil {
 					return err
 				}
@@ -892,6 +993,9 @@ func (c *BotController) SongVoices_CreateVoice(index int) handlers.Response {
 					return err
 				}
 
+				// The key and the tempo are sent when they are detected.
+				go c.detectVoiceAnalysis(bot, ctx.EffectiveChat.Id, voice, ctx.EffectiveUser.LanguageCode)
+
 				song, err := c.SongService.FindOneByID(user.Cache.Voice.SongID)
 				if err != nil {
 					return err
@@ -907,6 +1011,203 @@ func (c *BotController) SongVoices_CreateVoice(index int) handlers.Response {
 	}
 }
 
+// SongVoiceEditAskForPart starts changing the part, the role and the description of the existing voice.
+func (c *BotController) SongVoiceEditAskForPart(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+
+	voiceID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+
+	voice, err := c.VoiceService.FindOneByID(voiceID)
+	if err != nil {
+		return err
+	}
+
+	err = askForVoicePart(bot, ctx)
+	if err != nil {
+		return err
+	}
+
+	// Skipped questions keep the current values.
+	user.State = entity.State{
+		Name: state.SongVoiceEdit,
+	}
+	user.Cache = entity.Cache{
+		Voice: &entity.Voice{ID: voice.ID, SongID: voice.SongID, Part: voice.Part, RoleID: voice.RoleID, Description: voice.Description},
+	}
+
+	_, err = c.UserService.UpdateOne(*user)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// SongVoiceEdit asks for the part, the role and the description of the voice and saves them.
+func (c *BotController) SongVoiceEdit(index int) handlers.Response {
+	return func(bot *gotgbot.Bot, ctx *ext.Context) error {
+		user := ctx.Data["user"].(*entity.User)
+		lang := ctx.EffectiveUser.LanguageCode
+
+		if user.Cache.Voice == nil {
+			return c.Menu(bot, ctx)
+		}
+
+		switch index {
+		case 0:
+			if ctx.EffectiveMessage.Text != txt.Get("button.skip", lang) {
+				part, ok := voicePartByText(ctx.EffectiveMessage.Text, lang)
+				if !ok {
+					return askForVoicePart(bot, ctx)
+				}
+				user.Cache.Voice.Part = part
+			}
+
+			if user.Band == nil || len(user.Band.Roles) == 0 {
+				user.State.Index = 2
+				return askForVoiceDescription(bot, ctx)
+			}
+
+			user.State.Index = 1
+			return askForVoiceRole(bot, ctx, user.Band.Roles)
+		case 1:
+			if ctx.EffectiveMessage.Text != txt.Get("button.skip", lang) {
+				i := slices.IndexFunc(user.Band.Roles, func(role *entity.Role) bool {
+					return role.Name == ctx.EffectiveMessage.Text
+				})
+				if i == -1 {
+					return askForVoiceRole(bot, ctx, user.Band.Roles)
+				}
+				user.Cache.Voice.RoleID = user.Band.Roles[i].ID
+			}
+
+			user.State.Index = 2
+			return askForVoiceDescription(bot, ctx)
+		case 2:
+			if ctx.EffectiveMessage.Text != txt.Get("button.skip", lang) {
+				user.Cache.Voice.Description = ctx.EffectiveMessage.Text
+			}
+
+			voice := user.Cache.Voice
+			err := c.VoiceService.UpdateDetails(voice.ID, voice.Part, voice.RoleID, voice.Description)
+			if err != nil {
+				return err
+			}
+
+			_, err = ctx.EffectiveChat.SendMessage(bot, txt.Get("text.voiceUpdated", lang), nil)
+			if err != nil {
+				return err
+			}
+
+			song, err := c.SongService.FindOneByID(voice.SongID)
+			if err != nil {
+				return err
+			}
+			err = c.songByDriveFileID(bot, ctx, song.DriveFileID)
+			if err != nil {
+				return err
+			}
+			return c.Menu(bot, ctx)
+		}
+		return c.Menu(bot, ctx)
+	}
+}
+
+func askForVoicePart(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	markup := &gotgbot.ReplyKeyboardMarkup{ResizeKeyboard: true}
+	for i, part := range entity.VoiceParts {
+		if i%3 == 0 {
+			markup.Keyboard = append(markup.Keyboard, []gotgbot.KeyboardButton{})
+		}
+		markup.Keyboard[len(markup.Keyboard)-1] = append(markup.Keyboard[len(markup.Keyboard)-1], gotgbot.KeyboardButton{Text: txt.Get("voicePart."+string(part), lang)})
+	}
+	markup.Keyboard = append(markup.Keyboard, []gotgbot.KeyboardButton{{Text: txt.Get("button.menu", lang)}, {Text: txt.Get("button.skip", lang)}})
+
+	_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.chooseVoicePart", lang), &gotgbot.SendMessageOpts{
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+func askForVoiceRole(bot *gotgbot.Bot, ctx *ext.Context, roles []*entity.Role) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	markup := &gotgbot.ReplyKeyboardMarkup{ResizeKeyboard: true}
+	for _, role := range roles {
+		markup.Keyboard = append(markup.Keyboard, []gotgbot.KeyboardButton{{Text: role.Name}})
+	}
+	markup.Keyboard = append(markup.Keyboard, []gotgbot.KeyboardButton{{Text: txt.Get("button.menu", lang)}, {Text: txt.Get("button.skip", lang)}})
+
+	_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.chooseVoiceRole", lang), &gotgbot.SendMessageOpts{
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+func askForVoiceDescription(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	markup := &gotgbot.ReplyKeyboardMarkup{
+		Keyboard:       [][]gotgbot.KeyboardButton{{{Text: txt.Get("button.menu", lang)}, {Text: txt.Get("button.skip", lang)}}},
+		ResizeKeyboard: true,
+	}
+	_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.sendVoiceDescription", lang), &gotgbot.SendMessageOpts{
+		ReplyMarkup: markup,
+	})
+	return err
+}
+
+func voicePartByText(text, lang string) (entity.VoicePart, bool) {
+	for _, part := range entity.VoiceParts {
+		if txt.Get("voicePart."+string(part), lang) == text {
+			return part, true
+		}
+	}
+	return "", false
+}
+
+// voiceButtonText is the name of the voice with its part.
+func voiceButtonText(voice *entity.Voice, lang string) string {
+	if voice.Part == "" {
+		return voice.Name
+	}
+	return fmt.Sprintf("%s (%s)", voice.Name, txt.Get("voicePart."+string(voice.Part), lang))
+}
+
+// voiceCaption describes the voice: its part, role, duration, who and when uploaded it and the description.
+func (c *BotController) voiceCaption(voice *entity.Voice, lang string) string {
+	var lines []string
+	if voice.Part != "" {
+		lines = append(lines, txt.Get("text.voicePart", lang, txt.Get("voicePart."+string(voice.Part), lang)))
+	}
+	if !voice.RoleID.IsZero() {
+		if role, err := c.RoleService.FindOneByID(voice.RoleID); err == nil {
+			lines = append(lines, txt.Get("text.voiceRole", lang, html.EscapeString(role.Name)))
+		}
+	}
+	if voice.Duration > 0 {
+		lines = append(lines, txt.Get("text.voiceDuration", lang, fmt.Sprintf("%d:%02d", voice.Duration/60, voice.Duration%60)))
+	}
+	if voice.UploaderID != 0 {
+		if uploader, err := c.UserService.FindOneByID(voice.UploaderID); err == nil {
+			lines = append(lines, txt.Get("text.voiceUploader", lang, html.EscapeString(uploader.Name)))
+		}
+	}
+	if !voice.CreatedAt.IsZero() {
+		lines = append(lines, txt.Get("text.voiceCreatedAt", lang, voice.CreatedAt.Format("02.01.2006")))
+	}
+	if voice.Description != "" {
+		lines = append(lines, "", html.EscapeString(voice.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (c *BotController) SongVoice(bot *gotgbot.Bot, ctx *ext.Context) error {
 	user := ctx.Data["user"].(*entity.User)
 
@@ -943,9 +1244,31 @@ func (c *BotController) SongVoice(bot *gotgbot.Bot, ctx *ext.Context) error {
 
 	if ctx.EffectiveMessage != nil {
 		markup.InlineKeyboard[0] = append(markup.InlineKeyboard[0], gotgbot.InlineKeyboardButton{Text: txt.Get("button.delete", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongVoiceDeleteConfirm, song.ID.Hex()+":"+voice.ID.Hex())})
+		markup.InlineKeyboard = append([][]gotgbot.InlineKeyboardButton{{
+			{Text: txt.Get("button.voiceSegments", ctx.EffectiveUser.LanguageCode, len(voice.Segments)), CallbackData: util.CallbackData(state.VoiceSegments, voice.ID.Hex())},
+			{Text: txt.Get("button.edit", ctx.EffectiveUser.LanguageCode), CallbackData: util.CallbackData(state.SongVoiceEditAskForPart, voice.ID.Hex())},
+		}}, markup.InlineKeyboard...)
+		if len(song.Voices) > 1 {
+			markup.InlineKeyboard = append([][]gotgbot.InlineKeyboardButton{{
+				{Text: "⬆️", CallbackData: util.CallbackData(state.SongVoiceMove, song.ID.Hex()+":"+voice.ID.Hex()+":-1")},
+				{Text: "⬇️", CallbackData: util.CallbackData(state.SongVoiceMove, song.ID.Hex()+":"+voice.ID.Hex()+":1")},
+			}}, markup.InlineKeyboard...)
+		}
 	}
 
-	caption := user.CallbackCache.AddToText(song.Caption())
+	if event, eventKey := c.songUpcomingEventKey(song); event != nil {
+		if semitones, ok := service.SuggestSemitones(voiceKey(voice, song), eventKey); ok && semitones != 0 {
+			markup.InlineKeyboard = append([][]gotgbot.InlineKeyboardButton{
+				{{Text: txt.Get("button.transposeToEventKey", ctx.EffectiveUser.LanguageCode, eventKey), CallbackData: util.CallbackData(state.VoiceTransposeToEventKey, event.ID.Hex()+":"+voice.ID.Hex())}},
+			}, markup.InlineKeyboard...)
+		}
+	}
+
+	caption := song.Caption()
+	if details := c.voiceCaption(voice, ctx.EffectiveUser.LanguageCode); details != "" {
+		caption += "\n\n" + details
+	}
+	caption = user.CallbackCache.AddToText(caption)
 
 	if voice.AudioFileID == "" {
 		f, err := bot.GetFile(voice.FileID, nil)
@@ -1013,6 +1336,34 @@ func (c *BotController) SongVoice(bot *gotgbot.Bot, ctx *ext.Context) error {
 	return nil
 }
 
+// SongVoiceMove moves the voice up or down in the list of the song voices.
+func (c *BotController) SongVoiceMove(bot *gotgbot.Bot, ctx *ext.Context) error {
+	split := strings.Split(util.ParseCallbackPayload(ctx.CallbackQuery.Data), ":")
+	if len(split) < 3 {
+		return answerOutdatedButton(bot, ctx)
+	}
+
+	songID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+	voiceID, err := bson.ObjectIDFromHex(split[1])
+	if err != nil {
+		return err
+	}
+	shift, err := strconv.Atoi(split[2])
+	if err != nil {
+		return err
+	}
+
+	err = c.VoiceService.Move(voiceID, shift)
+	if err != nil {
+		return err
+	}
+
+	return c.songVoices(bot, ctx, songID)
+}
+
 func (c *BotController) SongVoiceDeleteConfirm(bot *gotgbot.Bot, ctx *ext.Context) error {
 	user := ctx.Data["user"].(*entity.User)
 
@@ -0,0 +1,400 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/service"
+	"github.com/joeyave/scala-bot/state"
+	"github.com/joeyave/scala-bot/txt"
+	"github.com/joeyave/scala-bot/util"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// voiceSegmentRange returns the start and the end of the segment like "2:10–2:45".
+func voiceSegmentRange(segment *entity.VoiceSegment) string {
+	return fmt.Sprintf("%s–%s",
+		service.FormatTimestamp(time.Duration(segment.Start)*time.Millisecond),
+		service.FormatTimestamp(time.Duration(segment.End)*time.Millisecond))
+}
+
+func voiceSegmentsMessage(voice *entity.Voice, lang string) (string, gotgbot.InlineKeyboardMarkup) {
+	markup := gotgbot.InlineKeyboardMarkup{}
+	for _, segment := range voice.Segments {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+			{Text: fmt.Sprintf("%s · %s", segment.Name, voiceSegmentRange(segment)), CallbackData: util.CallbackData(state.VoiceSegment, voice.ID.Hex()+":"+segment.ID.Hex())},
+		})
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+		{Text: txt.Get("button.addVoiceSegment", lang), CallbackData: util.CallbackData(state.VoiceSegmentCreateAskForStart, voice.ID.Hex())},
+	})
+
+	text := txt.Get("text.voiceSegments", lang)
+	if len(voice.Segments) == 0 {
+		text = txt.Get("text.noVoiceSegments", lang)
+	}
+	return fmt.Sprintf("<b>%s</b>\n\n%s", html.EscapeString(voice.Name), text), markup
+}
+
+func voiceSegmentMessage(voice *entity.Voice, segment *entity.VoiceSegment, lang string) (string, gotgbot.InlineKeyboardMarkup) {
+	markup := gotgbot.InlineKeyboardMarkup{}
+
+	var loopsRow []gotgbot.InlineKeyboardButton
+	for _, loops := range service.AudioLoopPresets {
+		loopsRow = append(loopsRow, gotgbot.InlineKeyboardButton{Text: fmt.Sprintf("×%d", loops), CallbackData: util.CallbackData(state.VoiceSegmentRun, fmt.Sprintf("%s:%s:%d", voice.ID.Hex(), segment.ID.Hex(), loops))})
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, loopsRow)
+	markup.InlineKeyboard = append(markup.InlineKeyboard, []gotgbot.InlineKeyboardButton{
+		{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.VoiceSegments, voice.ID.Hex()+":edit")},
+		{Text: txt.Get("button.delete", lang), CallbackData: util.CallbackData(state.VoiceSegmentDelete, voice.ID.Hex()+":"+segment.ID.Hex())},
+	})
+
+	length := time.Duration(segment.End-segment.Start) * time.Millisecond
+	text := fmt.Sprintf("<b>%s</b> · %s\n%s (%s)\n\n%s",
+		html.EscapeString(voice.Name), html.EscapeString(segment.Name), voiceSegmentRange(segment), service.FormatTimestamp(length),
+		txt.Get("text.chooseVoiceSegmentLoops", lang))
+	return text, markup
+}
+
+// VoiceSegments lists the saved segments of the voice. Payload is voiceID, or voiceID:edit to edit the message instead of sending the new one.
+func (c *BotController) VoiceSegments(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	split := strings.Split(util.ParseCallbackPayload(ctx.CallbackQuery.Data), ":")
+	voiceID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return err
+	}
+
+	voice, err := c.VoiceService.FindOneByID(voiceID)
+	if err != nil {
+		return err
+	}
+
+	text, markup := voiceSegmentsMessage(voice, lang)
+	if len(split) > 1 {
+		_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	} else {
+		_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: markup,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// VoiceSegment shows the segment with the buttons to cut it and repeat it.
+func (c *BotController) VoiceSegment(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	voice, segment, err := c.voiceSegmentFromPayload(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+	if segment == nil {
+		return c.voiceSegmentNotFound(bot, ctx, voice)
+	}
+
+	text, markup := voiceSegmentMessage(voice, segment, lang)
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+func (c *BotController) VoiceSegmentDelete(bot *gotgbot.Bot, ctx *ext.Context) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	voice, segment, err := c.voiceSegmentFromPayload(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+	if segment != nil {
+		voice, err = c.VoiceService.DeleteSegment(voice.ID, segment.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	text, markup := voiceSegmentsMessage(voice, lang)
+	_, _, err = ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.voiceSegmentDeleted", lang),
+	})
+	return nil
+}
+
+// VoiceSegmentRun puts cutting of the segment repeated the chosen number of times to the job queue.
+// Payload is voiceID:segmentID:loops.
+func (c *BotController) VoiceSegmentRun(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	payload := util.ParseCallbackPayload(ctx.CallbackQuery.Data)
+	voice, segment, err := c.voiceSegmentFromPayload(payload)
+	if err != nil {
+		return err
+	}
+	if segment == nil {
+		return c.voiceSegmentNotFound(bot, ctx, voice)
+	}
+
+	loops, err := strconv.Atoi(payload[strings.LastIndex(payload, ":")+1:])
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(service.AudioLoopPresets, loops) {
+		return fmt.Errorf("%w: unsupported loops %d", service.ErrInvalidOperation, loops)
+	}
+
+	trim := entity.AudioJobTrim{Start: segment.Start, End: segment.End, Loops: loops}
+	if err := service.ValidateAudioTrim(trim); err != nil {
+		_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+			Text:      txt.Get("text.voiceSegmentTooLong", lang, service.FormatTimestamp(service.MaxAudioTrimDuration)),
+			ShowAlert: true,
+		})
+		return nil
+	}
+
+	song, err := c.SongService.FindOneByID(voice.SongID)
+	if err != nil {
+		return err
+	}
+
+	audio, err := voiceAudioJobFile(bot, voice, song)
+	if err != nil {
+		return err
+	}
+	audio.Title = fmt.Sprintf("%s · %s", voice.Name, segment.Name)
+	if loops > 1 {
+		audio.Title += fmt.Sprintf(" ×%d", loops)
+	}
+	audio.FileName = audio.Title + filepath.Ext(audio.FileName)
+
+	job := entity.AudioJob{
+		UserID:       user.ID,
+		BandID:       user.BandID,
+		ChatID:       ctx.EffectiveChat.Id,
+		LanguageCode: lang,
+		Output:       user.AudioOutput,
+		Audio:        audio,
+		Trim:         &trim,
+	}
+
+	// The segment card stays, so the other number of repeats can be chosen.
+	if c.sendProcessedAudio(bot, &job) {
+		_, _ = ctx.CallbackQuery.Answer(bot, nil)
+		return nil
+	}
+
+	msg, err := bot.SendMessage(job.ChatID, txt.Get("text.audioStarting", lang), nil)
+	if err != nil {
+		return err
+	}
+	job.MessageID = msg.MessageId
+
+	enqueued, err := c.enqueueAudioJob(bot, ctx, job)
+	if !enqueued {
+		_, _ = msg.Delete(bot, nil)
+	}
+	return err
+}
+
+// voiceSegmentFromPayload returns the voice and its segment from the payload voiceID:segmentID[:...].
+// The segment is nil if it was deleted.
+func (c *BotController) voiceSegmentFromPayload(payload string) (*entity.Voice, *entity.VoiceSegment, error) {
+	split := strings.Split(payload, ":")
+	if len(split) < 2 {
+		return nil, nil, fmt.Errorf("%w: bad voice segment payload %q", service.ErrInvalidOperation, payload)
+	}
+
+	voiceID, err := bson.ObjectIDFromHex(split[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	segmentID, err := bson.ObjectIDFromHex(split[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	voice, err := c.VoiceService.FindOneByID(voiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return voice, voice.GetSegment(segmentID), nil
+}
+
+func (c *BotController) voiceSegmentNotFound(bot *gotgbot.Bot, ctx *ext.Context, voice *entity.Voice) error {
+	lang := ctx.EffectiveUser.LanguageCode
+
+	text, markup := voiceSegmentsMessage(voice, lang)
+	_, _, err := ctx.EffectiveMessage.EditText(bot, text, &gotgbot.EditMessageTextOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, &gotgbot.AnswerCallbackQueryOpts{
+		Text: txt.Get("text.voiceSegmentNotFound", lang),
+	})
+	return nil
+}
+
+func (c *BotController) VoiceSegmentCreateAskForStart(bot *gotgbot.Bot, ctx *ext.Context) error {
+	user := ctx.Data["user"].(*entity.User)
+	lang := ctx.EffectiveUser.LanguageCode
+
+	voiceID, err := bson.ObjectIDFromHex(util.ParseCallbackPayload(ctx.CallbackQuery.Data))
+	if err != nil {
+		return err
+	}
+
+	voice, err := c.VoiceService.FindOneByID(voiceID)
+	if err != nil {
+		return err
+	}
+
+	text := txt.Get("text.sendVoiceSegmentStart", lang)
+	if voice.Duration > 0 {
+		text = txt.Get("text.voiceDuration", lang, service.FormatTimestamp(time.Duration(voice.Duration)*time.Second)) + "\n\n" + text
+	}
+
+	markup := &gotgbot.ReplyKeyboardMarkup{
+		Keyboard:       [][]gotgbot.KeyboardButton{{{Text: txt.Get("button.menu", lang)}}},
+		ResizeKeyboard: true,
+	}
+	_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+		ParseMode:   "HTML",
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return err
+	}
+
+	user.State = entity.State{
+		Name: state.VoiceSegmentCreate,
+	}
+	user.Cache = entity.Cache{
+		Voice:        &entity.Voice{ID: voice.ID, Duration: voice.Duration},
+		VoiceSegment: &entity.VoiceSegment{},
+	}
+
+	_, err = c.UserService.UpdateOne(*user)
+	if err != nil {
+		return err
+	}
+
+	_, _ = ctx.CallbackQuery.Answer(bot, nil)
+	return nil
+}
+
+// VoiceSegmentCreate asks for the start, the end and the name of the segment and saves it to the voice.
+func (c *BotController) VoiceSegmentCreate(index int) handlers.Response {
+	return func(bot *gotgbot.Bot, ctx *ext.Context) error {
+		user := ctx.Data["user"].(*entity.User)
+		lang := ctx.EffectiveUser.LanguageCode
+
+		if user.Cache.Voice == nil || user.Cache.VoiceSegment == nil {
+			return c.Menu(bot, ctx)
+		}
+
+		markup := &gotgbot.ReplyKeyboardMarkup{
+			Keyboard:       [][]gotgbot.KeyboardButton{{{Text: txt.Get("button.menu", lang)}}},
+			ResizeKeyboard: true,
+		}
+
+		switch index {
+		case 0:
+			start, ok := service.ParseTimestamp(ctx.EffectiveMessage.Text)
+			if !ok || (user.Cache.Voice.Duration > 0 && start >= time.Duration(user.Cache.Voice.Duration)*time.Second) {
+				_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.badTimestamp", lang), &gotgbot.SendMessageOpts{ReplyMarkup: markup})
+				return err
+			}
+			user.Cache.VoiceSegment.Start = start.Milliseconds()
+
+			_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.sendVoiceSegmentEnd", lang), &gotgbot.SendMessageOpts{ReplyMarkup: markup})
+			if err != nil {
+				return err
+			}
+
+			user.State.Index = 1
+			return nil
+		case 1:
+			end, ok := service.ParseTimestamp(ctx.EffectiveMessage.Text)
+			if !ok || end.Milliseconds() <= user.Cache.VoiceSegment.Start {
+				_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.badVoiceSegmentEnd", lang), &gotgbot.SendMessageOpts{ReplyMarkup: markup})
+				return err
+			}
+			user.Cache.VoiceSegment.End = end.Milliseconds()
+
+			_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.sendVoiceSegmentName", lang), &gotgbot.SendMessageOpts{ReplyMarkup: markup})
+			if err != nil {
+				return err
+			}
+
+			user.State.Index = 2
+			return nil
+		case 2:
+			segment := *user.Cache.VoiceSegment
+			segment.Name = ctx.EffectiveMessage.Text
+
+			voice, err := c.VoiceService.AddSegment(user.Cache.Voice.ID, segment)
+			if errors.Is(err, service.ErrInvalidOperation) {
+				_, err := ctx.EffectiveChat.SendMessage(bot, txt.Get("text.sendVoiceSegmentName", lang), &gotgbot.SendMessageOpts{ReplyMarkup: markup})
+				return err
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = ctx.EffectiveChat.SendMessage(bot, txt.Get("text.added", lang), nil)
+			if err != nil {
+				return err
+			}
+
+			text, inlineMarkup := voiceSegmentsMessage(voice, lang)
+			_, err = ctx.EffectiveChat.SendMessage(bot, text, &gotgbot.SendMessageOpts{
+				ParseMode:   "HTML",
+				ReplyMarkup: inlineMarkup,
+			})
+			if err != nil {
+				return err
+			}
+			return c.Menu(bot, ctx)
+		}
+		return c.Menu(bot, ctx)
+	}
+}
@@ -3,11 +3,14 @@ package controller
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/joeyave/scala-bot/service"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/drive/v3"
 )
 
 type DriveFileController struct {
 	DriveFileService *service.DriveFileService
 	SongService      *service.SongService
+	BandService      *service.BandService
 }
 
 func (c *DriveFileController) SearchV2(ctx *gin.Context) {
@@ -15,11 +18,29 @@ func (c *DriveFileController) SearchV2(ctx *gin.Context) {
 	folderID := ctx.Query("driveFolderId")
 	archiveFolderID := ctx.Query("archiveFolderId")
 
-	driveFiles, _, err := c.DriveFileService.FindSomeByFullTextAndFolderID(query, []string{folderID, archiveFolderID}, "")
+	band, bandErr := c.BandService.FindOneByDriveFolderID(folderID)
+
+	var (
+		driveFiles []*drive.File
+		err        error
+	)
+	if bandErr == nil {
+		driveFiles, _, err = c.SongService.SearchDriveFiles(band, query, "")
+	} else {
+		driveFiles, _, err = c.DriveFileService.FindSomeByFullTextAndFolderID(query, []string{folderID, archiveFolderID}, "")
+	}
 	if err != nil {
 		return
 	}
 
+	if bandErr == nil {
+		infoDriveFiles, err := c.SongService.FindDriveFilesByInfo(band.ID, query)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding songs by info")
+		}
+		driveFiles = mergeDriveFiles(infoDriveFiles, driveFiles)
+	}
+
 	ctx.JSON(200, gin.H{
 		"data": gin.H{
 			"driveFiles": driveFiles,
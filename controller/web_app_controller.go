@@ -1,17 +1,23 @@
 package controller
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/helpers"
 	"github.com/joeyave/scala-bot/keyboard"
+	"github.com/joeyave/scala-bot/repository"
 	"github.com/joeyave/scala-bot/service"
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -54,6 +60,8 @@ type webAppDriveFileService interface {
 	DownloadOneByID(string) (io.ReadCloser, error)
 	StyleOne(string, string) (*drive.File, error)
 	DownloadOneByIDWithResp(string) (*http.Response, error)
+	DetectRoadMap(string) (string, error)
+	GetChartViewHTML(string, entity.Key, service.ChartView) (string, entity.Key, error)
 }
 
 type webAppSongService interface {
@@ -62,6 +70,14 @@ type webAppSongService interface {
 	UpdateOne(entity.Song) (*entity.Song, error)
 	SyncPDFMetadataByDriveFileID(string) (*entity.Song, *drive.File, error)
 	RetrieveFreshSongsForEvent(*entity.Event) ([]*entity.Song, error)
+	GetUsageReport(bson.ObjectID, time.Time, time.Time) ([]*entity.SongUsage, error)
+	AddArrangement(bson.ObjectID, entity.Arrangement) (*entity.Song, error)
+	UpdateArrangement(bson.ObjectID, entity.Arrangement) (*entity.Song, error)
+	DeleteArrangement(bson.ObjectID, bson.ObjectID) (*entity.Song, error)
+	FilterSongs(bson.ObjectID, entity.SongFilter, string, int) ([]*entity.Song, error)
+	SuggestSongs(bson.ObjectID, []bson.ObjectID, time.Time, []string, int) ([]*entity.SongSuggestion, error)
+	AnalyzeSetlist(*entity.Event) (*entity.SetlistFlow, error)
+	ProposeKeysForEvent(*entity.Event, []bson.ObjectID) ([]*entity.KeyProposal, error)
 }
 
 type webAppJoinRequestService interface {
@@ -74,17 +90,18 @@ type webAppJoinRequestService interface {
 }
 
 type WebAppController struct {
-	Bot                *gotgbot.Bot
-	EventService       webAppEventService
-	UserService        webAppUserService
-	BandService        webAppBandService
-	DriveFileService   webAppDriveFileService
-	SongService        webAppSongService
-	VoiceService       *service.VoiceService
-	MembershipService  *service.MembershipService
-	RoleService        *service.RoleService
-	JoinRequestService webAppJoinRequestService
-	IsTestBotAPI       bool
+	Bot                   *gotgbot.Bot
+	EventService          webAppEventService
+	UserService           webAppUserService
+	BandService           webAppBandService
+	DriveFileService      webAppDriveFileService
+	SongService           webAppSongService
+	VoiceService          *service.VoiceService
+	MembershipService     *service.MembershipService
+	RoleService           *service.RoleService
+	JoinRequestService    webAppJoinRequestService
+	ProcessedAudioService *service.ProcessedAudioService
+	IsTestBotAPI          bool
 }
 
 func (h *WebAppController) Statistics(ctx *gin.Context) {
@@ -124,11 +141,12 @@ type StatisticsRole struct {
 }
 
 type StatisticsResponse struct {
-	BandName        string            `json:"bandName"`
-	CurrentDate     string            `json:"currentDate"`
-	DefaultFromDate string            `json:"defaultFromDate"`
-	Roles           []*StatisticsRole `json:"roles"`
-	Users           []*StatisticsUser `json:"users"`
+	BandName        string                      `json:"bandName"`
+	CurrentDate     string                      `json:"currentDate"`
+	DefaultFromDate string                      `json:"defaultFromDate"`
+	Roles           []*StatisticsRole           `json:"roles"`
+	Users           []*StatisticsUser           `json:"users"`
+	AudioCache      *entity.ProcessedAudioStats `json:"audioCache,omitempty"`
 }
 
 func (h *WebAppController) StatisticsData(ctx *gin.Context) {
@@ -197,6 +215,15 @@ func (h *WebAppController) StatisticsData(ctx *gin.Context) {
 		viewUsers = append(viewUsers, viewUser)
 	}
 
+	var audioCache *entity.ProcessedAudioStats
+	if h.ProcessedAudioService != nil {
+		audioCache, err = h.ProcessedAudioService.GetStatsByBandID(bandID)
+		if err != nil {
+			// The cache stats are not essential for the page.
+			log.Error().Err(err).Msg("failed to get processed audio stats")
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"data": StatisticsResponse{
 			BandName:        band.Name,
@@ -204,6 +231,7 @@ func (h *WebAppController) StatisticsData(ctx *gin.Context) {
 			DefaultFromDate: defaultFromDate.Format("2006-01-02"),
 			Roles:           viewRoles,
 			Users:           viewUsers,
+			AudioCache:      audioCache,
 		},
 	})
 }
@@ -237,6 +265,224 @@ func parseStatisticsFromDate(raw string, fallback time.Time, loc *time.Location)
 	return fallback
 }
 
+// API Song usage report.
+
+func (h *WebAppController) SongUsageReport(ctx *gin.Context) {
+	hex := ctx.Query("bandId")
+	bandID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	band, err := h.BandService.FindOneByID(bandID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	now := band.GetNowTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, band.GetLocation())
+	fromDate := parseStatisticsFromDate(ctx.Query("from"), statisticsDefaultFromDate(now, band.GetLocation()), band.GetLocation())
+	toDate := parseStatisticsFromDate(ctx.Query("to"), today, band.GetLocation())
+
+	// The "to" date is inclusive.
+	usages, err := h.SongService.GetUsageReport(bandID, fromDate, toDate.AddDate(0, 0, 1))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	var buf bytes.Buffer
+	err = service.WriteUsageReportCSV(&buf, usages, band.GetLocation())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, songUsageReportFileName(fromDate, toDate)))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func songUsageReportFileName(from, to time.Time) string {
+	return fmt.Sprintf("song-usage_%s_%s.csv", from.Format("2006-01-02"), to.Format("2006-01-02"))
+}
+
+// API Song filter.
+
+const songFilterMaxLimit = 200
+
+// SongFilter finds band songs by structured filter params and/or the filter syntax in "q", e.g. "key:G bpm:120-140 #fast".
+func (h *WebAppController) SongFilter(ctx *gin.Context) {
+	hex := ctx.Query("bandId")
+	bandID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	band, err := h.BandService.FindOneByID(bandID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	filter, query, err := songFilterFromRequest(ctx, band.GetNowTime())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := helpers.SongsPageSize
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(limit, songFilterMaxLimit)
+	}
+
+	songs, err := h.SongService.FilterSongs(bandID, filter, query, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	if songs == nil {
+		songs = []*entity.Song{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"songs":  songs,
+			"filter": filter,
+		},
+	})
+}
+
+// songFilterFromRequest parses the filter syntax from "q" and adds structured params on top of it.
+// Returns the filter and the rest of "q" to search by text.
+func songFilterFromRequest(ctx *gin.Context, now time.Time) (entity.SongFilter, string, error) {
+	filter, query, _ := service.ParseSongFilter(ctx.Query("q"), now)
+
+	for _, key := range splitQueryList(ctx.Query("keys")) {
+		filter.Keys = append(filter.Keys, entity.Key(key))
+	}
+	filter.Times = append(filter.Times, splitQueryList(ctx.Query("times"))...)
+	filter.Tags = append(filter.Tags, splitQueryList(ctx.Query("tags"))...)
+	filter.ExcludeTags = append(filter.ExcludeTags, splitQueryList(ctx.Query("excludeTags"))...)
+
+	for param, bpm := range map[string]*int{"minBpm": &filter.MinBPM, "maxBpm": &filter.MaxBPM} {
+		raw := ctx.Query(param)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return filter, "", fmt.Errorf("invalid %s", param)
+		}
+		*bpm = value
+	}
+
+	switch archived := entity.SongFilterArchived(ctx.Query("archived")); archived {
+	case "":
+	case entity.SongFilterArchivedOnly, entity.SongFilterArchivedInclude:
+		filter.Archived = archived
+	default:
+		return filter, "", fmt.Errorf("invalid archived")
+	}
+
+	if raw := ctx.Query("notPlayedSince"); raw != "" {
+		since, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil {
+			return filter, "", fmt.Errorf("invalid notPlayedSince")
+		}
+		filter.NotPlayedSince = &since
+	}
+
+	return filter, query, nil
+}
+
+func splitQueryList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// API Song suggestions.
+
+const songSuggestionsDefaultLimit = 10
+
+// SongSuggestions suggests songs to add to the setlist of an event.
+// The setlist is passed in "songIds" in the order of songs, the event date in "date".
+func (h *WebAppController) SongSuggestions(ctx *gin.Context) {
+	hex := ctx.Query("bandId")
+	bandID, err := bson.ObjectIDFromHex(hex)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	band, err := h.BandService.FindOneByID(bandID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	var songIDs []bson.ObjectID
+	for _, hex := range splitQueryList(ctx.Query("songIds")) {
+		songID, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		songIDs = append(songIDs, songID)
+	}
+
+	at := band.GetNowTime()
+	if raw := ctx.Query("date"); raw != "" {
+		at, err = time.ParseInLocation("2006-01-02", raw, at.Location())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+			return
+		}
+	}
+
+	limit := songSuggestionsDefaultLimit
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = min(limit, songFilterMaxLimit)
+	}
+
+	suggestions, err := h.SongService.SuggestSongs(bandID, songIDs, at.UTC(), splitQueryList(ctx.Query("tags")), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"suggestions": suggestions,
+		},
+	})
+}
+
 // API Songs.
 
 func (h *WebAppController) SongData(ctx *gin.Context) {
@@ -329,6 +575,59 @@ func (h *WebAppController) SongLyrics(ctx *gin.Context) {
 	})
 }
 
+// SongChartView renders the song chart with capo shapes (?capo=2) or Nashville numbers (?nashville=true)
+// relative to the song key. The doc is not changed.
+func (h *WebAppController) SongChartView(ctx *gin.Context) {
+	songID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var view service.ChartView
+	if raw := ctx.Query("nashville"); raw != "" {
+		view.Nashville, err = strconv.ParseBool(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !view.Nashville {
+		view.Capo, err = strconv.Atoi(ctx.Query("capo"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	song, err := h.SongService.FindOneByID(songID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	chartHTML, chartKey, err := h.DriveFileService.GetChartViewHTML(song.DriveFileID, song.PDF.Key, view)
+	if errors.Is(err, service.ErrInvalidOperation) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msgf("Error:")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"lyricsHtml": chartHTML,
+			"key":        song.PDF.Key,
+			"chartKey":   chartKey,
+			"capo":       view.Capo,
+			"nashville":  view.Nashville,
+		},
+	})
+}
+
 type EditSongData struct {
 	Name             string     `json:"name"`
 	Key              entity.Key `json:"key"`
@@ -336,6 +635,59 @@ type EditSongData struct {
 	Time             string     `json:"time"`
 	Tags             []string   `json:"tags"`
 	TransposeSection string     `json:"transposeSection"`
+
+	// Extended metadata. Nil means the field is not changed.
+	Authors     *[]string   `json:"authors"`
+	Copyright   *string     `json:"copyright"`
+	CCLINumber  *string     `json:"ccliNumber"`
+	Language    *string     `json:"language"`
+	DurationSec *int        `json:"durationSec"`
+	OriginalKey *entity.Key `json:"originalKey"`
+	RoadMap     *string     `json:"roadMap"`
+
+	// WriteCreditsToDoc writes authors, copyright and CCLI number under the KEY/BPM/TIME line of the doc.
+	WriteCreditsToDoc bool `json:"writeCreditsToDoc"`
+}
+
+var ccliNumberRegex = regexp.MustCompile(`^\d{1,10}$`)
+
+func applyEditSongInfo(song *entity.Song, data *EditSongData) error {
+	if data.Authors != nil {
+		authors := make([]string, 0, len(*data.Authors))
+		for _, author := range *data.Authors {
+			author = strings.TrimSpace(author)
+			if author != "" {
+				authors = append(authors, author)
+			}
+		}
+		song.Authors = authors
+	}
+	if data.Copyright != nil {
+		song.Copyright = strings.TrimSpace(*data.Copyright)
+	}
+	if data.CCLINumber != nil {
+		ccliNumber := strings.TrimSpace(*data.CCLINumber)
+		if ccliNumber != "" && !ccliNumberRegex.MatchString(ccliNumber) {
+			return fmt.Errorf("invalid CCLI number: %s", ccliNumber)
+		}
+		song.CCLINumber = ccliNumber
+	}
+	if data.Language != nil {
+		song.Language = strings.ToLower(strings.TrimSpace(*data.Language))
+	}
+	if data.DurationSec != nil {
+		if *data.DurationSec < 0 {
+			return fmt.Errorf("invalid duration: %d", *data.DurationSec)
+		}
+		song.DurationSec = *data.DurationSec
+	}
+	if data.OriginalKey != nil {
+		song.OriginalKey = entity.Key(strings.TrimSpace(string(*data.OriginalKey)))
+	}
+	if data.RoadMap != nil {
+		song.RoadMap = service.NormalizeRoadMap(*data.RoadMap)
+	}
+	return nil
 }
 
 func (h *WebAppController) SongEdit(ctx *gin.Context) {
@@ -386,6 +738,11 @@ func (h *WebAppController) SongEdit(ctx *gin.Context) {
 	}
 
 	song.Tags = data.Tags
+	if err := applyEditSongInfo(song, data); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	nameChanged := song.PDF.Name != data.Name
 	bpmChanged := song.PDF.BPM != data.BPM
 	timeChanged := song.PDF.Time != data.Time
@@ -448,6 +805,12 @@ func (h *WebAppController) SongEdit(ctx *gin.Context) {
 		metadataChanged = true
 	}
 
+	if data.WriteCreditsToDoc {
+		credits := song.Credits()
+		metadataPatch.Credits = &credits
+		metadataChanged = true
+	}
+
 	if metadataChanged {
 		if err := h.DriveFileService.UpdateMetadataAcrossSections(song.DriveFileID, metadataPatch); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -651,6 +1014,144 @@ func (h *WebAppController) SongDownload(ctx *gin.Context) {
 	)
 }
 
+// SongRoadMap detects the order of sections in the song doc. Detected road-map is not saved.
+func (h *WebAppController) SongRoadMap(ctx *gin.Context) {
+	songID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := h.SongService.FindOneByID(songID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	roadMap, err := h.DriveFileService.DetectRoadMap(song.DriveFileID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"roadMap": roadMap, "defaultRoadMap": song.RoadMap}})
+}
+
+// API Song arrangements.
+
+type ArrangementData struct {
+	Name        string     `json:"name"`
+	Structure   string     `json:"structure"`
+	Key         entity.Key `json:"key"`
+	BPM         string     `json:"bpm"`
+	Time        string     `json:"time"`
+	DriveFileID string     `json:"driveFileId"`
+}
+
+func (d *ArrangementData) ToEntity(arrangementID bson.ObjectID) entity.Arrangement {
+	return entity.Arrangement{
+		ID:          arrangementID,
+		Name:        d.Name,
+		Structure:   d.Structure,
+		Key:         d.Key,
+		BPM:         d.BPM,
+		Time:        d.Time,
+		DriveFileID: d.DriveFileID,
+	}
+}
+
+func (h *WebAppController) SongArrangementCreate(ctx *gin.Context) {
+	songID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var data *ArrangementData
+	err = ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := h.SongService.AddArrangement(songID, data.ToEntity(bson.NilObjectID))
+	if err != nil {
+		h.handleArrangementError(ctx, err)
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"song": song}})
+}
+
+func (h *WebAppController) SongArrangementEdit(ctx *gin.Context) {
+	songID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arrangementID, err := bson.ObjectIDFromHex(ctx.Param("arrangementId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var data *ArrangementData
+	err = ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := h.SongService.UpdateArrangement(songID, data.ToEntity(arrangementID))
+	if err != nil {
+		h.handleArrangementError(ctx, err)
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"song": song}})
+}
+
+func (h *WebAppController) SongArrangementDelete(ctx *gin.Context) {
+	songID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	arrangementID, err := bson.ObjectIDFromHex(ctx.Param("arrangementId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	song, err := h.SongService.DeleteArrangement(songID, arrangementID)
+	if err != nil {
+		h.handleArrangementError(ctx, err)
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"song": song}})
+}
+
+func (h *WebAppController) handleArrangementError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "song not found"})
+	case errors.Is(err, service.ErrInvalidOperation):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "arrangement name is empty or arrangement not found"})
+	case errors.Is(err, service.ErrAlreadyExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": "arrangement with this name already exists"})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 // API tags.
 
 func (h *WebAppController) Tags(ctx *gin.Context) {
@@ -691,6 +1192,45 @@ func (h *WebAppController) EventData(ctx *gin.Context) {
 	})
 }
 
+// EventKeyProposals proposes keys for the songs from "songIds" based on preferences of the event singers.
+func (h *WebAppController) EventKeyProposals(ctx *gin.Context) {
+	eventID, err := bson.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var songIDs []bson.ObjectID
+	for _, hex := range splitQueryList(ctx.Query("songIds")) {
+		songID, err := bson.ObjectIDFromHex(hex)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		songIDs = append(songIDs, songID)
+	}
+
+	event, err := h.EventService.FindOneByID(eventID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	proposals, err := h.SongService.ProposeKeysForEvent(event, songIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"proposals": proposals,
+		},
+	})
+}
+
 func (h *WebAppController) FrequentEventNames(ctx *gin.Context) {
 	bandIdFromQ := ctx.Query("bandId")
 	bandID, err := bson.ObjectIDFromHex(bandIdFromQ)
@@ -713,10 +1253,33 @@ func (h *WebAppController) FrequentEventNames(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": gin.H{"names": names}})
 }
 
-// SongOverridesData represents a song in the setlist with optional key override from the frontend.
+// SongOverridesData represents a song in the setlist with optional key and arrangement override from the frontend.
 type SongOverridesData struct {
-	SongID   string     `json:"songId"`
-	EventKey entity.Key `json:"eventKey,omitempty"`
+	SongID        string     `json:"songId"`
+	EventKey      entity.Key `json:"eventKey,omitempty"`
+	ArrangementID string     `json:"arrangementId,omitempty"`
+	RoadMap       string     `json:"roadMap,omitempty"`
+}
+
+// ToEntity returns nil if there is nothing to override.
+func (d *SongOverridesData) ToEntity(songID bson.ObjectID) *entity.SongOverride {
+	if d == nil {
+		return nil
+	}
+
+	override := &entity.SongOverride{
+		SongID:   songID,
+		EventKey: d.EventKey,
+		RoadMap:  service.NormalizeRoadMap(d.RoadMap),
+	}
+	if arrangementID, err := bson.ObjectIDFromHex(d.ArrangementID); err == nil {
+		override.ArrangementID = &arrangementID
+	}
+
+	if override.EventKey == "" && override.ArrangementID == nil && override.RoadMap == "" {
+		return nil
+	}
+	return override
 }
 
 type EditEventData struct {
@@ -737,6 +1300,55 @@ func (d *EditEventData) GetSongOverride(songID string) *SongOverridesData {
 	return nil
 }
 
+// SetlistFlowData is the setlist being edited, in the order of songs.
+type SetlistFlowData struct {
+	SongIDs       []string            `json:"songIds"`
+	SongOverrides []SongOverridesData `json:"songOverrides"`
+}
+
+// EventSetlistFlow analyzes key and tempo transitions of the setlist before it's saved.
+func (h *WebAppController) EventSetlistFlow(ctx *gin.Context) {
+	var data *SetlistFlowData
+	err := ctx.ShouldBindJSON(&data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event := &entity.Event{}
+	for _, songIDHex := range data.SongIDs {
+		songID, err := bson.ObjectIDFromHex(songIDHex)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		event.SongIDs = append(event.SongIDs, songID)
+
+		for _, item := range data.SongOverrides {
+			if item.SongID != songIDHex {
+				continue
+			}
+			if override := item.ToEntity(songID); override != nil {
+				event.SongOverrides = append(event.SongOverrides, *override)
+			}
+			break
+		}
+	}
+
+	flow, err := h.SongService.AnalyzeSetlist(event)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Error().Err(err).Msgf("Error:")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"flow": flow,
+		},
+	})
+}
+
 func (h *WebAppController) EventEdit(ctx *gin.Context) {
 	eventIDStr := ctx.Param("id")
 	eventID, err := bson.ObjectIDFromHex(eventIDStr)
@@ -812,12 +1424,9 @@ func (h *WebAppController) EventEdit(ctx *gin.Context) {
 		}
 		songIDs = append(songIDs, songID)
 
-		override := data.GetSongOverride(songIDHex)
-		if override != nil && override.EventKey != "" {
-			songOverrides = append(songOverrides, entity.SongOverride{
-				SongID:   songID,
-				EventKey: override.EventKey,
-			})
+		override := data.GetSongOverride(songIDHex).ToEntity(songID)
+		if override != nil {
+			songOverrides = append(songOverrides, *override)
 		}
 	}
 	event.SongIDs = songIDs
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestSongFilterFromRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/api/songs/filter?q=key:G+%23fast+grace&keys=A,+B&minBpm=120&maxBpm=140&excludeTags=christmas&archived=include&notPlayedSince=2026-07-01", nil)
+
+	filter, query, err := songFilterFromRequest(ctx, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if query != "grace" {
+		t.Fatalf("expected query %q, got %q", "grace", query)
+	}
+	if !slices.Equal(filter.Keys, []entity.Key{"G", "A", "B"}) {
+		t.Fatalf("unexpected keys: %v", filter.Keys)
+	}
+	if filter.MinBPM != 120 || filter.MaxBPM != 140 {
+		t.Fatalf("unexpected BPM range: %d-%d", filter.MinBPM, filter.MaxBPM)
+	}
+	if !slices.Equal(filter.Tags, []string{"fast"}) || !slices.Equal(filter.ExcludeTags, []string{"christmas"}) {
+		t.Fatalf("unexpected tags: %v, %v", filter.Tags, filter.ExcludeTags)
+	}
+	if filter.Archived != entity.SongFilterArchivedInclude {
+		t.Fatalf("unexpected archived: %q", filter.Archived)
+	}
+	if filter.NotPlayedSince == nil || !filter.NotPlayedSince.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected notPlayedSince: %v", filter.NotPlayedSince)
+	}
+}
+
+func TestSongFilterFromRequestInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, rawQuery := range []string{"minBpm=fast", "archived=maybe", "notPlayedSince=yesterday"} {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest("GET", "/api/songs/filter?"+rawQuery, nil)
+
+		_, _, err := songFilterFromRequest(ctx, time.Now())
+		if err == nil {
+			t.Fatalf("expected error for %q", rawQuery)
+		}
+	}
+}
@@ -35,16 +35,18 @@ type SettingsUserResponse struct {
 }
 
 type SettingsBandResponse struct {
-	ID                    string `json:"id"`
-	Name                  string `json:"name"`
-	DriveFolderID         string `json:"driveFolderId"`
-	ArchiveFolderID       string `json:"archiveFolderId"`
-	TempFolderID          string `json:"tempFolderId"`
-	Timezone              string `json:"timezone"`
-	IsMember              bool   `json:"isMember"`
-	IsActive              bool   `json:"isActive"`
-	IsAdmin               bool   `json:"isAdmin"`
-	HasPendingJoinRequest bool   `json:"hasPendingJoinRequest"`
+	ID                    string                   `json:"id"`
+	Name                  string                   `json:"name"`
+	DriveFolderID         string                   `json:"driveFolderId"`
+	ArchiveFolderID       string                   `json:"archiveFolderId"`
+	TempFolderID          string                   `json:"tempFolderId"`
+	Timezone              string                   `json:"timezone"`
+	SlideRules            entity.SlideRules        `json:"slideRules"`
+	DriveStyle            entity.DriveStyleProfile `json:"driveStyle"`
+	IsMember              bool                     `json:"isMember"`
+	IsActive              bool                     `json:"isActive"`
+	IsAdmin               bool                     `json:"isAdmin"`
+	HasPendingJoinRequest bool                     `json:"hasPendingJoinRequest"`
 }
 
 type SettingsMemberResponse struct {
@@ -87,10 +89,12 @@ type SettingsConfigResponse struct {
 }
 
 type settingsBandRequest struct {
-	Name           *string `json:"name"`
-	DriveFolderID  *string `json:"driveFolderId"`
-	DriveFolderURL *string `json:"driveFolderUrl"`
-	Timezone       *string `json:"timezone"`
+	Name           *string                   `json:"name"`
+	DriveFolderID  *string                   `json:"driveFolderId"`
+	DriveFolderURL *string                   `json:"driveFolderUrl"`
+	Timezone       *string                   `json:"timezone"`
+	SlideRules     *entity.SlideRules        `json:"slideRules"`
+	DriveStyle     *entity.DriveStyleProfile `json:"driveStyle"`
 }
 
 type settingsMemberRoleRequest struct {
@@ -396,6 +400,24 @@ func (h *WebAppController) SettingsUpdateBand(ctx *gin.Context) {
 		band.Timezone = timezone
 	}
 
+	if request.SlideRules != nil {
+		slideRules, err := validateSettingsSlideRules(request.SlideRules)
+		if err != nil {
+			h.badSettingsRequest(ctx, err.Error())
+			return
+		}
+		band.SlideRules = slideRules
+	}
+
+	if request.DriveStyle != nil {
+		driveStyle, err := validateSettingsDriveStyle(request.DriveStyle)
+		if err != nil {
+			h.badSettingsRequest(ctx, err.Error())
+			return
+		}
+		band.DriveStyle = driveStyle
+	}
+
 	band, err := h.BandService.UpdateOne(*band)
 	if err != nil {
 		h.handleSettingsError(ctx, err)
@@ -713,6 +735,10 @@ func (h *WebAppController) settingsBandResponses(user *entity.User, bands []*ent
 
 func (h *WebAppController) settingsBandResponse(user *entity.User, band *entity.Band, pendingByBandID map[bson.ObjectID]*entity.JoinRequest) SettingsBandResponse {
 	_, hasPendingJoinRequest := pendingByBandID[band.ID]
+	var driveStyle entity.DriveStyleProfile
+	if band.DriveStyle != nil {
+		driveStyle = *band.DriveStyle
+	}
 	return SettingsBandResponse{
 		ID:                    band.ID.Hex(),
 		Name:                  band.Name,
@@ -720,6 +746,8 @@ func (h *WebAppController) settingsBandResponse(user *entity.User, band *entity.
 		ArchiveFolderID:       band.ArchiveFolderID,
 		TempFolderID:          band.TempFolderID,
 		Timezone:              band.Timezone,
+		SlideRules:            band.GetSlideRules(),
+		DriveStyle:            driveStyle,
 		IsMember:              user.BelongsToBand(band.ID),
 		IsActive:              user.BandID == band.ID,
 		IsAdmin:               h.BandService.IsUserAdmin(user, band),
@@ -867,6 +895,25 @@ func validateSettingsTimezone(rawTimezone *string) (string, error) {
 	return timezone, nil
 }
 
+func validateSettingsSlideRules(rules *entity.SlideRules) (*entity.SlideRules, error) {
+	if rules.MaxLines < 1 || rules.MaxLines > entity.MaxSlideMaxLines {
+		return nil, fmt.Errorf("max lines per slide must be between 1 and %d", entity.MaxSlideMaxLines)
+	}
+	return rules, nil
+}
+
+// validateSettingsDriveStyle checks the preset and YAML overrides the same way as the style config file.
+func validateSettingsDriveStyle(profile *entity.DriveStyleProfile) (*entity.DriveStyleProfile, error) {
+	profile.Preset = strings.TrimSpace(profile.Preset)
+	if strings.TrimSpace(profile.YAML) == "" {
+		profile.YAML = ""
+	}
+	if err := service.ValidateDriveStyleProfile(*profile); err != nil {
+		return nil, fmt.Errorf("invalid drive style: %w", err)
+	}
+	return profile, nil
+}
+
 func pendingJoinRequestsByBandID(requests []*entity.JoinRequest) map[bson.ObjectID]*entity.JoinRequest {
 	result := make(map[bson.ObjectID]*entity.JoinRequest, len(requests))
 	for _, request := range requests {
@@ -720,3 +720,127 @@ func TestSettingsUpdateBandMemberDescriptiveErrors(t *testing.T) {
 		t.Fatalf("expected %q, got %q", "cannot demote yourself", errRespDemoteSelf["error"])
 	}
 }
+
+func TestSettingsUpdateBand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		// check gets the saved band and the band from the response.
+		check func(t *testing.T, saved *entity.Band, resp SettingsBandResponse)
+	}{
+		{
+			name:       "invalid slide rules",
+			body:       `{"slideRules":{"maxLines":0}}`,
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if saved.SlideRules != nil {
+					t.Fatalf("invalid slide rules were saved: %+v", saved.SlideRules)
+				}
+			},
+		},
+		{
+			name:       "slide rules",
+			body:       `{"slideRules":{"maxLines":2,"uppercase":true}}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if resp.SlideRules != (entity.SlideRules{MaxLines: 2, Uppercase: true}) {
+					t.Fatalf("unexpected slide rules in response: %+v", resp.SlideRules)
+				}
+				if rules := saved.GetSlideRules(); rules.MaxLines != 2 || !rules.Uppercase {
+					t.Fatalf("unexpected saved slide rules: %+v", rules)
+				}
+			},
+		},
+		{
+			name:       "unknown drive style preset",
+			body:       `{"driveStyle":{"preset":"huge"}}`,
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if saved.DriveStyle != nil {
+					t.Fatalf("invalid drive style was saved: %+v", saved.DriveStyle)
+				}
+			},
+		},
+		{
+			name:       "invalid drive style color",
+			body:       `{"driveStyle":{"yaml":"chords:\n  primary_color: red\n"}}`,
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if saved.DriveStyle != nil {
+					t.Fatalf("invalid drive style was saved: %+v", saved.DriveStyle)
+				}
+			},
+		},
+		{
+			name:       "invalid drive style yaml",
+			body:       `{"driveStyle":{"yaml":"fonts: {}"}}`,
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if saved.DriveStyle != nil {
+					t.Fatalf("invalid drive style was saved: %+v", saved.DriveStyle)
+				}
+			},
+		},
+		{
+			name:       "drive style",
+			body:       `{"driveStyle":{"preset":" dark_chords ","yaml":"text:\n  font_family: Courier New\n"}}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, saved *entity.Band, resp SettingsBandResponse) {
+				if resp.DriveStyle.Preset != service.DriveStylePresetDarkChords {
+					t.Fatalf("unexpected drive style in response: %+v", resp.DriveStyle)
+				}
+				if saved.DriveStyle == nil || saved.DriveStyle.YAML == "" {
+					t.Fatalf("unexpected saved drive style: %+v", saved.DriveStyle)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bandID := bson.NewObjectID()
+			user := &entity.User{
+				ID:      42,
+				Name:    "Alice",
+				BandID:  bandID,
+				BandIDs: []bson.ObjectID{bandID},
+			}
+			bandService := &settingsStubBandService{bands: map[bson.ObjectID]*entity.Band{
+				bandID: {ID: bandID, Name: "Scala Band", AdminUserIDs: []int64{42}},
+			}}
+			controller := WebAppController{
+				UserService: &settingsStubUserService{users: map[int64]*entity.User{
+					user.ID: user,
+				}},
+				BandService: bandService,
+			}
+
+			router := gin.New()
+			router.PATCH("/api/settings/bands/:id", controller.SettingsUpdateBand)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/settings/bands/"+bandID.Hex()+"?userId=42", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+
+			var resp struct {
+				Data struct {
+					Band SettingsBandResponse `json:"band"`
+				} `json:"data"`
+			}
+			if w.Code == http.StatusOK {
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.check(t, bandService.bands[bandID], resp.Data.Band)
+		})
+	}
+}
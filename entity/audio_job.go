@@ -0,0 +1,209 @@
+package entity
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type AudioJobStatus string
+
+const (
+	AudioJobQueued   AudioJobStatus = "queued"
+	AudioJobRunning  AudioJobStatus = "running"
+	AudioJobDone     AudioJobStatus = "done"
+	AudioJobFailed   AudioJobStatus = "failed"
+	AudioJobCanceled AudioJobStatus = "canceled"
+)
+
+// AudioFormat is the file format of the processed audio.
+type AudioFormat string
+
+const (
+	// AudioFormatOriginal keeps the format of the source. Converted sources become mp3.
+	AudioFormatOriginal AudioFormat = ""
+	AudioFormatMP3_128  AudioFormat = "mp3_128"
+	AudioFormatMP3_192  AudioFormat = "mp3_192"
+	AudioFormatMP3_320  AudioFormat = "mp3_320"
+	AudioFormatOpus     AudioFormat = "opus"
+	AudioFormatM4A      AudioFormat = "m4a"
+	AudioFormatFLAC     AudioFormat = "flac"
+	AudioFormatWAV      AudioFormat = "wav"
+)
+
+// AudioFormats are the formats offered to the user, in the order of the buttons.
+var AudioFormats = []AudioFormat{
+	AudioFormatOriginal,
+	AudioFormatMP3_128, AudioFormatMP3_192, AudioFormatMP3_320,
+	AudioFormatOpus, AudioFormatM4A, AudioFormatFLAC, AudioFormatWAV,
+}
+
+// Extension returns the file extension of the format without the dot.
+func (f AudioFormat) Extension() string {
+	switch f {
+	case AudioFormatOpus:
+		return "ogg"
+	case AudioFormatM4A:
+		return "m4a"
+	case AudioFormatFLAC:
+		return "flac"
+	case AudioFormatWAV:
+		return "wav"
+	default:
+		return "mp3"
+	}
+}
+
+// AudioOutput is how the result of the job is encoded. It's chosen by the user.
+type AudioOutput struct {
+	Format AudioFormat `bson:"format,omitempty" json:"format,omitempty"`
+	// Normalize makes the loudness of the result -16 LUFS (EBU R128).
+	Normalize bool `bson:"normalize,omitempty" json:"normalize,omitempty"`
+}
+
+// AudioJobFile is the Telegram audio or voice message processed by the job.
+type AudioJobFile struct {
+	FileID       string `bson:"fileId" json:"fileId"`
+	FileUniqueID string `bson:"fileUniqueId,omitempty" json:"fileUniqueId,omitempty"`
+	FileName     string `bson:"fileName,omitempty" json:"fileName,omitempty"`
+	MimeType     string `bson:"mimeType,omitempty" json:"mimeType,omitempty"`
+	Title        string `bson:"title,omitempty" json:"title,omitempty"`
+	Performer    string `bson:"performer,omitempty" json:"performer,omitempty"`
+	Duration     int64  `bson:"duration,omitempty" json:"duration,omitempty"`
+	FileSize     int64  `bson:"fileSize,omitempty" json:"fileSize,omitempty"`
+	IsVoice      bool   `bson:"isVoice" json:"isVoice"`
+}
+
+// AudioJobStem is the voice mixed into the practice track.
+type AudioJobStem struct {
+	Audio AudioJobFile `bson:"audio" json:"audio"`
+	// Gain is the volume change in dB.
+	Gain float64 `bson:"gain,omitempty" json:"gain,omitempty"`
+	// Pan is the position from -1 (left) to 1 (right).
+	Pan float64 `bson:"pan,omitempty" json:"pan,omitempty"`
+	// Delay is the silence before the stem in milliseconds, e.g. for the count-in.
+	Delay int64 `bson:"delay,omitempty" json:"delay,omitempty"`
+}
+
+// AudioJobClick is the metronome generated by the job. The first bars are the count-in.
+type AudioJobClick struct {
+	BPM         float64 `bson:"bpm" json:"bpm"`
+	BeatsPerBar int     `bson:"beatsPerBar" json:"beatsPerBar"`
+	CountInBars int     `bson:"countInBars,omitempty" json:"countInBars,omitempty"`
+}
+
+// CountInDuration returns the duration of the count-in.
+func (c *AudioJobClick) CountInDuration() time.Duration {
+	return time.Duration(float64(c.CountInBars*c.BeatsPerBar) * 60 / c.BPM * float64(time.Second))
+}
+
+// AudioJobTrim is the part of the audio the result is made of. Start and End are in milliseconds.
+type AudioJobTrim struct {
+	Start int64 `bson:"start" json:"start"`
+	End   int64 `bson:"end" json:"end"`
+	// Loops is how many times the part is repeated.
+	Loops int `bson:"loops,omitempty" json:"loops,omitempty"`
+}
+
+// LoopCount returns how many times the part is repeated, at least once.
+func (t *AudioJobTrim) LoopCount() int {
+	return max(t.Loops, 1)
+}
+
+// Duration returns the duration of the result with all the repeats.
+func (t *AudioJobTrim) Duration() time.Duration {
+	return time.Duration(t.End-t.Start) * time.Millisecond * time.Duration(t.LoopCount())
+}
+
+// AudioJob is a queued audio transposition. Jobs are processed by a worker pool,
+// the result is sent to ChatID and MessageID is the progress message with the cancel button.
+type AudioJob struct {
+	ID           bson.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID       int64          `bson:"userId" json:"userId"`
+	BandID       bson.ObjectID  `bson:"bandId,omitempty" json:"bandId,omitempty"`
+	ChatID       int64          `bson:"chatId" json:"chatId"`
+	MessageID    int64          `bson:"messageId" json:"messageId"`
+	LanguageCode string         `bson:"languageCode" json:"languageCode"`
+	Status       AudioJobStatus `bson:"status" json:"status"`
+
+	Audio AudioJobFile `bson:"audio" json:"audio"`
+	// Stems are mixed into one track before the transposition. Audio describes the mix then.
+	Stems []AudioJobStem `bson:"stems,omitempty" json:"stems,omitempty"`
+	// Click is mixed over the stems, or it's the only result if there are no stems.
+	Click *AudioJobClick `bson:"click,omitempty" json:"click,omitempty"`
+	// Trim cuts the part of Audio before the transposition.
+	Trim      *AudioJobTrim `bson:"trim,omitempty" json:"trim,omitempty"`
+	Semitones int           `bson:"semitones" json:"semitones"`
+	// Fine is slower processing with better quality.
+	Fine bool `bson:"fine" json:"fine"`
+	// Tempo is the speed of the result in percent. 0 and 100 keep the original tempo.
+	Tempo int `bson:"tempo,omitempty" json:"tempo,omitempty"`
+	// BPM is the original tempo of the song the audio belongs to, if known.
+	BPM float64 `bson:"bpm,omitempty" json:"bpm,omitempty"`
+
+	Output AudioOutput `bson:"output,omitempty" json:"output,omitempty"`
+	// ConvertVoice sends the result of the voice message as the audio file.
+	ConvertVoice bool `bson:"convertVoice,omitempty" json:"convertVoice,omitempty"`
+
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+
+	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time  `bson:"updatedAt" json:"updatedAt"`
+	StartedAt  *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+}
+
+// IsActive reports whether the job is waiting in the queue or being processed.
+func (j *AudioJob) IsActive() bool {
+	return j.Status == AudioJobQueued || j.Status == AudioJobRunning
+}
+
+// IsMix reports whether the job mixes stems or generates the click instead of processing one file.
+func (j *AudioJob) IsMix() bool {
+	return len(j.Stems) > 0 || j.Click != nil
+}
+
+// SendsVoice reports whether the result is sent as the voice message.
+func (j *AudioJob) SendsVoice() bool {
+	return j.Audio.IsVoice && !j.ConvertVoice
+}
+
+// NeedsEncoding reports whether the result has to be encoded to the output format of the job.
+// Voice messages stay voice messages, only their loudness can be changed.
+func (j *AudioJob) NeedsEncoding() bool {
+	if j.SendsVoice() {
+		return j.Output.Normalize
+	}
+	return j.Output != AudioOutput{} || j.ConvertVoice
+}
+
+// TempoPercent returns the speed of the result in percent.
+func (j *AudioJob) TempoPercent() int {
+	if j.Tempo <= 0 {
+		return 100
+	}
+	return j.Tempo
+}
+
+// TargetBPM returns the tempo of the result. Returns false if the original BPM is unknown.
+func (j *AudioJob) TargetBPM() (int, bool) {
+	if j.BPM <= 0 {
+		return 0, false
+	}
+	return TempoBPM(j.BPM, j.TempoPercent()), true
+}
+
+// ResultDuration returns the duration of the result in seconds.
+func (j *AudioJob) ResultDuration() int64 {
+	duration := j.Audio.Duration
+	if j.Trim != nil {
+		duration = int64(math.Round(j.Trim.Duration().Seconds()))
+	}
+	return duration * 100 / int64(j.TempoPercent())
+}
+
+// TempoBPM returns the BPM of the song played at tempo percent of the original speed.
+func TempoBPM(bpm float64, tempo int) int {
+	return int(math.Round(bpm * float64(tempo) / 100))
+}
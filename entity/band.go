@@ -15,6 +15,47 @@ type Band struct {
 	Roles           []*Role       `bson:"roles,omitempty" json:"roles,omitempty"`
 	Timezone        string        `bson:"timezone,omitempty" json:"timezone,omitempty"`
 	AdminUserIDs    []int64       `bson:"adminUserIds,omitempty" json:"adminUserIds,omitempty"`
+	// SlideRules tell how lyrics are split into projection slides. Default rules are used if nil.
+	SlideRules *SlideRules `bson:"slideRules,omitempty" json:"slideRules,omitempty"`
+	// DriveStyle is the style profile of the band Drive docs. The config file style is used if nil.
+	DriveStyle *DriveStyleProfile `bson:"driveStyle,omitempty" json:"driveStyle,omitempty"`
+	// DriveChangesPageToken is the page of the Drive changes feed the band songs are synced up to.
+	DriveChangesPageToken string `bson:"driveChangesPageToken,omitempty" json:"-"`
+}
+
+const (
+	DefaultSlideMaxLines = 4
+	MaxSlideMaxLines     = 12
+)
+
+// SlideRules tell how song lyrics are split into projection slides.
+type SlideRules struct {
+	// MaxLines is the max number of lines on a slide. Longer stanzas are split evenly.
+	MaxLines int `bson:"maxLines" json:"maxLines"`
+	// IgnoreBlankLines keeps stanzas of a section together instead of starting a new slide on each blank line.
+	IgnoreBlankLines bool `bson:"ignoreBlankLines" json:"ignoreBlankLines"`
+	// Uppercase renders lyrics in capital letters.
+	Uppercase bool `bson:"uppercase" json:"uppercase"`
+}
+
+// DriveStyleProfile overrides the Drive doc style config file for a band.
+type DriveStyleProfile struct {
+	// Preset is the name of a built-in preset applied over the config file. Empty means no preset.
+	Preset string `bson:"preset,omitempty" json:"preset"`
+	// YAML overrides the preset, it has the same format as the config file.
+	YAML string `bson:"yaml,omitempty" json:"yaml"`
+}
+
+// GetSlideRules returns slide rules of the band or the default ones.
+func (b *Band) GetSlideRules() SlideRules {
+	rules := SlideRules{MaxLines: DefaultSlideMaxLines}
+	if b.SlideRules != nil {
+		rules = *b.SlideRules
+	}
+	if rules.MaxLines <= 0 {
+		rules.MaxLines = DefaultSlideMaxLines
+	}
+	return rules
 }
 
 func (b *Band) GetLocation() *time.Location {
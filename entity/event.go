@@ -11,10 +11,13 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-// SongOverride represents a song in an event's setlist with optional key override and caching.
+// SongOverride represents a song in an event's setlist with optional key, arrangement and road-map override.
 type SongOverride struct {
 	SongID   bson.ObjectID `bson:"songId" json:"songId"`
 	EventKey Key                `bson:"eventKey,omitempty" json:"eventKey,omitempty"` // Key override for this event
+
+	ArrangementID *bson.ObjectID `bson:"arrangementId,omitempty" json:"arrangementId,omitempty"` // Arrangement of the song for this event
+	RoadMap       string         `bson:"roadMap,omitempty" json:"roadMap,omitempty"`             // Order of sections for this event
 }
 
 type Event struct {
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ProcessedAudio is the cached result of the audio job. Results are found by the source file
+// and the processing parameters, so the same transposition is never processed twice.
+type ProcessedAudio struct {
+	ID bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+
+	SourceFileUniqueID string `bson:"sourceFileUniqueId" json:"sourceFileUniqueId"`
+	Semitones          int    `bson:"semitones" json:"semitones"`
+	Fine               bool   `bson:"fine" json:"fine"`
+	Tempo              int    `bson:"tempo" json:"tempo"`
+	// IsVoice is true if the result is a voice message. Voice file IDs can't be sent as audio.
+	IsVoice bool `bson:"isVoice" json:"isVoice"`
+	// IsDocument is true if Telegram returned the result as the file, e.g. FLAC or WAV. Document file IDs can't be sent as audio.
+	IsDocument bool `bson:"isDocument,omitempty" json:"isDocument,omitempty"`
+	// Output is the format of the result. It's empty for the original format without normalization.
+	Output string `bson:"output,omitempty" json:"output,omitempty"`
+
+	// FileID is the Telegram file ID of the result.
+	FileID   string `bson:"fileId" json:"fileId"`
+	FileSize int64  `bson:"fileSize,omitempty" json:"fileSize,omitempty"`
+
+	// BandID is the band of the user who requested the result first.
+	BandID bson.ObjectID `bson:"bandId,omitempty" json:"bandId,omitempty"`
+	// Hits is how many times the result was sent from the cache.
+	Hits int64 `bson:"hits" json:"hits"`
+
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+	LastUsedAt time.Time `bson:"lastUsedAt" json:"lastUsedAt"`
+}
+
+// ProcessedAudioStats describes the audio cache of the band.
+type ProcessedAudioStats struct {
+	Entries int64 `bson:"entries" json:"entries"`
+	Hits    int64 `bson:"hits" json:"hits"`
+	Size    int64 `bson:"size" json:"size"`
+}
@@ -13,6 +13,16 @@ import (
 
 type Key string
 
+var (
+	MajorKeys = []Key{"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+	MinorKeys = []Key{"Cm", "C#m", "Dm", "Ebm", "Em", "Fm", "F#m", "Gm", "G#m", "Am", "Bbm", "Bm"}
+)
+
+// IsMinor reports whether the key is minor, e.g. "F#m".
+func (k Key) IsMinor() bool {
+	return strings.HasSuffix(string(k), "m")
+}
+
 type Song struct {
 	ID bson.ObjectID `bson:"_id,omitempty" json:"id"`
 
@@ -27,17 +37,98 @@ type Song struct {
 
 	Voices []*Voice `bson:"voices,omitempty" json:"-"`
 
-	Likes []*Like  `bson:"likes,omitempty" json:"-"`
-	Tags  []string `bson:"tags" json:"tags"`
+	Arrangements []*Arrangement `bson:"arrangements,omitempty" json:"arrangements"`
+	Arrangement  *Arrangement   `bson:"-" json:"-"`
+
+	Likes []*Like `bson:"likes,omitempty" json:"-"`
+	// KeyPreferences are keys preferred by singers of the band.
+	KeyPreferences []*KeyPreference `bson:"keyPreferences,omitempty" json:"keyPreferences,omitempty"`
+	Tags           []string         `bson:"tags" json:"tags"`
+
+	Authors     []string `bson:"authors" json:"authors"`
+	Copyright   string   `bson:"copyright" json:"copyright"`
+	CCLINumber  string   `bson:"ccliNumber" json:"ccliNumber"`
+	Language    string   `bson:"language" json:"language"`
+	DurationSec int      `bson:"durationSec" json:"durationSec"`
+	OriginalKey Key      `bson:"originalKey" json:"originalKey"`
+
+	// RoadMap is the default order of song sections, e.g. "V1 C V2 C B C C".
+	RoadMap string `bson:"roadMap" json:"roadMap"`
 
 	IsArchived bool `bson:"isArchived" json:"isArchived"`
 }
 
+// Arrangement is a named version of a song, e.g. acoustic or shortened for youth services.
+// Arrangements are stored inside the song, so events and statistics still refer to the parent song.
+type Arrangement struct {
+	ID   bson.ObjectID `bson:"_id" json:"id"`
+	Name string        `bson:"name" json:"name"`
+
+	Structure string `bson:"structure,omitempty" json:"structure,omitempty"`
+	Key       Key    `bson:"key,omitempty" json:"key,omitempty"`
+	BPM       string `bson:"bpm,omitempty" json:"bpm,omitempty"`
+	Time      string `bson:"time,omitempty" json:"time,omitempty"`
+
+	// DriveFileID is an optional separate doc. Song doc is used if empty.
+	DriveFileID string `bson:"driveFileId,omitempty" json:"driveFileId,omitempty"`
+}
+
+func (a *Arrangement) HasDoc() bool {
+	return a != nil && a.DriveFileID != ""
+}
+
+func (a *Arrangement) WebViewLink() string {
+	return fmt.Sprintf("https://docs.google.com/document/d/%s/edit", a.DriveFileID)
+}
+
 type Like struct {
 	UserID int64     `bson:"userId"`
 	Time   time.Time `bson:"time"`
 }
 
+// KeyPreference is a key a singer prefers to sing the song in.
+type KeyPreference struct {
+	UserID int64 `bson:"userId" json:"userId"`
+	// UserName is saved with the preference to show it without loading users.
+	UserName  string    `bson:"userName" json:"userName"`
+	Key       Key       `bson:"key" json:"key"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// LyricsSection is a section of song lyrics, e.g. a verse or a chorus, split into projection slides.
+type LyricsSection struct {
+	// Name is the OpenLyrics verse name, e.g. "v1", "c" or "b".
+	Name string `json:"name"`
+	// Slides are lines of each slide.
+	Slides [][]string `json:"slides"`
+}
+
+// SongSlides are projection slides of a song.
+type SongSlides struct {
+	Song     *Song            `json:"song"`
+	Sections []*LyricsSection `json:"sections"`
+	// Order is the order of section names to show. Sections may repeat.
+	Order []string `json:"order"`
+}
+
+// GetSection returns the section with the given name or nil.
+func (s *SongSlides) GetSection(name string) *LyricsSection {
+	for _, section := range s.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+	return nil
+}
+
+// KeyProposal is a key proposed for the song in the event, based on preferences of the event singers.
+type KeyProposal struct {
+	SongID   bson.ObjectID `json:"songId"`
+	Key      Key           `json:"key"`
+	UserID   int64         `json:"userId"`
+	UserName string        `json:"userName"`
+}
+
 type AltPDF struct {
 	Key         Key    `bson:"key,omitempty" json:"key,omitempty"`
 	Version     int64  `bson:"version,omitempty" json:"version,omitempty"`
@@ -58,14 +149,66 @@ type PDF struct {
 	WebViewLink string `bson:"webViewLink,omitempty" json:"webViewLink,omitempty"`
 }
 
+func (s *Song) GetArrangement(arrangementID bson.ObjectID) *Arrangement {
+	for _, arrangement := range s.Arrangements {
+		if arrangement.ID == arrangementID {
+			return arrangement
+		}
+	}
+	return nil
+}
+
+func (s *Song) GetKeyPreference(userID int64) *KeyPreference {
+	for _, preference := range s.KeyPreferences {
+		if preference.UserID == userID {
+			return preference
+		}
+	}
+	return nil
+}
+
+// NameWithArrangement returns song name with the name of the chosen arrangement, if any.
+func (s *Song) NameWithArrangement() string {
+	if s.Arrangement == nil {
+		return s.PDF.Name
+	}
+	return fmt.Sprintf("%s [%s]", s.PDF.Name, s.Arrangement.Name)
+}
+
+// RoadMapForEvent returns the order of song sections for the event:
+// event override first, then the structure of the chosen arrangement, then the song default.
+func (s *Song) RoadMapForEvent(override *SongOverride) string {
+	if override != nil && override.RoadMap != "" {
+		return override.RoadMap
+	}
+	if s.Arrangement != nil && s.Arrangement.Structure != "" {
+		return s.Arrangement.Structure
+	}
+	return s.RoadMap
+}
+
+func (s *Song) GetWebViewLink() string {
+	if s.Arrangement.HasDoc() {
+		return s.Arrangement.WebViewLink()
+	}
+	return s.PDF.WebViewLink
+}
+
 func (s *Song) GetTgFileID() string {
 	if s.AltPDF != nil {
 		return s.AltPDF.TgFileID
 	}
+	// Separate arrangement docs are not cached.
+	if s.Arrangement.HasDoc() {
+		return ""
+	}
 	return s.PDF.TgFileID
 }
 
 func (s *Song) SetTgFileID(tgFileID string) {
+	if s.Arrangement.HasDoc() && s.AltPDF == nil {
+		return
+	}
 	if s.AltPDF != nil {
 		s.AltPDF.TgFileID = tgFileID
 		if s.AltPDFs == nil {
@@ -81,15 +224,66 @@ func (s *Song) GetDriveFileID() string {
 	if s.AltPDF != nil {
 		return s.AltPDF.DriveFileID
 	}
+	if s.Arrangement.HasDoc() {
+		return s.Arrangement.DriveFileID
+	}
 	return s.DriveFileID
 }
 
 func (s *Song) Meta() string {
-	key := s.PDF.Key
+	return fmt.Sprintf("%s, %s, %s", s.EffectiveKey(), s.EffectiveBPM(), s.EffectiveTime())
+}
+
+// EffectiveKey returns the key the song is sung in: the key of the alternative PDF,
+// then the key of the chosen arrangement, then the song key.
+func (s *Song) EffectiveKey() Key {
 	if s.AltPDF != nil {
-		key = s.AltPDF.Key
+		return s.AltPDF.Key
+	}
+	if s.Arrangement != nil && s.Arrangement.Key != "" {
+		return s.Arrangement.Key
+	}
+	return s.PDF.Key
+}
+
+// EffectiveBPM returns BPM of the chosen arrangement or the song BPM.
+func (s *Song) EffectiveBPM() string {
+	if s.Arrangement != nil && s.Arrangement.BPM != "" {
+		return s.Arrangement.BPM
+	}
+	return s.PDF.BPM
+}
+
+// EffectiveTime returns the time signature of the chosen arrangement or the song time signature.
+func (s *Song) EffectiveTime() string {
+	if s.Arrangement != nil && s.Arrangement.Time != "" {
+		return s.Arrangement.Time
+	}
+	return s.PDF.Time
+}
+
+// Credits returns authors, copyright and CCLI number in one line, e.g.
+// "✍ Chris Tomlin, Jesse Reeves; © 2004 worshiptogether.com songs; CCLI: 4348399".
+func (s *Song) Credits() string {
+	var parts []string
+	if len(s.Authors) > 0 {
+		parts = append(parts, "✍ "+strings.Join(s.Authors, ", "))
+	}
+	if s.Copyright != "" {
+		parts = append(parts, "© "+strings.TrimSpace(strings.TrimPrefix(s.Copyright, "©")))
+	}
+	if s.CCLINumber != "" {
+		parts = append(parts, "CCLI: "+s.CCLINumber)
 	}
-	return fmt.Sprintf("%s, %s, %s", key, s.PDF.BPM, s.PDF.Time)
+	return strings.Join(parts, "; ")
+}
+
+// Duration returns song duration formatted as m:ss or empty string if it is unknown.
+func (s *Song) Duration() string {
+	if s.DurationSec <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", s.DurationSec/60, s.DurationSec%60)
 }
 
 func (s *Song) Caption() string {
@@ -102,6 +296,133 @@ type SongTagFrequencies struct {
 	Count int    `bson:"count"`
 }
 
+// SongDuplicate is a pair of songs of the same band that are likely the same song.
+// Song is the one that should be kept, Duplicate is the one that should be merged into it.
+type SongDuplicate struct {
+	Song      *Song
+	Duplicate *Song
+
+	NameSimilarity   float32
+	LyricsSimilarity float32
+}
+
+// SongUsage is how many times a song was performed in a period.
+type SongUsage struct {
+	Song *Song `bson:"song"`
+
+	Count     int       `bson:"count"`
+	FirstTime time.Time `bson:"firstTime"`
+	LastTime  time.Time `bson:"lastTime"`
+}
+
+type SongFilterArchived string
+
+const (
+	// SongFilterArchivedExclude is the default: archived songs are not shown.
+	SongFilterArchivedExclude SongFilterArchived = ""
+	SongFilterArchivedOnly    SongFilterArchived = "only"
+	SongFilterArchivedInclude SongFilterArchived = "include"
+)
+
+// SongFilter describes songs to plan a set from, e.g. "songs in G or A, 120-140 BPM, tagged 'fast' but not 'christmas'".
+// Empty fields don't filter anything.
+type SongFilter struct {
+	Keys        []Key              `json:"keys,omitempty"`
+	MinBPM      int                `json:"minBpm,omitempty"`
+	MaxBPM      int                `json:"maxBpm,omitempty"`
+	Times       []string           `json:"times,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	ExcludeTags []string           `json:"excludeTags,omitempty"`
+	Archived    SongFilterArchived `json:"archived,omitempty"`
+	// NotPlayedSince keeps songs that were not sung on or after this time.
+	NotPlayedSince *time.Time `json:"notPlayedSince,omitempty"`
+}
+
+type SongSuggestionReason string
+
+const (
+	SongSuggestionReasonNeverPlayed   SongSuggestionReason = "neverPlayed"
+	SongSuggestionReasonNotPlayedLong SongSuggestionReason = "notPlayedLong"
+	SongSuggestionReasonPopular       SongSuggestionReason = "popular"
+	SongSuggestionReasonTagMatch      SongSuggestionReason = "tagMatch"
+	SongSuggestionReasonKeyFlow       SongSuggestionReason = "keyFlow"
+	SongSuggestionReasonTempoFlow     SongSuggestionReason = "tempoFlow"
+)
+
+// SongSuggestion is a song proposed for the setlist of an event.
+type SongSuggestion struct {
+	Song  *Song   `json:"song"`
+	Score float64 `json:"score"`
+
+	LastPlayed  *time.Time `json:"lastPlayed,omitempty"`
+	TimesPlayed int        `json:"timesPlayed"`
+
+	Reasons []SongSuggestionReason `json:"reasons"`
+}
+
+type SetlistTransitionIssue string
+
+const (
+	SetlistTransitionIssueKeyJump   SetlistTransitionIssue = "keyJump"
+	SetlistTransitionIssueTempoJump SetlistTransitionIssue = "tempoJump"
+)
+
+// SetlistTransition describes the transition from the song at index From to the next song of the setlist.
+type SetlistTransition struct {
+	From int `json:"from"`
+
+	FromKey Key    `json:"fromKey"`
+	ToKey   Key    `json:"toKey"`
+	FromBPM string `json:"fromBpm"`
+	ToBPM   string `json:"toBpm"`
+
+	// Score is how smooth the transition is, from 0 to 1.
+	Score  float64                  `json:"score"`
+	Issues []SetlistTransitionIssue `json:"issues"`
+	// SuggestedKey is a key close to the key of the next song that flows better, if any.
+	SuggestedKey Key `json:"suggestedKey,omitempty"`
+}
+
+// SetlistFlow is the analysis of key and tempo transitions between songs of the setlist.
+type SetlistFlow struct {
+	Songs       []*Song             `json:"songs"`
+	Transitions []SetlistTransition `json:"transitions"`
+	// Score is the average score of transitions.
+	Score float64 `json:"score"`
+
+	// SuggestedOrder is the order of song indexes with smoother transitions, if it's better than the current one.
+	SuggestedOrder []int   `json:"suggestedOrder,omitempty"`
+	SuggestedScore float64 `json:"suggestedScore,omitempty"`
+
+	DurationSec int `json:"durationSec"`
+	// SongsWithoutDuration is the number of songs with unknown duration that are not counted in DurationSec.
+	SongsWithoutDuration int `json:"songsWithoutDuration"`
+}
+
+// SongSearchIndex is a searchable copy of the song with normalized lyrics, so search doesn't have to go to Drive.
+type SongSearchIndex struct {
+	SongID      bson.ObjectID `bson:"_id"`
+	BandID      bson.ObjectID `bson:"bandId"`
+	DriveFileID string        `bson:"driveFileId"`
+	Name        string        `bson:"name"`
+
+	// Normalized fields.
+	Title   string   `bson:"title"`
+	Lyrics  string   `bson:"lyrics"`
+	Authors []string `bson:"authors"`
+	Tags    []string `bson:"tags"`
+	Key     Key      `bson:"key"`
+
+	// Trigrams of all words, used to find candidates for typo-tolerant search.
+	Trigrams []string `bson:"trigrams,omitempty"`
+
+	Likes      int  `bson:"likes"`
+	IsArchived bool `bson:"isArchived"`
+	// Version of the drive file the lyrics were taken from.
+	Version   int64     `bson:"version"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
 type SongWithEvents struct {
 	Song `bson:",inline"`
 
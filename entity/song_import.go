@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type SongImportStatus string
+
+const (
+	SongImportRunning SongImportStatus = "running"
+	SongImportDone    SongImportStatus = "done"
+	SongImportFailed  SongImportStatus = "failed"
+)
+
+// MaxSongImportFailedFiles limits how many failed files are kept on the import to be reported.
+const MaxSongImportFailedFiles = 20
+
+// SongImport is an import of the band Drive folder into the song library.
+// The import goes page by page, PageToken is the page to continue from after restart.
+type SongImport struct {
+	ID           bson.ObjectID    `bson:"_id,omitempty" json:"id,omitempty"`
+	BandID       bson.ObjectID    `bson:"bandId" json:"bandId"`
+	UserID       int64            `bson:"userId" json:"userId"`
+	ChatID       int64            `bson:"chatId" json:"chatId"`
+	MessageID    int64            `bson:"messageId" json:"messageId"`
+	LanguageCode string           `bson:"languageCode" json:"languageCode"`
+	Status       SongImportStatus `bson:"status" json:"status"`
+	PageToken    string           `bson:"pageToken" json:"pageToken"`
+
+	Processed     int      `bson:"processed" json:"processed"`
+	Created       int      `bson:"created" json:"created"`
+	Failed        int      `bson:"failed" json:"failed"`
+	FailedFileIDs []string `bson:"failedFileIds,omitempty" json:"failedFileIds,omitempty"`
+	// Error and FinishedAt are reset when the failed import is continued.
+	Error string `bson:"error" json:"error,omitempty"`
+
+	CreatedAt  time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time  `bson:"updatedAt" json:"updatedAt"`
+	FinishedAt *time.Time `bson:"finishedAt" json:"finishedAt,omitempty"`
+}
+
+// AddFailed counts the failed file and keeps its ID if the limit is not reached.
+func (i *SongImport) AddFailed(fileID string) {
+	i.Failed++
+	if len(i.FailedFileIDs) < MaxSongImportFailedFiles {
+		i.FailedFileIDs = append(i.FailedFileIDs, fileID)
+	}
+}
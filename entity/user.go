@@ -27,6 +27,9 @@ type User struct {
 	Band   *Band         `bson:"band,omitempty" json:"-"`
 
 	BandIDs []bson.ObjectID `bson:"bandIDs,omitempty" json:"bandIDs,omitempty"`
+
+	// AudioOutput is the format of the processed audio the user prefers.
+	AudioOutput AudioOutput `bson:"audioOutput,omitempty" json:"audioOutput,omitempty"`
 }
 
 func (u *User) BelongsToBand(bandID bson.ObjectID) bool {
@@ -99,6 +102,7 @@ type Cache struct {
 	SongNames     []string       `bson:"song_names,omitempty"`
 	DriveFileIDs  []string       `bson:"drive_file_ids,omitempty"`
 	Voice         *Voice         `bson:"voice,omitempty"`
+	VoiceSegment  *VoiceSegment  `bson:"voice_segment,omitempty"`
 	SongID        bson.ObjectID  `bson:"song_id,omitempty"`
 	Band          *Band          `bson:"band,omitempty"`
 	Role          *Role          `bson:"role,omitempty"`
@@ -114,13 +118,18 @@ type CallbackCache struct {
 	MessageID int64 `schema:"messageId,omitempty"`
 	UserID    int64 `schema:"userId,omitempty"`
 
-	AudioFileId    string `schema:"audioFileId,omitempty"`
-	AudioDuration  int64  `schema:"audioDuration,omitempty"`
-	AudioPerformer string `schema:"audioPerformer,omitempty"`
-	AudioTitle     string `schema:"audioTitle,omitempty"`
-	AudioFileName  string `schema:"audioFileName,omitempty"`
-	AudioMimeType  string `schema:"audioMimeType,omitempty"`
-	AudioFileSize  int64  `schema:"audioFileSize,omitempty"`
+	AudioFileId       string `schema:"audioFileId,omitempty"`
+	AudioFileUniqueId string `schema:"audioFileUniqueId,omitempty"`
+	AudioDuration     int64  `schema:"audioDuration,omitempty"`
+	AudioPerformer    string `schema:"audioPerformer,omitempty"`
+	AudioTitle        string `schema:"audioTitle,omitempty"`
+	AudioFileName     string `schema:"audioFileName,omitempty"`
+	AudioMimeType     string `schema:"audioMimeType,omitempty"`
+	AudioFileSize     int64  `schema:"audioFileSize,omitempty"`
+	AudioBPM          string `schema:"audioBpm,omitempty"`
+	// AudioMix is the gain and the pan of the song voices in the mixer.
+	AudioMix       string `schema:"audioMix,omitempty"`
+	AudioSemitones int    `schema:"audioSemitones,omitempty"`
 
 	AudioThumbFileId       string `schema:"thumbFileId,omitempty"`
 	AudioThumbFileUniqueId string `schema:"thumbFileUniqueId,omitempty"`
@@ -1,6 +1,26 @@
 package entity
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// VoicePart is the vocal part the recording is for.
+type VoicePart string
+
+const (
+	VoicePartSoprano VoicePart = "soprano"
+	VoicePartAlto    VoicePart = "alto"
+	VoicePartTenor   VoicePart = "tenor"
+	VoicePartBass    VoicePart = "bass"
+	VoicePartLead    VoicePart = "lead"
+	VoicePartHarmony VoicePart = "harmony"
+)
+
+var VoiceParts = []VoicePart{VoicePartSoprano, VoicePartAlto, VoicePartTenor, VoicePartBass, VoicePartLead, VoicePartHarmony}
 
 type Voice struct {
 	ID          bson.ObjectID `bson:"_id,omitempty"`
@@ -9,4 +29,45 @@ type Voice struct {
 	AudioFileID string             `bson:"audioFileId,omitempty"`
 
 	SongID bson.ObjectID `bson:"songId,omitempty"`
+
+	// DetectedKey and DetectedBPM are estimated from the recording, empty if unknown.
+	DetectedKey Key `bson:"detectedKey,omitempty"`
+	DetectedBPM int `bson:"detectedBpm,omitempty"`
+
+	Part VoicePart `bson:"part,omitempty"`
+	// RoleID is the band role the recording is for, e.g. the members with "Alto" role learn it.
+	RoleID      bson.ObjectID `bson:"roleId,omitempty"`
+	Description string        `bson:"description,omitempty"`
+	UploaderID  int64         `bson:"uploaderId,omitempty"`
+	// Duration is in seconds.
+	Duration  int64     `bson:"duration,omitempty"`
+	CreatedAt time.Time `bson:"createdAt,omitempty"`
+	// Position is the manual order of the voices of the song.
+	Position int `bson:"position"`
+	// Segments are the named parts of the recording, shared by the whole band.
+	Segments []*VoiceSegment `bson:"segments,omitempty"`
+}
+
+// VoiceSegment is the named part of the recording, e.g. "Bridge". Start and End are in milliseconds.
+type VoiceSegment struct {
+	ID    bson.ObjectID `bson:"_id"`
+	Name  string        `bson:"name"`
+	Start int64         `bson:"start"`
+	End   int64         `bson:"end"`
+}
+
+func (v *Voice) GetSegment(ID bson.ObjectID) *VoiceSegment {
+	for _, segment := range v.Segments {
+		if segment.ID == ID {
+			return segment
+		}
+	}
+	return nil
+}
+
+// SortVoices sorts voices of the song by their manual order, voices without it are sorted by name.
+func SortVoices(voices []*Voice) {
+	slices.SortStableFunc(voices, func(v1, v2 *Voice) int {
+		return cmp.Or(cmp.Compare(v1.Position, v2.Position), cmp.Compare(v1.Name, v2.Name))
+	})
 }
@@ -220,7 +220,7 @@ func GetEventsStateFilterButtons(events []*entity.Event, lang string) []gotgbot.
 
 func GetSongsStateFilterButtons(lang string) []gotgbot.KeyboardButton {
 	return []gotgbot.KeyboardButton{
-		{Text: txt.Get("button.like", lang)}, {Text: txt.Get("button.calendar", lang)}, {Text: txt.Get("button.numbers", lang)}, {Text: txt.Get("button.tag", lang)},
+		{Text: txt.Get("button.like", lang)}, {Text: txt.Get("button.calendar", lang)}, {Text: txt.Get("button.numbers", lang)}, {Text: txt.Get("button.tag", lang)}, {Text: txt.Get("button.songFilter", lang)},
 	}
 }
 
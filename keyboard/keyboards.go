@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/joeyave/scala-bot/entity"
@@ -60,6 +61,10 @@ func EventInit(event *entity.Event, user *entity.User, lang string) [][]gotgbot.
 	keyboard := [][]gotgbot.InlineKeyboardButton{
 		{
 			{Text: txt.Get("button.chords", lang), CallbackData: util.CallbackData(state.EventSetlistDocs, event.ID.Hex())},
+			{Text: txt.Get("button.eventVoices", lang), CallbackData: util.CallbackData(state.EventVoices, event.ID.Hex())},
+		},
+		{
+			{Text: txt.Get("button.myVoices", lang), CallbackData: util.CallbackData(state.EventMyVoices, event.ID.Hex())},
 			// {Text: txt.Get("button.metronome", lang), CallbackData: util.CallbackData(state.EventSetlistMetronome, event.ID.Hex())},
 		},
 	}
@@ -79,6 +84,13 @@ func EventEdit(event *entity.Event, user *entity.User, chatID, messageID int64,
 			{Text: txt.Get("button.setlist", lang), WebApp: &gotgbot.WebAppInfo{Url: fmt.Sprintf("%s/webapp-react/#/events/%s/edit?messageId=%d&chatId=%d&userId=%d&lang=%s", os.Getenv("BOT_DOMAIN"), event.ID.Hex(), messageID, chatID, user.ID, lang)}},
 			{Text: txt.Get("button.members", lang), CallbackData: util.CallbackData(state.EventMembers, event.ID.Hex())},
 		},
+		{
+			{Text: txt.Get("button.songSuggestions", lang), CallbackData: util.CallbackData(state.EventSongSuggestions, event.ID.Hex())},
+			{Text: txt.Get("button.setlistFlow", lang), CallbackData: util.CallbackData(state.EventSetlistFlow, event.ID.Hex())},
+		},
+		{
+			{Text: txt.Get("button.slides", lang), CallbackData: util.CallbackData(state.EventSlides, event.ID.Hex())},
+		},
 		{
 			{Text: txt.Get("button.delete", lang), CallbackData: util.CallbackData(state.EventDeleteConfirm, event.ID.Hex())},
 		},
@@ -116,6 +128,7 @@ func SongInit(song *entity.Song, user *entity.User, chatID, messageID int64, lan
 			})
 		}
 
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: keyPreferencesButtonText(song, lang), CallbackData: util.CallbackData(state.SongKeyPreference, song.ID.Hex())}})
 		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.edit", lang), WebApp: &gotgbot.WebAppInfo{Url: fmt.Sprintf("%s/webapp-react/#/songs/%s/edit?userId=%d&messageId=%d&chatId=%d&lang=%s", os.Getenv("BOT_DOMAIN"), song.ID.Hex(), user.ID, messageID, chatID, lang)}}})
 	} else {
 		keyboard = [][]gotgbot.InlineKeyboardButton{
@@ -131,6 +144,56 @@ func SongInit(song *entity.Song, user *entity.User, chatID, messageID int64, lan
 	return keyboard
 }
 
+// songKeyPreferencesShown is how many preferred keys fit into the button.
+const songKeyPreferencesShown = 3
+
+func keyPreferencesButtonText(song *entity.Song, lang string) string {
+	if len(song.KeyPreferences) == 0 {
+		return txt.Get("button.keyPreferences", lang)
+	}
+
+	var preferences []string
+	for i, preference := range song.KeyPreferences {
+		if i == songKeyPreferencesShown {
+			preferences = append(preferences, "…")
+			break
+		}
+		preferences = append(preferences, fmt.Sprintf("%s: %s", preference.UserName, preference.Key))
+	}
+	return "🎤 " + strings.Join(preferences, ", ")
+}
+
+// SongKeyPreference lets the user choose the key they prefer to sing the song in.
+func SongKeyPreference(song *entity.Song, user *entity.User, lang string) [][]gotgbot.InlineKeyboardButton {
+	keys := entity.MajorKeys
+	if song.PDF.Key.IsMinor() {
+		keys = entity.MinorKeys
+	}
+
+	preference := song.GetKeyPreference(user.ID)
+
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var row []gotgbot.InlineKeyboardButton
+	for _, key := range keys {
+		text := string(key)
+		if preference != nil && preference.Key == key {
+			text += " ✅"
+		}
+		row = append(row, gotgbot.InlineKeyboardButton{Text: text, CallbackData: util.CallbackData(state.SongKeyPreferenceSet, song.ID.Hex()+":"+string(key))})
+		if len(row) == 4 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+
+	if preference != nil {
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.removeKeyPreference", lang), CallbackData: util.CallbackData(state.SongKeyPreferenceSet, song.ID.Hex()+":-")}})
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.SongCB, song.ID.Hex()+":init")}})
+
+	return keyboard
+}
+
 func SongInitIQ(song *entity.Song, user *entity.User, lang string) [][]gotgbot.InlineKeyboardButton {
 	var keyboard [][]gotgbot.InlineKeyboardButton
 
@@ -180,8 +243,32 @@ func SongEdit(song *entity.Song, driveFile *drive.File, user *entity.User, lang
 	}
 	//	}
 
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.chartViews", lang), CallbackData: util.CallbackData(state.SongChartViews, song.ID.Hex())}})
 	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.stats", lang), CallbackData: util.CallbackData(state.SongStats, song.ID.Hex())}})
 	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.SongCB, song.ID.Hex()+":init")}})
 
 	return keyboard
 }
+
+// SongChartViews lets the user choose how to render the song chart: with capo shapes or with Nashville numbers.
+// capoKeys[i] is the key of chord shapes with the capo on the fret i+1.
+func SongChartViews(song *entity.Song, capoKeys []entity.Key, lang string) [][]gotgbot.InlineKeyboardButton {
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	var row []gotgbot.InlineKeyboardButton
+	for i, capoKey := range capoKeys {
+		capo := i + 1
+		row = append(row, gotgbot.InlineKeyboardButton{Text: txt.Get("button.capo", lang, capo, capoKey), CallbackData: util.CallbackData(state.SongChartView, fmt.Sprintf("%s:%d", song.ID.Hex(), capo))})
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.nashville", lang), CallbackData: util.CallbackData(state.SongChartView, song.ID.Hex()+":n")}})
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{{Text: txt.Get("button.back", lang), CallbackData: util.CallbackData(state.SongCB, song.ID.Hex()+":edit")}})
+
+	return keyboard
+}
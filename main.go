@@ -128,10 +128,17 @@ func main() {
 	bandRepository := repository.NewBandRepository(mongoClient)
 	bandService := service.NewBandService(bandRepository)
 
-	driveFileService := service.NewDriveFileService(driveRepository, docsRepository)
+	driveFileService := service.NewDriveFileService(driveRepository, docsRepository, bandRepository)
+
+	eventRepository := repository.NewEventRepository(mongoClient)
 
 	songRepository := repository.NewSongRepository(mongoClient)
-	songService := service.NewSongService(songRepository, voiceRepository, bandRepository, driveRepository, driveFileService)
+	songSearchIndexRepository := repository.NewSongSearchIndexRepository(mongoClient)
+	err = songSearchIndexRepository.CreateIndexes(pingCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating song search indexes")
+	}
+	songService := service.NewSongService(songRepository, voiceRepository, bandRepository, eventRepository, songSearchIndexRepository, driveRepository, driveFileService)
 
 	userRepository := repository.NewUserRepository(mongoClient)
 	userService := service.NewUserService(userRepository)
@@ -139,7 +146,6 @@ func main() {
 	membershipRepository := repository.NewMembershipRepository(mongoClient)
 	membershipService := service.NewMembershipService(membershipRepository)
 
-	eventRepository := repository.NewEventRepository(mongoClient)
 	eventService := service.NewEventService(eventRepository, membershipRepository, driveFileService)
 
 	roleRepository := repository.NewRoleRepository(mongoClient)
@@ -148,6 +154,23 @@ func main() {
 	joinRequestRepository := repository.NewJoinRequestRepository(mongoClient)
 	joinRequestService := service.NewJoinRequestService(joinRequestRepository, userService)
 
+	songImportRepository := repository.NewSongImportRepository(mongoClient)
+	err = songImportRepository.CreateIndexes(pingCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating song import indexes")
+	}
+	songImportService := service.NewSongImportService(songImportRepository, bandRepository, songRepository, songService, driveFileService)
+
+	audioJobRepository := repository.NewAudioJobRepository(mongoClient)
+	audioJobService := service.NewAudioJobService(audioJobRepository)
+
+	processedAudioRepository := repository.NewProcessedAudioRepository(mongoClient)
+	err = processedAudioRepository.CreateIndexes(pingCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Error creating processed audio indexes")
+	}
+	processedAudioService := service.NewProcessedAudioService(processedAudioRepository)
+
 	// handler := myhandlers.NewHandler(
 	//	bot,
 	//	userService,
@@ -162,33 +185,38 @@ func main() {
 
 	botController := controller.BotController{
 		// OldHandler:        handler,
-		UserService:        userService,
-		DriveFileService:   driveFileService,
-		SongService:        songService,
-		VoiceService:       voiceService,
-		BandService:        bandService,
-		MembershipService:  membershipService,
-		EventService:       eventService,
-		RoleService:        roleService,
-		JoinRequestService: joinRequestService,
+		UserService:           userService,
+		DriveFileService:      driveFileService,
+		SongService:           songService,
+		VoiceService:          voiceService,
+		BandService:           bandService,
+		MembershipService:     membershipService,
+		EventService:          eventService,
+		RoleService:           roleService,
+		JoinRequestService:    joinRequestService,
+		SongImportService:     songImportService,
+		AudioJobService:       audioJobService,
+		ProcessedAudioService: processedAudioService,
 	}
 	webAppController := controller.WebAppController{
 		Bot: bot,
 
-		UserService:        userService,
-		DriveFileService:   driveFileService,
-		SongService:        songService,
-		VoiceService:       voiceService,
-		BandService:        bandService,
-		MembershipService:  membershipService,
-		EventService:       eventService,
-		RoleService:        roleService,
-		JoinRequestService: joinRequestService,
-		IsTestBotAPI:       botAPIMode == "test",
+		UserService:           userService,
+		DriveFileService:      driveFileService,
+		SongService:           songService,
+		VoiceService:          voiceService,
+		BandService:           bandService,
+		MembershipService:     membershipService,
+		EventService:          eventService,
+		RoleService:           roleService,
+		JoinRequestService:    joinRequestService,
+		ProcessedAudioService: processedAudioService,
+		IsTestBotAPI:          botAPIMode == "test",
 	}
 	driveFileController := controller.DriveFileController{
 		DriveFileService: driveFileService,
 		SongService:      songService,
+		BandService:      bandService,
 	}
 
 	// Create updater and dispatcher.
@@ -248,6 +276,13 @@ func main() {
 	dispatcher.AddHandlerToGroup(handlers.NewCommand("schedule", botController.GetEvents(0)), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCommand("songs", botController.GetSongs(0)), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCommand("menu", botController.Menu), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("duplicates", botController.SongDuplicates), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("report", botController.SongUsageReport), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("reindex", botController.SongSearchReindex), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("import", botController.SongImport), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("myparts", botController.MyVoices), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("audio", botController.AudioSettings), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCommand("roles", botController.BandRoles), 1)
 
 	dispatcher.AddHandlerToGroup(handlers.NewMessage(func(msg *gotgbot.Message) bool {
 		return msg.Text == txt.Get("button.menu", msg.From.LanguageCode) || msg.Text == txt.Get("button.cancel", msg.From.LanguageCode)
@@ -301,9 +336,38 @@ func main() {
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongCopyToMyBand), botController.SongCopyToMyBand), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongStyle), botController.SongStyle), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongAddLyricsPage), botController.SongAddLyricsPage), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongMergeConfirm), botController.SongMergeConfirm), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongMerge), botController.SongMerge), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSongSuggestions), botController.EventSongSuggestions), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSongSuggestionAdd), botController.EventSongSuggestionAdd), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSetlistFlow), botController.EventSetlistFlow), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSetlistFlowReorder), botController.EventSetlistFlowReorder), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongKeyPreference), botController.SongKeyPreference), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongKeyPreferenceSet), botController.SongKeyPreferenceSet), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongChartViews), botController.SongChartViews), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongChartView), botController.SongChartView), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSlides), botController.EventSlides), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventSlidesExport), botController.EventSlidesExport), 1)
 
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.TransposeAudio_AskForSemitonesNumber), botController.TransposeAudio_AskForSemitonesNumber), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.TransposeAudio), botController.TransposeAudio), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.AudioJobCancel), botController.AudioJobCancel), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventVoices), botController.EventVoices), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceTransposeToEventKey), botController.VoiceTransposeToEventKey), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongVoiceMove), botController.SongVoiceMove), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongVoiceEditAskForPart), botController.SongVoiceEditAskForPart), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.EventMyVoices), botController.EventMyVoices), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongVoicesMix), botController.SongVoicesMix), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongVoicesMixRun), botController.SongVoicesMixRun), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongClickTrack), botController.SongClickTrack), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.SongClickTrackRun), botController.SongClickTrackRun), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceSegments), botController.VoiceSegments), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceSegment), botController.VoiceSegment), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceSegmentCreateAskForStart), botController.VoiceSegmentCreateAskForStart), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceSegmentRun), botController.VoiceSegmentRun), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.VoiceSegmentDelete), botController.VoiceSegmentDelete), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.AudioSettings), botController.AudioSettings), 1)
+	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.BandRoles), botController.BandRoles), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.JoinRequestApprove), botController.JoinRequestApprove), 1)
 	dispatcher.AddHandlerToGroup(handlers.NewCallback(util.CallbackState(state.JoinRequestDecline), botController.JoinRequestDecline), 1)
 
@@ -316,19 +380,31 @@ func main() {
 		//	return nil
 		//}
 
-		driveFiles, _, err := driveFileService.FindSomeByFullTextAndFolderID(ctx.InlineQuery.Query, []string{user.Band.DriveFolderID, user.Band.ArchiveFolderID}, "")
-		if err != nil {
-			return err
-		}
+		var (
+			songs []*entity.Song
+			err   error
+		)
+		filter, query, isFilter := service.ParseSongFilter(ctx.InlineQuery.Query, user.Band.GetNowTime())
+		if isFilter {
+			songs, err = songService.FilterSongs(user.BandID, filter, query, helpers.SongsPageSize)
+			if err != nil {
+				return err
+			}
+		} else {
+			driveFiles, _, err := songService.SearchDriveFiles(user.Band, ctx.InlineQuery.Query, "")
+			if err != nil {
+				return err
+			}
 
-		var driveFileIDs []string
-		for _, file := range driveFiles {
-			driveFileIDs = append(driveFileIDs, file.Id)
-		}
+			var driveFileIDs []string
+			for _, file := range driveFiles {
+				driveFileIDs = append(driveFileIDs, file.Id)
+			}
 
-		songs, err := songService.FindManyByDriveFileIDs(driveFileIDs)
-		if err != nil {
-			return err
+			songs, err = songService.FindManyByDriveFileIDs(driveFileIDs)
+			if err != nil {
+				return err
+			}
 		}
 
 		var results []gotgbot.InlineQueryResult
@@ -371,6 +447,31 @@ func main() {
 		}()
 		botController.NotifyUsers(bot)
 	}()
+	go botController.ResumeSongImports(bot)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("RunAudioJobs panic: %v", r)
+			}
+		}()
+		botController.RunAudioJobs(bot)
+	}()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("RunEviction panic: %v", r)
+			}
+		}()
+		processedAudioService.RunEviction(service.ProcessedAudioEvictionInterval)
+	}()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error().Msgf("WatchDriveChanges panic: %v", r)
+			}
+		}()
+		songService.WatchDriveChanges(service.DriveChangesSyncInterval)
+	}()
 
 	router := gin.New()
 	router.SetFuncMap(template.FuncMap{
@@ -411,17 +512,27 @@ func main() {
 	router.GET("/api/v2/drive-files/search", driveFileController.SearchV2)
 	router.GET("/api/v2/songs/find-by-drive-file-id", driveFileController.FindByDriveFileIDV2)
 
+	router.GET("/api/songs/usage-report", webAppController.SongUsageReport)
+	router.GET("/api/songs/filter", webAppController.SongFilter)
+	router.GET("/api/songs/suggestions", webAppController.SongSuggestions)
 	router.GET("/api/songs/:id", webAppController.SongData)
 	router.GET("/api/songs/:id/lyrics", webAppController.SongLyrics)
+	router.GET("/api/songs/:id/chart", webAppController.SongChartView)
 	router.POST("/api/songs/:id/edit", webAppController.SongEdit)
 	router.POST("/api/songs/:id/format", webAppController.SongFormat)
 	router.GET("/api/songs/:id/download", webAppController.SongDownload)
+	router.GET("/api/songs/:id/road-map", webAppController.SongRoadMap)
+	router.POST("/api/songs/:id/arrangements", webAppController.SongArrangementCreate)
+	router.POST("/api/songs/:id/arrangements/:arrangementId/edit", webAppController.SongArrangementEdit)
+	router.DELETE("/api/songs/:id/arrangements/:arrangementId", webAppController.SongArrangementDelete)
 
 	router.GET("/api/tags", webAppController.Tags)
 
 	router.GET("/api/events/:id", webAppController.EventData)
 	router.GET("/api/events/frequent-names", webAppController.FrequentEventNames)
+	router.GET("/api/events/:id/key-proposals", webAppController.EventKeyProposals)
 	router.POST("/api/events/:id/edit", webAppController.EventEdit)
+	router.POST("/api/events/setlist-flow", webAppController.EventSetlistFlow)
 
 	// Check if we're in development mode
 	if os.Getenv("ENV") == "dev" {
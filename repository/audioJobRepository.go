@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type AudioJobRepository struct {
+	mongoClient *mongo.Client
+}
+
+func NewAudioJobRepository(mongoClient *mongo.Client) *AudioJobRepository {
+	return &AudioJobRepository{
+		mongoClient: mongoClient,
+	}
+}
+
+func (r *AudioJobRepository) FindOneByID(ID bson.ObjectID) (*entity.AudioJob, error) {
+	jobs, err := r.find(bson.M{"_id": ID})
+	if err != nil {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+// FindManyQueued returns queued jobs in the queue order.
+func (r *AudioJobRepository) FindManyQueued() ([]*entity.AudioJob, error) {
+	return r.find(bson.M{
+		"status": entity.AudioJobQueued,
+	})
+}
+
+// CountActiveByUserID counts queued and running jobs of the user.
+func (r *AudioJobRepository) CountActiveByUserID(userID int64) (int64, error) {
+	return r.collection().CountDocuments(context.TODO(), bson.M{
+		"userId": userID,
+		"status": bson.M{"$in": []entity.AudioJobStatus{entity.AudioJobQueued, entity.AudioJobRunning}},
+	})
+}
+
+// ClaimNext marks the first queued job as running and returns it.
+// Jobs of the users from excludeUserIDs are skipped.
+func (r *AudioJobRepository) ClaimNext(excludeUserIDs []int64) (*entity.AudioJob, error) {
+	filter := bson.M{
+		"status": entity.AudioJobQueued,
+	}
+	if len(excludeUserIDs) > 0 {
+		filter["userId"] = bson.M{"$nin": excludeUserIDs}
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":    entity.AudioJobRunning,
+			"startedAt": now,
+			"updatedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"createdAt": 1}).SetReturnDocument(options.After)
+
+	return r.findOneAndUpdate(filter, update, opts)
+}
+
+// Finish sets the final status of the running job. Nothing is changed if the job was canceled meanwhile.
+func (r *AudioJobRepository) Finish(ID bson.ObjectID, status entity.AudioJobStatus, errText string) (*entity.AudioJob, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    ID,
+		"status": entity.AudioJobRunning,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"error":      errText,
+			"updatedAt":  now,
+			"finishedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	return r.findOneAndUpdate(filter, update, opts)
+}
+
+// Cancel cancels the queued or running job. ErrNotFound is returned if the job has already finished.
+func (r *AudioJobRepository) Cancel(ID bson.ObjectID) (*entity.AudioJob, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    ID,
+		"status": bson.M{"$in": []entity.AudioJobStatus{entity.AudioJobQueued, entity.AudioJobRunning}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     entity.AudioJobCanceled,
+			"updatedAt":  now,
+			"finishedAt": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	return r.findOneAndUpdate(filter, update, opts)
+}
+
+// RequeueRunning puts jobs that were interrupted by restart back to the queue.
+func (r *AudioJobRepository) RequeueRunning() error {
+	filter := bson.M{"status": entity.AudioJobRunning}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    entity.AudioJobQueued,
+			"updatedAt": time.Now(),
+		},
+	}
+
+	_, err := r.collection().UpdateMany(context.TODO(), filter, update)
+	return err
+}
+
+func (r *AudioJobRepository) UpdateOne(job entity.AudioJob) (*entity.AudioJob, error) {
+	if job.ID.IsZero() {
+		job.ID = bson.NewObjectID()
+	}
+
+	filter := bson.M{"_id": job.ID}
+	update := bson.M{"$set": job}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+
+	return r.findOneAndUpdate(filter, update, opts)
+}
+
+func (r *AudioJobRepository) findOneAndUpdate(filter, update bson.M, opts *options.FindOneAndUpdateOptionsBuilder) (*entity.AudioJob, error) {
+	result := r.collection().FindOneAndUpdate(context.TODO(), filter, update, opts)
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var job *entity.AudioJob
+	if err := result.Decode(&job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *AudioJobRepository) find(m bson.M) ([]*entity.AudioJob, error) {
+	cursor, err := r.collection().Find(context.TODO(), m, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*entity.AudioJob
+	if err := cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return jobs, nil
+}
+
+func (r *AudioJobRepository) collection() *mongo.Collection {
+	return r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("audio_jobs")
+}
@@ -64,6 +64,22 @@ func (r *BandRepository) FindOneByDriveFolderID(driveFolderID string) (*entity.B
 	return bands[0], nil
 }
 
+// FindOneByAnyFolderID finds the band by its drive, archive or temp folder.
+func (r *BandRepository) FindOneByAnyFolderID(folderID string) (*entity.Band, error) {
+	bands, err := r.find(bson.M{
+		"$or": []bson.M{
+			{"driveFolderId": folderID},
+			{"archiveFolderId": folderID},
+			{"tempFolderID": folderID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bands[0], nil
+}
+
 func (r *BandRepository) find(m bson.M) ([]*entity.Band, error) {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("bands")
 
@@ -133,3 +149,18 @@ func (r *BandRepository) UpdateOne(band entity.Band) (*entity.Band, error) {
 
 	return r.FindOneByID(newBand.ID)
 }
+
+// SetDriveChangesPageToken saves the page token of the Drive changes feed the band songs are synced up to.
+func (r *BandRepository) SetDriveChangesPageToken(bandID bson.ObjectID, pageToken string) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("bands")
+
+	filter := bson.M{"_id": bandID}
+	update := bson.M{
+		"$set": bson.M{
+			"driveChangesPageToken": pageToken,
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
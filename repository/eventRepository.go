@@ -704,6 +704,89 @@ func (r *EventRepository) PullSongID(eventID, songID bson.ObjectID) error {
 	return err
 }
 
+// ReplaceSongID replaces oldSongID with newSongID in songIds and songOverrides of every event.
+// If an event already contains newSongID, oldSongID is just removed, so the setlist order is preserved.
+func (r *EventRepository) ReplaceSongID(oldSongID, newSongID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("events")
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"songIds": oldSongID},
+			bson.M{"songOverrides.songId": oldSongID},
+		},
+	}
+
+	update := bson.A{
+		bson.M{
+			"$set": bson.M{
+				"songIds": bson.M{
+					"$reduce": bson.M{
+						"input": bson.M{
+							"$map": bson.M{
+								"input": bson.M{"$ifNull": bson.A{"$songIds", bson.A{}}},
+								"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$this", oldSongID}}, newSongID, "$$this"}},
+							},
+						},
+						"initialValue": bson.A{},
+						"in": bson.M{
+							"$cond": bson.A{
+								bson.M{"$in": bson.A{"$$this", "$$value"}},
+								"$$value",
+								bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+							},
+						},
+					},
+				},
+				"songOverrides": bson.M{
+					"$reduce": bson.M{
+						"input": bson.M{
+							"$map": bson.M{
+								"input": bson.M{"$ifNull": bson.A{"$songOverrides", bson.A{}}},
+								"in": bson.M{
+									"$cond": bson.A{
+										bson.M{"$eq": bson.A{"$$this.songId", oldSongID}},
+										bson.M{"$mergeObjects": bson.A{"$$this", bson.M{"songId": newSongID}}},
+										"$$this",
+									},
+								},
+							},
+						},
+						"initialValue": bson.A{},
+						"in": bson.M{
+							"$cond": bson.A{
+								bson.M{"$in": bson.A{"$$this.songId", "$$value.songId"}},
+								"$$value",
+								bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := collection.UpdateMany(context.TODO(), filter, update)
+	return err
+}
+
+// UnsetArrangementID removes deleted arrangement from song overrides of all events.
+func (r *EventRepository) UnsetArrangementID(arrangementID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("events")
+
+	filter := bson.M{"songOverrides.arrangementId": arrangementID}
+
+	update := bson.M{
+		"$unset": bson.M{
+			"songOverrides.$[o].arrangementId": "",
+		},
+	}
+
+	opts := options.UpdateMany().SetArrayFilters([]any{bson.M{"o.arrangementId": arrangementID}})
+
+	_, err := collection.UpdateMany(context.TODO(), filter, update, opts)
+	return err
+}
+
 func (r *EventRepository) GetMostFrequentEventNames(bandID bson.ObjectID, limit int, fromUTC time.Time) ([]*entity.EventNameFrequencies, error) {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("events")
 
@@ -732,3 +815,64 @@ func (r *EventRepository) GetMostFrequentEventNames(bandID bson.ObjectID, limit
 
 	return frequencies, nil
 }
+
+func (r *EventRepository) GetSongUsage(bandID bson.ObjectID, fromUTC, toUTC time.Time) ([]*entity.SongUsage, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("events")
+
+	pipeline := bson.A{
+		bson.M{
+			"$match": bson.M{
+				"bandId": bandID,
+				"time": bson.M{
+					"$gte": fromUTC,
+					"$lt":  toUTC,
+				},
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"time": 1,
+				"songIds": bson.M{
+					"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$songIds", bson.A{}}}},
+				},
+			},
+		},
+		bson.M{"$unwind": "$songIds"},
+		bson.M{
+			"$group": bson.M{
+				"_id":       "$songIds",
+				"count":     bson.M{"$sum": 1},
+				"firstTime": bson.M{"$min": "$time"},
+				"lastTime":  bson.M{"$max": "$time"},
+			},
+		},
+		bson.M{
+			"$lookup": bson.M{
+				"from":         "songs",
+				"localField":   "_id",
+				"foreignField": "_id",
+				"as":           "song",
+			},
+		},
+		bson.M{"$unwind": "$song"},
+		bson.M{
+			"$sort": bson.D{
+				{Key: "count", Value: -1},
+				{Key: "song.pdf.name", Value: 1},
+			},
+		},
+	}
+
+	cur, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []*entity.SongUsage
+	err = cur.All(context.TODO(), &usages)
+	if err != nil {
+		return nil, err
+	}
+
+	return usages, nil
+}
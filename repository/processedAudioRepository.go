@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ProcessedAudioRepository struct {
+	mongoClient *mongo.Client
+}
+
+func NewProcessedAudioRepository(mongoClient *mongo.Client) *ProcessedAudioRepository {
+	return &ProcessedAudioRepository{
+		mongoClient: mongoClient,
+	}
+}
+
+// CreateIndexes makes sure there is only one result processed with the same parameters.
+func (r *ProcessedAudioRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "sourceFileUniqueId", Value: 1},
+			{Key: "semitones", Value: 1},
+			{Key: "fine", Value: 1},
+			{Key: "tempo", Value: 1},
+			{Key: "isVoice", Value: 1},
+			{Key: "output", Value: 1},
+		},
+		Options: options.Index().SetName("key").SetUnique(true),
+	})
+	return err
+}
+
+// FindOneByKey finds the result processed with the same parameters.
+func (r *ProcessedAudioRepository) FindOneByKey(key entity.ProcessedAudio) (*entity.ProcessedAudio, error) {
+	result := r.collection().FindOne(context.TODO(), processedAudioKeyFilter(key))
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var audio *entity.ProcessedAudio
+	if err := result.Decode(&audio); err != nil {
+		return nil, err
+	}
+	return audio, nil
+}
+
+// Hit counts the use of the result.
+func (r *ProcessedAudioRepository) Hit(ID bson.ObjectID) error {
+	update := bson.M{
+		"$inc": bson.M{"hits": 1},
+		"$set": bson.M{"lastUsedAt": time.Now()},
+	}
+	_, err := r.collection().UpdateOne(context.TODO(), bson.M{"_id": ID}, update)
+	return err
+}
+
+// UpdateOne saves the result. The result processed with the same parameters is replaced.
+func (r *ProcessedAudioRepository) UpdateOne(audio entity.ProcessedAudio) (*entity.ProcessedAudio, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"fileId":     audio.FileID,
+			"fileSize":   audio.FileSize,
+			"lastUsedAt": audio.LastUsedAt,
+		},
+		"$setOnInsert": bson.M{
+			"bandId":    audio.BandID,
+			"hits":      0,
+			"createdAt": audio.CreatedAt,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+
+	result := r.collection().FindOneAndUpdate(context.TODO(), processedAudioKeyFilter(audio), update, opts)
+	// The same result was inserted by the concurrent job, it's updated now.
+	if mongo.IsDuplicateKeyError(result.Err()) {
+		result = r.collection().FindOneAndUpdate(context.TODO(), processedAudioKeyFilter(audio), update, opts)
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var newAudio *entity.ProcessedAudio
+	if err := result.Decode(&newAudio); err != nil {
+		return nil, err
+	}
+	return newAudio, nil
+}
+
+func (r *ProcessedAudioRepository) DeleteOneByID(ID bson.ObjectID) error {
+	_, err := r.collection().DeleteOne(context.TODO(), bson.M{"_id": ID})
+	return err
+}
+
+// DeleteManyUnusedSince deletes results that weren't used since the time.
+func (r *ProcessedAudioRepository) DeleteManyUnusedSince(t time.Time) (int64, error) {
+	result, err := r.collection().DeleteMany(context.TODO(), bson.M{"lastUsedAt": bson.M{"$lt": t}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// DeleteLeastRecentlyUsed keeps only the limit of the most recently used results.
+func (r *ProcessedAudioRepository) DeleteLeastRecentlyUsed(limit int64) (int64, error) {
+	opts := options.Find().
+		SetSort(bson.M{"lastUsedAt": -1}).
+		SetSkip(limit).
+		SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.collection().Find(context.TODO(), bson.M{}, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var audios []*entity.ProcessedAudio
+	if err := cursor.All(context.TODO(), &audios); err != nil {
+		return 0, err
+	}
+	if len(audios) == 0 {
+		return 0, nil
+	}
+
+	IDs := make([]bson.ObjectID, 0, len(audios))
+	for _, audio := range audios {
+		IDs = append(IDs, audio.ID)
+	}
+
+	result, err := r.collection().DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": IDs}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// GetStatsByBandID counts results first requested by members of the band.
+func (r *ProcessedAudioRepository) GetStatsByBandID(bandID bson.ObjectID) (*entity.ProcessedAudioStats, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"bandId": bandID}},
+		bson.M{"$group": bson.M{
+			"_id":     nil,
+			"entries": bson.M{"$sum": 1},
+			"hits":    bson.M{"$sum": "$hits"},
+			"size":    bson.M{"$sum": "$fileSize"},
+		}},
+	}
+
+	cursor, err := r.collection().Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []*entity.ProcessedAudioStats
+	if err := cursor.All(context.TODO(), &stats); err != nil {
+		return nil, err
+	}
+
+	if len(stats) == 0 {
+		return &entity.ProcessedAudioStats{}, nil
+	}
+	return stats[0], nil
+}
+
+func processedAudioKeyFilter(key entity.ProcessedAudio) bson.M {
+	filter := bson.M{
+		"sourceFileUniqueId": key.SourceFileUniqueID,
+		"semitones":          key.Semitones,
+		"fine":               key.Fine,
+		"tempo":              key.Tempo,
+		"isVoice":            key.IsVoice,
+		// Results in the original format are saved without the output.
+		"output": bson.M{"$exists": false},
+	}
+	if key.Output != "" {
+		filter["output"] = key.Output
+	}
+	return filter
+}
+
+func (r *ProcessedAudioRepository) collection() *mongo.Collection {
+	return r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("processed_audios")
+}
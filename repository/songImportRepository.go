@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"os"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SongImportRepository struct {
+	mongoClient *mongo.Client
+}
+
+func NewSongImportRepository(mongoClient *mongo.Client) *SongImportRepository {
+	return &SongImportRepository{
+		mongoClient: mongoClient,
+	}
+}
+
+// CreateIndexes makes sure only one import of the band can be running at a time.
+func (r *SongImportRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "bandId", Value: 1}},
+		Options: options.Index().
+			SetName("bandId_running").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"status": entity.SongImportRunning}),
+	})
+	return err
+}
+
+func (r *SongImportRepository) FindOneByID(ID bson.ObjectID) (*entity.SongImport, error) {
+	imports, err := r.find(bson.M{"_id": ID})
+	if err != nil {
+		return nil, err
+	}
+	return imports[0], nil
+}
+
+func (r *SongImportRepository) FindManyRunning() ([]*entity.SongImport, error) {
+	return r.find(bson.M{
+		"status": entity.SongImportRunning,
+	})
+}
+
+func (r *SongImportRepository) FindLastByBandID(bandID bson.ObjectID) (*entity.SongImport, error) {
+	imports, err := r.find(bson.M{
+		"bandId": bandID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imports[0], nil
+}
+
+func (r *SongImportRepository) UpdateOne(songImport entity.SongImport) (*entity.SongImport, error) {
+	if songImport.ID.IsZero() {
+		songImport.ID = bson.NewObjectID()
+	}
+
+	collection := r.collection()
+	filter := bson.M{"_id": songImport.ID}
+	update := bson.M{"$set": songImport}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After).SetUpsert(true)
+
+	result := collection.FindOneAndUpdate(context.TODO(), filter, update, opts)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var newImport *entity.SongImport
+	if err := result.Decode(&newImport); err != nil {
+		return nil, err
+	}
+
+	return newImport, nil
+}
+
+func (r *SongImportRepository) find(m bson.M) ([]*entity.SongImport, error) {
+	collection := r.collection()
+
+	cursor, err := collection.Find(context.TODO(), m, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	var imports []*entity.SongImport
+	if err := cursor.All(context.TODO(), &imports); err != nil {
+		return nil, err
+	}
+
+	if len(imports) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return imports, nil
+}
+
+func (r *SongImportRepository) collection() *mongo.Collection {
+	return r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("song_imports")
+}
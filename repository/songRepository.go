@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"os"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/joeyave/scala-bot/entity"
@@ -113,6 +115,12 @@ func (r *SongRepository) FindManyByDriveFileIDs(IDs []string) ([]*entity.Song, e
 	return songs, nil
 }
 
+func (r *SongRepository) FindManyByIDs(IDs []bson.ObjectID) ([]*entity.Song, error) {
+	return r.find(bson.M{
+		"_id": bson.M{"$in": IDs},
+	})
+}
+
 func (r *SongRepository) FindOneByID(ID bson.ObjectID) (*entity.Song, error) {
 	songs, err := r.find(bson.M{"_id": ID})
 	if err != nil {
@@ -140,6 +148,34 @@ func (r *SongRepository) FindOneByName(name string, bandID bson.ObjectID) (*enti
 	return songs[0], nil
 }
 
+func (r *SongRepository) FindManyByBandID(bandID bson.ObjectID) ([]*entity.Song, error) {
+	return r.find(bson.M{
+		"bandId": bandID,
+	})
+}
+
+func (r *SongRepository) FindManyNotArchivedByBandID(bandID bson.ObjectID) ([]*entity.Song, error) {
+	return r.find(bson.M{
+		"bandId":     bandID,
+		"isArchived": bson.M{"$ne": true},
+	})
+}
+
+// FindManyByInfo finds songs of the band whose authors, copyright, CCLI number or language match the query.
+func (r *SongRepository) FindManyByInfo(bandID bson.ObjectID, query string) ([]*entity.Song, error) {
+	regex := bson.Regex{Pattern: regexp.QuoteMeta(query), Options: "i"}
+
+	return r.find(bson.M{
+		"bandId": bandID,
+		"$or": bson.A{
+			bson.M{"authors": regex},
+			bson.M{"copyright": regex},
+			bson.M{"ccliNumber": query},
+			bson.M{"language": strings.ToLower(query)},
+		},
+	})
+}
+
 func (r *SongRepository) find(m bson.M, opts ...bson.M) ([]*entity.Song, error) {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
 
@@ -316,6 +352,24 @@ func (r *SongRepository) Unarchive(songID bson.ObjectID) error {
 	return err
 }
 
+// UnsetAltPDFs removes transposed PDFs cached for the song, e.g. when the doc has changed.
+func (r *SongRepository) UnsetAltPDFs(songID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{
+		"_id": songID,
+	}
+
+	update := bson.M{
+		"$unset": bson.M{
+			"altPdfs": "",
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
 func (r *SongRepository) Like(songID bson.ObjectID, userID int64, likeTime time.Time) error {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
 
@@ -354,6 +408,96 @@ func (r *SongRepository) Dislike(songID bson.ObjectID, userID int64) error {
 	return err
 }
 
+// SetKeyPreference replaces the key preference of the user for the song.
+func (r *SongRepository) SetKeyPreference(songID bson.ObjectID, preference *entity.KeyPreference) error {
+	err := r.PullKeyPreference(songID, preference.UserID)
+	if err != nil {
+		return err
+	}
+
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{"_id": songID}
+
+	update := bson.M{
+		"$push": bson.M{
+			"keyPreferences": preference,
+		},
+	}
+
+	_, err = collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
+func (r *SongRepository) PullKeyPreference(songID bson.ObjectID, userID int64) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{"_id": songID}
+
+	update := bson.M{
+		"$pull": bson.M{
+			"keyPreferences": bson.M{"userId": userID},
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
+func (r *SongRepository) PushArrangement(songID bson.ObjectID, arrangement *entity.Arrangement) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{"_id": songID}
+
+	update := bson.M{
+		"$push": bson.M{
+			"arrangements": arrangement,
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
+func (r *SongRepository) UpdateArrangement(songID bson.ObjectID, arrangement *entity.Arrangement) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{
+		"_id":              songID,
+		"arrangements._id": arrangement.ID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"arrangements.$": arrangement,
+		},
+	}
+
+	result, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *SongRepository) PullArrangement(songID, arrangementID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songs")
+
+	filter := bson.M{"_id": songID}
+
+	update := bson.M{
+		"$pull": bson.M{
+			"arrangements": bson.M{"_id": arrangementID},
+		},
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
 func (r *SongRepository) FindOneWithExtraByID(songID bson.ObjectID, eventsStartDate time.Time) (*entity.SongWithEvents, error) {
 	songs, err := r.findWithExtra(
 		bson.M{
@@ -432,6 +576,17 @@ func (r *SongRepository) FindAllExtraByPageNumberSortedByEventDate(bandID bson.O
 	)
 }
 
+// FindManyNotArchivedExtraByBandID finds all not archived songs of the band with events since eventsStartDate.
+func (r *SongRepository) FindManyNotArchivedExtraByBandID(bandID bson.ObjectID, eventsStartDate time.Time) ([]*entity.SongWithEvents, error) {
+	return r.findWithExtra(
+		bson.M{
+			"bandId":     bandID,
+			"isArchived": bson.M{"$ne": true},
+		},
+		eventsStartDate,
+	)
+}
+
 func (r *SongRepository) FindManyExtraByTag(tag string, bandID bson.ObjectID, eventsStartDate time.Time, pageNumber int) ([]*entity.SongWithEvents, error) {
 	return r.findWithExtra(
 		bson.M{
@@ -452,6 +607,140 @@ func (r *SongRepository) FindManyExtraByTag(tag string, bandID bson.ObjectID, ev
 	)
 }
 
+// FindManyExtraByFilter finds band songs matching the filter sorted by name.
+// If songIDs is not nil, only these songs are searched.
+func (r *SongRepository) FindManyExtraByFilter(bandID bson.ObjectID, filter entity.SongFilter, songIDs []bson.ObjectID, eventsStartDate time.Time, pageNumber int) ([]*entity.SongWithEvents, error) {
+	m, err := r.songFilterMatch(bandID, filter, songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.findWithExtra(
+		m,
+		eventsStartDate,
+		bson.M{
+			"$sort": bson.D{
+				{Key: "pdf.name", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+		},
+		bson.M{
+			"$skip": pageNumber * helpers.SongsPageSize,
+		},
+		bson.M{
+			"$limit": helpers.SongsPageSize,
+		},
+	)
+}
+
+// FindManyByFilter finds band songs matching the filter sorted by name.
+// If songIDs is not nil, only these songs are searched.
+func (r *SongRepository) FindManyByFilter(bandID bson.ObjectID, filter entity.SongFilter, songIDs []bson.ObjectID, limit int) ([]*entity.Song, error) {
+	m, err := r.songFilterMatch(bandID, filter, songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.find(
+		m,
+		bson.M{
+			"$sort": bson.D{
+				{Key: "pdf.name", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+		},
+		bson.M{
+			"$limit": limit,
+		},
+	)
+}
+
+func (r *SongRepository) songFilterMatch(bandID bson.ObjectID, filter entity.SongFilter, songIDs []bson.ObjectID) (bson.M, error) {
+	m := bson.M{
+		"bandId": bandID,
+	}
+
+	id := bson.M{}
+	if songIDs != nil {
+		id["$in"] = songIDs
+	}
+
+	switch filter.Archived {
+	case entity.SongFilterArchivedOnly:
+		m["isArchived"] = true
+	case entity.SongFilterArchivedInclude:
+	default:
+		m["isArchived"] = bson.M{"$ne": true}
+	}
+
+	if len(filter.Keys) > 0 {
+		m["pdf.key"] = bson.M{"$in": filter.Keys}
+	}
+
+	if len(filter.Times) > 0 {
+		m["pdf.time"] = bson.M{"$in": filter.Times}
+	}
+
+	tags := bson.M{}
+	if len(filter.Tags) > 0 {
+		tags["$all"] = filter.Tags
+	}
+	if len(filter.ExcludeTags) > 0 {
+		tags["$nin"] = filter.ExcludeTags
+	}
+	if len(tags) > 0 {
+		m["tags"] = tags
+	}
+
+	// BPM is stored as text, songs with BPM that is not a number don't match BPM range.
+	if filter.MinBPM > 0 || filter.MaxBPM > 0 {
+		bpm := bson.M{"$convert": bson.M{"input": "$pdf.bpm", "to": "double", "onError": nil, "onNull": nil}}
+
+		conditions := bson.A{bson.M{"$ne": bson.A{bpm, nil}}}
+		if filter.MinBPM > 0 {
+			conditions = append(conditions, bson.M{"$gte": bson.A{bpm, filter.MinBPM}})
+		}
+		if filter.MaxBPM > 0 {
+			conditions = append(conditions, bson.M{"$lte": bson.A{bpm, filter.MaxBPM}})
+		}
+		m["$expr"] = bson.M{"$and": conditions}
+	}
+
+	if filter.NotPlayedSince != nil {
+		playedSongIDs, err := r.findPlayedSongIDs(bandID, *filter.NotPlayedSince)
+		if err != nil {
+			return nil, err
+		}
+		id["$nin"] = playedSongIDs
+	}
+	if len(id) > 0 {
+		m["_id"] = id
+	}
+
+	return m, nil
+}
+
+func (r *SongRepository) findPlayedSongIDs(bandID bson.ObjectID, since time.Time) ([]bson.ObjectID, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("events")
+
+	filter := bson.M{
+		"bandId": bandID,
+		"time":   bson.M{"$gte": since},
+	}
+
+	var songIDs []bson.ObjectID
+	err := collection.Distinct(context.TODO(), "songIds", filter).Decode(&songIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if songIDs == nil {
+		songIDs = []bson.ObjectID{}
+	}
+
+	return songIDs, nil
+}
+
 func (r *SongRepository) FindManyExtraByDriveFileIDs(driveFileIDs []string, eventsStartDate time.Time) ([]*entity.SongWithEvents, error) {
 	return r.findWithExtra(
 		bson.M{
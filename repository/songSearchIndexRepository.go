@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/joeyave/scala-bot/entity"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SongSearchIndexRepository struct {
+	mongoClient *mongo.Client
+}
+
+func NewSongSearchIndexRepository(mongoClient *mongo.Client) *SongSearchIndexRepository {
+	return &SongSearchIndexRepository{
+		mongoClient: mongoClient,
+	}
+}
+
+func (r *SongSearchIndexRepository) CreateIndexes(ctx context.Context) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "bandId", Value: 1}, {Key: "trigrams", Value: 1}},
+			Options: options.Index().SetName("bandId_trigrams"),
+		},
+		{
+			Keys:    bson.D{{Key: "driveFileId", Value: 1}},
+			Options: options.Index().SetName("driveFileId"),
+		},
+	})
+	return err
+}
+
+func (r *SongSearchIndexRepository) FindOneBySongID(songID bson.ObjectID) (*entity.SongSearchIndex, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	result := collection.FindOne(context.TODO(), bson.M{"_id": songID})
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var index *entity.SongSearchIndex
+	err := result.Decode(&index)
+	return index, err
+}
+
+// FindManyByTrigrams finds at most limit indexed songs of the band that share at least one trigram with the query.
+func (r *SongSearchIndexRepository) FindManyByTrigrams(bandID bson.ObjectID, trigrams []string, limit int64) ([]*entity.SongSearchIndex, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	filter := bson.M{
+		"bandId":   bandID,
+		"trigrams": bson.M{"$in": trigrams},
+	}
+
+	opts := options.Find().SetProjection(bson.M{"trigrams": 0}).SetLimit(limit)
+
+	cur, err := collection.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []*entity.SongSearchIndex
+	err = cur.All(context.TODO(), &indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(indexes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return indexes, nil
+}
+
+// FindIndexedDriveFileIDs returns those of the given drive file IDs that are already indexed.
+func (r *SongSearchIndexRepository) FindIndexedDriveFileIDs(driveFileIDs []string) (map[string]bool, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	opts := options.Find().SetProjection(bson.M{"driveFileId": 1})
+
+	cur, err := collection.Find(context.TODO(), bson.M{"driveFileId": bson.M{"$in": driveFileIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []*entity.SongSearchIndex
+	err = cur.All(context.TODO(), &indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[string]bool, len(indexes))
+	for _, index := range indexes {
+		indexed[index.DriveFileID] = true
+	}
+
+	return indexed, nil
+}
+
+func (r *SongSearchIndexRepository) UpdateOne(index entity.SongSearchIndex) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	opts := options.UpdateOne().SetUpsert(true)
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": index.SongID}, bson.M{"$set": index}, opts)
+	return err
+}
+
+func (r *SongSearchIndexRepository) DeleteOneBySongID(songID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": songID})
+	return err
+}
+
+func (r *SongSearchIndexRepository) DeleteOneByDriveFileID(driveFileID string) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("songSearchIndex")
+
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"driveFileId": driveFileID})
+	return err
+}
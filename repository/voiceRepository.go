@@ -29,6 +29,27 @@ func (r *VoiceRepository) FindOneByFileID(fileID string) (*entity.Voice, error)
 	return r.findOne(bson.M{"fileId": fileID})
 }
 
+// FindOneByAnyFileID finds the voice by the file ID of the original file or its audio copy.
+func (r *VoiceRepository) FindOneByAnyFileID(fileID string) (*entity.Voice, error) {
+	return r.findOne(bson.M{"$or": bson.A{
+		bson.M{"fileId": fileID},
+		bson.M{"audioFileId": fileID},
+	}})
+}
+
+func (r *VoiceRepository) FindManyBySongID(songID bson.ObjectID) ([]*entity.Voice, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"songId": songID})
+	if err != nil {
+		return nil, err
+	}
+
+	var voices []*entity.Voice
+	err = cursor.All(context.TODO(), &voices)
+	return voices, err
+}
+
 func (r *VoiceRepository) UpdateOne(voice entity.Voice) (*entity.Voice, error) {
 	if voice.ID.IsZero() {
 		voice.ID = bson.NewObjectID()
@@ -56,6 +77,94 @@ func (r *VoiceRepository) UpdateOne(voice entity.Voice) (*entity.Voice, error) {
 	return newVoice, err
 }
 
+// UpdateDetected sets the key and the tempo detected from the recording.
+func (r *VoiceRepository) UpdateDetected(voiceID bson.ObjectID, key entity.Key, bpm int) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": voiceID}, bson.M{
+		"$set": bson.M{"detectedKey": key, "detectedBpm": bpm},
+	})
+	return err
+}
+
+// UpdatePosition sets the manual order of the voice among the song voices.
+func (r *VoiceRepository) UpdatePosition(voiceID bson.ObjectID, position int) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": voiceID}, bson.M{
+		"$set": bson.M{"position": position},
+	})
+	return err
+}
+
+// UpdateDetails sets the part, the role and the description of the voice. Empty ones are removed.
+func (r *VoiceRepository) UpdateDetails(voiceID bson.ObjectID, part entity.VoicePart, roleID bson.ObjectID, description string) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	set, unset := bson.M{}, bson.M{}
+	if part != "" {
+		set["part"] = part
+	} else {
+		unset["part"] = ""
+	}
+	if !roleID.IsZero() {
+		set["roleId"] = roleID
+	} else {
+		unset["roleId"] = ""
+	}
+	if description != "" {
+		set["description"] = description
+	} else {
+		unset["description"] = ""
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := collection.UpdateOne(context.TODO(), bson.M{"_id": voiceID}, update)
+	return err
+}
+
+// PushSegment adds the segment to the voice. Segments are kept in the order of their start.
+func (r *VoiceRepository) PushSegment(voiceID bson.ObjectID, segment entity.VoiceSegment) (*entity.Voice, error) {
+	return r.updateSegments(voiceID, bson.M{
+		"$push": bson.M{
+			"segments": bson.M{
+				"$each": []entity.VoiceSegment{segment},
+				"$sort": bson.M{"start": 1},
+			},
+		},
+	})
+}
+
+func (r *VoiceRepository) PullSegment(voiceID, segmentID bson.ObjectID) (*entity.Voice, error) {
+	return r.updateSegments(voiceID, bson.M{
+		"$pull": bson.M{
+			"segments": bson.M{"_id": segmentID},
+		},
+	})
+}
+
+func (r *VoiceRepository) updateSegments(voiceID bson.ObjectID, update bson.M) (*entity.Voice, error) {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	result := collection.FindOneAndUpdate(context.TODO(), bson.M{"_id": voiceID}, update, opts)
+	if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	var newVoice *entity.Voice
+	err := result.Decode(&newVoice)
+	return newVoice, err
+}
+
 func (r *VoiceRepository) DeleteOneByID(ID bson.ObjectID) error {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
 
@@ -70,6 +179,21 @@ func (r *VoiceRepository) DeleteManyByIDs(IDs []bson.ObjectID) error {
 	return err
 }
 
+func (r *VoiceRepository) MoveVoicesToSongID(oldSongID, newSongID bson.ObjectID) error {
+	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
+
+	filter := bson.M{"songId": oldSongID}
+
+	update := bson.M{
+		"$set": bson.M{
+			"songId": newSongID,
+		},
+	}
+
+	_, err := collection.UpdateMany(context.TODO(), filter, update)
+	return err
+}
+
 func (r *VoiceRepository) findOne(m bson.M) (*entity.Voice, error) {
 	collection := r.mongoClient.Database(os.Getenv("BOT_MONGODB_NAME")).Collection("voices")
 
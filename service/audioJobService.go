@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// AudioJobWorkers is how many audio jobs are processed at once.
+	AudioJobWorkers = 3
+	// MaxActiveAudioJobsPerUser limits queued and running jobs of one user.
+	MaxActiveAudioJobsPerUser = 3
+	// audioJobPollInterval is how often idle workers check the queue if nobody woke them up.
+	audioJobPollInterval = 5 * time.Second
+)
+
+// AudioTempoPresets are the speeds in percent offered for practice tracks.
+var AudioTempoPresets = []int{75, 90, 100, 110}
+
+// AudioJobHandler processes the job. ctx is canceled when the job is canceled by the user.
+type AudioJobHandler func(ctx context.Context, job *entity.AudioJob) error
+
+type AudioJobService struct {
+	audioJobRepository *repository.AudioJobRepository
+
+	mu sync.Mutex
+	// cancels of the running jobs by job ID.
+	cancels map[bson.ObjectID]context.CancelFunc
+	// runningUsers counts running jobs by user ID. Only one job of the user runs at a time,
+	// so a user with a long queue doesn't block everyone else.
+	runningUsers map[int64]int
+	wake         chan struct{}
+}
+
+func NewAudioJobService(audioJobRepository *repository.AudioJobRepository) *AudioJobService {
+	return &AudioJobService{
+		audioJobRepository: audioJobRepository,
+		cancels:            make(map[bson.ObjectID]context.CancelFunc),
+		runningUsers:       make(map[int64]int),
+		wake:               make(chan struct{}, 1),
+	}
+}
+
+func (s *AudioJobService) FindOneByID(ID bson.ObjectID) (*entity.AudioJob, error) {
+	return s.audioJobRepository.FindOneByID(ID)
+}
+
+// FindManyQueued returns queued jobs in the queue order.
+func (s *AudioJobService) FindManyQueued() ([]*entity.AudioJob, error) {
+	jobs, err := s.audioJobRepository.FindManyQueued()
+	if errors.Is(err, repository.ErrNotFound) {
+		return []*entity.AudioJob{}, nil
+	}
+	return jobs, err
+}
+
+// Enqueue saves the job to the queue. ErrLimitExceeded is returned if the user has too many active jobs.
+func (s *AudioJobService) Enqueue(job entity.AudioJob) (*entity.AudioJob, error) {
+	active, err := s.audioJobRepository.CountActiveByUserID(job.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= MaxActiveAudioJobsPerUser {
+		return nil, fmt.Errorf("%w: user has %d active audio jobs", ErrLimitExceeded, active)
+	}
+
+	now := time.Now()
+	job.ID = bson.NilObjectID
+	job.Status = entity.AudioJobQueued
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	newJob, err := s.audioJobRepository.UpdateOne(job)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify()
+	return newJob, nil
+}
+
+// Cancel cancels the queued or running job of the user.
+// repository.ErrNotFound is returned if the job has already finished.
+func (s *AudioJobService) Cancel(ID bson.ObjectID, userID int64) (*entity.AudioJob, error) {
+	job, err := s.audioJobRepository.FindOneByID(ID)
+	if err != nil {
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrForbidden
+	}
+
+	job, err = s.audioJobRepository.Cancel(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if cancel, ok := s.cancels[ID]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	return job, nil
+}
+
+// Run puts jobs interrupted by restart back to the queue and starts the worker pool.
+// onQueueChanged is called when a job leaves the queue, so positions of the others can be updated.
+func (s *AudioJobService) Run(handle AudioJobHandler, onQueueChanged func()) error {
+	if err := s.audioJobRepository.RequeueRunning(); err != nil {
+		return err
+	}
+
+	for range AudioJobWorkers {
+		go s.work(handle, onQueueChanged)
+	}
+	s.notify()
+	return nil
+}
+
+func (s *AudioJobService) work(handle AudioJobHandler, onQueueChanged func()) {
+	ticker := time.NewTicker(audioJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, ctx, err := s.claim()
+		if err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				log.Error().Err(err).Msg("failed to claim audio job")
+			}
+			select {
+			case <-s.wake:
+			case <-ticker.C:
+			}
+			continue
+		}
+
+		onQueueChanged()
+		err = s.process(ctx, job, handle)
+		s.finish(job, err)
+
+		// The job of the same user could be skipped while this one was running.
+		s.notify()
+	}
+}
+
+func (s *AudioJobService) claim() (*entity.AudioJob, context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	busyUserIDs := make([]int64, 0, len(s.runningUsers))
+	for userID := range s.runningUsers {
+		busyUserIDs = append(busyUserIDs, userID)
+	}
+
+	job, err := s.audioJobRepository.ClaimNext(busyUserIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancels[job.ID] = cancel
+	s.runningUsers[job.UserID]++
+	return job, ctx, nil
+}
+
+func (s *AudioJobService) process(ctx context.Context, job *entity.AudioJob, handle AudioJobHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handle(ctx, job)
+}
+
+func (s *AudioJobService) finish(job *entity.AudioJob, jobErr error) {
+	s.mu.Lock()
+	if cancel, ok := s.cancels[job.ID]; ok {
+		cancel()
+		delete(s.cancels, job.ID)
+	}
+	s.runningUsers[job.UserID]--
+	if s.runningUsers[job.UserID] <= 0 {
+		delete(s.runningUsers, job.UserID)
+	}
+	s.mu.Unlock()
+
+	status, errText := entity.AudioJobDone, ""
+	if jobErr != nil {
+		status, errText = entity.AudioJobFailed, jobErr.Error()
+	}
+
+	// Canceled jobs are not running anymore and keep their status.
+	_, err := s.audioJobRepository.Finish(job.ID, status, errText)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Error().Err(err).Str("audioJobID", job.ID.Hex()).Msg("failed to finish audio job")
+	}
+}
+
+// notify wakes up an idle worker.
+func (s *AudioJobService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
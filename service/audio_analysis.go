@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+const (
+	// audioAnalysisSampleRate is enough to hear chords and beats and keeps the analysis fast.
+	audioAnalysisSampleRate = 11025
+	// audioAnalysisMaxDuration is how much of the recording is analyzed.
+	audioAnalysisMaxDuration = 90 * time.Second
+	// AudioAnalysisTimeout limits decoding and analysis of one recording.
+	AudioAnalysisTimeout = 30 * time.Second
+
+	keyFrameSize = 4096
+	keyMinFreq   = 80.0
+	keyMaxFreq   = 2000.0
+
+	onsetHopSize    = 64
+	onsetWindowSize = 512
+	minDetectedBPM  = 60.0
+	maxDetectedBPM  = 200.0
+	// preferredBPM is the tempo most songs are close to. It's used to choose between double and half tempo.
+	preferredBPM = 120.0
+	// firstBeatOnsetRatio is how strong the onset must be, compared to the strongest one, to start the sound.
+	firstBeatOnsetRatio = 0.3
+)
+
+var errAudioTooShort = errors.New("audio is too short to analyze")
+
+// Krumhansl-Kessler key profiles, starting from the tonic.
+var (
+	majorKeyProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorKeyProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// AudioAnalysis is the estimated key and tempo of the recording. Empty fields are unknown.
+type AudioAnalysis struct {
+	Key entity.Key
+	BPM int
+}
+
+// AnalyzeAudio estimates the key and the tempo of the recording. The recording is decoded with ffmpeg.
+func AnalyzeAudio(ctx context.Context, r io.Reader) (*AudioAnalysis, error) {
+	ctx, cancel := context.WithTimeout(ctx, AudioAnalysisTimeout)
+	defer cancel()
+
+	// Some formats, e.g. m4a, can't be decoded from a pipe.
+	inputTmpFile, err := os.CreateTemp("", "analyze_audio_*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(inputTmpFile.Name())
+
+	if _, err := io.Copy(inputTmpFile, r); err != nil {
+		_ = inputTmpFile.Close()
+		return nil, err
+	}
+	if err := inputTmpFile.Close(); err != nil {
+		return nil, err
+	}
+
+	samples, err := decodeAudioSamples(ctx, inputTmpFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	return AnalyzeSamples(samples, audioAnalysisSampleRate)
+}
+
+// DetectAudioFirstBeat finds the first beat of the recording file at the known tempo.
+// Returns false if there is no sound.
+func DetectAudioFirstBeat(ctx context.Context, input string, bpm float64) (time.Duration, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, AudioAnalysisTimeout)
+	defer cancel()
+
+	samples, err := decodeAudioSamples(ctx, input)
+	if err != nil {
+		return 0, false, err
+	}
+	at, ok := DetectFirstBeat(samples, audioAnalysisSampleRate, bpm)
+	return at, ok, nil
+}
+
+// decodeAudioSamples decodes the beginning of the recording to mono samples in the range from -1 to 1.
+func decodeAudioSamples(ctx context.Context, input string) ([]float64, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "error",
+		"-i", input,
+		"-t", strconv.Itoa(int(audioAnalysisMaxDuration.Seconds())),
+		"-ac", "1", "-ar", strconv.Itoa(audioAnalysisSampleRate),
+		"-f", "s16le", "pipe:1",
+	)
+	pcm, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("decode audio: %w", err)
+	}
+
+	samples := make([]float64, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(pcm[i*2:]))) / math.MaxInt16
+	}
+	return samples, nil
+}
+
+// AnalyzeSamples estimates the key and the tempo of mono samples in the range from -1 to 1.
+func AnalyzeSamples(samples []float64, sampleRate int) (*AudioAnalysis, error) {
+	if len(samples) < keyFrameSize {
+		return nil, errAudioTooShort
+	}
+
+	analysis := &AudioAnalysis{}
+	if key, ok := DetectKey(samples, sampleRate); ok {
+		analysis.Key = key
+	}
+	if bpm, ok := DetectBPM(samples, sampleRate); ok {
+		analysis.BPM = int(math.Round(bpm))
+	}
+	return analysis, nil
+}
+
+// DetectKey estimates the key by matching the pitch class profile of the recording with the key profiles.
+// Returns false for silence.
+func DetectKey(samples []float64, sampleRate int) (entity.Key, bool) {
+	var chroma [12]float64
+
+	window := hannWindow(keyFrameSize)
+	frame := make([]complex128, keyFrameSize)
+	binFreq := float64(sampleRate) / keyFrameSize
+	minBin := int(math.Ceil(keyMinFreq / binFreq))
+	maxBin := min(int(keyMaxFreq/binFreq), keyFrameSize/2-1)
+
+	for start := 0; start+keyFrameSize <= len(samples); start += keyFrameSize / 2 {
+		for i := range frame {
+			frame[i] = complex(samples[start+i]*window[i], 0)
+		}
+		fft(frame)
+
+		for bin := minBin; bin <= maxBin; bin++ {
+			magnitude := cmplx.Abs(frame[bin])
+			if magnitude == 0 {
+				continue
+			}
+			// MIDI note number, C is 0.
+			note := int(math.Round(69 + 12*math.Log2(float64(bin)*binFreq/440)))
+			chroma[((note%12)+12)%12] += magnitude
+		}
+	}
+
+	total := 0.0
+	for _, v := range chroma {
+		total += v
+	}
+	if total < 1e-9 {
+		return "", false
+	}
+
+	bestKey, bestScore := entity.Key(""), math.Inf(-1)
+	for tonic := range 12 {
+		if score := keyProfileCorrelation(chroma, majorKeyProfile, tonic); score > bestScore {
+			bestKey, bestScore = entity.MajorKeys[tonic], score
+		}
+		if score := keyProfileCorrelation(chroma, minorKeyProfile, tonic); score > bestScore {
+			bestKey, bestScore = entity.MinorKeys[tonic], score
+		}
+	}
+	return bestKey, true
+}
+
+// keyProfileCorrelation is the Pearson correlation of the chroma with the key profile shifted to the tonic.
+func keyProfileCorrelation(chroma, profile [12]float64, tonic int) float64 {
+	var chromaMean, profileMean float64
+	for i := range 12 {
+		chromaMean += chroma[i] / 12
+		profileMean += profile[i] / 12
+	}
+
+	var cov, chromaVar, profileVar float64
+	for i := range 12 {
+		c := chroma[(i+tonic)%12] - chromaMean
+		p := profile[i] - profileMean
+		cov += c * p
+		chromaVar += c * c
+		profileVar += p * p
+	}
+	if chromaVar == 0 || profileVar == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(chromaVar*profileVar)
+}
+
+// DetectBPM estimates the tempo by autocorrelation of the onset strength of the recording.
+// Returns false if there is no clear beat.
+func DetectBPM(samples []float64, sampleRate int) (float64, bool) {
+	onsets := onsetStrength(samples)
+
+	framesPerSecond := float64(sampleRate) / onsetHopSize
+	minLag := int(math.Floor(60 * framesPerSecond / maxDetectedBPM))
+	maxLag := int(math.Ceil(60 * framesPerSecond / minDetectedBPM))
+	if len(onsets) < 2*maxLag {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, onset := range onsets {
+		mean += onset / float64(len(onsets))
+	}
+	for i := range onsets {
+		onsets[i] -= mean
+	}
+
+	autocorrelation := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		for i := lag; i < len(onsets); i++ {
+			autocorrelation[lag] += onsets[i] * onsets[i-lag]
+		}
+	}
+
+	bestLag, bestScore := 0, 0.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		value := autocorrelation[lag]
+		if value <= 0 || value < autocorrelation[lag-1] || value < autocorrelation[lag+1] {
+			continue
+		}
+		// Prefer tempos close to the usual one, so a beat is not taken for a half of it.
+		octaves := math.Log2(60 * framesPerSecond / float64(lag) / preferredBPM)
+		score := value * math.Exp(-0.5*octaves*octaves)
+		if score > bestScore {
+			bestLag, bestScore = lag, score
+		}
+	}
+	if bestLag == 0 {
+		return 0, false
+	}
+
+	// Parabolic interpolation of the peak for sub-frame precision.
+	lag := float64(bestLag)
+	prev, curr, next := autocorrelation[bestLag-1], autocorrelation[bestLag], autocorrelation[bestLag+1]
+	if denominator := prev - 2*curr + next; denominator != 0 {
+		lag += 0.5 * (prev - next) / denominator
+	}
+
+	return 60 * framesPerSecond / lag, true
+}
+
+// DetectFirstBeat finds the first beat of the recording at the known tempo. The beat grid is placed
+// where the onsets are the strongest, and the first beat is the first one of the grid when the sound starts.
+// Returns false if there is no sound.
+func DetectFirstBeat(samples []float64, sampleRate int, bpm float64) (time.Duration, bool) {
+	if bpm <= 0 {
+		return 0, false
+	}
+
+	onsets := onsetStrength(samples)
+	period := 60 * float64(sampleRate) / onsetHopSize / bpm
+	if len(onsets) < int(2*period) {
+		return 0, false
+	}
+
+	strongest := slices.Max(onsets)
+	if strongest <= 0 {
+		return 0, false
+	}
+	start := slices.IndexFunc(onsets, func(onset float64) bool {
+		return onset >= firstBeatOnsetRatio*strongest
+	})
+
+	bestPhase, bestScore := 0.0, -1.0
+	for phase := 0.0; phase < period; phase++ {
+		score := 0.0
+		for at := phase; int(math.Round(at)) < len(onsets); at += period {
+			score += onsets[int(math.Round(at))]
+		}
+		if score > bestScore {
+			bestPhase, bestScore = phase, score
+		}
+	}
+
+	// The sound may start a bit after the beat, e.g. with a soft note.
+	frame := bestPhase + math.Ceil((float64(start)-bestPhase-period/4)/period)*period
+	// The onset is heard at the end of its window, so the beat at the very start is before the first frame.
+	seconds := max(0, (frame*onsetHopSize+onsetWindowSize)/float64(sampleRate))
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// onsetStrength returns the growth of the energy of the recording at every hop.
+func onsetStrength(samples []float64) []float64 {
+	// Energy of the windows ending at every hop, computed with prefix sums.
+	prefix := make([]float64, len(samples)+1)
+	for i, s := range samples {
+		prefix[i+1] = prefix[i] + s*s
+	}
+
+	var energy []float64
+	for end := onsetWindowSize; end <= len(samples); end += onsetHopSize {
+		energy = append(energy, math.Log1p(1000*(prefix[end]-prefix[end-onsetWindowSize])))
+	}
+
+	if len(energy) == 0 {
+		return nil
+	}
+
+	// The recording starts from silence.
+	onsets := make([]float64, len(energy))
+	onsets[0] = energy[0]
+	for i := 1; i < len(energy); i++ {
+		onsets[i] = max(energy[i]-energy[i-1], 0)
+	}
+	return onsets
+}
+
+// SuggestSemitones returns the shortest shift from one key to another, from -5 to +6 semitones.
+func SuggestSemitones(from, to entity.Key) (int, bool) {
+	semitones, ok := keySemitones(from, to)
+	if !ok {
+		return 0, false
+	}
+	if semitones > 6 {
+		semitones -= 12
+	}
+	return semitones, true
+}
+
+func hannWindow(size int) []float64 {
+	window := make([]float64, size)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+	}
+	return window
+}
+
+// fft is the in-place iterative radix-2 FFT. The length of x must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
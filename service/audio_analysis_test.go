@@ -0,0 +1,100 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func synthesizeNotes(midiNotes []int, seconds float64, sampleRate int) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	for _, note := range midiNotes {
+		freq := 440 * math.Pow(2, float64(note-69)/12)
+		for i := range samples {
+			samples[i] += 0.2 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		}
+	}
+	return samples
+}
+
+func synthesizeClicks(bpm, seconds float64, sampleRate int) []float64 {
+	samples := make([]float64, int(seconds*float64(sampleRate)))
+	period := 60 / bpm * float64(sampleRate)
+	for beat := 0.0; int(beat) < len(samples); beat += period {
+		for i := 0; i < sampleRate/50 && int(beat)+i < len(samples); i++ {
+			samples[int(beat)+i] = math.Exp(-float64(i)/float64(sampleRate/200)) * math.Sin(2*math.Pi*1000*float64(i)/float64(sampleRate))
+		}
+	}
+	return samples
+}
+
+func TestDetectKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []int
+		want  entity.Key
+	}{
+		{name: "C major", notes: []int{60, 64, 67, 72}, want: "C"},
+		{name: "G major", notes: []int{55, 59, 62, 67}, want: "G"},
+		{name: "A minor", notes: []int{57, 60, 64, 69}, want: "Am"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := DetectKey(synthesizeNotes(tt.notes, 3, audioAnalysisSampleRate), audioAnalysisSampleRate)
+			if !ok || key != tt.want {
+				t.Fatalf("DetectKey() = %q, %v, want %q", key, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := DetectKey(make([]float64, keyFrameSize*4), audioAnalysisSampleRate); ok {
+		t.Fatalf("DetectKey() of silence must fail")
+	}
+}
+
+func TestDetectBPM(t *testing.T) {
+	for _, want := range []float64{72, 90, 120, 140} {
+		bpm, ok := DetectBPM(synthesizeClicks(want, 20, audioAnalysisSampleRate), audioAnalysisSampleRate)
+		if !ok || math.Abs(bpm-want) > 1.5 {
+			t.Fatalf("DetectBPM() = %.1f, %v, want %.0f", bpm, ok, want)
+		}
+	}
+}
+
+func TestDetectFirstBeat(t *testing.T) {
+	for _, start := range []time.Duration{0, 300 * time.Millisecond, 1700 * time.Millisecond} {
+		clicks := synthesizeClicks(120, 10, audioAnalysisSampleRate)
+		silence := make([]float64, int(start.Seconds()*audioAnalysisSampleRate))
+
+		at, ok := DetectFirstBeat(append(silence, clicks...), audioAnalysisSampleRate, 120)
+		if !ok || (at-start).Abs() > 20*time.Millisecond {
+			t.Fatalf("DetectFirstBeat() = %s, %v, want %s", at, ok, start)
+		}
+	}
+
+	if _, ok := DetectFirstBeat(make([]float64, 10*audioAnalysisSampleRate), audioAnalysisSampleRate, 120); ok {
+		t.Fatalf("DetectFirstBeat() of silence must fail")
+	}
+}
+
+func TestSuggestSemitones(t *testing.T) {
+	tests := []struct {
+		from, to entity.Key
+		want     int
+	}{
+		{from: "A", to: "B", want: 2},
+		{from: "C", to: "A", want: -3},
+		{from: "Em", to: "G", want: 0},
+		{from: "C", to: "F#", want: 6},
+	}
+
+	for _, tt := range tests {
+		got, ok := SuggestSemitones(tt.from, tt.to)
+		if !ok || got != tt.want {
+			t.Fatalf("SuggestSemitones(%q, %q) = %d, %v, want %d", tt.from, tt.to, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+// AudioMixTimeout limits mixing of one practice track.
+const AudioMixTimeout = 5 * time.Minute
+
+// AudioMixGains are the volume changes in dB offered for the stems of the practice track.
+var AudioMixGains = []float64{0, -6, -10, -20}
+
+var errNoStems = errors.New("nothing to mix")
+
+// MixAudio mixes the input files into one mp3 file with the gain and the pan of the stems.
+// inputs are the files of the stems in the same order. The click, if it's not empty, is mixed over the stems
+// and ends with them.
+func MixAudio(ctx context.Context, inputs []string, stems []entity.AudioJobStem, click, output string) error {
+	if len(stems) == 0 {
+		return errNoStems
+	}
+	if len(inputs) != len(stems) {
+		return fmt.Errorf("%w: %d inputs for %d stems", ErrInvalidOperation, len(inputs), len(stems))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, AudioMixTimeout)
+	defer cancel()
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	for _, input := range inputs {
+		args = append(args, "-i", input)
+	}
+	if click != "" {
+		args = append(args, "-i", click)
+	}
+	args = append(args,
+		"-filter_complex", AudioMixFilter(stems, click != ""),
+		"-map", "[mix]",
+		"-c:a", "libmp3lame", "-q:a", "2",
+		"-f", "mp3", output,
+	)
+
+	out, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("mix audio: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AudioMixFilter builds the ffmpeg filter graph that applies the gain and the pan to every input
+// and mixes them without lowering the volume. The limiter keeps loud mixes from clipping.
+// The click is the input after the stems, the mix is cut when the stems end.
+func AudioMixFilter(stems []entity.AudioJobStem, click bool) string {
+	var b strings.Builder
+	for i, stem := range stems {
+		fmt.Fprintf(&b, "[%d:a]aformat=sample_rates=44100:channel_layouts=stereo", i)
+		if stem.Delay > 0 {
+			fmt.Fprintf(&b, ",adelay=%d:all=1", stem.Delay)
+		}
+		if stem.Gain != 0 {
+			fmt.Fprintf(&b, ",volume=%sdB", formatFilterFloat(stem.Gain))
+		}
+		if stem.Pan != 0 {
+			// Balance: the opposite channel gets quieter, the other one stays as it is.
+			pan := max(-1, min(stem.Pan, 1))
+			fmt.Fprintf(&b, ",pan=stereo|c0=%s*c0|c1=%s*c1", formatFilterFloat(min(1, 1-pan)), formatFilterFloat(min(1, 1+pan)))
+		}
+		fmt.Fprintf(&b, "[s%d];", i)
+	}
+	if click {
+		fmt.Fprintf(&b, "[%d:a]aformat=sample_rates=44100:channel_layouts=stereo[click];", len(stems))
+	}
+	for i := range stems {
+		fmt.Fprintf(&b, "[s%d]", i)
+	}
+	fmt.Fprintf(&b, "amix=inputs=%d:duration=longest:normalize=0", len(stems))
+	if click {
+		b.WriteString("[stems];[stems][click]amix=inputs=2:duration=first:normalize=0")
+	}
+	b.WriteString(",alimiter=limit=0.95:level=false[mix]")
+	return b.String()
+}
+
+func formatFilterFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
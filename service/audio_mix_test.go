@@ -0,0 +1,37 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestAudioMixFilter(t *testing.T) {
+	stems := []entity.AudioJobStem{
+		{},
+		{Gain: -10},
+		{Gain: -6, Pan: -1},
+		{Pan: 0.5},
+		{Delay: 1500},
+	}
+
+	want := "[0:a]aformat=sample_rates=44100:channel_layouts=stereo[s0];" +
+		"[1:a]aformat=sample_rates=44100:channel_layouts=stereo,volume=-10dB[s1];" +
+		"[2:a]aformat=sample_rates=44100:channel_layouts=stereo,volume=-6dB,pan=stereo|c0=1*c0|c1=0*c1[s2];" +
+		"[3:a]aformat=sample_rates=44100:channel_layouts=stereo,pan=stereo|c0=0.5*c0|c1=1*c1[s3];" +
+		"[4:a]aformat=sample_rates=44100:channel_layouts=stereo,adelay=1500:all=1[s4];" +
+		"[s0][s1][s2][s3][s4]amix=inputs=5:duration=longest:normalize=0,alimiter=limit=0.95:level=false[mix]"
+
+	if got := AudioMixFilter(stems, false); got != want {
+		t.Fatalf("AudioMixFilter() = %q, want %q", got, want)
+	}
+
+	want = "[0:a]aformat=sample_rates=44100:channel_layouts=stereo,adelay=1500:all=1[s0];" +
+		"[1:a]aformat=sample_rates=44100:channel_layouts=stereo[click];" +
+		"[s0]amix=inputs=1:duration=longest:normalize=0[stems];" +
+		"[stems][click]amix=inputs=2:duration=first:normalize=0,alimiter=limit=0.95:level=false[mix]"
+
+	if got := AudioMixFilter([]entity.AudioJobStem{{Delay: 1500}}, true); got != want {
+		t.Fatalf("AudioMixFilter() with click = %q, want %q", got, want)
+	}
+}
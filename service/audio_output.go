@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+const (
+	// AudioEncodeTimeout limits encoding of one result.
+	AudioEncodeTimeout = 5 * time.Minute
+
+	// audioLoudnessFilter normalizes the loudness to -16 LUFS with the true peak below -1.5 dBTP (EBU R128).
+	audioLoudnessFilter = "loudnorm=I=-16:TP=-1.5:LRA=11"
+)
+
+// EncodeAudio encodes the input file into the output file in the format of the result.
+// voice encodes the Telegram voice message, the format of the result is ignored then.
+func EncodeAudio(ctx context.Context, input string, result entity.AudioOutput, voice bool, output string) error {
+	ctx, cancel := context.WithTimeout(ctx, AudioEncodeTimeout)
+	defer cancel()
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", input}
+	args = append(args, AudioEncodeArgs(result, voice)...)
+	args = append(args, output)
+
+	out, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("encode audio: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ConvertAudioToMP3 converts the input, e.g. the audio/mp4 file, into the mp3 output file.
+func ConvertAudioToMP3(ctx context.Context, input io.Reader, output string) error {
+	ctx, cancel := context.WithTimeout(ctx, AudioEncodeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", "pipe:",
+		"-c:v", "copy", "-c:a", "libmp3lame", "-q:a", "4",
+		"-f", "mp3", output,
+	)
+	cmd.Stdin = input
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("convert audio: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AudioEncodeArgs returns the ffmpeg output arguments for the format of the result.
+// Only the first audio stream is kept, cover art can't be put into every format.
+func AudioEncodeArgs(result entity.AudioOutput, voice bool) []string {
+	args := []string{"-map", "0:a:0"}
+
+	sampleRate := "44100"
+	var codec []string
+	switch {
+	case voice:
+		sampleRate = "48000"
+		codec = []string{"-c:a", "libopus", "-b:a", "64k", "-ac", "1", "-f", "ogg"}
+	case result.Format == entity.AudioFormatMP3_128:
+		codec = []string{"-c:a", "libmp3lame", "-b:a", "128k", "-f", "mp3"}
+	case result.Format == entity.AudioFormatMP3_192:
+		codec = []string{"-c:a", "libmp3lame", "-b:a", "192k", "-f", "mp3"}
+	case result.Format == entity.AudioFormatMP3_320:
+		codec = []string{"-c:a", "libmp3lame", "-b:a", "320k", "-f", "mp3"}
+	case result.Format == entity.AudioFormatOpus:
+		sampleRate = "48000"
+		codec = []string{"-c:a", "libopus", "-b:a", "128k", "-f", "ogg"}
+	case result.Format == entity.AudioFormatM4A:
+		codec = []string{"-c:a", "aac", "-b:a", "192k", "-movflags", "+faststart", "-f", "ipod"}
+	case result.Format == entity.AudioFormatFLAC:
+		codec = []string{"-c:a", "flac", "-f", "flac"}
+	case result.Format == entity.AudioFormatWAV:
+		codec = []string{"-c:a", "pcm_s16le", "-f", "wav"}
+	default:
+		// The same quality the converted sources always had.
+		codec = []string{"-c:a", "libmp3lame", "-q:a", "4", "-f", "mp3"}
+	}
+
+	if result.Normalize {
+		// loudnorm works at 192 kHz, the result is resampled back.
+		args = append(args, "-af", audioLoudnessFilter, "-ar", sampleRate)
+	} else if sampleRate == "48000" {
+		// Opus doesn't support 44.1 kHz.
+		args = append(args, "-ar", sampleRate)
+	}
+	return append(args, codec...)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestAudioEncodeArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		result entity.AudioOutput
+		voice  bool
+		want   string
+	}{
+		{"original", entity.AudioOutput{}, false, "-map 0:a:0 -c:a libmp3lame -q:a 4 -f mp3"},
+		{"mp3 bitrate", entity.AudioOutput{Format: entity.AudioFormatMP3_320}, false, "-map 0:a:0 -c:a libmp3lame -b:a 320k -f mp3"},
+		{"opus", entity.AudioOutput{Format: entity.AudioFormatOpus}, false, "-map 0:a:0 -ar 48000 -c:a libopus -b:a 128k -f ogg"},
+		{"m4a", entity.AudioOutput{Format: entity.AudioFormatM4A}, false, "-map 0:a:0 -c:a aac -b:a 192k -movflags +faststart -f ipod"},
+		{"normalized flac", entity.AudioOutput{Format: entity.AudioFormatFLAC, Normalize: true}, false, "-map 0:a:0 -af loudnorm=I=-16:TP=-1.5:LRA=11 -ar 44100 -c:a flac -f flac"},
+		{"normalized voice", entity.AudioOutput{Format: entity.AudioFormatWAV, Normalize: true}, true, "-map 0:a:0 -af loudnorm=I=-16:TP=-1.5:LRA=11 -ar 48000 -c:a libopus -b:a 64k -ac 1 -f ogg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(AudioEncodeArgs(tt.result, tt.voice), " ")
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudioEncodeArgsOfEveryFormat(t *testing.T) {
+	for _, format := range entity.AudioFormats {
+		args := AudioEncodeArgs(entity.AudioOutput{Format: format}, false)
+		if !slices.Contains(args, "-c:a") || !slices.Contains(args, "-f") {
+			t.Fatalf("%q: codec or container is missing in %v", format, args)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+const (
+	// AudioTrimTimeout limits cutting and looping of one part.
+	AudioTrimTimeout = 5 * time.Minute
+	// MaxAudioTrimDuration limits the result with all the repeats.
+	MaxAudioTrimDuration = 30 * time.Minute
+
+	audioTrimSampleRate = 44100
+	// audioTrimFade removes clicks at the edges of the part, so the loop is smooth.
+	audioTrimFade = 10 * time.Millisecond
+)
+
+// AudioLoopPresets are the repeat counts offered for the saved parts.
+var AudioLoopPresets = []int{1, 2, 4, 8}
+
+// ParseTimestamp parses the position in the recording like "83", "1:23", "1:23.5" or "1:02:03".
+func ParseTimestamp(s string) (time.Duration, bool) {
+	parts := strings.Split(strings.TrimSpace(strings.ReplaceAll(s, ",", ".")), ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 || (len(parts) > 1 && seconds >= 60) {
+		return 0, false
+	}
+
+	multiplier := 60.0
+	for i := len(parts) - 2; i >= 0; i-- {
+		value, err := strconv.Atoi(parts[i])
+		if err != nil || value < 0 || (i > 0 && value >= 60) {
+			return 0, false
+		}
+		seconds += float64(value) * multiplier
+		multiplier *= 60
+	}
+
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond, true
+}
+
+// FormatTimestamp formats the position in the recording like "1:23" or "1:02:03.5".
+func FormatTimestamp(d time.Duration) string {
+	d = d.Round(100 * time.Millisecond)
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := d % time.Minute
+
+	s := fmt.Sprintf("%d:%02d", minutes, int(seconds/time.Second))
+	if hours > 0 {
+		s = fmt.Sprintf("%d:%02d:%02d", hours, minutes, int(seconds/time.Second))
+	}
+	if tenths := int(seconds % time.Second / (100 * time.Millisecond)); tenths > 0 {
+		s += "." + strconv.Itoa(tenths)
+	}
+	return s
+}
+
+// ValidateAudioTrim checks that the part is not empty and the result is not too long.
+func ValidateAudioTrim(trim entity.AudioJobTrim) error {
+	if trim.Start < 0 || trim.End <= trim.Start {
+		return fmt.Errorf("%w: part ends before it starts", ErrInvalidOperation)
+	}
+	if trim.Duration() > MaxAudioTrimDuration {
+		return fmt.Errorf("%w: result is longer than %s", ErrInvalidOperation, MaxAudioTrimDuration)
+	}
+	return nil
+}
+
+// TrimAudio cuts the part of the input file and repeats it into the output mp3 file.
+func TrimAudio(ctx context.Context, input string, trim entity.AudioJobTrim, output string) error {
+	if err := ValidateAudioTrim(trim); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, AudioTrimTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "ffmpeg",
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", input,
+		"-af", AudioTrimFilter(trim),
+		"-c:a", "libmp3lame", "-q:a", "2",
+		"-f", "mp3", output,
+	).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("trim audio: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AudioTrimFilter builds the ffmpeg filter that cuts the part, fades its edges and repeats it.
+func AudioTrimFilter(trim entity.AudioJobTrim) string {
+	length := time.Duration(trim.End-trim.Start) * time.Millisecond
+	fade := min(audioTrimFade, length/4)
+
+	filter := fmt.Sprintf("atrim=start=%s:end=%s,asetpts=PTS-STARTPTS,aformat=sample_rates=%d,afade=t=in:d=%s,afade=t=out:st=%s:d=%s",
+		formatFilterFloat(float64(trim.Start)/1000), formatFilterFloat(float64(trim.End)/1000), audioTrimSampleRate,
+		formatFilterFloat(fade.Seconds()), formatFilterFloat((length - fade).Seconds()), formatFilterFloat(fade.Seconds()))
+
+	if loops := trim.LoopCount(); loops > 1 {
+		// aloop repeats the buffered samples, so the buffer must fit the whole part.
+		size := int64(math.Ceil(length.Seconds() * audioTrimSampleRate))
+		filter += fmt.Sprintf(",aloop=loop=%d:size=%d", loops-1, size)
+	}
+	return filter
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   time.Duration
+		wantOK bool
+	}{
+		{s: "83", want: 83 * time.Second, wantOK: true},
+		{s: "1:23", want: 83 * time.Second, wantOK: true},
+		{s: " 2:10,5 ", want: 130*time.Second + 500*time.Millisecond, wantOK: true},
+		{s: "1:02:03", want: time.Hour + 2*time.Minute + 3*time.Second, wantOK: true},
+		{s: "1:60", wantOK: false},
+		{s: "-5", wantOK: false},
+		{s: "bridge", wantOK: false},
+		{s: "1:2:3:4", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTimestamp(tt.s)
+		if got != tt.want || ok != tt.wantOK {
+			t.Fatalf("ParseTimestamp(%q) = %s, %v, want %s, %v", tt.s, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := map[time.Duration]string{
+		83 * time.Second:                          "1:23",
+		130*time.Second + 500*time.Millisecond:    "2:10.5",
+		time.Hour + 2*time.Minute + 3*time.Second: "1:02:03",
+		0: "0:00",
+	}
+
+	for d, want := range tests {
+		if got := FormatTimestamp(d); got != want {
+			t.Fatalf("FormatTimestamp(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAudioTrimFilter(t *testing.T) {
+	trim := entity.AudioJobTrim{Start: 130000, End: 132000}
+	want := "atrim=start=130:end=132,asetpts=PTS-STARTPTS,aformat=sample_rates=44100,afade=t=in:d=0.01,afade=t=out:st=1.99:d=0.01"
+	if got := AudioTrimFilter(trim); got != want {
+		t.Fatalf("AudioTrimFilter() = %q, want %q", got, want)
+	}
+
+	trim.Loops = 4
+	if got := AudioTrimFilter(trim); got != want+",aloop=loop=3:size=88200" {
+		t.Fatalf("AudioTrimFilter() of loop = %q", got)
+	}
+}
+
+func TestValidateAudioTrim(t *testing.T) {
+	if err := ValidateAudioTrim(entity.AudioJobTrim{Start: 2000, End: 1000}); err == nil {
+		t.Fatalf("part ending before it starts must fail")
+	}
+	if err := ValidateAudioTrim(entity.AudioJobTrim{Start: 0, End: 10 * 60 * 1000, Loops: 4}); err == nil {
+		t.Fatalf("too long result must fail")
+	}
+	if err := ValidateAudioTrim(entity.AudioJobTrim{Start: 1000, End: 31000, Loops: 8}); err != nil {
+		t.Fatalf("got %v", err)
+	}
+}
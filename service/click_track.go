@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+const (
+	// DefaultClickTrackDuration is the length of the click without a voice to play along with.
+	DefaultClickTrackDuration = 5 * time.Minute
+	// clickTrackSampleRate is enough for the click and keeps the file small.
+	clickTrackSampleRate = 22050
+	clickSoundDuration   = 30 * time.Millisecond
+	// clickSoundDecay is the time constant of the click fade out in seconds.
+	clickSoundDecay = 0.006
+
+	minClickBPM = 30
+	maxClickBPM = 300
+)
+
+// ClickCountInPresets are the count-in lengths in bars offered for the click.
+var ClickCountInPresets = []int{0, 1, 2}
+
+// ParseTimeSignature returns the beats per bar of the time signature, e.g. 4 for "4/4".
+// Compound meters are counted in dotted quarters, e.g. 2 for "6/8", and the BPM is of them too.
+func ParseTimeSignature(s string) (int, bool) {
+	numerator, denominator, _ := strings.Cut(strings.TrimSpace(s), "/")
+	beats, err := strconv.Atoi(strings.TrimSpace(numerator))
+	if err != nil || beats < 1 || beats > 16 {
+		return 0, false
+	}
+	if strings.TrimSpace(denominator) == "8" && beats > 3 && beats%3 == 0 {
+		beats /= 3
+	}
+	return beats, true
+}
+
+type clickBeat struct {
+	At       time.Duration
+	Downbeat bool
+	CountIn  bool
+}
+
+// clickTrackBeats returns the beats of the click that start before the end of the track.
+// The first beat is at the offset.
+func clickTrackBeats(click entity.AudioJobClick, offset, duration time.Duration) []clickBeat {
+	beatDuration := 60 / click.BPM * float64(time.Second)
+	countInBeats := click.CountInBars * click.BeatsPerBar
+
+	var beats []clickBeat
+	for i := 0; ; i++ {
+		at := offset + time.Duration(float64(i)*beatDuration)
+		if at >= duration {
+			break
+		}
+		beats = append(beats, clickBeat{At: at, Downbeat: i%click.BeatsPerBar == 0, CountIn: i < countInBeats})
+	}
+	return beats
+}
+
+// clickSound returns the pitch and the volume of the beat. Downbeats are accented
+// and the count-in is higher, so it's clear where the song starts.
+func clickSound(beat clickBeat) (float64, float64) {
+	switch {
+	case beat.CountIn && beat.Downbeat:
+		return 2000, 0.9
+	case beat.CountIn:
+		return 1600, 0.7
+	case beat.Downbeat:
+		return 1500, 0.9
+	default:
+		return 1000, 0.6
+	}
+}
+
+// WriteClickTrack writes the click of the duration as 16-bit mono WAV. The click is silent until the offset.
+func WriteClickTrack(w io.Writer, click entity.AudioJobClick, offset, duration time.Duration) error {
+	if click.BPM < minClickBPM || click.BPM > maxClickBPM {
+		return fmt.Errorf("%w: unsupported BPM %g", ErrInvalidOperation, click.BPM)
+	}
+	if click.BeatsPerBar < 1 {
+		return fmt.Errorf("%w: unsupported beats per bar %d", ErrInvalidOperation, click.BeatsPerBar)
+	}
+
+	samples := int(duration.Seconds() * clickTrackSampleRate)
+	dataSize := uint32(samples * 2)
+
+	bw := bufio.NewWriter(w)
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataSize, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(1),
+		uint32(clickTrackSampleRate), uint32(clickTrackSampleRate * 2), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(bw, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	beats := clickTrackBeats(click, offset, duration)
+	soundSamples := int(clickSoundDuration.Seconds() * clickTrackSampleRate)
+	beatSample := func(beat clickBeat) int {
+		return int(beat.At.Seconds() * clickTrackSampleRate)
+	}
+
+	var buf [2]byte
+	current := 0
+	for i := range samples {
+		for current+1 < len(beats) && beatSample(beats[current+1]) <= i {
+			current++
+		}
+
+		value := 0.0
+		if len(beats) > 0 {
+			if offset := i - beatSample(beats[current]); offset >= 0 && offset < soundSamples {
+				freq, volume := clickSound(beats[current])
+				t := float64(offset) / clickTrackSampleRate
+				value = volume * math.Exp(-t/clickSoundDecay) * math.Sin(2*math.Pi*freq*t)
+			}
+		}
+
+		binary.LittleEndian.PutUint16(buf[:], uint16(int16(value*math.MaxInt16)))
+		if _, err := bw.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestParseTimeSignature(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   int
+		wantOK bool
+	}{
+		{s: "4/4", want: 4, wantOK: true},
+		{s: " 6/8 ", want: 2, wantOK: true},
+		{s: "9/8", want: 3, wantOK: true},
+		{s: "12/8", want: 4, wantOK: true},
+		{s: "3/8", want: 3, wantOK: true},
+		{s: "5/8", want: 5, wantOK: true},
+		{s: "3", want: 3, wantOK: true},
+		{s: "", wantOK: false},
+		{s: "0/4", wantOK: false},
+		{s: "x/4", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTimeSignature(tt.s)
+		if got != tt.want || ok != tt.wantOK {
+			t.Fatalf("ParseTimeSignature(%q) = %d, %v, want %d, %v", tt.s, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestClickTrackBeats(t *testing.T) {
+	click := entity.AudioJobClick{BPM: 120, BeatsPerBar: 3, CountInBars: 1}
+
+	beats := clickTrackBeats(click, 250*time.Millisecond, 3*time.Second)
+	if len(beats) != 6 {
+		t.Fatalf("got %d beats, want 6", len(beats))
+	}
+
+	for i, beat := range beats {
+		if beat.At != 250*time.Millisecond+time.Duration(i)*500*time.Millisecond {
+			t.Fatalf("beat %d is at %s", i, beat.At)
+		}
+		if beat.Downbeat != (i%3 == 0) || beat.CountIn != (i < 3) {
+			t.Fatalf("beat %d: got %+v", i, beat)
+		}
+	}
+
+	if got := click.CountInDuration(); got != 1500*time.Millisecond {
+		t.Fatalf("CountInDuration() = %s, want 1.5s", got)
+	}
+}
+
+func TestWriteClickTrack(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteClickTrack(&buf, entity.AudioJobClick{BPM: 60, BeatsPerBar: 4}, 0, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wav := buf.Bytes()
+	if len(wav) != 44+2*2*clickTrackSampleRate || string(wav[:4]) != "RIFF" || string(wav[36:40]) != "data" {
+		t.Fatalf("broken WAV of %d bytes", len(wav))
+	}
+
+	sample := func(at time.Duration) int16 {
+		i := int(at.Seconds() * clickTrackSampleRate)
+		return int16(binary.LittleEndian.Uint16(wav[44+2*i:]))
+	}
+	if sample(time.Millisecond) == 0 || sample(1001*time.Millisecond) == 0 {
+		t.Fatalf("beats must be audible")
+	}
+	if sample(500*time.Millisecond) != 0 {
+		t.Fatalf("click must be silent between the beats")
+	}
+
+	if err := WriteClickTrack(&buf, entity.AudioJobClick{BPM: 0, BeatsPerBar: 4}, 0, time.Second); err == nil {
+		t.Fatalf("zero BPM must fail")
+	}
+}
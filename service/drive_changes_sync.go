@@ -0,0 +1,255 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/drive/v3"
+)
+
+// DriveChangesSyncInterval is how often songs are synced with the Drive changes feed.
+const DriveChangesSyncInterval = time.Minute
+
+const googleDocMimeType = "application/vnd.google-apps.document"
+
+// GetChangesStartPageToken returns the token of the current end of the changes feed.
+func (s *DriveFileService) GetChangesStartPageToken() (string, error) {
+	res, err := s.driveClient.Changes.GetStartPageToken().Do()
+	if err != nil {
+		return "", err
+	}
+	return res.StartPageToken, nil
+}
+
+// ListChanges returns a page of the changes feed. nextPageToken is empty on the last page,
+// newStartPageToken is set there instead and should be used to get future changes.
+func (s *DriveFileService) ListChanges(pageToken string) (changes []*drive.Change, nextPageToken, newStartPageToken string, err error) {
+	res, err := s.driveClient.Changes.List(pageToken).
+		IncludeRemoved(true).
+		PageSize(100).
+		Fields("nextPageToken, newStartPageToken, changes(fileId, removed, file(id, name, mimeType, version, webViewLink, parents, trashed))").
+		Do()
+	if err != nil {
+		return nil, "", "", err
+	}
+	return res.Changes, res.NextPageToken, res.NewStartPageToken, nil
+}
+
+// DriveChangesSyncResult counts songs changed by the sync.
+type DriveChangesSyncResult struct {
+	Created int
+	Updated int
+	Deleted int
+}
+
+type songSyncAction int
+
+const (
+	songSyncSkip songSyncAction = iota
+	songSyncCreate
+	songSyncUpdate
+	songSyncDelete
+)
+
+// songSyncActionForChange decides what to do with the band song after the change of its file.
+// song is nil if there is no song for the file yet.
+func songSyncActionForChange(band *entity.Band, change *drive.Change, song *entity.Song) songSyncAction {
+	if song != nil && song.BandID != band.ID {
+		return songSyncSkip
+	}
+
+	if change.Removed || change.File == nil || change.File.Trashed {
+		if song != nil {
+			return songSyncDelete
+		}
+		return songSyncSkip
+	}
+
+	file := change.File
+	if file.MimeType != "" && file.MimeType != googleDocMimeType {
+		return songSyncSkip
+	}
+
+	if song == nil {
+		// Docs added to the archive directly are imported when they are unarchived.
+		if slices.Contains(file.Parents, band.DriveFolderID) {
+			return songSyncCreate
+		}
+		return songSyncSkip
+	}
+
+	// Docs moved out of the band folders are left as they are, they could be moved back.
+	if !songFileInBandFolders(band, file) {
+		return songSyncSkip
+	}
+	return songSyncUpdate
+}
+
+func songFileInBandFolders(band *entity.Band, file *drive.File) bool {
+	return slices.Contains(file.Parents, band.DriveFolderID) ||
+		band.ArchiveFolderID != "" && slices.Contains(file.Parents, band.ArchiveFolderID)
+}
+
+// songFileIsArchived reports whether the doc is in the archive folder of the band.
+func songFileIsArchived(band *entity.Band, file *drive.File) bool {
+	return band.ArchiveFolderID != "" && slices.Contains(file.Parents, band.ArchiveFolderID) &&
+		!slices.Contains(file.Parents, band.DriveFolderID)
+}
+
+// WatchDriveChanges syncs songs of all bands with the Drive changes feed every interval.
+func (s *SongService) WatchDriveChanges(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		bands, err := s.bandRepository.FindAll()
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Error().Err(err).Msg("failed to find bands to sync drive changes")
+		}
+
+		for _, band := range bands {
+			if band.DriveFolderID == "" {
+				continue
+			}
+
+			result, err := s.SyncDriveChanges(band)
+			if err != nil {
+				log.Error().Err(err).Str("bandID", band.ID.Hex()).Msg("failed to sync drive changes")
+				continue
+			}
+			if *result != (DriveChangesSyncResult{}) {
+				log.Info().Str("bandID", band.ID.Hex()).Msgf("synced drive changes: created=%d updated=%d deleted=%d", result.Created, result.Updated, result.Deleted)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+// SyncDriveChanges applies changes of the band docs since the saved page token:
+// renamed or edited docs get fresh metadata and cached PDFs are invalidated, docs moved to or from
+// the archive folder archive or unarchive songs, songs of removed docs are deleted.
+// The first sync only saves the current page token.
+func (s *SongService) SyncDriveChanges(band *entity.Band) (*DriveChangesSyncResult, error) {
+	result := &DriveChangesSyncResult{}
+
+	pageToken := band.DriveChangesPageToken
+	if pageToken == "" {
+		startPageToken, err := s.driveFileService.GetChangesStartPageToken()
+		if err != nil {
+			return nil, err
+		}
+		return result, s.bandRepository.SetDriveChangesPageToken(band.ID, startPageToken)
+	}
+
+	for pageToken != "" {
+		changes, nextPageToken, newStartPageToken, err := s.driveFileService.ListChanges(pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes {
+			err := s.syncDriveChange(band, change, result)
+			if err != nil {
+				// One broken doc shouldn't stop the sync, it will be synced when it's opened.
+				log.Warn().Err(err).Str("driveFileID", change.FileId).Msg("failed to sync drive change")
+			}
+		}
+
+		// Save the progress after each page, so the sync continues from here after restart.
+		if nextPageToken == "" {
+			pageToken = newStartPageToken
+		} else {
+			pageToken = nextPageToken
+		}
+		if err := s.bandRepository.SetDriveChangesPageToken(band.ID, pageToken); err != nil {
+			return nil, err
+		}
+		band.DriveChangesPageToken = pageToken
+
+		if nextPageToken == "" {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+func (s *SongService) syncDriveChange(band *entity.Band, change *drive.Change, result *DriveChangesSyncResult) error {
+	song, err := s.songRepository.FindOneByDriveFileID(change.FileId)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	switch songSyncActionForChange(band, change, song) {
+	case songSyncCreate:
+		_, err := s.FindOrCreateOneByDriveFile(change.File)
+		if err != nil {
+			return err
+		}
+		result.Created++
+	case songSyncUpdate:
+		changed, err := s.syncSongWithDriveFile(band, song, change.File)
+		if err != nil {
+			return err
+		}
+		if changed {
+			result.Updated++
+		}
+	case songSyncDelete:
+		deleted, err := s.DeleteOneByDriveFileIDFromDatabase(change.FileId)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			result.Deleted++
+		}
+	}
+	return nil
+}
+
+// syncSongWithDriveFile updates the song PDF from the doc and the archived state from its folder.
+// Cached Telegram files and transposed PDFs of the old version are invalidated.
+func (s *SongService) syncSongWithDriveFile(band *entity.Band, song *entity.Song, file *drive.File) (bool, error) {
+	changed := false
+
+	pdfChanged := songHasOutdatedPDF(song, file) || song.PDF.Name != file.Name
+	if pdfChanged {
+		song.PDF.Name = file.Name
+		song.PDF.Key, song.PDF.BPM, song.PDF.Time = s.driveFileService.GetMetadata(file.Id)
+		song.PDF.Version = file.Version
+		song.PDF.WebViewLink = file.WebViewLink
+		song.PDF.TgFileID = ""
+		changed = true
+	}
+
+	if isArchived := songFileIsArchived(band, file); song.IsArchived != isArchived {
+		song.IsArchived = isArchived
+		changed = true
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	updatedSong, err := s.songRepository.UpdateOne(*song)
+	if err != nil {
+		return false, err
+	}
+
+	// Transposed PDFs are cached for the doc version, Telegram files of the old version are useless.
+	if pdfChanged && len(song.AltPDFs) > 0 {
+		err = s.songRepository.UnsetAltPDFs(song.ID)
+		if err != nil {
+			return false, err
+		}
+		updatedSong.AltPDFs = nil
+	}
+
+	s.indexSongAsync(updatedSong)
+	return true, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestSongSyncActionForChange(t *testing.T) {
+	band := &entity.Band{ID: bson.NewObjectID(), DriveFolderID: "folder", ArchiveFolderID: "archive"}
+	song := &entity.Song{BandID: band.ID}
+	otherSong := &entity.Song{BandID: bson.NewObjectID()}
+
+	doc := func(parents ...string) *drive.Change {
+		return &drive.Change{FileId: "doc", File: &drive.File{Id: "doc", MimeType: googleDocMimeType, Parents: parents}}
+	}
+
+	tests := []struct {
+		name   string
+		change *drive.Change
+		song   *entity.Song
+		want   songSyncAction
+	}{
+		{"new doc in folder", doc("folder"), nil, songSyncCreate},
+		{"new doc in archive", doc("archive"), nil, songSyncSkip},
+		{"new doc elsewhere", doc("other"), nil, songSyncSkip},
+		{"edited doc", doc("folder"), song, songSyncUpdate},
+		{"archived doc", doc("archive"), song, songSyncUpdate},
+		{"doc moved out", doc("other"), song, songSyncSkip},
+		{"song of other band", doc("folder"), otherSong, songSyncSkip},
+		{"removed doc", &drive.Change{FileId: "doc", Removed: true}, song, songSyncDelete},
+		{"trashed doc", &drive.Change{FileId: "doc", File: &drive.File{Id: "doc", Trashed: true, Parents: []string{"folder"}}}, song, songSyncDelete},
+		{"removed unknown doc", &drive.Change{FileId: "doc", Removed: true}, nil, songSyncSkip},
+		{"not a doc", &drive.Change{FileId: "pdf", File: &drive.File{Id: "pdf", MimeType: "application/pdf", Parents: []string{"folder"}}}, nil, songSyncSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, songSyncActionForChange(band, tt.change, tt.song))
+		})
+	}
+}
+
+func TestSongFileIsArchived(t *testing.T) {
+	band := &entity.Band{DriveFolderID: "folder", ArchiveFolderID: "archive"}
+
+	assert.True(t, songFileIsArchived(band, &drive.File{Parents: []string{"archive"}}))
+	assert.False(t, songFileIsArchived(band, &drive.File{Parents: []string{"folder"}}))
+	assert.False(t, songFileIsArchived(band, &drive.File{Parents: []string{"folder", "archive"}}))
+	assert.False(t, songFileIsArchived(&entity.Band{DriveFolderID: "folder"}, &drive.File{Parents: []string{""}}))
+}
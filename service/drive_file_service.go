@@ -13,6 +13,8 @@ import (
 	"github.com/joeyave/chords-transposer/transposer"
 	"github.com/joeyave/scala-bot/entity"
 	"github.com/joeyave/scala-bot/helpers"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
@@ -21,15 +23,49 @@ import (
 type DriveFileService struct {
 	driveClient    *drive.Service
 	docsRepository *docs.Service
+	bandRepository *repository.BandRepository
 }
 
-func NewDriveFileService(driveRepository *drive.Service, docsRepository *docs.Service) *DriveFileService {
+func NewDriveFileService(driveRepository *drive.Service, docsRepository *docs.Service, bandRepository *repository.BandRepository) *DriveFileService {
 	return &DriveFileService{
 		driveClient:    driveRepository,
 		docsRepository: docsRepository,
+		bandRepository: bandRepository,
 	}
 }
 
+// styleConfigForDoc returns the style config of the band the doc belongs to.
+func (s *DriveFileService) styleConfigForDoc(ID string) DriveStyleConfig {
+	file, err := s.driveClient.Files.Get(ID).Fields("parents").Do()
+	if err != nil {
+		log.Warn().Err(err).Str("driveFileID", ID).Msg("failed to get doc parents for drive style")
+		return getDriveStyleConfig()
+	}
+	return s.styleConfigForParents(file.Parents)
+}
+
+// styleConfigForParents returns the style config of the band by the doc parent folders.
+// The config file style is used for docs outside of band folders and if the band style can't be applied.
+func (s *DriveFileService) styleConfigForParents(parents []string) DriveStyleConfig {
+	for _, parent := range parents {
+		band, err := s.bandRepository.FindOneByAnyFolderID(parent)
+		if err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				log.Warn().Err(err).Str("folderID", parent).Msg("failed to find band for drive style")
+			}
+			continue
+		}
+
+		cfg, err := DriveStyleConfigForBand(band)
+		if err != nil {
+			log.Warn().Err(err).Str("bandID", band.ID.Hex()).Msg("failed to apply band drive style")
+			break
+		}
+		return cfg
+	}
+	return getDriveStyleConfig()
+}
+
 var newLinesRegex = regexp.MustCompile(`\n{3,}`)
 
 func (s *DriveFileService) FindAllByFolderID(folderID, nextPageToken string) ([]*drive.File, string, error) {
@@ -201,9 +237,10 @@ func (s *DriveFileService) CreateOne(newFile *drive.File, lyrics string, key ent
 		}
 	}
 
+	styleCfg := s.styleConfigForParents(newFile.Parents)
 	requests := make([]*docs.Request, 0)
 
-	if docStyle, fields := newDriveDocumentStyleFromConfig(); docStyle != nil {
+	if docStyle, fields := newDriveDocumentStyleFromConfig(styleCfg); docStyle != nil {
 		requests = append(requests, newUpdateDocumentStyleRequest(docStyle, fields))
 	}
 
@@ -249,7 +286,6 @@ func (s *DriveFileService) CreateOne(newFile *drive.File, lyrics string, key ent
 	}
 
 	requests = nil
-	styleCfg := getDriveStyleConfig()
 	for _, paragraph := range doc.Body.Content {
 		if paragraph.Paragraph == nil {
 			continue
@@ -290,7 +326,7 @@ func (s *DriveFileService) CreateOne(newFile *drive.File, lyrics string, key ent
 		BPM:   &BPM,
 		Time:  &time,
 	}
-	if err := s.UpdateMetadataAcrossSections(newFile.Id, mdPatch); err != nil {
+	if err := s.updateMetadataAcrossSections(newFile.Id, mdPatch, styleCfg); err != nil {
 		return nil, err
 	}
 
@@ -430,7 +466,8 @@ func (s *DriveFileService) DownloadOneByIDWithResp(ID string) (*http.Response, e
 }
 
 func (s *DriveFileService) AddLyricsPage(ID string) (*drive.File, error) {
-	doc, err := s.ensureBodyMetadataLayout(ID)
+	styleCfg := s.styleConfigForDoc(ID)
+	doc, err := s.ensureBodyMetadataLayout(ID, styleCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -443,7 +480,7 @@ func (s *DriveFileService) AddLyricsPage(ID string) (*drive.File, error) {
 			return nil, err
 		}
 
-		doc, err = s.ensureBodyMetadataLayout(ID)
+		doc, err = s.ensureBodyMetadataLayout(ID, styleCfg)
 		if err != nil {
 			return nil, err
 		}
@@ -465,7 +502,7 @@ func (s *DriveFileService) AddLyricsPage(ID string) (*drive.File, error) {
 		Key:   &sourceMetadata.Key,
 		BPM:   &sourceMetadata.BPM,
 		Time:  &sourceMetadata.Time,
-	}); err != nil {
+	}, styleCfg); err != nil {
 		return nil, err
 	}
 
@@ -618,6 +655,12 @@ func (s *DriveFileService) appendSectionByID(ID string) ([]docs.StructuralElemen
 }
 
 func docToHTML(doc *docs.Document) string {
+	return docToTransposedHTML(doc, "", "")
+}
+
+// docToTransposedHTML renders the first section of the doc to HTML with chord paragraphs
+// transposed from key to toKey the same way as TransposeOne does. Empty toKey means no transposition.
+func docToTransposedHTML(doc *docs.Document, key, toKey entity.Key) string {
 	var sb strings.Builder
 	firstSectionBodyStarted := false
 	firstSectionContinuousBreakStartIndex := int64(-1)
@@ -649,10 +692,25 @@ func docToHTML(doc *docs.Document) string {
 		}
 
 		if item.Paragraph != nil && item.Paragraph.Elements != nil {
+			shouldTranspose := false
+			if toKey != "" {
+				var fullText strings.Builder
+				for _, element := range item.Paragraph.Elements {
+					if element.TextRun != nil {
+						fullText.WriteString(element.TextRun.Content)
+					}
+				}
+				shouldTranspose = shouldTransposeParagraph(fullText.String(), getDriveStyleConfig().Chords.ChordRatioThreshold)
+				key = guessKeyIfNeeded(key, fullText.String())
+			}
+
 			for _, element := range item.Paragraph.Elements {
 				if element.TextRun != nil && element.TextRun.Content != "" {
 					style := element.TextRun.TextStyle
 					text := element.TextRun.Content
+					if shouldTranspose && key != "" {
+						text = transposeText(text, key, toKey)
+					}
 
 					if style != nil {
 						if style.Bold {
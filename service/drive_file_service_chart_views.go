@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/joeyave/scala-bot/entity"
+	"google.golang.org/api/drive/v3"
+)
+
+// MaxCapo is the highest capo fret a chart can be rendered for.
+const MaxCapo = 7
+
+// ChartView is a rendering of the song chart made on the fly. The doc itself is never changed.
+type ChartView struct {
+	// Capo is the fret of the capo, chords are rendered as shapes to play with it. Zero means no capo.
+	Capo int
+	// Nashville renders chords as Nashville numbers relative to the song key.
+	Nashville bool
+}
+
+// TargetKey returns the key the chords of the song in the given key are rendered in.
+func (v ChartView) TargetKey(key entity.Key) (entity.Key, error) {
+	if v.Nashville {
+		if _, ok := keySemitones(key, key); !ok {
+			return "", fmt.Errorf("%w: unknown key %q", ErrInvalidOperation, key)
+		}
+		return keyNashville, nil
+	}
+	return CapoKey(key, v.Capo)
+}
+
+// Title returns the name of the song with the view, e.g. "Song (Capo 2)".
+func (v ChartView) Title(name string) string {
+	if v.Nashville {
+		return fmt.Sprintf("%s (Nashville)", name)
+	}
+	return fmt.Sprintf("%s (Capo %d)", name, v.Capo)
+}
+
+// CapoKey returns the key of the chord shapes to play a song in the given key with the capo on the given fret.
+// For example, A with the capo on the 2nd fret is played with G shapes. Minor keys stay minor.
+func CapoKey(key entity.Key, capo int) (entity.Key, error) {
+	if capo < 1 || capo > MaxCapo {
+		return "", fmt.Errorf("%w: capo must be from 1 to %d", ErrInvalidOperation, MaxCapo)
+	}
+
+	candidates := entity.MajorKeys
+	if key.IsMinor() {
+		candidates = entity.MinorKeys
+	}
+	for _, candidate := range candidates {
+		if semitones, ok := keySemitones(candidate, key); ok && semitones == capo {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unknown key %q", ErrInvalidOperation, key)
+}
+
+// GetChartViewHTML renders the first section of the doc to HTML with chords of the song in the given key
+// rendered for the view. Returns the key the chords are rendered in.
+func (s *DriveFileService) GetChartViewHTML(ID string, key entity.Key, view ChartView) (string, entity.Key, error) {
+	toKey, err := view.TargetKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	doc, err := s.getDoc(ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return docToTransposedHTML(doc, key, toKey), toKey, nil
+}
+
+// CopyAndRenderChartView copies the doc of the song in the given key to the temp folder
+// and renders its first section for the view. The caller should delete the copy after use.
+func (s *DriveFileService) CopyAndRenderChartView(sourceID, sourceName string, key entity.Key, view ChartView, tempFolderID string) (*drive.File, error) {
+	toKey, err := view.TargetKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.CopyAndTransposeFirstSection(sourceID, view.Title(sourceName), toKey, tempFolderID)
+}
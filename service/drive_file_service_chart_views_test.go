@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestCapoKey(t *testing.T) {
+	tests := []struct {
+		key  entity.Key
+		capo int
+		want entity.Key
+	}{
+		{key: "A", capo: 2, want: "G"},
+		{key: "Bb", capo: 1, want: "A"},
+		{key: "Eb", capo: 3, want: "C"},
+		{key: "C", capo: 5, want: "G"},
+		{key: "Bm", capo: 2, want: "Am"},
+		{key: "F#m", capo: 2, want: "Em"},
+	}
+	for _, tt := range tests {
+		got, err := CapoKey(tt.key, tt.capo)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s capo %d", tt.key, tt.capo)
+	}
+
+	_, err := CapoKey("A", 0)
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+	_, err = CapoKey("A", MaxCapo+1)
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+	_, err = CapoKey("?", 2)
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+}
+
+func TestChartViewTargetKey(t *testing.T) {
+	key, err := ChartView{Nashville: true}.TargetKey("D")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.Key(keyNashville), key)
+
+	_, err = ChartView{Nashville: true}.TargetKey("?")
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+
+	key, err = ChartView{Capo: 2}.TargetKey("D")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.Key("C"), key)
+
+	assert.Equal(t, "Song (Capo 2)", ChartView{Capo: 2}.Title("Song"))
+	assert.Equal(t, "Song (Nashville)", ChartView{Nashville: true}.Title("Song"))
+}
+
+func TestDocToTransposedHTML(t *testing.T) {
+	doc := &docs.Document{
+		Body: &docs.Body{
+			Content: []*docs.StructuralElement{
+				{StartIndex: 0, EndIndex: 1, SectionBreak: &docs.SectionBreak{}},
+				createTestStructuralParagraph("A D E A\n", 1, 9),
+				createTestStructuralParagraph("Amazing grace\n", 9, 23),
+			},
+		},
+	}
+
+	assert.Equal(t, "A D E A\nAmazing grace", docToHTML(doc))
+	assert.Equal(t, "G C D G\nAmazing grace", docToTransposedHTML(doc, "A", "G"))
+	assert.Equal(t, "1 4 5 1\nAmazing grace", docToTransposedHTML(doc, "A", keyNashville))
+}
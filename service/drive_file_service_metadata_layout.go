@@ -12,18 +12,24 @@ import (
 
 var metadataLineRegex = regexp.MustCompile(`(?i)^\s*key:\s*(.*?);\s*bpm:\s*(.*?);\s*time:\s*(.*?);\s*$`)
 
+// creditsLineRegex matches the optional line with authors, copyright and CCLI number right after the KEY/BPM/TIME line.
+// "Authors:" is kept for the docs written before the label was dropped.
+var creditsLineRegex = regexp.MustCompile(`(?i)^\s*(✍|authors:|©|ccli:)`)
+
 type SectionMetadata struct {
-	Title string
-	Key   entity.Key
-	BPM   string
-	Time  string
+	Title   string
+	Key     entity.Key
+	BPM     string
+	Time    string
+	Credits string
 }
 
 type MetadataPatch struct {
-	Title *string
-	Key   *entity.Key
-	BPM   *string
-	Time  *string
+	Title   *string
+	Key     *entity.Key
+	BPM     *string
+	Time    *string
+	Credits *string
 }
 
 type MetadataNormalizeResult struct {
@@ -52,17 +58,37 @@ func normalizeMetadata(md SectionMetadata) SectionMetadata {
 	md.Key = entity.Key(normalizeTextValue(string(md.Key)))
 	md.BPM = normalizeTextValue(md.BPM)
 	md.Time = normalizeTextValue(md.Time)
+	md.Credits = strings.TrimSpace(md.Credits)
 	return md
 }
 
 func composeCanonicalMetadataText(md SectionMetadata) string {
-	md = normalizeMetadata(md)
-	return fmt.Sprintf("%s\nKEY: %s; BPM: %s; TIME: %s;\n\n", md.Title, md.Key, md.BPM, md.Time)
+	return composeMetadataTextWithoutTrailingEmpty(md) + "\n"
 }
 
 func composeMetadataTextWithoutTrailingEmpty(md SectionMetadata) string {
 	md = normalizeMetadata(md)
-	return fmt.Sprintf("%s\nKEY: %s; BPM: %s; TIME: %s;\n", md.Title, md.Key, md.BPM, md.Time)
+	text := fmt.Sprintf("%s\nKEY: %s; BPM: %s; TIME: %s;\n", md.Title, md.Key, md.BPM, md.Time)
+	if md.Credits != "" {
+		text += md.Credits + "\n"
+	}
+	return text
+}
+
+func isCreditsLine(text string) bool {
+	return creditsLineRegex.MatchString(text)
+}
+
+// creditsAfterMetadataLine returns the credits line if it directly follows the KEY/BPM/TIME line.
+func creditsAfterMetadataLine(paragraphs []*docs.StructuralElement, metadataIdx int) (string, bool) {
+	if metadataIdx < 0 || metadataIdx+1 >= len(paragraphs) {
+		return "", false
+	}
+	text := paragraphToPlainText(paragraphs[metadataIdx+1])
+	if !isCreditsLine(text) {
+		return "", false
+	}
+	return text, true
 }
 
 func parseMetadataLine(text string) (entity.Key, string, string, bool) {
@@ -179,7 +205,7 @@ func keepTailParagraphsForBody(elements []*docs.StructuralElement) []*docs.Struc
 	return copyParagraphElements(elements)
 }
 
-func cloneParagraphElementsRequests(content []*docs.StructuralElement, index int64, segmentID string) []*docs.Request {
+func cloneParagraphElementsRequests(content []*docs.StructuralElement, index int64, segmentID string, styleCfg DriveStyleConfig) []*docs.Request {
 	requests := make([]*docs.Request, 0)
 
 	for _, item := range content {
@@ -194,7 +220,7 @@ func cloneParagraphElementsRequests(content []*docs.StructuralElement, index int
 				element.TextRun.TextStyle = &docs.TextStyle{}
 			}
 			if element.TextRun.TextStyle.ForegroundColor == nil {
-				element.TextRun.TextStyle.ForegroundColor = newOptionalColor(driveStylePlainTextColor())
+				element.TextRun.TextStyle.ForegroundColor = newOptionalColor(driveStylePlainTextColor(styleCfg))
 			}
 
 			textLen := int64(len([]rune(element.TextRun.Content)))
@@ -261,7 +287,7 @@ func (s *DriveFileService) extractSectionMetadata(doc *docs.Document, section do
 	if continuous != nil {
 		paragraphs := paragraphElementsInRange(doc, sectionStart, continuous.StartIndex)
 		nonEmptyBeforeMetadata := make([]string, 0)
-		for _, para := range paragraphs {
+		for i, para := range paragraphs {
 			text := paragraphToPlainText(para)
 			if strings.TrimSpace(text) == "" {
 				continue
@@ -270,6 +296,7 @@ func (s *DriveFileService) extractSectionMetadata(doc *docs.Document, section do
 				md.Key = key
 				md.BPM = bpm
 				md.Time = time
+				md.Credits, _ = creditsAfterMetadataLine(paragraphs, i)
 				break
 			}
 			nonEmptyBeforeMetadata = append(nonEmptyBeforeMetadata, text)
@@ -438,11 +465,13 @@ func applyMetadataPatch(md SectionMetadata, patch MetadataPatch) SectionMetadata
 	if patch.Time != nil {
 		md.Time = *patch.Time
 	}
+	if patch.Credits != nil {
+		md.Credits = *patch.Credits
+	}
 	return normalizeMetadata(md)
 }
 
-func newMetadataParagraphStyle(alignment string) *docs.ParagraphStyle {
-	styleCfg := getDriveStyleConfig()
+func newMetadataParagraphStyle(styleCfg DriveStyleConfig, alignment string) *docs.ParagraphStyle {
 	return &docs.ParagraphStyle{
 		Alignment: alignment,
 		SpaceAbove: &docs.Dimension{
@@ -457,8 +486,7 @@ func newMetadataParagraphStyle(alignment string) *docs.ParagraphStyle {
 	}
 }
 
-func newMetadataTextStyle(fontSize float64) *docs.TextStyle {
-	styleCfg := getDriveStyleConfig()
+func newMetadataTextStyle(styleCfg DriveStyleConfig, fontSize float64) *docs.TextStyle {
 	return &docs.TextStyle{
 		WeightedFontFamily: &docs.WeightedFontFamily{FontFamily: styleCfg.Text.FontFamily},
 		FontSize: &docs.Dimension{
@@ -467,13 +495,13 @@ func newMetadataTextStyle(fontSize float64) *docs.TextStyle {
 		},
 		Bold:            true,
 		BaselineOffset:  styleCfg.Metadata.BaselineOffset,
-		ForegroundColor: newOptionalColor(driveStylePlainTextColor()),
+		ForegroundColor: newOptionalColor(driveStylePlainTextColor(styleCfg)),
 	}
 }
 
-func newMetadataKeyAccentStyle(chordColor *docs.RgbColor) *docs.TextStyle {
+func newMetadataKeyAccentStyle(styleCfg DriveStyleConfig, chordColor *docs.RgbColor) *docs.TextStyle {
 	if chordColor == nil {
-		chordColor = driveStyleChordPrimaryColor()
+		chordColor = driveStyleChordPrimaryColor(styleCfg)
 	}
 	return &docs.TextStyle{
 		ForegroundColor: newOptionalColor(chordColor),
@@ -481,60 +509,65 @@ func newMetadataKeyAccentStyle(chordColor *docs.RgbColor) *docs.TextStyle {
 	}
 }
 
-func composeCanonicalMetadataStyleRequests(sectionStart int64, md SectionMetadata, chordColor *docs.RgbColor) []*docs.Request {
+func composeCanonicalMetadataStyleRequests(sectionStart int64, md SectionMetadata, styleCfg DriveStyleConfig, chordColor *docs.RgbColor) []*docs.Request {
 	md = normalizeMetadata(md)
-	styleCfg := getDriveStyleConfig()
 
 	titleText := md.Title + "\n"
 	metaLineText := fmt.Sprintf("KEY: %s; BPM: %s; TIME: %s;\n", md.Key, md.BPM, md.Time)
+	creditsText := ""
+	if md.Credits != "" {
+		creditsText = md.Credits + "\n"
+	}
 	lastLineText := "\n"
 
 	titleStart := sectionStart
 	titleEnd := titleStart + int64(len([]rune(titleText)))
 	metaStart := titleEnd
 	metaEnd := metaStart + int64(len([]rune(metaLineText)))
-	lastStart := metaEnd
+	creditsStart := metaEnd
+	creditsEnd := creditsStart + int64(len([]rune(creditsText)))
+	lastStart := creditsEnd
 	lastEnd := lastStart + int64(len([]rune(lastLineText)))
 
-	requests := make([]*docs.Request, 0, 7)
+	requests := make([]*docs.Request, 0, 9)
 	requests = append(requests,
 		newUpdateParagraphStyleRequest(
-			newMetadataParagraphStyle(styleCfg.Metadata.TitleAlignment),
+			newMetadataParagraphStyle(styleCfg, styleCfg.Metadata.TitleAlignment),
 			"alignment,lineSpacing,spaceAbove,spaceBelow",
 			titleStart,
 			titleEnd,
 			"",
 		),
 		newUpdateParagraphStyleRequest(
-			newMetadataParagraphStyle(styleCfg.Metadata.LineAlignment),
+			newMetadataParagraphStyle(styleCfg, styleCfg.Metadata.LineAlignment),
 			"alignment,lineSpacing,spaceAbove,spaceBelow",
 			metaStart,
 			metaEnd,
 			"",
 		),
 		newUpdateParagraphStyleRequest(
-			newMetadataParagraphStyle(styleCfg.Metadata.LastLineAlignment),
+			newMetadataParagraphStyle(styleCfg, styleCfg.Metadata.LastLineAlignment),
 			"alignment,lineSpacing,spaceAbove,spaceBelow",
 			lastStart,
 			lastEnd,
 			"",
 		),
 		newUpdateTextStyleRequest(
-			newMetadataTextStyle(styleCfg.Metadata.FontSizeTitlePt),
+			newMetadataTextStyle(styleCfg, styleCfg.Metadata.FontSizeTitlePt),
 			"*",
 			titleStart,
 			titleEnd,
 			"",
 		),
 		newUpdateTextStyleRequest(
-			newMetadataTextStyle(styleCfg.Metadata.FontSizeLinePt),
+			newMetadataTextStyle(styleCfg, styleCfg.Metadata.FontSizeLinePt),
 			"*",
 			metaStart,
 			metaEnd,
 			"",
 		),
 		newUpdateTextStyleRequest(
-			newMetadataTextStyle(styleCfg.Metadata.FontSizeLastLinePt),
+			newMetadataTextStyle(styleCfg, styleCfg.Metadata.FontSizeLastLinePt),
 			"*",
 			lastStart,
 			lastEnd,
@@ -545,7 +578,7 @@ func composeCanonicalMetadataStyleRequests(sectionStart int64, md SectionMetadat
 	keyEnd := keyStart + int64(len([]rune(string(md.Key))))
 	if keyEnd > keyStart {
 		requests = append(requests, newUpdateTextStyleRequest(
-			newMetadataKeyAccentStyle(chordColor),
+			newMetadataKeyAccentStyle(styleCfg, chordColor),
 			"foregroundColor,bold",
 			keyStart,
 			keyEnd,
@@ -553,11 +586,36 @@ func composeCanonicalMetadataStyleRequests(sectionStart int64, md SectionMetadat
 		))
 	}
 
+	if creditsEnd > creditsStart {
+		creditsStyle := newMetadataTextStyle(styleCfg, styleCfg.Metadata.FontSizeLastLinePt)
+		creditsStyle.Bold = false
+		requests = append(requests,
+			newUpdateParagraphStyleRequest(
+				newMetadataParagraphStyle(styleCfg, styleCfg.Metadata.LineAlignment),
+				"alignment,lineSpacing,spaceAbove,spaceBelow",
+				creditsStart,
+				creditsEnd,
+				"",
+			),
+			newUpdateTextStyleRequest(
+				creditsStyle,
+				"*",
+				creditsStart,
+				creditsEnd,
+				"",
+			),
+		)
+	}
+
 	return requests
 }
 
 func isCanonicalMetadataSubsection(paragraphs []*docs.StructuralElement, md SectionMetadata, titleIdx, metadataIdx int) bool {
-	if len(paragraphs) != 3 {
+	expectedLen := 3
+	if md.Credits != "" {
+		expectedLen = 4
+	}
+	if len(paragraphs) != expectedLen {
 		return false
 	}
 	if titleIdx != 0 || metadataIdx != 1 {
@@ -575,7 +633,11 @@ func isCanonicalMetadataSubsection(paragraphs []*docs.StructuralElement, md Sect
 		return false
 	}
 
-	if paragraphToExactLineText(paragraphs[2]) != "" {
+	if md.Credits != "" && paragraphToExactLineText(paragraphs[2]) != md.Credits {
+		return false
+	}
+
+	if paragraphToExactLineText(paragraphs[len(paragraphs)-1]) != "" {
 		return false
 	}
 
@@ -656,7 +718,7 @@ func findMetadataTitleAndLine(paragraphs []*docs.StructuralElement, docTitle str
 	return titleIdx, metadataIdx
 }
 
-func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options normalizeMetadataLayoutOptions) (*docs.Document, *MetadataNormalizeResult, error) {
+func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, styleCfg DriveStyleConfig, options normalizeMetadataLayoutOptions) (*docs.Document, *MetadataNormalizeResult, error) {
 	doc, err := s.getDoc(ID)
 	if err != nil {
 		return nil, nil, err
@@ -694,6 +756,7 @@ func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options
 				md.BPM = bpm
 				md.Time = time
 			}
+			md.Credits, _ = creditsAfterMetadataLine(metadataParagraphs, metadataIdx)
 		}
 		md = normalizeMetadata(md)
 		// Title is always synced with the document title.
@@ -707,8 +770,12 @@ func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options
 		bodyStart := sectionStart + metadataLen + 1
 		tailParagraphs := make([]*docs.StructuralElement, 0)
 		if !canonicalAlready {
-			if metadataIdx >= 0 && metadataIdx+1 < len(metadataParagraphs) {
-				tailCandidates := metadataParagraphs[metadataIdx+1:]
+			tailStartIdx := metadataIdx + 1
+			if md.Credits != "" {
+				tailStartIdx++
+			}
+			if metadataIdx >= 0 && tailStartIdx < len(metadataParagraphs) {
+				tailCandidates := metadataParagraphs[tailStartIdx:]
 				// Preserve the canonical empty paragraph after KEY/BPM/TIME inside metadata.
 				if len(tailCandidates) > 0 && strings.TrimSpace(paragraphToPlainText(tailCandidates[0])) == "" {
 					tailCandidates = tailCandidates[1:]
@@ -775,7 +842,7 @@ func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options
 		}
 
 		if needsMetadataRestyle && options.applyMetadataStyles {
-			requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, chordColorForSectionIndex(i))...)
+			requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, styleCfg, chordColorForSectionIndex(styleCfg, i))...)
 		}
 
 		if metaStyle, fields := singleColumnSectionStyle(); metaStyle != nil {
@@ -785,7 +852,7 @@ func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options
 		}
 
 		if len(tailParagraphs) > 0 {
-			requests = append(requests, cloneParagraphElementsRequests(tailParagraphs, bodyStart, "")...)
+			requests = append(requests, cloneParagraphElementsRequests(tailParagraphs, bodyStart, "", styleCfg)...)
 		}
 
 		result.SectionsNormalized++
@@ -818,18 +885,18 @@ func (s *DriveFileService) normalizeMetadataLayoutWithOptions(ID string, options
 	return doc, result, nil
 }
 
-func (s *DriveFileService) normalizeMetadataLayout(ID string) (*docs.Document, *MetadataNormalizeResult, error) {
-	return s.normalizeMetadataLayoutWithOptions(ID, normalizeMetadataLayoutOptions{
+func (s *DriveFileService) normalizeMetadataLayout(ID string, styleCfg DriveStyleConfig) (*docs.Document, *MetadataNormalizeResult, error) {
+	return s.normalizeMetadataLayoutWithOptions(ID, styleCfg, normalizeMetadataLayoutOptions{
 		applyMetadataStyles: true,
 	})
 }
 
-func (s *DriveFileService) ensureBodyMetadataLayout(ID string) (*docs.Document, error) {
-	doc, _, err := s.normalizeMetadataLayout(ID)
+func (s *DriveFileService) ensureBodyMetadataLayout(ID string, styleCfg DriveStyleConfig) (*docs.Document, error) {
+	doc, _, err := s.normalizeMetadataLayout(ID, styleCfg)
 	return doc, err
 }
 
-func metadataRewriteRequestsForSection(doc *docs.Document, sections []docs.StructuralElement, sectionIndex int, md SectionMetadata) ([]*docs.Request, error) {
+func metadataRewriteRequestsForSection(doc *docs.Document, sections []docs.StructuralElement, sectionIndex int, md SectionMetadata, styleCfg DriveStyleConfig) ([]*docs.Request, error) {
 	if sectionIndex < 0 || sectionIndex >= len(sections) {
 		return nil, fmt.Errorf("section index %d is out of bounds", sectionIndex)
 	}
@@ -854,7 +921,7 @@ func metadataRewriteRequestsForSection(doc *docs.Document, sections []docs.Struc
 		requests = append(requests, newDeleteContentRangeRequest(sectionStart, deleteEnd, ""))
 	}
 	requests = append(requests, newInsertTextRequest(insertText, sectionStart, ""))
-	requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, chordColorForSectionIndex(sectionIndex))...)
+	requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, styleCfg, chordColorForSectionIndex(styleCfg, sectionIndex))...)
 
 	if metaStyle, fields := singleColumnSectionStyle(); metaStyle != nil {
 		if req := sectionStyleUpdateRequest(sectionStart, metaStyle, fields); req != nil {
@@ -865,8 +932,8 @@ func metadataRewriteRequestsForSection(doc *docs.Document, sections []docs.Struc
 	return requests, nil
 }
 
-func (s *DriveFileService) updateSectionMetadataByIndex(ID string, sectionIndex int, patch MetadataPatch) error {
-	doc, _, err := s.normalizeMetadataLayout(ID)
+func (s *DriveFileService) updateSectionMetadataByIndex(ID string, sectionIndex int, patch MetadataPatch, styleCfg DriveStyleConfig) error {
+	doc, _, err := s.normalizeMetadataLayout(ID, styleCfg)
 	if err != nil {
 		return err
 	}
@@ -878,7 +945,7 @@ func (s *DriveFileService) updateSectionMetadataByIndex(ID string, sectionIndex
 	md := s.extractSectionMetadata(doc, sections[sectionIndex])
 	md = applyMetadataPatch(md, patch)
 	md.Title = normalizeTextValue(doc.Title)
-	requests, err := metadataRewriteRequestsForSection(doc, sections, sectionIndex, md)
+	requests, err := metadataRewriteRequestsForSection(doc, sections, sectionIndex, md, styleCfg)
 	if err != nil {
 		return err
 	}
@@ -887,8 +954,8 @@ func (s *DriveFileService) updateSectionMetadataByIndex(ID string, sectionIndex
 	return err
 }
 
-func (s *DriveFileService) updateMetadataAcrossSections(ID string, patch MetadataPatch) error {
-	doc, _, err := s.normalizeMetadataLayout(ID)
+func (s *DriveFileService) updateMetadataAcrossSections(ID string, patch MetadataPatch, styleCfg DriveStyleConfig) error {
+	doc, _, err := s.normalizeMetadataLayout(ID, styleCfg)
 	if err != nil {
 		return err
 	}
@@ -908,7 +975,7 @@ func (s *DriveFileService) updateMetadataAcrossSections(ID string, patch Metadat
 		md := s.extractSectionMetadata(doc, section)
 		md = applyMetadataPatch(md, patch)
 		md.Title = normalizeTextValue(doc.Title)
-		sectionReqs, reqErr := metadataRewriteRequestsForSection(doc, sections, i, md)
+		sectionReqs, reqErr := metadataRewriteRequestsForSection(doc, sections, i, md, styleCfg)
 		if reqErr != nil {
 			return reqErr
 		}
@@ -920,14 +987,14 @@ func (s *DriveFileService) updateMetadataAcrossSections(ID string, patch Metadat
 }
 
 func (s *DriveFileService) NormalizeMetadataLayout(ID string) error {
-	_, _, err := s.normalizeMetadataLayout(ID)
+	_, _, err := s.normalizeMetadataLayout(ID, s.styleConfigForDoc(ID))
 	return err
 }
 
 func (s *DriveFileService) EnsureBodyMetadataLayout(ID string) (*docs.Document, error) {
-	return s.ensureBodyMetadataLayout(ID)
+	return s.ensureBodyMetadataLayout(ID, s.styleConfigForDoc(ID))
 }
 
 func (s *DriveFileService) UpdateMetadataAcrossSections(ID string, patch MetadataPatch) error {
-	return s.updateMetadataAcrossSections(ID, patch)
+	return s.updateMetadataAcrossSections(ID, patch, s.styleConfigForDoc(ID))
 }
@@ -48,6 +48,34 @@ func TestComposeCanonicalMetadataText(t *testing.T) {
 	assert.Equal(t, "?\nKEY: ?; BPM: ?; TIME: ?;\n\n", text)
 }
 
+func TestComposeCanonicalMetadataTextWithCredits(t *testing.T) {
+	text := composeCanonicalMetadataText(SectionMetadata{Title: "Song", Key: "C", BPM: "72", Time: "4/4", Credits: " CCLI: 4348399 "})
+	assert.Equal(t, "Song\nKEY: C; BPM: 72; TIME: 4/4;\nCCLI: 4348399\n\n", text)
+}
+
+func TestCreditsAfterMetadataLine(t *testing.T) {
+	paragraphs := []*docs.StructuralElement{
+		paragraphElementFromText("Song"),
+		paragraphElementFromText("KEY: C; BPM: 120; TIME: 4/4;"),
+		paragraphElementFromText("✍ Chris Tomlin; © 2004 worshiptogether.com songs"),
+		paragraphElementFromText(""),
+	}
+
+	credits, ok := creditsAfterMetadataLine(paragraphs, 1)
+	assert.True(t, ok)
+	assert.Equal(t, "✍ Chris Tomlin; © 2004 worshiptogether.com songs", credits)
+
+	paragraphs[2] = paragraphElementFromText("Authors: Chris Tomlin")
+	credits, ok = creditsAfterMetadataLine(paragraphs, 1)
+	assert.True(t, ok)
+	assert.Equal(t, "Authors: Chris Tomlin", credits)
+
+	_, ok = creditsAfterMetadataLine(paragraphs, 2)
+	assert.False(t, ok)
+	_, ok = creditsAfterMetadataLine(paragraphs, -1)
+	assert.False(t, ok)
+}
+
 func TestFindMetadataTitleAndLine(t *testing.T) {
 	paragraphs := []*docs.StructuralElement{
 		paragraphElementFromText("intro"),
@@ -75,8 +103,7 @@ func TestFindMetadataTitleAndLineFallbackFirstNonEmpty(t *testing.T) {
 }
 
 func TestComposeCanonicalMetadataStyleRequests(t *testing.T) {
-	setDriveStyleConfig(DefaultDriveStyleConfig())
-	cfg := getDriveStyleConfig()
+	cfg := DefaultDriveStyleConfig()
 
 	md := SectionMetadata{
 		Title: "Song",
@@ -85,7 +112,7 @@ func TestComposeCanonicalMetadataStyleRequests(t *testing.T) {
 		Time:  "4/4",
 	}
 
-	requests := composeCanonicalMetadataStyleRequests(10, md, driveStyleChordPrimaryColor())
+	requests := composeCanonicalMetadataStyleRequests(10, md, cfg, driveStyleChordPrimaryColor(cfg))
 	assert.Len(t, requests, 7)
 
 	assert.Equal(t, "alignment,lineSpacing,spaceAbove,spaceBelow", requests[0].UpdateParagraphStyle.Fields)
@@ -108,6 +135,27 @@ func TestComposeCanonicalMetadataStyleRequests(t *testing.T) {
 	assert.NotNil(t, requests[6].UpdateTextStyle.TextStyle.ForegroundColor)
 }
 
+func TestComposeCanonicalMetadataStyleRequestsWithCredits(t *testing.T) {
+	cfg := DefaultDriveStyleConfig()
+
+	md := SectionMetadata{
+		Title:   "Song",
+		Key:     "Am",
+		BPM:     "120",
+		Time:    "4/4",
+		Credits: "CCLI: 1",
+	}
+
+	requests := composeCanonicalMetadataStyleRequests(10, md, cfg, driveStyleChordPrimaryColor(cfg))
+	assert.Len(t, requests, 9)
+
+	// Title "Song\n" is 5 runes, metadata line is 30 runes.
+	assert.Equal(t, int64(45), requests[7].UpdateParagraphStyle.Range.StartIndex)
+	assert.Equal(t, int64(53), requests[7].UpdateParagraphStyle.Range.EndIndex)
+	assert.False(t, requests[8].UpdateTextStyle.TextStyle.Bold)
+	assert.Equal(t, int64(53), requests[2].UpdateParagraphStyle.Range.StartIndex)
+}
+
 func TestIsCanonicalMetadataSubsection(t *testing.T) {
 	md := SectionMetadata{
 		Title: "Song",
@@ -143,6 +191,17 @@ func TestIsCanonicalMetadataSubsection(t *testing.T) {
 		paragraphElementFromText(""),
 	}
 	assert.False(t, isCanonicalMetadataSubsection(nonCanonicalMetadataLeadingSpace, md, 0, 1))
+
+	md.Credits = "CCLI: 1"
+	assert.False(t, isCanonicalMetadataSubsection(canonical, md, 0, 1))
+
+	canonicalWithCredits := []*docs.StructuralElement{
+		paragraphElementFromText("Song"),
+		paragraphElementFromText("KEY: Am; BPM: 120; TIME: 4/4;"),
+		paragraphElementFromText("CCLI: 1"),
+		paragraphElementFromText(""),
+	}
+	assert.True(t, isCanonicalMetadataSubsection(canonicalWithCredits, md, 0, 1))
 }
 
 func TestLeadingEmptyBodyParagraphRange(t *testing.T) {
@@ -46,7 +46,8 @@ func (s *DriveFileService) batchUpdate(docID string, requests []*docs.Request) (
 }
 
 func (s *DriveFileService) TransposeOne(ID string, toKey entity.Key, sectionIndex int) (*drive.File, error) {
-	doc, _, err := s.normalizeMetadataLayout(ID)
+	styleCfg := s.styleConfigForDoc(ID)
+	doc, _, err := s.normalizeMetadataLayout(ID, styleCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +62,7 @@ func (s *DriveFileService) TransposeOne(ID string, toKey entity.Key, sectionInde
 			return nil, err
 		}
 
-		doc, err = s.ensureBodyMetadataLayout(ID)
+		doc, err = s.ensureBodyMetadataLayout(ID, styleCfg)
 		if err != nil {
 			return nil, err
 		}
@@ -71,7 +72,7 @@ func (s *DriveFileService) TransposeOne(ID string, toKey entity.Key, sectionInde
 	}
 
 	sourceMetadata := s.extractSectionMetadata(doc, sections[0])
-	requests := transposeBody(doc, sections, sectionIndex, sourceMetadata.Key, toKey)
+	requests := transposeBody(doc, sections, sectionIndex, sourceMetadata.Key, toKey, styleCfg)
 	if createdNewSection {
 		targetBodyStart := getSectionBodyStartIndex(doc, sections, sectionIndex)
 		sourceBodyStyle := getSectionBodyStyle(doc, sections, 0)
@@ -85,7 +86,7 @@ func (s *DriveFileService) TransposeOne(ID string, toKey entity.Key, sectionInde
 	targetMetadata := sourceMetadata
 	targetMetadata.Title = normalizeTextValue(doc.Title)
 	targetMetadata.Key = toKey
-	metadataReqs, err := metadataRewriteRequestsForSection(doc, sections, sectionIndex, targetMetadata)
+	metadataReqs, err := metadataRewriteRequestsForSection(doc, sections, sectionIndex, targetMetadata, styleCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +129,8 @@ func (s *DriveFileService) CopyAndTransposeFirstSection(sourceID, sourceName str
 func (s *DriveFileService) StyleOne(ID, lang string) (*drive.File, error) {
 	requests := make([]*docs.Request, 0)
 
-	doc, _, err := s.normalizeMetadataLayoutWithOptions(ID, normalizeMetadataLayoutOptions{
+	styleCfg := s.styleConfigForDoc(ID)
+	doc, _, err := s.normalizeMetadataLayoutWithOptions(ID, styleCfg, normalizeMetadataLayoutOptions{
 		applyMetadataStyles: false,
 	})
 	if err != nil {
@@ -136,19 +138,18 @@ func (s *DriveFileService) StyleOne(ID, lang string) (*drive.File, error) {
 	}
 	_ = lang
 	sections := getSections(doc)
-	styleCfg := getDriveStyleConfig()
 
 	// Hard mode: always restore canonical metadata styles on StyleOne.
 	for sectionIndex, section := range sections {
 		sectionStart := section.StartIndex + 1
 		md := s.extractSectionMetadata(doc, section)
 		md.Title = normalizeTextValue(doc.Title)
-		requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, chordColorForSectionIndex(sectionIndex))...)
+		requests = append(requests, composeCanonicalMetadataStyleRequests(sectionStart, md, styleCfg, chordColorForSectionIndex(styleCfg, sectionIndex))...)
 	}
 
-	requests = append(requests, composeStyleRequests(getContentForSectionBody(doc, sections, 0), "", false, styleCfg.Chords.ChordRatioThreshold, chordColorForSectionIndex(0))...)
+	requests = append(requests, composeStyleRequests(getContentForSectionBody(doc, sections, 0), "", false, styleCfg, chordColorForSectionIndex(styleCfg, 0))...)
 
-	if docStyle, fields := newDriveDocumentStyleFromConfig(); docStyle != nil {
+	if docStyle, fields := newDriveDocumentStyleFromConfig(styleCfg); docStyle != nil {
 		requests = append(requests, newUpdateDocumentStyleRequest(docStyle, fields))
 	}
 
@@ -199,11 +200,11 @@ func (s *DriveFileService) StyleOne(ID, lang string) (*drive.File, error) {
 			}
 
 			for _, target := range targets {
-				targetRequests := transposeBody(doc, sections, target.sectionIndex, sourceMetadata.Key, target.toKey)
+				targetRequests := transposeBody(doc, sections, target.sectionIndex, sourceMetadata.Key, target.toKey, styleCfg)
 				targetMetadata := sourceMetadata
 				targetMetadata.Title = normalizeTextValue(doc.Title)
 				targetMetadata.Key = target.toKey
-				metadataReqs, reqErr := metadataRewriteRequestsForSection(doc, sections, target.sectionIndex, targetMetadata)
+				metadataReqs, reqErr := metadataRewriteRequestsForSection(doc, sections, target.sectionIndex, targetMetadata, styleCfg)
 				if reqErr != nil {
 					return nil, reqErr
 				}
@@ -241,7 +242,7 @@ func (s *DriveFileService) StyleOne(ID, lang string) (*drive.File, error) {
 // Core Logic - Transposition
 // =========================================================================
 
-func transposeBody(doc *docs.Document, sections []docs.StructuralElement, sectionIndex int, key, toKey entity.Key) []*docs.Request {
+func transposeBody(doc *docs.Document, sections []docs.StructuralElement, sectionIndex int, key, toKey entity.Key, styleCfg DriveStyleConfig) []*docs.Request {
 	requests := make([]*docs.Request, 0)
 
 	sectionToInsertStartIndex := getSectionBodyStartIndex(doc, sections, sectionIndex)
@@ -266,15 +267,15 @@ func transposeBody(doc *docs.Document, sections []docs.StructuralElement, sectio
 		key,
 		toKey,
 		"",
-		getDriveStyleConfig().Chords.ChordRatioThreshold,
-		chordColorForSectionIndex(sectionIndex),
+		styleCfg,
+		chordColorForSectionIndex(styleCfg, sectionIndex),
 	)
 	requests = append(requests, transposeRequests...)
 
 	return requests
 }
 
-func composeTransposeRequests(content []*docs.StructuralElement, index int64, key, toKey entity.Key, segmentId string, chordRatioThreshold float64, chordColor *docs.RgbColor) ([]*docs.Request, entity.Key) {
+func composeTransposeRequests(content []*docs.StructuralElement, index int64, key, toKey entity.Key, segmentId string, styleCfg DriveStyleConfig, chordColor *docs.RgbColor) ([]*docs.Request, entity.Key) {
 	allRequests := make([]*docs.Request, 0)
 	paragraphs, idxs := getParagraphs(content)
 
@@ -282,7 +283,7 @@ func composeTransposeRequests(content []*docs.StructuralElement, index int64, ke
 		fullText := idxs[i].fullText
 
 		// Decide if this paragraph should be treated as chords
-		shouldTranspose := shouldTransposeParagraph(fullText, chordRatioThreshold)
+		shouldTranspose := shouldTransposeParagraph(fullText, styleCfg.Chords.ChordRatioThreshold)
 
 		// Determine a paragraph-level key once (fall back to curKey)
 		key = guessKeyIfNeeded(key, fullText)
@@ -291,7 +292,7 @@ func composeTransposeRequests(content []*docs.StructuralElement, index int64, ke
 		isLastParagraph := i == len(paragraphs)-1
 		paraRequests, newIndex := newTransposeRequestsForParagraph(
 			paragraph, isLastParagraph, shouldTranspose,
-			key, toKey, segmentId, index, chordColor,
+			key, toKey, segmentId, index, styleCfg, chordColor,
 		)
 
 		allRequests = append(allRequests, paraRequests...)
@@ -305,12 +306,11 @@ func composeTransposeRequests(content []*docs.StructuralElement, index int64, ke
 // Core Logic - Styling
 // =========================================================================
 
-func composeStyleRequests(content []*docs.StructuralElement, segmentID string, isHeader bool, chordRatioThreshold float64, chordColor *docs.RgbColor) []*docs.Request {
+func composeStyleRequests(content []*docs.StructuralElement, segmentID string, isHeader bool, styleCfg DriveStyleConfig, chordColor *docs.RgbColor) []*docs.Request {
 	requests := make([]*docs.Request, 0)
 	_ = isHeader
-	styleCfg := getDriveStyleConfig()
 	if chordColor == nil {
-		chordColor = driveStyleChordPrimaryColor()
+		chordColor = driveStyleChordPrimaryColor(styleCfg)
 	}
 
 	for _, paragraph := range content {
@@ -329,7 +329,7 @@ func composeStyleRequests(content []*docs.StructuralElement, segmentID string, i
 		requests = append(requests, newUpdateParagraphStyleRequest(&paragraphStyle, paragraphStyleFields, paragraph.StartIndex, paragraph.EndIndex, segmentID))
 
 		// 2) Ensure all runs use configured base font.
-		requests = append(requests, newBaseTextStyleRequests(paragraph.Paragraph, segmentID)...)
+		requests = append(requests, newBaseTextStyleRequests(paragraph.Paragraph, segmentID, styleCfg)...)
 
 		// Build the index ONCE for this paragraph
 		ip, ok := newIndexedParagraph(paragraph.Paragraph)
@@ -338,7 +338,7 @@ func composeStyleRequests(content []*docs.StructuralElement, segmentID string, i
 		}
 
 		// 3) Style chords across the whole paragraph (paragraph-level heuristic)
-		requests = append(requests, changeStyleForChordsAcross(ip, segmentID, chordRatioThreshold, chordColor)...)
+		requests = append(requests, changeStyleForChordsAcross(ip, segmentID, styleCfg, chordColor)...)
 
 		if !styleCfg.Tokens.Enabled {
 			continue
@@ -347,7 +347,7 @@ func composeStyleRequests(content []*docs.StructuralElement, segmentID string, i
 		// [|] -> bold, black
 		textStyle := docs.TextStyle{
 			Bold:            true,
-			ForegroundColor: newOptionalColor(driveStylePlainTextColor()),
+			ForegroundColor: newOptionalColor(driveStylePlainTextColor(styleCfg)),
 		}
 		requests = append(requests, changeStyleByRegexAcross(ip, barlineRe, textStyle, "bold,foregroundColor", nil, segmentID)...)
 
@@ -519,17 +519,16 @@ func changeStyleByRegexAcross(ip *indexedParagraph, regex *regexp.Regexp, style
 
 // changeStyleForChordsAcross applies chord styling across an entire paragraph,
 // using a paragraph-level heuristic to avoid false positives (e.g., verse numbers).
-// If the ratio of chord tokens to total tokens is below chordRatioThreshold,
+// If the ratio of chord tokens to total tokens is below the configured chord ratio threshold,
 // no styling is applied for this paragraph.
-func changeStyleForChordsAcross(ip *indexedParagraph, segmentID string, chordRatioThreshold float64, chordColor *docs.RgbColor) []*docs.Request {
+func changeStyleForChordsAcross(ip *indexedParagraph, segmentID string, styleCfg DriveStyleConfig, chordColor *docs.RgbColor) []*docs.Request {
 	requests := make([]*docs.Request, 0)
-	styleCfg := getDriveStyleConfig()
 	if chordColor == nil {
-		chordColor = driveStyleChordPrimaryColor()
+		chordColor = driveStyleChordPrimaryColor(styleCfg)
 	}
 
 	// Tokenize the full paragraph (heuristic is inside Tokenize via ChordRatioThreshold)
-	lines := transposer.Tokenize(ip.fullText, true, false, transposer.WithChordRatioThreshold(chordRatioThreshold))
+	lines := transposer.Tokenize(ip.fullText, true, false, transposer.WithChordRatioThreshold(styleCfg.Chords.ChordRatioThreshold))
 
 	// Style for chords
 	chordStyle := docs.TextStyle{
@@ -641,9 +640,8 @@ func getParagraphs(content []*docs.StructuralElement) ([]*docs.Paragraph, []*ind
 }
 
 // newBaseTextStyleRequests applies the configured base font and preserves existing weight/bold flags.
-func newBaseTextStyleRequests(paragraph *docs.Paragraph, segmentID string) []*docs.Request {
+func newBaseTextStyleRequests(paragraph *docs.Paragraph, segmentID string, styleCfg DriveStyleConfig) []*docs.Request {
 	requests := make([]*docs.Request, 0, len(paragraph.Elements))
-	styleCfg := getDriveStyleConfig()
 
 	for _, element := range paragraph.Elements {
 		if element.TextRun == nil || element.TextRun.Content == "" {
@@ -716,13 +714,29 @@ func isTranspositionTargetKeySupported(key entity.Key) bool {
 	return err == nil
 }
 
+// transposeText transposes chords of the text from key to toKey or to Nashville numbers.
+// Returns the text as is if it can't be transposed.
+func transposeText(text string, key, toKey entity.Key) string {
+	var transposedText string
+	var err error
+	if toKey == keyNashville {
+		transposedText, err = transposer.TransposeToNashville(text, string(key))
+	} else {
+		transposedText, err = transposer.TransposeToKey(text, string(key), string(toKey))
+	}
+	if err != nil {
+		return text
+	}
+	return transposedText
+}
+
 // newTransposeRequestsForParagraph generates all the requests for a single paragraph's elements.
-func newTransposeRequestsForParagraph(paragraph *docs.Paragraph, isLastParagraph, shouldTranspose bool, key, toKey entity.Key, segmentId string, index int64, chordColor *docs.RgbColor) ([]*docs.Request, int64) {
+func newTransposeRequestsForParagraph(paragraph *docs.Paragraph, isLastParagraph, shouldTranspose bool, key, toKey entity.Key, segmentId string, index int64, styleCfg DriveStyleConfig, chordColor *docs.RgbColor) ([]*docs.Request, int64) {
 	requests := make([]*docs.Request, 0)
 	paragraphRangeStart := int64(-1)
 	paragraphRangeEnd := int64(-1)
 	if chordColor == nil {
-		chordColor = driveStyleChordPrimaryColor()
+		chordColor = driveStyleChordPrimaryColor(styleCfg)
 	}
 
 	for j, element := range paragraph.Elements {
@@ -745,22 +759,13 @@ func newTransposeRequestsForParagraph(paragraph *docs.Paragraph, isLastParagraph
 		}
 
 		if shouldTranspose && key != "" {
-			var transposedText string
-			var err error
-			if toKey == keyNashville {
-				transposedText, err = transposer.TransposeToNashville(runText, string(key))
-			} else {
-				transposedText, err = transposer.TransposeToKey(runText, string(key), string(toKey))
-			}
-			if err == nil {
-				runText = transposedText
-			}
+			runText = transposeText(runText, key, toKey)
 		}
 
 		if textStyle.ForegroundColor == nil {
-			textStyle.ForegroundColor = newOptionalColor(driveStylePlainTextColor())
+			textStyle.ForegroundColor = newOptionalColor(driveStylePlainTextColor(styleCfg))
 		}
-		if isChordColoredText(textStyle) {
+		if isChordColoredText(styleCfg, textStyle) {
 			textStyle.ForegroundColor = newOptionalColor(chordColor)
 		}
 
@@ -795,14 +800,14 @@ func newTransposeRequestsForParagraph(paragraph *docs.Paragraph, isLastParagraph
 	return requests, index
 }
 
-func chordColorForSectionIndex(sectionIndex int) *docs.RgbColor {
+func chordColorForSectionIndex(styleCfg DriveStyleConfig, sectionIndex int) *docs.RgbColor {
 	if sectionIndex%2 == 0 {
-		return driveStyleChordPrimaryColor()
+		return driveStyleChordPrimaryColor(styleCfg)
 	}
-	return driveStyleChordAlternateColor()
+	return driveStyleChordAlternateColor(styleCfg)
 }
 
-func isChordColoredText(style *docs.TextStyle) bool {
+func isChordColoredText(styleCfg DriveStyleConfig, style *docs.TextStyle) bool {
 	if style == nil ||
 		style.ForegroundColor == nil ||
 		style.ForegroundColor.Color == nil ||
@@ -810,7 +815,7 @@ func isChordColoredText(style *docs.TextStyle) bool {
 		return false
 	}
 	rgb := style.ForegroundColor.Color.RgbColor
-	return isSameRGBColor(rgb, driveStyleChordPrimaryColor()) || isSameRGBColor(rgb, driveStyleChordAlternateColor())
+	return isSameRGBColor(rgb, driveStyleChordPrimaryColor(styleCfg)) || isSameRGBColor(rgb, driveStyleChordAlternateColor(styleCfg))
 }
 
 func isSameRGBColor(a, b *docs.RgbColor) bool {
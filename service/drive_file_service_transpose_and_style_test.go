@@ -208,7 +208,7 @@ func TestNewTransposeRequestsForParagraphAppliesParagraphStyleOnce(t *testing.T)
 		},
 	}
 
-	requests, _ := newTransposeRequestsForParagraph(paragraph, false, false, "", "", "", 1, nil)
+	requests, _ := newTransposeRequestsForParagraph(paragraph, false, false, "", "", "", 1, DefaultDriveStyleConfig(), nil)
 
 	paragraphStyleReqs := 0
 	for _, req := range requests {
@@ -255,9 +255,7 @@ func TestGetContentForSectionBodyKeepsBoundaryParagraph(t *testing.T) {
 }
 
 func TestNewDriveDocumentStyleFromConfigExcludesPageSize(t *testing.T) {
-	setDriveStyleConfig(DefaultDriveStyleConfig())
-
-	style, fields := newDriveDocumentStyleFromConfig()
+	style, fields := newDriveDocumentStyleFromConfig(DefaultDriveStyleConfig())
 	assert.NotNil(t, style)
 	assert.Equal(t, "marginBottom,marginLeft,marginRight,marginTop,marginHeader", fields)
 	assert.Nil(t, style.PageSize)
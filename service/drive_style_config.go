@@ -1,12 +1,16 @@
 package service
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/joeyave/scala-bot/entity"
 	"google.golang.org/api/docs/v1"
 	"gopkg.in/yaml.v3"
 )
@@ -16,6 +20,57 @@ const (
 	defaultDriveStyleConfigPath = "config/drive_style.yml"
 )
 
+// MaxDriveStyleYAMLLength is the max length of the band style overrides.
+const MaxDriveStyleYAMLLength = 4096
+
+// Built-in presets of the band style profile.
+const (
+	DriveStylePresetLargePrint = "large_print"
+	DriveStylePresetCompact    = "compact"
+	DriveStylePresetDarkChords = "dark_chords"
+)
+
+// driveStylePresets are overrides in the config file format applied over the config file.
+var driveStylePresets = map[string]string{
+	DriveStylePresetLargePrint: `
+new_document:
+  default_font_size_pt: 20
+paragraph:
+  line_spacing: 100
+metadata:
+  font_size_title_pt: 26
+  font_size_line_pt: 18
+  font_size_last_line_pt: 14
+`,
+	DriveStylePresetCompact: `
+new_document:
+  default_font_size_pt: 12
+document:
+  margins_pt:
+    top: 10
+    right: 20
+    bottom: 10
+    left: 20
+    header: 10
+paragraph:
+  line_spacing: 80
+metadata:
+  font_size_title_pt: 16
+  font_size_line_pt: 12
+  font_size_last_line_pt: 10
+`,
+	DriveStylePresetDarkChords: `
+chords:
+  primary_color: "#660000"
+  alternate_color: "#20124D"
+`,
+}
+
+// DriveStylePresets returns names of the built-in presets.
+func DriveStylePresets() []string {
+	return []string{DriveStylePresetLargePrint, DriveStylePresetCompact, DriveStylePresetDarkChords}
+}
+
 type DriveStyleConfig struct {
 	NewDocument DriveStyleNewDocumentConfig `yaml:"new_document"`
 	Document    DriveStyleDocumentConfig    `yaml:"document"`
@@ -167,6 +222,56 @@ func loadDriveStyleConfig(path string) (DriveStyleConfig, error) {
 	return cfg, nil
 }
 
+// ParseDriveStyleConfig applies overrides in the config file format over the base config.
+// Unknown fields are rejected, the result is validated the same way as the config file.
+func ParseDriveStyleConfig(base DriveStyleConfig, overrides ...string) (DriveStyleConfig, error) {
+	cfg := base
+	for _, override := range overrides {
+		decoder := yaml.NewDecoder(bytes.NewReader([]byte(override)))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return DriveStyleConfig{}, fmt.Errorf("parse drive style config: %w", err)
+		}
+	}
+
+	normalizeDriveStyleConfig(&cfg)
+	if err := validateDriveStyleConfig(cfg); err != nil {
+		return DriveStyleConfig{}, fmt.Errorf("validate drive style config: %w", err)
+	}
+	return cfg, nil
+}
+
+// DriveStyleConfigForBand returns the config file style with the band preset and overrides applied.
+func DriveStyleConfigForBand(band *entity.Band) (DriveStyleConfig, error) {
+	base := getDriveStyleConfig()
+	if band == nil || band.DriveStyle == nil {
+		return base, nil
+	}
+	return driveStyleConfigForProfile(base, *band.DriveStyle)
+}
+
+// ValidateDriveStyleProfile checks that the profile can be applied over the config file.
+func ValidateDriveStyleProfile(profile entity.DriveStyleProfile) error {
+	if len(profile.YAML) > MaxDriveStyleYAMLLength {
+		return fmt.Errorf("drive style overrides must be at most %d characters", MaxDriveStyleYAMLLength)
+	}
+	_, err := driveStyleConfigForProfile(getDriveStyleConfig(), profile)
+	return err
+}
+
+func driveStyleConfigForProfile(base DriveStyleConfig, profile entity.DriveStyleProfile) (DriveStyleConfig, error) {
+	overrides := make([]string, 0, 2)
+	if profile.Preset != "" {
+		preset, ok := driveStylePresets[profile.Preset]
+		if !ok {
+			return DriveStyleConfig{}, fmt.Errorf("unknown drive style preset %q", profile.Preset)
+		}
+		overrides = append(overrides, preset)
+	}
+	overrides = append(overrides, profile.YAML)
+	return ParseDriveStyleConfig(base, overrides...)
+}
+
 func normalizeDriveStyleConfig(cfg *DriveStyleConfig) {
 	cfg.Document.Unit = strings.ToUpper(strings.TrimSpace(cfg.Document.Unit))
 	cfg.Text.FontFamily = strings.TrimSpace(cfg.Text.FontFamily)
@@ -314,23 +419,19 @@ func driveStyleColorFromHex(hexColor string) *docs.RgbColor {
 	return newRgbColor(r, g, b)
 }
 
-func driveStylePlainTextColor() *docs.RgbColor {
-	cfg := getDriveStyleConfig()
+func driveStylePlainTextColor(cfg DriveStyleConfig) *docs.RgbColor {
 	return driveStyleColorFromHex(cfg.Chords.PlainTextColor)
 }
 
-func driveStyleChordPrimaryColor() *docs.RgbColor {
-	cfg := getDriveStyleConfig()
+func driveStyleChordPrimaryColor(cfg DriveStyleConfig) *docs.RgbColor {
 	return driveStyleColorFromHex(cfg.Chords.PrimaryColor)
 }
 
-func driveStyleChordAlternateColor() *docs.RgbColor {
-	cfg := getDriveStyleConfig()
+func driveStyleChordAlternateColor(cfg DriveStyleConfig) *docs.RgbColor {
 	return driveStyleColorFromHex(cfg.Chords.AlternateColor)
 }
 
-func newDriveDocumentStyleFromConfig() (*docs.DocumentStyle, string) {
-	cfg := getDriveStyleConfig()
+func newDriveDocumentStyleFromConfig(cfg DriveStyleConfig) (*docs.DocumentStyle, string) {
 	unit := cfg.Document.Unit
 
 	style := &docs.DocumentStyle{
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/joeyave/scala-bot/entity"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -112,3 +113,45 @@ func TestLoadAndApplyDriveStyleConfigInvalidColor(t *testing.T) {
 	assert.ErrorContains(t, err, "chords.primary_color")
 	assert.Equal(t, original, getDriveStyleConfig())
 }
+
+func TestParseDriveStyleConfig(t *testing.T) {
+	base := DefaultDriveStyleConfig()
+
+	cfg, err := ParseDriveStyleConfig(base, "text:\n  font_family: \" Courier New \"\n", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Courier New", cfg.Text.FontFamily)
+	assert.Equal(t, base.Document, cfg.Document)
+
+	_, err = ParseDriveStyleConfig(base, "text:\n  font_size: 12\n")
+	assert.ErrorContains(t, err, "parse drive style config")
+
+	_, err = ParseDriveStyleConfig(base, "chords:\n  chord_ratio_threshold: 2\n")
+	assert.ErrorContains(t, err, "chords.chord_ratio_threshold")
+}
+
+func TestDriveStyleConfigForBand(t *testing.T) {
+	original := getDriveStyleConfig()
+	t.Cleanup(func() {
+		setDriveStyleConfig(original)
+	})
+	setDriveStyleConfig(DefaultDriveStyleConfig())
+
+	cfg, err := DriveStyleConfigForBand(&entity.Band{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDriveStyleConfig(), cfg)
+
+	for _, preset := range DriveStylePresets() {
+		assert.NoError(t, ValidateDriveStyleProfile(entity.DriveStyleProfile{Preset: preset}), preset)
+	}
+
+	cfg, err = DriveStyleConfigForBand(&entity.Band{DriveStyle: &entity.DriveStyleProfile{
+		Preset: DriveStylePresetLargePrint,
+		YAML:   "metadata:\n  font_size_title_pt: 30\n",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, cfg.Metadata.FontSizeTitlePt)
+	assert.Equal(t, 18.0, cfg.Metadata.FontSizeLinePt)
+
+	err = ValidateDriveStyleProfile(entity.DriveStyleProfile{Preset: "huge"})
+	assert.ErrorContains(t, err, "unknown drive style preset")
+}
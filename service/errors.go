@@ -6,4 +6,5 @@ var (
 	ErrForbidden        = errors.New("forbidden")
 	ErrInvalidOperation = errors.New("invalid operation")
 	ErrAlreadyExists    = errors.New("already exists")
+	ErrLimitExceeded    = errors.New("limit exceeded")
 )
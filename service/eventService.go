@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
@@ -155,11 +156,22 @@ func HTMLStringForEvent(event entity.Event, songs []*entity.Song, lang string) s
 
 		var songNames []string
 		for i, song := range songs {
-			songName := fmt.Sprintf("%d. <a href=\"%s\">%s</a>  (%s)", i+1, song.PDF.WebViewLink, song.PDF.Name, song.Meta())
+			songName := fmt.Sprintf("%d. <a href=\"%s\">%s</a>  (%s)", i+1, song.GetWebViewLink(), html.EscapeString(song.NameWithArrangement()), song.Meta())
+			if roadMap := song.RoadMapForEvent(event.GetSongOverride(song.ID)); roadMap != "" {
+				songName += fmt.Sprintf("\n    <i>%s</i>", html.EscapeString(roadMap))
+			}
 			songNames = append(songNames, songName)
 		}
 
 		fmt.Fprintf(&b, "\n%s", strings.Join(songNames, "\n"))
+
+		var durationSec int
+		for _, song := range songs {
+			durationSec += song.DurationSec
+		}
+		if durationSec > 0 {
+			fmt.Fprintf(&b, "\n\n%s", txt.Get("text.setlistDuration", lang, (durationSec+30)/60))
+		}
 	}
 
 	if event.Notes != nil && *event.Notes != "" {
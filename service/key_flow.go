@@ -0,0 +1,296 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joeyave/chords-transposer/transposer"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+)
+
+// keySemitones returns the number of semitones (0-11) to go up from one key to another.
+// Minor keys are treated as their relative major, so Em -> G is 0.
+func keySemitones(from, to entity.Key) (int, bool) {
+	fromKey, err := transposer.ParseKey(strings.TrimSpace(string(from)))
+	if err != nil {
+		return 0, false
+	}
+	toKey, err := transposer.ParseKey(strings.TrimSpace(string(to)))
+	if err != nil {
+		return 0, false
+	}
+
+	return ((fromKey.SemitonesTo(toKey) % 12) + 12) % 12, true
+}
+
+// fifthsDistance returns how many steps two keys are apart on the circle of fifths (0-6).
+func fifthsDistance(semitones int) int {
+	steps := (semitones * 7) % 12
+	return min(steps, 12-steps)
+}
+
+// keyCompatibility rates how smooth the transition between two keys is, from 0 to 1.
+// The same or relative key is the best, then neighbours on the circle of fifths
+// and a lift by a half or a whole step. Returns false if any of the keys is unknown.
+func keyCompatibility(from, to entity.Key) (float64, bool) {
+	semitones, ok := keySemitones(from, to)
+	if !ok {
+		return 0, false
+	}
+
+	switch fifthsDistance(semitones) {
+	case 0:
+		return 1, true
+	case 1:
+		return 0.8, true
+	}
+	if semitones == 1 || semitones == 2 {
+		return 0.7, true
+	}
+	if fifthsDistance(semitones) == 2 {
+		return 0.5, true
+	}
+
+	return 0.2, true
+}
+
+// ParseBPM parses BPM of the song, e.g. "72" or "72.5". Returns false if BPM is unknown.
+func ParseBPM(bpm string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(strings.ReplaceAll(bpm, ",", ".")), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// bpmCompatibility rates how close the tempos of two songs are, from 0 to 1.
+// Returns false if any of the BPMs is unknown.
+func bpmCompatibility(from, to string) (float64, bool) {
+	fromBPM, ok := ParseBPM(from)
+	if !ok {
+		return 0, false
+	}
+	toBPM, ok := ParseBPM(to)
+	if !ok {
+		return 0, false
+	}
+
+	switch diff := math.Abs(fromBPM - toBPM); {
+	case diff <= 10:
+		return 1, true
+	case diff <= 25:
+		return 0.6, true
+	default:
+		return 0.3, true
+	}
+}
+
+const (
+	setlistFlowKeyWeight = 0.7
+	setlistFlowBPMWeight = 0.3
+	// setlistFlowMaxKeyShift is how many semitones a song may be transposed to flow better.
+	setlistFlowMaxKeyShift = 2
+	// setlistFlowMaxPermutedSongs is the max setlist size to find the best order by trying all orders.
+	setlistFlowMaxPermutedSongs = 8
+	// setlistFlowMinImprovement is how much the average score must grow to suggest another order.
+	setlistFlowMinImprovement = 0.1
+)
+
+// AnalyzeSetlist analyzes transitions between songs of the event setlist
+// with keys and arrangements chosen for the event.
+func (s *SongService) AnalyzeSetlist(event *entity.Event) (*entity.SetlistFlow, error) {
+	var songs []*entity.Song
+	if len(event.SongIDs) > 0 {
+		found, err := s.songRepository.FindManyByIDs(event.SongIDs)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+
+		for _, songID := range event.SongIDs {
+			for _, song := range found {
+				if song.ID != songID {
+					continue
+				}
+				override := eventSongOverride(event, song)
+				if override != nil && override.EventKey != "" && override.EventKey != song.PDF.Key {
+					song.AltPDF = &entity.AltPDF{Key: override.EventKey}
+				}
+				songs = append(songs, song)
+				break
+			}
+		}
+	}
+
+	flow := AnalyzeSetlistFlow(songs)
+	return &flow, nil
+}
+
+// AnalyzeSetlistFlow rates key and tempo transitions between songs in the given order,
+// suggests keys for awkward key jumps and a smoother order of songs. The first song stays first,
+// because the opening song is usually chosen on purpose.
+func AnalyzeSetlistFlow(songs []*entity.Song) entity.SetlistFlow {
+	flow := entity.SetlistFlow{
+		Songs:       songs,
+		Transitions: []entity.SetlistTransition{},
+	}
+
+	for i, song := range songs {
+		if song.DurationSec > 0 {
+			flow.DurationSec += song.DurationSec
+		} else {
+			flow.SongsWithoutDuration++
+		}
+
+		if i > 0 {
+			transition := analyzeSetlistTransition(songs[i-1], song)
+			transition.From = i - 1
+			flow.Transitions = append(flow.Transitions, transition)
+			flow.Score += transition.Score
+		}
+	}
+
+	if len(flow.Transitions) == 0 {
+		return flow
+	}
+	flow.Score /= float64(len(flow.Transitions))
+
+	order, score := bestSetlistOrder(songs)
+	if score-flow.Score >= setlistFlowMinImprovement {
+		flow.SuggestedOrder = order
+		flow.SuggestedScore = score
+	}
+
+	return flow
+}
+
+func analyzeSetlistTransition(from, to *entity.Song) entity.SetlistTransition {
+	transition := entity.SetlistTransition{
+		FromKey: from.EffectiveKey(),
+		ToKey:   to.EffectiveKey(),
+		FromBPM: from.EffectiveBPM(),
+		ToBPM:   to.EffectiveBPM(),
+		Issues:  []entity.SetlistTransitionIssue{},
+	}
+
+	keyScore, bpmScore := 0.5, 0.5
+	if compatibility, ok := keyCompatibility(transition.FromKey, transition.ToKey); ok {
+		keyScore = compatibility
+		if compatibility < 0.5 {
+			transition.Issues = append(transition.Issues, entity.SetlistTransitionIssueKeyJump)
+			transition.SuggestedKey = suggestTransitionKey(transition.FromKey, transition.ToKey)
+		}
+	}
+	if compatibility, ok := bpmCompatibility(transition.FromBPM, transition.ToBPM); ok {
+		bpmScore = compatibility
+		if compatibility <= 0.3 {
+			transition.Issues = append(transition.Issues, entity.SetlistTransitionIssueTempoJump)
+		}
+	}
+
+	transition.Score = setlistFlowKeyWeight*keyScore + setlistFlowBPMWeight*bpmScore
+	return transition
+}
+
+// suggestTransitionKey finds a key at most setlistFlowMaxKeyShift semitones away from the key of the next song
+// that flows well from the previous key. Returns an empty key if there is no such key.
+func suggestTransitionKey(from, to entity.Key) entity.Key {
+	candidates := entity.MajorKeys
+	if to.IsMinor() {
+		candidates = entity.MinorKeys
+	}
+
+	var (
+		bestKey           entity.Key
+		bestCompatibility float64
+		bestShift         int
+	)
+	for _, candidate := range candidates {
+		semitones, ok := keySemitones(to, candidate)
+		if !ok {
+			continue
+		}
+		shift := min(semitones, 12-semitones)
+		if shift == 0 || shift > setlistFlowMaxKeyShift {
+			continue
+		}
+
+		compatibility, ok := keyCompatibility(from, candidate)
+		if !ok || compatibility < 0.7 {
+			continue
+		}
+		if compatibility > bestCompatibility || compatibility == bestCompatibility && shift < bestShift {
+			bestKey, bestCompatibility, bestShift = candidate, compatibility, shift
+		}
+	}
+
+	return bestKey
+}
+
+// bestSetlistOrder returns the order of song indexes with the best average transition score.
+// All orders are tried for small setlists, otherwise the next song is chosen greedily.
+func bestSetlistOrder(songs []*entity.Song) ([]int, float64) {
+	n := len(songs)
+	scores := make([][]float64, n)
+	for i := range songs {
+		scores[i] = make([]float64, n)
+		for j := range songs {
+			if i != j {
+				scores[i][j] = analyzeSetlistTransition(songs[i], songs[j]).Score
+			}
+		}
+	}
+
+	orderScore := func(order []int) float64 {
+		var sum float64
+		for i := 1; i < len(order); i++ {
+			sum += scores[order[i-1]][order[i]]
+		}
+		return sum / float64(len(order)-1)
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	if n > setlistFlowMaxPermutedSongs {
+		used := make([]bool, n)
+		used[0] = true
+		for i := 1; i < n; i++ {
+			next := -1
+			for j := range songs {
+				if !used[j] && (next == -1 || scores[order[i-1]][j] > scores[order[i-1]][next]) {
+					next = j
+				}
+			}
+			order[i] = next
+			used[next] = true
+		}
+		return order, orderScore(order)
+	}
+
+	best := slices.Clone(order)
+	bestScore := orderScore(order)
+
+	var permute func(k int)
+	permute = func(k int) {
+		if k == n {
+			if score := orderScore(order); score > bestScore {
+				best, bestScore = slices.Clone(order), score
+			}
+			return
+		}
+		for i := k; i < n; i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(1)
+
+	return best, bestScore
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestKeyCompatibility(t *testing.T) {
+	tests := []struct {
+		from, to entity.Key
+		want     float64
+		ok       bool
+	}{
+		{"G", "G", 1, true},
+		{"Em", "G", 1, true},
+		{"G", "D", 0.8, true},
+		{"G", "C", 0.8, true},
+		{"G", "Ab", 0.7, true},
+		{"G", "A", 0.7, true},
+		{"A", "G", 0.5, true},
+		{"C", "F#", 0.2, true},
+		{"G", "", 0, false},
+		{"?", "G", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			got, ok := keyCompatibility(tt.from, tt.to)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBPMCompatibility(t *testing.T) {
+	got, ok := bpmCompatibility("72", "80")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, got)
+
+	got, ok = bpmCompatibility("72", "95.5")
+	assert.True(t, ok)
+	assert.Equal(t, 0.6, got)
+
+	got, ok = bpmCompatibility("72", "140")
+	assert.True(t, ok)
+	assert.Equal(t, 0.3, got)
+
+	_, ok = bpmCompatibility("72", "")
+	assert.False(t, ok)
+}
+
+func TestSuggestTransitionKey(t *testing.T) {
+	assert.Equal(t, entity.Key("F"), suggestTransitionKey("C", "F#"))
+	assert.Equal(t, entity.Key("Gm"), suggestTransitionKey("Dm", "G#m"))
+	assert.Equal(t, entity.Key(""), suggestTransitionKey("?", "F#"))
+}
+
+func TestAnalyzeSetlistFlow(t *testing.T) {
+	song := func(key entity.Key, bpm string, durationSec int) *entity.Song {
+		return &entity.Song{ID: bson.NewObjectID(), PDF: entity.PDF{Key: key, BPM: bpm}, DurationSec: durationSec}
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		flow := AnalyzeSetlistFlow(nil)
+		assert.Empty(t, flow.Transitions)
+		assert.Zero(t, flow.Score)
+	})
+
+	t.Run("smooth", func(t *testing.T) {
+		flow := AnalyzeSetlistFlow([]*entity.Song{song("G", "72", 300), song("D", "76", 0), song("D", "70", 240)})
+		assert.Len(t, flow.Transitions, 2)
+		for _, transition := range flow.Transitions {
+			assert.Empty(t, transition.Issues)
+		}
+		assert.Equal(t, 540, flow.DurationSec)
+		assert.Equal(t, 1, flow.SongsWithoutDuration)
+		assert.Nil(t, flow.SuggestedOrder)
+	})
+
+	t.Run("jumps", func(t *testing.T) {
+		songs := []*entity.Song{song("C", "130", 0), song("F#", "68", 0), song("C", "128", 0), song("F#", "70", 0)}
+		flow := AnalyzeSetlistFlow(songs)
+
+		assert.Equal(t, []entity.SetlistTransitionIssue{entity.SetlistTransitionIssueKeyJump, entity.SetlistTransitionIssueTempoJump}, flow.Transitions[0].Issues)
+		assert.Equal(t, entity.Key("C"), flow.Transitions[0].FromKey)
+		assert.Equal(t, entity.Key("F#"), flow.Transitions[0].ToKey)
+		assert.Equal(t, entity.Key("F"), flow.Transitions[0].SuggestedKey)
+
+		assert.Equal(t, []int{0, 2, 1, 3}, flow.SuggestedOrder)
+		assert.Greater(t, flow.SuggestedScore, flow.Score)
+	})
+
+	t.Run("event key", func(t *testing.T) {
+		second := song("F#", "", 0)
+		second.AltPDF = &entity.AltPDF{Key: "G"}
+		flow := AnalyzeSetlistFlow([]*entity.Song{song("C", "", 0), second})
+		assert.Equal(t, entity.Key("G"), flow.Transitions[0].ToKey)
+		assert.Empty(t, flow.Transitions[0].Issues)
+	})
+}
+
+func TestHTMLStringForEventDuration(t *testing.T) {
+	songs := []*entity.Song{
+		{ID: bson.NewObjectID(), PDF: entity.PDF{Name: "First"}, DurationSec: 290},
+		{ID: bson.NewObjectID(), PDF: entity.PDF{Name: "Second"}, DurationSec: 250},
+	}
+
+	assert.Contains(t, HTMLStringForEvent(entity.Event{Name: "Sunday"}, songs, "ru"), "⏱ Длительность: ~9 мин.")
+
+	songs[0].DurationSec, songs[1].DurationSec = 0, 0
+	assert.NotContains(t, HTMLStringForEvent(entity.Event{Name: "Sunday"}, songs, "ru"), "⏱")
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/joeyave/chords-transposer/transposer"
+	"github.com/joeyave/scala-bot/entity"
+	"golang.org/x/sync/errgroup"
+)
+
+// lyricsSlidesChordRatioThreshold is the share of chords in a line to skip it as a chord line.
+// It is higher than the style one, so lyrics like "A mighty fortress" are kept.
+const lyricsSlidesChordRatioThreshold = 0.5
+
+// lyricsSlidesConcurrency is how many docs are read at once for the setlist.
+const lyricsSlidesConcurrency = 4
+
+// openLyricsVerseNames maps road-map abbreviations (lowercased) to OpenLyrics verse types.
+var openLyricsVerseNames = map[string]string{
+	"v": "v", "c": "c", "pc": "p", "b": "b", "i": "i", "o": "e", "inst": "o", "t": "o",
+}
+
+var roadMapSectionRegex = regexp.MustCompile(`^(?i)(pc|inst|[vcbiot])(\d*)$`)
+
+// openLyricsVerseName converts a road-map section, e.g. "V1" or "PC", to the OpenLyrics verse name, e.g. "v1" or "p".
+func openLyricsVerseName(abbreviation string) (string, bool) {
+	m := roadMapSectionRegex.FindStringSubmatch(abbreviation)
+	if m == nil {
+		return "", false
+	}
+	return openLyricsVerseNames[strings.ToLower(m[1])] + m[2], true
+}
+
+// lyricsSlidesBuilder collects sections of lyrics line by line.
+type lyricsSlidesBuilder struct {
+	rules entity.SlideRules
+
+	sections []*lyricsSectionInstance
+	order    []*lyricsSectionInstance
+	current  *lyricsSectionInstance
+	stanza   []string
+	verses   int
+}
+
+// lyricsSectionInstance is a section as it's written in the doc. The same section may be written several times,
+// e.g. the chorus may be written with lyrics once and then only as a label.
+type lyricsSectionInstance struct {
+	section  *entity.LyricsSection
+	implicit bool
+	alias    *lyricsSectionInstance
+}
+
+// SplitLyricsSlides splits lyrics lines into sections by section labels (see DetectRoadMap)
+// and sections into slides by blank lines and the max number of lines.
+// Lyrics without labels are split into verses by blank lines. Chord lines are skipped.
+// Returns sections and the order of their names as written in the doc.
+func SplitLyricsSlides(lines []string, rules entity.SlideRules) ([]*entity.LyricsSection, []string) {
+	if rules.MaxLines <= 0 {
+		rules.MaxLines = entity.DefaultSlideMaxLines
+	}
+
+	b := &lyricsSlidesBuilder{rules: rules}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if abbreviations, ok := parseSectionLabel(line); ok {
+			b.startSection(abbreviations)
+			continue
+		}
+		if line == "" {
+			b.breakStanza()
+			continue
+		}
+		if !isLyricsLine(line) {
+			continue
+		}
+		b.addLine(line)
+	}
+
+	return b.finish()
+}
+
+func isLyricsLine(line string) bool {
+	if !strings.ContainsFunc(line, unicode.IsLetter) {
+		return false
+	}
+
+	for _, tokens := range transposer.Tokenize(line, true, false, transposer.WithChordRatioThreshold(lyricsSlidesChordRatioThreshold)) {
+		for _, token := range tokens {
+			if token.Chord != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *lyricsSlidesBuilder) startSection(abbreviations []string) {
+	b.flushStanza()
+
+	var instance *lyricsSectionInstance
+	for _, abbreviation := range abbreviations {
+		name, ok := openLyricsVerseName(abbreviation)
+		if !ok {
+			continue
+		}
+		if instance == nil {
+			instance = &lyricsSectionInstance{section: &entity.LyricsSection{Name: name, Slides: [][]string{}}}
+			b.sections = append(b.sections, instance)
+		}
+		// Repeats like "Chorus x2".
+		b.order = append(b.order, instance)
+	}
+	b.current = instance
+}
+
+func (b *lyricsSlidesBuilder) breakStanza() {
+	if b.current != nil && b.current.implicit {
+		b.flushStanza()
+		b.current = nil
+		return
+	}
+	if !b.rules.IgnoreBlankLines {
+		b.flushStanza()
+	}
+}
+
+func (b *lyricsSlidesBuilder) addLine(line string) {
+	if b.current == nil {
+		b.verses++
+		b.current = &lyricsSectionInstance{
+			section:  &entity.LyricsSection{Name: fmt.Sprintf("v%d", b.verses), Slides: [][]string{}},
+			implicit: true,
+		}
+		b.sections = append(b.sections, b.current)
+		b.order = append(b.order, b.current)
+	}
+
+	if b.rules.Uppercase {
+		line = strings.ToUpper(line)
+	}
+	b.stanza = append(b.stanza, line)
+}
+
+// flushStanza adds collected lines to the current section, split evenly into slides of at most MaxLines lines.
+func (b *lyricsSlidesBuilder) flushStanza() {
+	if len(b.stanza) == 0 {
+		return
+	}
+
+	slides := (len(b.stanza) + b.rules.MaxLines - 1) / b.rules.MaxLines
+	for i := range slides {
+		from := i * len(b.stanza) / slides
+		to := (i + 1) * len(b.stanza) / slides
+		b.current.section.Slides = append(b.current.section.Slides, slices.Clone(b.stanza[from:to]))
+	}
+	b.stanza = nil
+}
+
+func (b *lyricsSlidesBuilder) finish() ([]*entity.LyricsSection, []string) {
+	b.flushStanza()
+
+	sections := make([]*entity.LyricsSection, 0, len(b.sections))
+	// lastByName is the last instance with lyrics for each section name from the doc.
+	lastByName := make(map[string]*lyricsSectionInstance)
+	for _, instance := range b.sections {
+		name := instance.section.Name
+		last := lastByName[name]
+
+		if len(instance.section.Slides) == 0 || last != nil && slices.EqualFunc(last.section.Slides, instance.section.Slides, slices.Equal) {
+			// Label without lyrics or the same lyrics means the section is repeated.
+			instance.alias = last
+			continue
+		}
+
+		lastByName[name] = instance
+		instance.section.Name = uniqueLyricsSectionName(sections, name)
+		sections = append(sections, instance.section)
+	}
+
+	order := make([]string, 0, len(b.order))
+	for _, instance := range b.order {
+		if instance.alias != nil {
+			instance = instance.alias
+		}
+		if len(instance.section.Slides) > 0 {
+			order = append(order, instance.section.Name)
+		}
+	}
+
+	return sections, order
+}
+
+// uniqueLyricsSectionName adds a part letter to the name if it is taken, e.g. "c" -> "c1b".
+func uniqueLyricsSectionName(sections []*entity.LyricsSection, name string) string {
+	taken := func(name string) bool {
+		return slices.ContainsFunc(sections, func(section *entity.LyricsSection) bool { return section.Name == name })
+	}
+	if !taken(name) {
+		return name
+	}
+
+	base := name
+	if !strings.ContainsFunc(base, unicode.IsDigit) {
+		base += "1"
+	}
+	for part := 'b'; part <= 'z'; part++ {
+		if name := base + string(part); !taken(name) {
+			return name
+		}
+	}
+	return name
+}
+
+// LyricsSlidesOrder converts the road-map to the order of section names. Sections without lyrics
+// (e.g. an intro) are skipped. Returns false if the road-map doesn't match the sections.
+func LyricsSlidesOrder(sections []*entity.LyricsSection, roadMap string) ([]string, bool) {
+	var order []string
+	for _, abbreviation := range strings.Fields(roadMap) {
+		name, ok := openLyricsVerseName(abbreviation)
+		if !ok {
+			continue
+		}
+
+		// "C" in the road-map matches "c1" in the doc and vice versa.
+		candidates := []string{name}
+		if verseType, number := strings.TrimRightFunc(name, unicode.IsDigit), strings.TrimLeftFunc(name, unicode.IsLetter); number == "" {
+			candidates = append(candidates, verseType+"1")
+		} else if number == "1" {
+			candidates = append(candidates, verseType)
+		}
+		for _, candidate := range candidates {
+			if slices.ContainsFunc(sections, func(section *entity.LyricsSection) bool { return section.Name == candidate }) {
+				order = append(order, candidate)
+				break
+			}
+		}
+	}
+	return order, len(order) > 0
+}
+
+// GetLyricsLines returns lines of the first section of the doc without the metadata header.
+func (s *DriveFileService) GetLyricsLines(ID string) ([]string, error) {
+	doc, err := s.getDoc(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := getSections(doc)
+	if len(sections) == 0 {
+		return nil, nil
+	}
+
+	var lines []string
+	for _, item := range getContentForSectionBody(doc, sections, 0) {
+		text := strings.TrimSuffix(paragraphToRawText(item), "\n")
+		// Soft line breaks are stored as vertical tabs.
+		lines = append(lines, strings.Split(text, "\v")...)
+	}
+	return lines, nil
+}
+
+// GetSongSlides splits lyrics of the song (or its arrangement doc) into projection slides.
+// The road-map, if it matches the sections, sets the order of slides.
+func (s *DriveFileService) GetSongSlides(song *entity.Song, roadMap string, rules entity.SlideRules) (*entity.SongSlides, error) {
+	fileID := song.DriveFileID
+	if song.Arrangement.HasDoc() {
+		fileID = song.Arrangement.DriveFileID
+	}
+
+	lines, err := s.GetLyricsLines(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	slides := &entity.SongSlides{Song: song}
+	slides.Sections, slides.Order = SplitLyricsSlides(lines, rules)
+	if order, ok := LyricsSlidesOrder(slides.Sections, roadMap); ok {
+		slides.Order = order
+	}
+	return slides, nil
+}
+
+// GetSetlistSlides splits lyrics of the event songs into projection slides in the setlist order,
+// taking into account arrangements and road-maps chosen for the event.
+func (s *DriveFileService) GetSetlistSlides(event *entity.Event, songs []*entity.Song, rules entity.SlideRules) ([]*entity.SongSlides, error) {
+	setlist := make([]*entity.SongSlides, len(songs))
+
+	g := new(errgroup.Group)
+	g.SetLimit(lyricsSlidesConcurrency)
+	for i, song := range songs {
+		g.Go(func() error {
+			// The song is copied, so setting the arrangement doesn't change the shared setlist entry.
+			song := *song
+			override := event.GetSongOverride(song.ID)
+			if override != nil && override.ArrangementID != nil {
+				song.Arrangement = song.GetArrangement(*override.ArrangementID)
+			}
+
+			slides, err := s.GetSongSlides(&song, song.RoadMapForEvent(override), rules)
+			if err != nil {
+				return err
+			}
+			setlist[i] = slides
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return setlist, nil
+}
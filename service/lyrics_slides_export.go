@@ -0,0 +1,290 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/joeyave/scala-bot/entity"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+)
+
+// SlidesFormat is a format of the lyrics slides export.
+// There is no PNG deck: Docs can't be exported to images, the PDF deck is used instead.
+type SlidesFormat string
+
+const (
+	// SlidesFormatOpenLyrics is a zip of OpenLyrics XML files, one per song. OpenLP and others import them.
+	SlidesFormatOpenLyrics SlidesFormat = "openlyrics"
+	// SlidesFormatText is a zip of plain text files, one per song, with slides separated by blank lines.
+	// ProPresenter imports them with "one slide per paragraph".
+	SlidesFormatText SlidesFormat = "text"
+	// SlidesFormatPDF is a single PDF deck with a title slide and lyrics slides for each song.
+	SlidesFormatPDF SlidesFormat = "pdf"
+)
+
+func (f SlidesFormat) IsValid() bool {
+	switch f {
+	case SlidesFormatOpenLyrics, SlidesFormatText, SlidesFormatPDF:
+		return true
+	}
+	return false
+}
+
+// FileName returns the name of the export file with the extension of the format.
+func (f SlidesFormat) FileName(name string) string {
+	if f == SlidesFormatPDF {
+		return name + ".pdf"
+	}
+	return name + ".zip"
+}
+
+const openLyricsNamespace = "http://openlyrics.info/namespace/2009/song"
+
+type openLyricsSong struct {
+	XMLName      xml.Name             `xml:"song"`
+	Xmlns        string               `xml:"xmlns,attr"`
+	Version      string               `xml:"version,attr"`
+	CreatedIn    string               `xml:"createdIn,attr"`
+	ModifiedDate string               `xml:"modifiedDate,attr"`
+	Properties   openLyricsProperties `xml:"properties"`
+	Verses       []openLyricsVerse    `xml:"lyrics>verse"`
+}
+
+type openLyricsProperties struct {
+	Titles     []string         `xml:"titles>title"`
+	Authors    []string         `xml:"authors>author,omitempty"`
+	Copyright  string           `xml:"copyright,omitempty"`
+	CCLINo     string           `xml:"ccliNo,omitempty"`
+	Tempo      *openLyricsTempo `xml:"tempo,omitempty"`
+	Key        string           `xml:"key,omitempty"`
+	VerseOrder string           `xml:"verseOrder,omitempty"`
+}
+
+type openLyricsTempo struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type openLyricsVerse struct {
+	Name  string            `xml:"name,attr"`
+	Lines []openLyricsLines `xml:"lines"`
+}
+
+// openLyricsLines is a slide. Lines are separated by <br/>.
+type openLyricsLines struct {
+	InnerXML string `xml:",innerxml"`
+}
+
+// OpenLyricsXML renders song slides to the OpenLyrics 0.9 format.
+func OpenLyricsXML(slides *entity.SongSlides, modifiedAt time.Time) ([]byte, error) {
+	song := slides.Song
+
+	ol := openLyricsSong{
+		Xmlns:        openLyricsNamespace,
+		Version:      "0.9",
+		CreatedIn:    "scala-bot",
+		ModifiedDate: modifiedAt.UTC().Format("2006-01-02T15:04:05"),
+		Properties: openLyricsProperties{
+			Titles:     []string{song.PDF.Name},
+			Authors:    song.Authors,
+			Copyright:  song.Copyright,
+			CCLINo:     song.CCLINumber,
+			VerseOrder: strings.Join(slides.Order, " "),
+		},
+	}
+	if key := song.EffectiveKey(); key != "" && key != "?" {
+		ol.Properties.Key = string(key)
+	}
+	if bpm, ok := ParseBPM(song.EffectiveBPM()); ok {
+		ol.Properties.Tempo = &openLyricsTempo{Type: "bpm", Value: fmt.Sprintf("%g", bpm)}
+	}
+
+	for _, section := range slides.Sections {
+		verse := openLyricsVerse{Name: section.Name}
+		for _, slide := range section.Slides {
+			escaped := make([]string, 0, len(slide))
+			for _, line := range slide {
+				var b strings.Builder
+				if err := xml.EscapeText(&b, []byte(line)); err != nil {
+					return nil, err
+				}
+				escaped = append(escaped, b.String())
+			}
+			verse.Lines = append(verse.Lines, openLyricsLines{InnerXML: strings.Join(escaped, "<br/>")})
+		}
+		ol.Verses = append(ol.Verses, verse)
+	}
+
+	out, err := xml.MarshalIndent(ol, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// PlainTextSlides renders song slides in the order of sections, separated by blank lines.
+func PlainTextSlides(slides *entity.SongSlides) string {
+	var blocks []string
+	for _, name := range slides.Order {
+		section := slides.GetSection(name)
+		if section == nil {
+			continue
+		}
+		for _, slide := range section.Slides {
+			blocks = append(blocks, strings.Join(slide, "\n"))
+		}
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// WriteSlidesArchive writes a zip with a file for each song of the setlist in the given format.
+// Files are numbered in the setlist order.
+func WriteSlidesArchive(w io.Writer, setlist []*entity.SongSlides, format SlidesFormat, modifiedAt time.Time) error {
+	archive := zip.NewWriter(w)
+
+	for i, slides := range setlist {
+		var (
+			content []byte
+			ext     string
+			err     error
+		)
+		switch format {
+		case SlidesFormatOpenLyrics:
+			content, err = OpenLyricsXML(slides, modifiedAt)
+			ext = "xml"
+		case SlidesFormatText:
+			content = []byte(PlainTextSlides(slides))
+			ext = "txt"
+		default:
+			return fmt.Errorf("%w: unsupported slides format %q", ErrInvalidOperation, format)
+		}
+		if err != nil {
+			return err
+		}
+
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("%02d %s.%s", i+1, slidesFileName(slides.Song.PDF.Name), ext),
+			Method:   zip.Deflate,
+			Modified: modifiedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(content); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+var slidesFileNameReplacer = strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "", "?", "", "\"", "", "<", "", ">", "", "|", "")
+
+func slidesFileName(name string) string {
+	return strings.TrimSpace(slidesFileNameReplacer.Replace(name))
+}
+
+// Slides deck page is 16:9, text is big enough to be read from the back of the hall.
+const (
+	slidesDeckPageWidth  = 960
+	slidesDeckPageHeight = 540
+	slidesDeckMargin     = 36
+	slidesDeckFontSize   = 36
+	slidesDeckTitleSize  = 48
+)
+
+// CreateSlidesDoc creates a doc in the folder with a page for each slide of the setlist:
+// a title slide and lyrics slides in the order of sections for each song.
+// The doc can be exported to PDF. The caller should delete it after use.
+func (s *DriveFileService) CreateSlidesDoc(name string, setlist []*entity.SongSlides, folderID string) (*drive.File, error) {
+	file, err := s.driveClient.Files.
+		Create(&drive.File{
+			Name:     name,
+			MimeType: "application/vnd.google-apps.document",
+			Parents:  []string{folderID},
+		}).
+		Fields("id, name, version, webViewLink, parents").
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []slidesDeckPage
+	for _, slides := range setlist {
+		pages = append(pages, slidesDeckPage{text: slides.Song.PDF.Name, isTitle: true})
+		for _, sectionName := range slides.Order {
+			section := slides.GetSection(sectionName)
+			if section == nil {
+				continue
+			}
+			for _, slide := range section.Slides {
+				// Soft line breaks keep lines of the slide in one paragraph.
+				pages = append(pages, slidesDeckPage{text: strings.Join(slide, "\v")})
+			}
+		}
+	}
+
+	_, err = s.batchUpdate(file.Id, composeSlidesDeckRequests(pages))
+	if err != nil {
+		_ = s.DeleteOne(file.Id)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+type slidesDeckPage struct {
+	text    string
+	isTitle bool
+}
+
+// composeSlidesDeckRequests composes requests to fill an empty doc with pages separated by page breaks.
+func composeSlidesDeckRequests(pages []slidesDeckPage) []*docs.Request {
+	requests := []*docs.Request{
+		newUpdateDocumentStyleRequest(&docs.DocumentStyle{
+			Background: &docs.Background{Color: newOptionalColor(newRgbColor(0, 0, 0))},
+			PageSize: &docs.Size{
+				Width:  &docs.Dimension{Magnitude: slidesDeckPageWidth, Unit: "PT"},
+				Height: &docs.Dimension{Magnitude: slidesDeckPageHeight, Unit: "PT"},
+			},
+			MarginTop:    &docs.Dimension{Magnitude: slidesDeckMargin, Unit: "PT"},
+			MarginBottom: &docs.Dimension{Magnitude: slidesDeckMargin, Unit: "PT"},
+			MarginLeft:   &docs.Dimension{Magnitude: slidesDeckMargin, Unit: "PT"},
+			MarginRight:  &docs.Dimension{Magnitude: slidesDeckMargin, Unit: "PT"},
+		}, "background,pageSize,marginTop,marginBottom,marginLeft,marginRight"),
+	}
+
+	// Body of an empty doc starts at index 1. A page break is inserted with a new paragraph after it.
+	index := int64(1)
+	for i, page := range pages {
+		if i > 0 {
+			requests = append(requests, &docs.Request{InsertPageBreak: &docs.InsertPageBreakRequest{Location: newLocation(index, "")}})
+			index += 2
+		}
+
+		start := index
+		requests = append(requests, newInsertTextRequest(page.text, index, ""))
+		index += int64(len(utf16.Encode([]rune(page.text))))
+
+		fontSize := float64(slidesDeckFontSize)
+		if page.isTitle {
+			fontSize = slidesDeckTitleSize
+		}
+		requests = append(requests,
+			newUpdateTextStyleRequest(&docs.TextStyle{
+				Bold:            page.isTitle,
+				FontSize:        &docs.Dimension{Magnitude: fontSize, Unit: "PT"},
+				ForegroundColor: newOptionalColor(newRgbColor(1, 1, 1)),
+			}, "bold,fontSize,foregroundColor", start, index, ""),
+			newUpdateParagraphStyleRequest(&docs.ParagraphStyle{Alignment: "CENTER"}, "alignment", start, index, ""),
+		)
+	}
+
+	return requests
+}
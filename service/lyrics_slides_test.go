@@ -0,0 +1,144 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLyricsSlides(t *testing.T) {
+	lines := []string{
+		"Куплет 1:",
+		"A         D",
+		"Line one",
+		"Line two",
+		"",
+		"Line three",
+		"",
+		"Припев:",
+		"G    D",
+		"Chorus one",
+		"Chorus two",
+		"Chorus three",
+		"Chorus four",
+		"Chorus five",
+		"",
+		"Куплет 2",
+		"Verse two",
+		"",
+		"Припев x2",
+		"",
+		"Проигрыш",
+		"| A | D |",
+	}
+
+	sections, order := SplitLyricsSlides(lines, entity.SlideRules{MaxLines: 4})
+
+	assert.Equal(t, []string{"v1", "c", "v2", "c", "c"}, order)
+	assert.Len(t, sections, 3)
+	assert.Equal(t, [][]string{{"Line one", "Line two"}, {"Line three"}}, sections[0].Slides)
+	// Five lines are split evenly.
+	assert.Equal(t, [][]string{{"Chorus one", "Chorus two"}, {"Chorus three", "Chorus four", "Chorus five"}}, sections[1].Slides)
+	assert.Equal(t, [][]string{{"Verse two"}}, sections[2].Slides)
+
+	t.Run("ignore blank lines and uppercase", func(t *testing.T) {
+		sections, _ := SplitLyricsSlides(lines[:7], entity.SlideRules{MaxLines: 4, IgnoreBlankLines: true, Uppercase: true})
+		assert.Equal(t, [][]string{{"LINE ONE", "LINE TWO", "LINE THREE"}}, sections[0].Slides)
+	})
+
+	t.Run("no labels", func(t *testing.T) {
+		sections, order := SplitLyricsSlides([]string{"One", "Two", "", "Three", "", "Two"}, entity.SlideRules{})
+		assert.Equal(t, []string{"v1", "v2", "v3"}, order)
+		assert.Len(t, sections, 3)
+	})
+
+	t.Run("same label with other lyrics", func(t *testing.T) {
+		sections, order := SplitLyricsSlides([]string{"Chorus", "One", "", "Chorus", "Two"}, entity.SlideRules{})
+		assert.Equal(t, []string{"c", "c1b"}, order)
+		assert.Len(t, sections, 2)
+	})
+}
+
+func TestLyricsSlidesOrder(t *testing.T) {
+	sections := []*entity.LyricsSection{{Name: "v1"}, {Name: "c"}, {Name: "b1"}}
+
+	order, ok := LyricsSlidesOrder(sections, "I V1 C1 V2 C B C Inst C")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"v1", "c", "c", "b1", "c", "c"}, order)
+
+	_, ok = LyricsSlidesOrder(sections, "I Inst")
+	assert.False(t, ok)
+}
+
+func TestOpenLyricsXML(t *testing.T) {
+	slides := &entity.SongSlides{
+		Song: &entity.Song{
+			PDF:     entity.PDF{Name: "Song & Co", Key: "G", BPM: "72"},
+			Authors: []string{"Author"},
+		},
+		Sections: []*entity.LyricsSection{
+			{Name: "v1", Slides: [][]string{{"One <1>", "Two"}, {"Three"}}},
+		},
+		Order: []string{"v1", "v1"},
+	}
+
+	out, err := OpenLyricsXML(slides, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	assert.NoError(t, err)
+
+	xml := string(out)
+	assert.Contains(t, xml, `<song xmlns="http://openlyrics.info/namespace/2009/song" version="0.9" createdIn="scala-bot" modifiedDate="2026-01-02T03:04:05">`)
+	assert.Contains(t, xml, `<title>Song &amp; Co</title>`)
+	assert.Contains(t, xml, `<author>Author</author>`)
+	assert.Contains(t, xml, `<tempo type="bpm">72</tempo>`)
+	assert.Contains(t, xml, `<key>G</key>`)
+	assert.Contains(t, xml, `<verseOrder>v1 v1</verseOrder>`)
+	assert.Contains(t, xml, `<verse name="v1">`)
+	assert.Contains(t, xml, `<lines>One &lt;1&gt;<br/>Two</lines>`)
+	assert.NotContains(t, xml, `<copyright>`)
+}
+
+func TestWriteSlidesArchive(t *testing.T) {
+	setlist := []*entity.SongSlides{
+		{
+			Song:     &entity.Song{PDF: entity.PDF{Name: "First: song"}},
+			Sections: []*entity.LyricsSection{{Name: "v1", Slides: [][]string{{"One", "Two"}, {"Three"}}}, {Name: "c", Slides: [][]string{{"Chorus"}}}},
+			Order:    []string{"v1", "c", "c"},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteSlidesArchive(&buf, setlist, SlidesFormatText, time.Now())
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 1)
+	assert.Equal(t, "01 First- song.txt", archive.File[0].Name)
+
+	file, err := archive.File[0].Open()
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	_ = file.Close()
+	assert.Equal(t, "One\nTwo\n\nThree\n\nChorus\n\nChorus\n", string(content))
+
+	err = WriteSlidesArchive(&buf, setlist, SlidesFormatPDF, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidOperation)
+}
+
+func TestComposeSlidesDeckRequests(t *testing.T) {
+	requests := composeSlidesDeckRequests([]slidesDeckPage{{text: "Title", isTitle: true}, {text: "One\vTwo"}})
+
+	assert.NotNil(t, requests[0].UpdateDocumentStyle)
+	assert.Equal(t, int64(1), requests[1].InsertText.Location.Index)
+	assert.Equal(t, int64(6), requests[2].UpdateTextStyle.Range.EndIndex)
+	assert.True(t, requests[2].UpdateTextStyle.TextStyle.Bold)
+	assert.Equal(t, int64(6), requests[4].InsertPageBreak.Location.Index)
+	assert.Equal(t, int64(8), requests[5].InsertText.Location.Index)
+	assert.Equal(t, int64(15), requests[6].UpdateTextStyle.Range.EndIndex)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// ProcessedAudioEvictionInterval is how often old results are evicted from the audio cache.
+	ProcessedAudioEvictionInterval = time.Hour
+	// processedAudioTTL is how long the result is kept after it was used last time.
+	processedAudioTTL = 90 * 24 * time.Hour
+	// maxProcessedAudios limits the size of the audio cache, least recently used results are evicted first.
+	maxProcessedAudios = 10000
+)
+
+type ProcessedAudioService struct {
+	processedAudioRepository *repository.ProcessedAudioRepository
+}
+
+func NewProcessedAudioService(processedAudioRepository *repository.ProcessedAudioRepository) *ProcessedAudioService {
+	return &ProcessedAudioService{
+		processedAudioRepository: processedAudioRepository,
+	}
+}
+
+// FindOneByJob returns the result of the job processed earlier.
+// repository.ErrNotFound is returned if there is no such result.
+func (s *ProcessedAudioService) FindOneByJob(job *entity.AudioJob) (*entity.ProcessedAudio, error) {
+	if processedAudioSourceID(job) == "" {
+		return nil, repository.ErrNotFound
+	}
+	return s.processedAudioRepository.FindOneByKey(processedAudioKey(job))
+}
+
+// Hit counts the use of the result. It's called after the result was sent.
+func (s *ProcessedAudioService) Hit(ID bson.ObjectID) error {
+	return s.processedAudioRepository.Hit(ID)
+}
+
+// SaveJobResult saves the file ID of the job result sent to Telegram.
+func (s *ProcessedAudioService) SaveJobResult(job *entity.AudioJob, fileID string, fileSize int64, isDocument bool) (*entity.ProcessedAudio, error) {
+	if processedAudioSourceID(job) == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	audio := processedAudioKey(job)
+	audio.FileID = fileID
+	audio.FileSize = fileSize
+	audio.IsDocument = isDocument
+	audio.BandID = job.BandID
+	audio.CreatedAt = now
+	audio.LastUsedAt = now
+
+	return s.processedAudioRepository.UpdateOne(audio)
+}
+
+// DeleteOne deletes the result, e.g. if Telegram doesn't accept its file ID anymore.
+func (s *ProcessedAudioService) DeleteOne(ID bson.ObjectID) error {
+	return s.processedAudioRepository.DeleteOneByID(ID)
+}
+
+func (s *ProcessedAudioService) GetStatsByBandID(bandID bson.ObjectID) (*entity.ProcessedAudioStats, error) {
+	return s.processedAudioRepository.GetStatsByBandID(bandID)
+}
+
+// Evict deletes results unused for a long time and the least recently used ones above the cache limit.
+func (s *ProcessedAudioService) Evict() (int64, error) {
+	expired, err := s.processedAudioRepository.DeleteManyUnusedSince(time.Now().Add(-processedAudioTTL))
+	if err != nil {
+		return 0, err
+	}
+
+	overflow, err := s.processedAudioRepository.DeleteLeastRecentlyUsed(maxProcessedAudios)
+	if err != nil {
+		return expired, err
+	}
+
+	return expired + overflow, nil
+}
+
+// RunEviction evicts old results from the audio cache every interval.
+func (s *ProcessedAudioService) RunEviction(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		evicted, err := s.Evict()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to evict processed audios")
+		} else if evicted > 0 {
+			log.Info().Msgf("evicted %d processed audios", evicted)
+		}
+
+		<-ticker.C
+	}
+}
+
+func processedAudioKey(job *entity.AudioJob) entity.ProcessedAudio {
+	return entity.ProcessedAudio{
+		SourceFileUniqueID: processedAudioSourceID(job),
+		Semitones:          job.Semitones,
+		Fine:               job.Fine,
+		Tempo:              job.TempoPercent(),
+		IsVoice:            job.SendsVoice(),
+		Output:             processedAudioOutput(job),
+	}
+}
+
+// processedAudioOutput identifies the encoding of the job result.
+// Results in the original format without normalization have no output.
+func processedAudioOutput(job *entity.AudioJob) string {
+	if !job.NeedsEncoding() {
+		return ""
+	}
+
+	output := ""
+	if !job.SendsVoice() {
+		output = job.Output.Format.Extension()
+		if job.Output.Format != entity.AudioFormatOriginal {
+			output = string(job.Output.Format)
+		}
+	}
+	if job.Output.Normalize {
+		output += ":r128"
+	}
+	return output
+}
+
+// processedAudioSourceID identifies the source of the job result.
+// Parts of the audio are identified by the file and the part.
+// Mixes are identified by their stems, the settings of the stems and the click.
+func processedAudioSourceID(job *entity.AudioJob) string {
+	if !job.IsMix() {
+		if job.Trim != nil && job.Audio.FileUniqueID != "" {
+			return fmt.Sprintf("%s:trim:%d:%d:%d", job.Audio.FileUniqueID, job.Trim.Start, job.Trim.End, job.Trim.LoopCount())
+		}
+		return job.Audio.FileUniqueID
+	}
+
+	h := sha256.New()
+	for _, stem := range job.Stems {
+		if stem.Audio.FileUniqueID == "" {
+			return ""
+		}
+		fmt.Fprintf(h, "%s:%g:%g:%d;", stem.Audio.FileUniqueID, stem.Gain, stem.Pan, stem.Delay)
+	}
+	if job.Click != nil {
+		// The length of the click depends on the duration of the result.
+		// The click over the voice is aligned to it, older unaligned results are not reused.
+		fmt.Fprintf(h, "aligned-click:%g:%d:%d:%d;", job.Click.BPM, job.Click.BeatsPerBar, job.Click.CountInBars, job.Audio.Duration)
+	}
+	return "mix:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+)
+
+func TestProcessedAudioKey(t *testing.T) {
+	job := &entity.AudioJob{
+		Audio:     entity.AudioJobFile{FileID: "file-id", FileUniqueID: "unique-id", IsVoice: true},
+		Semitones: -2,
+		Fine:      true,
+	}
+
+	key := processedAudioKey(job)
+	want := entity.ProcessedAudio{SourceFileUniqueID: "unique-id", Semitones: -2, Fine: true, Tempo: 100, IsVoice: true}
+	if key != want {
+		t.Fatalf("got %+v, want %+v", key, want)
+	}
+
+	job.Tempo = 100
+	if processedAudioKey(job) != key {
+		t.Fatalf("jobs without tempo and with 100%% tempo must share the result")
+	}
+
+	job.Trim = &entity.AudioJobTrim{Start: 1000, End: 5000}
+	trimmed := processedAudioKey(job)
+	if trimmed == key {
+		t.Fatalf("part of the audio must not share the result with the whole audio")
+	}
+	job.Trim.Loops = 1
+	if processedAudioKey(job) != trimmed {
+		t.Fatalf("part without loops and with one loop must share the result")
+	}
+}
+
+func TestProcessedAudioKeyOfMix(t *testing.T) {
+	job := &entity.AudioJob{
+		Stems: []entity.AudioJobStem{
+			{Audio: entity.AudioJobFile{FileUniqueID: "soprano"}},
+			{Audio: entity.AudioJobFile{FileUniqueID: "alto"}, Gain: -10},
+		},
+	}
+
+	key := processedAudioKey(job)
+	if key.SourceFileUniqueID == "" {
+		t.Fatalf("mix must have the source ID")
+	}
+
+	job.Stems[1].Gain = -6
+	if processedAudioKey(job) == key {
+		t.Fatalf("mixes with different gains must not share the result")
+	}
+
+	withoutClick := processedAudioKey(job)
+	job.Click = &entity.AudioJobClick{BPM: 120, BeatsPerBar: 4}
+	if processedAudioKey(job) == withoutClick {
+		t.Fatalf("mixes with and without the click must not share the result")
+	}
+
+	job.Stems = nil
+	if processedAudioSourceID(job) == "" {
+		t.Fatalf("click must have the source ID")
+	}
+
+	job.Stems = []entity.AudioJobStem{{}}
+	if processedAudioSourceID(job) != "" {
+		t.Fatalf("mix with unknown stem must not be cached")
+	}
+}
+
+func TestProcessedAudioKeyOfOutput(t *testing.T) {
+	job := &entity.AudioJob{
+		Audio:     entity.AudioJobFile{FileUniqueID: "unique-id"},
+		Semitones: 2,
+	}
+
+	original := processedAudioKey(job)
+	if original.Output != "" {
+		t.Fatalf("original format must have no output, got %q", original.Output)
+	}
+
+	job.Output.Format = entity.AudioFormatFLAC
+	flac := processedAudioKey(job)
+	if flac.Output != "flac" {
+		t.Fatalf("got output %q, want %q", flac.Output, "flac")
+	}
+
+	job.Output.Normalize = true
+	if got := processedAudioKey(job).Output; got != "flac:r128" {
+		t.Fatalf("got output %q, want %q", got, "flac:r128")
+	}
+
+	job.Output.Format = entity.AudioFormatOriginal
+	if got := processedAudioKey(job).Output; got != "mp3:r128" {
+		t.Fatalf("got output %q, want %q", got, "mp3:r128")
+	}
+}
+
+func TestProcessedAudioKeyOfConvertedVoice(t *testing.T) {
+	job := &entity.AudioJob{
+		Audio:  entity.AudioJobFile{FileUniqueID: "unique-id", IsVoice: true},
+		Output: entity.AudioOutput{Format: entity.AudioFormatM4A},
+	}
+
+	voice := processedAudioKey(job)
+	if !voice.IsVoice || voice.Output != "" {
+		t.Fatalf("voice message must ignore the format, got %+v", voice)
+	}
+
+	job.ConvertVoice = true
+	converted := processedAudioKey(job)
+	if converted.IsVoice || converted.Output != "m4a" {
+		t.Fatalf("converted voice message must be the audio in the format, got %+v", converted)
+	}
+}
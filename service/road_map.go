@@ -0,0 +1,108 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sectionLabels maps section labels (en/ru/uk) to road-map abbreviations.
+// Longer labels go first, so "pre-chorus" is not detected as "chorus".
+var sectionLabels = []struct {
+	label        string
+	abbreviation string
+}{
+	{"pre-chorus", "PC"}, {"pre chorus", "PC"}, {"prechorus", "PC"},
+	{"пре-припев", "PC"}, {"предприпев", "PC"}, {"пре-приспів", "PC"}, {"передприспів", "PC"},
+	{"verse", "V"}, {"куплет", "V"},
+	{"chorus", "C"}, {"припев", "C"}, {"приспів", "C"},
+	{"bridge", "B"}, {"бридж", "B"}, {"брідж", "B"}, {"мост", "B"}, {"міст", "B"},
+	{"intro", "I"}, {"интро", "I"}, {"інтро", "I"}, {"вступление", "I"}, {"вступ", "I"},
+	{"instrumental", "Inst"}, {"interlude", "Inst"}, {"инструментал", "Inst"}, {"інструментал", "Inst"},
+	{"проигрыш", "Inst"}, {"програш", "Inst"},
+	{"outro", "O"}, {"ending", "O"}, {"coda", "O"}, {"аутро", "O"}, {"окончание", "O"},
+	{"концовка", "O"}, {"кінцівка", "O"}, {"закінчення", "O"}, {"кода", "O"},
+	{"tag", "T"}, {"тег", "T"}, {"тэг", "T"},
+}
+
+var (
+	sectionLabelLeadingNumberRegex = regexp.MustCompile(`^(\d+)\s*-?\s*`)
+	// Rest of the header after the label: optional number and optional repeat count, e.g. " 2", " x2", " 1 (2x)".
+	sectionLabelSuffixRegex = regexp.MustCompile(`^\s*(\d+)?\s*(?:\(?\s*[xх×]\s*(\d+)\s*\)?|\(?\s*(\d+)\s*[xх×]\s*\)?)?$`)
+)
+
+const maxSectionRepeat = 4
+
+// DetectRoadMap returns the order of song sections found in lyrics lines, e.g. "I V1 C V2 C B C C".
+func DetectRoadMap(lines []string) string {
+	var roadMap []string
+	for _, line := range lines {
+		abbreviations, ok := parseSectionLabel(line)
+		if ok {
+			roadMap = append(roadMap, abbreviations...)
+		}
+	}
+	return strings.Join(roadMap, " ")
+}
+
+// NormalizeRoadMap collapses whitespace in user-provided road-map.
+func NormalizeRoadMap(roadMap string) string {
+	return strings.Join(strings.Fields(roadMap), " ")
+}
+
+func parseSectionLabel(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || len([]rune(line)) > 30 {
+		return nil, false
+	}
+
+	line = strings.TrimSuffix(line, ":")
+	line = strings.TrimSpace(strings.Trim(line, "[]"))
+	line = strings.ToLower(line)
+
+	number := ""
+	if m := sectionLabelLeadingNumberRegex.FindStringSubmatch(line); m != nil {
+		number = m[1]
+		line = line[len(m[0]):]
+	}
+
+	for _, l := range sectionLabels {
+		rest, found := strings.CutPrefix(line, l.label)
+		if !found {
+			continue
+		}
+
+		m := sectionLabelSuffixRegex.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, false
+		}
+		if m[1] != "" {
+			number = m[1]
+		}
+
+		repeat := 1
+		for _, r := range m[2:] {
+			if n, err := strconv.Atoi(r); err == nil && n > 0 {
+				repeat = min(n, maxSectionRepeat)
+			}
+		}
+
+		abbreviations := make([]string, repeat)
+		for i := range abbreviations {
+			abbreviations[i] = l.abbreviation + number
+		}
+		return abbreviations, true
+	}
+
+	return nil, false
+}
+
+// DetectRoadMap finds section labels in the first section of the doc.
+func (s *DriveFileService) DetectRoadMap(ID string) (string, error) {
+	lines, err := s.GetLyricsLines(ID)
+	if err != nil {
+		return "", err
+	}
+
+	return DetectRoadMap(lines), nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDetectRoadMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{
+			name: "english",
+			lines: []string{
+				"Intro:", "G D Em C",
+				"Verse 1:", "Amazing grace", "how sweet the sound",
+				"Chorus:", "My chains are gone",
+				"Verse 2:", "Twas grace",
+				"Pre-Chorus:", "I once was lost",
+				"Chorus x2:", "My chains are gone",
+				"Bridge", "The earth shall soon",
+				"Outro:",
+			},
+			want: "I V1 C V2 PC C C B O",
+		},
+		{
+			name: "russian",
+			lines: []string{
+				"Вступление:",
+				"1 куплет:", "Ты достоин славы",
+				"Припев:", "Свят, свят, свят",
+				"Куплет 2:", "Ты мой Господь",
+				"Припев (2x):",
+				"Мост:",
+				"Проигрыш:",
+				"Концовка:",
+			},
+			want: "I V1 C V2 C C B Inst O",
+		},
+		{
+			name: "ukrainian",
+			lines: []string{
+				"[Куплет 1]", "Ти гідний слави",
+				"Приспів:", "Святий, святий",
+				"Міст:",
+				"Приспів х2",
+				"Кінцівка:",
+			},
+			want: "V1 C B C C O",
+		},
+		{
+			name: "lyrics that start with a label are ignored",
+			lines: []string{
+				"Chorus of angels sings tonight:",
+				"Мостом через реку",
+				"Verse: with lyrics on the same line",
+			},
+			want: "",
+		},
+		{
+			name:  "repeat is limited",
+			lines: []string{"Chorus x10:"},
+			want:  "C C C C",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectRoadMap(tt.lines))
+		})
+	}
+}
+
+func TestNormalizeRoadMap(t *testing.T) {
+	assert.Equal(t, "V1 C V2 C", NormalizeRoadMap("  V1  C\nV2\tC "))
+	assert.Equal(t, "", NormalizeRoadMap("   "))
+}
+
+func TestHTMLStringForEventRoadMap(t *testing.T) {
+	arrangementID := bson.NewObjectID()
+
+	withDefault := &entity.Song{ID: bson.NewObjectID(), PDF: entity.PDF{Name: "First"}, RoadMap: "V1 C V2 C"}
+	withOverride := &entity.Song{ID: bson.NewObjectID(), PDF: entity.PDF{Name: "Second"}, RoadMap: "V C"}
+	withArrangement := &entity.Song{
+		ID:           bson.NewObjectID(),
+		PDF:          entity.PDF{Name: "Third"},
+		RoadMap:      "V C B C",
+		Arrangements: []*entity.Arrangement{{ID: arrangementID, Name: "Youth", Structure: "V C C"}},
+	}
+	withArrangement.Arrangement = withArrangement.Arrangements[0]
+
+	event := entity.Event{
+		Name: "Sunday",
+		SongOverrides: []entity.SongOverride{
+			{SongID: withOverride.ID, RoadMap: "C V C <B>"},
+			{SongID: withArrangement.ID, ArrangementID: &arrangementID},
+		},
+	}
+
+	text := HTMLStringForEvent(event, []*entity.Song{withDefault, withOverride, withArrangement}, "ru")
+
+	assert.Contains(t, text, "First</a>  (, , )\n    <i>V1 C V2 C</i>")
+	assert.Contains(t, text, "Second</a>  (, , )\n    <i>C V C &lt;B&gt;</i>")
+	assert.Contains(t, text, "Third [Youth]</a>  (, , )\n    <i>V C C</i>")
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/drive/v3"
+)
+
+// songImportConcurrency is how many docs are imported at once. Every doc takes several Drive and Docs calls.
+const songImportConcurrency = 4
+
+type SongImportService struct {
+	songImportRepository *repository.SongImportRepository
+	bandRepository       *repository.BandRepository
+	songRepository       *repository.SongRepository
+	songService          *SongService
+	driveFileService     *DriveFileService
+}
+
+func NewSongImportService(songImportRepository *repository.SongImportRepository, bandRepository *repository.BandRepository,
+	songRepository *repository.SongRepository, songService *SongService, driveFileService *DriveFileService,
+) *SongImportService {
+	return &SongImportService{
+		songImportRepository: songImportRepository,
+		bandRepository:       bandRepository,
+		songRepository:       songRepository,
+		songService:          songService,
+		driveFileService:     driveFileService,
+	}
+}
+
+func (s *SongImportService) FindOneByID(ID bson.ObjectID) (*entity.SongImport, error) {
+	return s.songImportRepository.FindOneByID(ID)
+}
+
+// Start creates an import of the band Drive folder or continues the last one if it has failed.
+// Only one import of the band can run at a time, ErrAlreadyExists is returned with the running import otherwise.
+func (s *SongImportService) Start(band *entity.Band, userID, chatID, messageID int64, lang string) (*entity.SongImport, error) {
+	if band == nil || band.DriveFolderID == "" {
+		return nil, fmt.Errorf("%w: band has no drive folder", ErrInvalidOperation)
+	}
+
+	last, err := s.songImportRepository.FindLastByBandID(band.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if last != nil {
+		switch last.Status {
+		case entity.SongImportRunning:
+			return last, ErrAlreadyExists
+		case entity.SongImportFailed:
+			last.UserID = userID
+			last.ChatID = chatID
+			last.MessageID = messageID
+			last.LanguageCode = lang
+			last.Status = entity.SongImportRunning
+			last.Error = ""
+			last.FinishedAt = nil
+			return s.startOne(*last)
+		}
+	}
+
+	return s.startOne(entity.SongImport{
+		BandID:       band.ID,
+		UserID:       userID,
+		ChatID:       chatID,
+		MessageID:    messageID,
+		LanguageCode: lang,
+		Status:       entity.SongImportRunning,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+}
+
+// startOne saves the running import. The unique index on the running imports of the band
+// rejects it if another /import has started one in the meantime.
+func (s *SongImportService) startOne(songImport entity.SongImport) (*entity.SongImport, error) {
+	songImport.UpdatedAt = time.Now()
+	started, err := s.songImportRepository.UpdateOne(songImport)
+	if mongo.IsDuplicateKeyError(err) {
+		running, err := s.songImportRepository.FindLastByBandID(songImport.BandID)
+		if err != nil {
+			return nil, err
+		}
+		return running, ErrAlreadyExists
+	}
+	return started, err
+}
+
+// FindManyRunning returns imports that were interrupted by restart and should be resumed.
+func (s *SongImportService) FindManyRunning() ([]*entity.SongImport, error) {
+	imports, err := s.songImportRepository.FindManyRunning()
+	if errors.Is(err, repository.ErrNotFound) {
+		return []*entity.SongImport{}, nil
+	}
+	return imports, err
+}
+
+// Run imports the band Drive folder page by page, continuing from the saved page token.
+// Progress is saved after each page, so an interrupted import can be run again without starting over.
+// onProgress is called after each page and when the import is finished.
+func (s *SongImportService) Run(songImport *entity.SongImport, onProgress func(*entity.SongImport)) error {
+	band, err := s.bandRepository.FindOneByID(songImport.BandID)
+	if err != nil {
+		return s.fail(songImport, err, onProgress)
+	}
+
+	for {
+		files, nextPageToken, err := s.driveFileService.FindAllByFolderID(band.DriveFolderID, songImport.PageToken)
+		if err != nil {
+			return s.fail(songImport, err, onProgress)
+		}
+
+		result := importSongsPage(files, songImportConcurrency, s.importOne)
+		songImport.Processed += len(files)
+		songImport.Created += result.created
+		for _, fileID := range result.failedFileIDs {
+			songImport.AddFailed(fileID)
+		}
+
+		songImport.PageToken = nextPageToken
+		if nextPageToken == "" {
+			songImport.Status = entity.SongImportDone
+			songImport.FinishedAt = new(time.Now())
+		}
+
+		songImport, err = s.save(songImport)
+		if err != nil {
+			return err
+		}
+		onProgress(songImport)
+
+		if songImport.Status == entity.SongImportDone {
+			return nil
+		}
+	}
+}
+
+// importOne normalizes metadata layout of the doc and creates or syncs its song.
+// Returns true if the song was created.
+func (s *SongImportService) importOne(file *drive.File) (bool, error) {
+	if err := s.driveFileService.NormalizeMetadataLayout(file.Id); err != nil {
+		// The song is still useful without the canonical layout, metadata is read from the old one.
+		log.Warn().Err(err).Str("driveFileID", file.Id).Msg("failed to normalize metadata layout")
+	} else if freshFile, err := s.driveFileService.FindOneByID(file.Id); err == nil {
+		// Normalization creates a new version of the doc.
+		file = freshFile
+	}
+
+	_, err := s.songRepository.FindOneByDriveFileID(file.Id)
+	created := errors.Is(err, repository.ErrNotFound)
+	if err != nil && !created {
+		return false, err
+	}
+
+	_, err = s.songService.FindOrCreateOneByDriveFile(file)
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func (s *SongImportService) fail(songImport *entity.SongImport, cause error, onProgress func(*entity.SongImport)) error {
+	songImport.Status = entity.SongImportFailed
+	songImport.Error = cause.Error()
+	songImport.FinishedAt = new(time.Now())
+
+	songImport, err := s.save(songImport)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to save song import")
+	} else {
+		onProgress(songImport)
+	}
+	return cause
+}
+
+func (s *SongImportService) save(songImport *entity.SongImport) (*entity.SongImport, error) {
+	songImport.UpdatedAt = time.Now()
+	return s.songImportRepository.UpdateOne(*songImport)
+}
+
+type songImportPageResult struct {
+	created       int
+	failedFileIDs []string
+}
+
+// importSongsPage imports files of the page with bounded parallelism.
+// One broken doc shouldn't stop the whole import, failed files are collected in the page order.
+func importSongsPage(files []*drive.File, concurrency int, importOne func(*drive.File) (bool, error)) songImportPageResult {
+	var (
+		mu      sync.Mutex
+		created int
+		failed  = make([]bool, len(files))
+	)
+
+	errwg := new(errgroup.Group)
+	errwg.SetLimit(concurrency)
+	for i, file := range files {
+		errwg.Go(func() error {
+			isCreated, err := importOne(file)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Warn().Err(err).Str("driveFileID", file.Id).Msg("failed to import song")
+				failed[i] = true
+				return nil
+			}
+			if isCreated {
+				created++
+			}
+			return nil
+		})
+	}
+	_ = errwg.Wait()
+
+	result := songImportPageResult{created: created}
+	for i, file := range files {
+		if failed[i] {
+			result.failedFileIDs = append(result.failedFileIDs, file.Id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestImportSongsPage(t *testing.T) {
+	files := []*drive.File{{Id: "a"}, {Id: "b"}, {Id: "c"}, {Id: "d"}, {Id: "e"}}
+
+	var running, maxRunning atomic.Int32
+	result := importSongsPage(files, 2, func(file *drive.File) (bool, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		switch file.Id {
+		case "b", "d":
+			return false, errors.New("broken doc")
+		case "a":
+			return true, nil
+		}
+		return false, nil
+	})
+
+	assert.Equal(t, 1, result.created)
+	assert.Equal(t, []string{"b", "d"}, result.failedFileIDs)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestSongImportAddFailed(t *testing.T) {
+	songImport := &entity.SongImport{}
+	for range entity.MaxSongImportFailedFiles + 5 {
+		songImport.AddFailed("id")
+	}
+
+	assert.Equal(t, entity.MaxSongImportFailedFiles+5, songImport.Failed)
+	assert.Len(t, songImport.FailedFileIDs, entity.MaxSongImportFailedFiles)
+}
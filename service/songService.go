@@ -3,12 +3,14 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/flowchartsman/retry"
 	"github.com/joeyave/scala-bot/entity"
 	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"golang.org/x/sync/errgroup"
@@ -19,22 +21,26 @@ import (
 type TransposeHandler func(freshSong *entity.Song, override *entity.SongOverride) (freshSongWithAltPDF *entity.Song, transposedDriveFile *drive.File, err error)
 
 type SongService struct {
-	songRepository   *repository.SongRepository
-	voiceRepository  *repository.VoiceRepository
-	bandRepository   *repository.BandRepository
-	driveRepository  *drive.Service
-	driveFileService *DriveFileService
+	songRepository            *repository.SongRepository
+	voiceRepository           *repository.VoiceRepository
+	bandRepository            *repository.BandRepository
+	eventRepository           *repository.EventRepository
+	songSearchIndexRepository *repository.SongSearchIndexRepository
+	driveRepository           *drive.Service
+	driveFileService          *DriveFileService
 }
 
 func NewSongService(songRepository *repository.SongRepository, voiceRepository *repository.VoiceRepository, bandRepository *repository.BandRepository,
-	driveClient *drive.Service, driveFileService *DriveFileService,
+	eventRepository *repository.EventRepository, songSearchIndexRepository *repository.SongSearchIndexRepository, driveClient *drive.Service, driveFileService *DriveFileService,
 ) *SongService {
 	return &SongService{
-		songRepository:   songRepository,
-		voiceRepository:  voiceRepository,
-		bandRepository:   bandRepository,
-		driveRepository:  driveClient,
-		driveFileService: driveFileService,
+		songRepository:            songRepository,
+		voiceRepository:           voiceRepository,
+		bandRepository:            bandRepository,
+		eventRepository:           eventRepository,
+		songSearchIndexRepository: songSearchIndexRepository,
+		driveRepository:           driveClient,
+		driveFileService:          driveFileService,
 	}
 }
 
@@ -54,6 +60,29 @@ func (s *SongService) FindManyExtraLiked(bandID bson.ObjectID, userID int64, eve
 	return s.songRepository.FindManyExtraByPageNumberLiked(bandID, userID, eventsStartDate, pageNumber)
 }
 
+// FindDriveFilesByInfo finds drive files of band songs whose authors, copyright, CCLI number or language match the query.
+func (s *SongService) FindDriveFilesByInfo(bandID bson.ObjectID, query string) ([]*drive.File, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+
+	songs, err := s.songRepository.FindManyByInfo(bandID, query)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	driveFileIDs := make([]string, 0, len(songs))
+	for _, song := range songs {
+		driveFileIDs = append(driveFileIDs, song.DriveFileID)
+	}
+
+	return s.driveFileService.FindManyByIDs(driveFileIDs)
+}
+
 func (s *SongService) FindOneByID(ID bson.ObjectID) (*entity.Song, error) {
 	return s.songRepository.FindOneByID(ID)
 }
@@ -112,6 +141,15 @@ func (s *SongService) FindOrCreateOneByDriveFile(driveFile *drive.File) (*entity
 		song.PDF.TgFileID = ""
 		song.PDF.Version = driveFile.Version
 		song.PDF.WebViewLink = driveFile.WebViewLink
+
+		// Road-map is detected only once, so it doesn't override the one edited by user.
+		if song.RoadMap == "" {
+			roadMap, err := s.driveFileService.DetectRoadMap(driveFile.Id)
+			if err != nil {
+				log.Warn().Err(err).Str("driveFileID", driveFile.Id).Msg("failed to detect road-map")
+			}
+			song.RoadMap = roadMap
+		}
 	}
 
 	// 5. Persist song
@@ -122,6 +160,18 @@ func (s *SongService) FindOrCreateOneByDriveFile(driveFile *drive.File) (*entity
 		}
 	}
 
+	// 6. Keep search index up to date, songs that were never indexed get indexed here too.
+	if needsSave {
+		s.indexSongAsync(song)
+	} else {
+		indexed, err := s.songSearchIndexRepository.FindIndexedDriveFileIDs([]string{song.DriveFileID})
+		if err != nil {
+			log.Warn().Err(err).Str("driveFileID", song.DriveFileID).Msg("failed to check song search index")
+		} else if !indexed[song.DriveFileID] {
+			s.indexSongAsync(song)
+		}
+	}
+
 	return song, nil
 }
 
@@ -176,7 +226,14 @@ func (s *SongService) FindOrCreateManyByDriveFileIDs(driveFileIDs []string) ([]*
 }
 
 func (s *SongService) UpdateOne(song entity.Song) (*entity.Song, error) {
-	return s.songRepository.UpdateOne(song)
+	newSong, err := s.songRepository.UpdateOne(song)
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexSongAsync(newSong)
+
+	return newSong, nil
 }
 
 func (s *SongService) SyncPDFMetadataByDriveFileID(driveFileID string) (*entity.Song, *drive.File, error) {
@@ -218,6 +275,7 @@ func (s *SongService) SyncPDFMetadataByDriveFileID(driveFileID string) (*entity.
 		if err != nil {
 			return nil, nil, err
 		}
+		s.indexSongAsync(song)
 	}
 
 	return song, driveFile, nil
@@ -252,6 +310,8 @@ func (s *SongService) DeleteOneByDriveFileID(driveFileID string) error {
 		return err
 	}
 
+	s.deleteSongSearchIndex(driveFileID)
+
 	return nil
 }
 
@@ -262,17 +322,33 @@ func (s *SongService) DeleteOneByDriveFileIDFromDatabase(driveFileID string) (bo
 		return deleted, err
 	}
 
+	s.deleteSongSearchIndex(driveFileID)
+
 	return deleted, nil
 }
 
 func (s *SongService) Like(songID bson.ObjectID, userID int64) error {
-	return s.songRepository.Like(songID, userID, time.Now().UTC())
+	err := s.songRepository.Like(songID, userID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s.indexSongByIDAsync(songID)
+
+	return nil
 }
 
 const archiveFolderName = "Archive"
 
 func (s *SongService) Dislike(songID bson.ObjectID, userID int64) error {
-	return s.songRepository.Dislike(songID, userID)
+	err := s.songRepository.Dislike(songID, userID)
+	if err != nil {
+		return err
+	}
+
+	s.indexSongByIDAsync(songID)
+
+	return nil
 }
 
 func (s *SongService) FindOneWithExtraByID(songID bson.ObjectID, eventsStartDate time.Time) (*entity.SongWithEvents, error) {
@@ -305,6 +381,7 @@ func (s *SongService) Archive(songID bson.ObjectID) (*drive.File, error) {
 	}
 
 	_ = s.songRepository.Archive(songID)
+	s.indexSongByIDAsync(songID)
 
 	return driveFile, err
 }
@@ -321,6 +398,7 @@ func (s *SongService) Unarchive(songID bson.ObjectID) (*drive.File, error) {
 	}
 
 	_ = s.songRepository.Unarchive(songID)
+	s.indexSongByIDAsync(songID)
 
 	return driveFile, err
 }
@@ -354,7 +432,14 @@ func (s *SongService) GetTags(bandID bson.ObjectID) ([]string, error) {
 }
 
 func (s *SongService) TagOrUntag(tag string, songID bson.ObjectID) (*entity.Song, error) {
-	return s.songRepository.TagOrUntag(tag, songID)
+	song, err := s.songRepository.TagOrUntag(tag, songID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.indexSongAsync(song)
+
+	return song, nil
 }
 
 func (s *SongService) RetrieveFreshSongsForEvent(event *entity.Event) ([]*entity.Song, error) {
@@ -362,6 +447,30 @@ func (s *SongService) RetrieveFreshSongsForEvent(event *entity.Event) ([]*entity
 	return songs, err
 }
 
+// eventSongOverride sets the arrangement chosen for the event on the song and returns the override
+// with the key the song should be transposed to. Arrangement key is used if the event key is not set.
+// Arrangements with a separate doc are never transposed.
+func eventSongOverride(event *entity.Event, song *entity.Song) *entity.SongOverride {
+	override := event.GetSongOverride(song.ID)
+	if override == nil {
+		return nil
+	}
+
+	if override.ArrangementID != nil {
+		song.Arrangement = song.GetArrangement(*override.ArrangementID)
+	}
+
+	if song.Arrangement.HasDoc() {
+		return nil
+	}
+
+	if override.EventKey == "" && song.Arrangement != nil {
+		override.EventKey = song.Arrangement.Key
+	}
+
+	return override
+}
+
 func (s *SongService) RetrieveFreshSongsForEventWithTransposeHandler(
 	event *entity.Event, transposeHandler TransposeHandler,
 ) ([]*entity.Song, []*drive.File, error) {
@@ -378,11 +487,11 @@ func (s *SongService) RetrieveFreshSongsForEventWithTransposeHandler(
 				return err
 			}
 
-			// Check if this event has a specific key override for this song.
-			override := event.GetSongOverride(freshSong.ID)
+			// Check if this event has a specific key or arrangement override for this song.
+			override := eventSongOverride(event, freshSong)
 
 			// Only process if there's an override AND the key differs from the original.
-			if override != nil && override.EventKey != freshSong.PDF.Key {
+			if override != nil && override.EventKey != "" && override.EventKey != freshSong.PDF.Key {
 				// First, check if we already have a cached PDF in the desired key.
 				altPDF, ok := freshSong.AltPDFs[override.EventKey]
 				if ok && altPDF.Version == freshSong.PDF.Version {
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// AddArrangement creates a new arrangement of the song.
+func (s *SongService) AddArrangement(songID bson.ObjectID, arrangement entity.Arrangement) (*entity.Song, error) {
+	song, err := s.songRepository.FindOneByID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	arrangement.ID = bson.NewObjectID()
+	err = s.validateArrangement(song, &arrangement)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.songRepository.PushArrangement(songID, &arrangement)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.songRepository.FindOneByID(songID)
+}
+
+// UpdateArrangement replaces the arrangement of the song with the same ID.
+func (s *SongService) UpdateArrangement(songID bson.ObjectID, arrangement entity.Arrangement) (*entity.Song, error) {
+	song, err := s.songRepository.FindOneByID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	if song.GetArrangement(arrangement.ID) == nil {
+		return nil, ErrInvalidOperation
+	}
+
+	err = s.validateArrangement(song, &arrangement)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.songRepository.UpdateArrangement(songID, &arrangement)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.songRepository.FindOneByID(songID)
+}
+
+// DeleteArrangement removes the arrangement from the song and from all events it was chosen for.
+// Such events fall back to the song itself.
+func (s *SongService) DeleteArrangement(songID, arrangementID bson.ObjectID) (*entity.Song, error) {
+	err := s.songRepository.PullArrangement(songID, arrangementID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.eventRepository.UnsetArrangementID(arrangementID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.songRepository.FindOneByID(songID)
+}
+
+func (s *SongService) validateArrangement(song *entity.Song, arrangement *entity.Arrangement) error {
+	normalizeArrangement(arrangement)
+
+	if arrangement.Name == "" {
+		return ErrInvalidOperation
+	}
+
+	for _, other := range song.Arrangements {
+		if other.ID != arrangement.ID && strings.EqualFold(other.Name, arrangement.Name) {
+			return ErrAlreadyExists
+		}
+	}
+
+	// Make sure a new separate doc is accessible.
+	existing := song.GetArrangement(arrangement.ID)
+	if arrangement.DriveFileID != "" && (existing == nil || existing.DriveFileID != arrangement.DriveFileID) {
+		_, err := s.driveFileService.FindOneByID(arrangement.DriveFileID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueArrangementName returns the name, or the name with a number if the song already has an arrangement with it.
+func uniqueArrangementName(arrangements []*entity.Arrangement, name string) string {
+	taken := func(name string) bool {
+		return slices.ContainsFunc(arrangements, func(a *entity.Arrangement) bool {
+			return strings.EqualFold(a.Name, name)
+		})
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return unique
+}
+
+func normalizeArrangement(arrangement *entity.Arrangement) {
+	arrangement.Name = strings.TrimSpace(arrangement.Name)
+	arrangement.Structure = strings.Join(strings.Fields(arrangement.Structure), " ")
+	arrangement.Key = entity.Key(strings.TrimSpace(string(arrangement.Key)))
+	arrangement.BPM = strings.TrimSpace(arrangement.BPM)
+	arrangement.Time = strings.TrimSpace(arrangement.Time)
+	arrangement.DriveFileID = strings.TrimSpace(arrangement.DriveFileID)
+}
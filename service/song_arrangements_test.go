@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestEventSongOverride(t *testing.T) {
+	acousticID := bson.NewObjectID()
+	youthID := bson.NewObjectID()
+	unknownID := bson.NewObjectID()
+
+	newSong := func() *entity.Song {
+		return &entity.Song{
+			ID:  bson.NewObjectID(),
+			PDF: entity.PDF{Name: "Song", Key: "C", BPM: "72", Time: "4/4"},
+			Arrangements: []*entity.Arrangement{
+				{ID: acousticID, Name: "Acoustic", Key: "D", BPM: "68"},
+				{ID: youthID, Name: "Youth", DriveFileID: "youth-doc", Key: "E"},
+			},
+		}
+	}
+
+	t.Run("no override", func(t *testing.T) {
+		song := newSong()
+		override := eventSongOverride(&entity.Event{}, song)
+		assert.Nil(t, override)
+		assert.Nil(t, song.Arrangement)
+		assert.Equal(t, "C, 72, 4/4", song.Meta())
+	})
+
+	t.Run("arrangement key is used when event key is empty", func(t *testing.T) {
+		song := newSong()
+		event := &entity.Event{SongOverrides: []entity.SongOverride{{SongID: song.ID, ArrangementID: &acousticID}}}
+		override := eventSongOverride(event, song)
+		assert.Equal(t, entity.Key("D"), override.EventKey)
+		assert.Equal(t, "Song [Acoustic]", song.NameWithArrangement())
+		assert.Equal(t, "D, 68, 4/4", song.Meta())
+		assert.Empty(t, event.SongOverrides[0].EventKey)
+	})
+
+	t.Run("event key wins over arrangement key", func(t *testing.T) {
+		song := newSong()
+		event := &entity.Event{SongOverrides: []entity.SongOverride{{SongID: song.ID, EventKey: "G", ArrangementID: &acousticID}}}
+		override := eventSongOverride(event, song)
+		assert.Equal(t, entity.Key("G"), override.EventKey)
+	})
+
+	t.Run("arrangement with separate doc is not transposed", func(t *testing.T) {
+		song := newSong()
+		event := &entity.Event{SongOverrides: []entity.SongOverride{{SongID: song.ID, EventKey: "G", ArrangementID: &youthID}}}
+		override := eventSongOverride(event, song)
+		assert.Nil(t, override)
+		assert.Equal(t, "youth-doc", song.GetDriveFileID())
+		assert.Empty(t, song.GetTgFileID())
+		assert.Equal(t, "E, 72, 4/4", song.Meta())
+	})
+
+	t.Run("unknown arrangement falls back to song", func(t *testing.T) {
+		song := newSong()
+		event := &entity.Event{SongOverrides: []entity.SongOverride{{SongID: song.ID, ArrangementID: &unknownID}}}
+		override := eventSongOverride(event, song)
+		assert.Empty(t, override.EventKey)
+		assert.Nil(t, song.Arrangement)
+		assert.Equal(t, "Song", song.NameWithArrangement())
+	})
+}
+
+func TestNormalizeArrangement(t *testing.T) {
+	arrangement := &entity.Arrangement{
+		Name:      "  Acoustic ",
+		Structure: "V1  C\nV2 C\n B  C ",
+		Key:       " D ",
+	}
+
+	normalizeArrangement(arrangement)
+
+	assert.Equal(t, "Acoustic", arrangement.Name)
+	assert.Equal(t, "V1 C V2 C B C", arrangement.Structure)
+	assert.Equal(t, entity.Key("D"), arrangement.Key)
+}
+
+func TestUniqueArrangementName(t *testing.T) {
+	arrangements := []*entity.Arrangement{{Name: "Acoustic"}, {Name: "acoustic (2)"}, {Name: "Full band"}}
+
+	assert.Equal(t, "Christmas", uniqueArrangementName(arrangements, "Christmas"))
+	assert.Equal(t, "Full band (2)", uniqueArrangementName(arrangements, "Full band"))
+	assert.Equal(t, "Acoustic (3)", uniqueArrangementName(arrangements, "Acoustic"))
+}
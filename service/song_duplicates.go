@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hbollon/go-edlib"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// Songs with names this similar are duplicates regardless of lyrics.
+	duplicateNameThreshold = 0.9
+	// Songs with names at least this similar are compared by lyrics.
+	duplicateCandidateNameThreshold = 0.6
+	duplicateLyricsThreshold        = 0.75
+
+	// Only the beginning of the lyrics is compared, this is enough to tell songs apart
+	// and keeps Levenshtein distance cheap.
+	duplicateLyricsPrefixLen = 500
+)
+
+var (
+	bracketsRegex   = regexp.MustCompile(`\(.*?\)|\[.*?]`)
+	chordTokenRegex = regexp.MustCompile(`^[A-H][#b]?(m|maj|min|dim|aug|sus|add)?\d*(/[A-H][#b]?)?$`)
+)
+
+// normalizeSongName returns lowercased song title without the artist, brackets, punctuation and extra spaces.
+// "Way Maker (Live) - Sinach" -> "way maker".
+func normalizeSongName(name string) string {
+	title, _, _ := strings.Cut(name, " - ")
+	title = bracketsRegex.ReplaceAllString(title, " ")
+
+	return normalizeText(title)
+}
+
+// normalizeLyrics drops metadata, chord lines and section headers from the plain text export of the doc
+// and returns lowercased words separated by a single space.
+func normalizeLyrics(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if line == "" || metadataLineRegex.MatchString(line) || isCreditsLine(line) || isChordLine(line) || isSectionHeaderLine(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return normalizeText(strings.Join(lines, " "))
+}
+
+func normalizeText(text string) string {
+	text = strings.ToLower(text)
+	text = strings.ReplaceAll(text, "ё", "е")
+
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, text)
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func isChordLine(line string) bool {
+	fields := strings.Fields(strings.NewReplacer("|", " ", "-", " ").Replace(line))
+	if len(fields) == 0 {
+		return false
+	}
+	for _, f := range fields {
+		if !chordTokenRegex.MatchString(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSectionHeaderLine(line string) bool {
+	return strings.HasSuffix(line, ":") && len([]rune(line)) <= 30
+}
+
+func textSimilarity(a, b string) float32 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	similarity, err := edlib.StringsSimilarity(a, b, edlib.Levenshtein)
+	if err != nil {
+		return 0
+	}
+	return similarity
+}
+
+// nameSimilarity ignores spaces, so "Way Maker" and "Waymaker" are the same name.
+func nameSimilarity(a, b string) float32 {
+	return textSimilarity(strings.ReplaceAll(a, " ", ""), strings.ReplaceAll(b, " ", ""))
+}
+
+func lyricsSimilarity(a, b string) float32 {
+	return textSimilarity(truncateRunes(a, duplicateLyricsPrefixLen), truncateRunes(b, duplicateLyricsPrefixLen))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// isDuplicate decides if two songs are the same song by their name and lyrics similarity.
+// lyricsSim is negative if lyrics were not compared.
+func isDuplicate(nameSim, lyricsSim float32) bool {
+	if nameSim >= duplicateNameThreshold {
+		return true
+	}
+	return nameSim >= duplicateCandidateNameThreshold && lyricsSim >= duplicateLyricsThreshold
+}
+
+// songToKeep returns the song that should survive the merge: the one with more voices and likes, or the older one.
+func songToKeep(a, b *entity.Song) (keep, merge *entity.Song) {
+	aWeight := len(a.Voices) + len(a.Likes)
+	bWeight := len(b.Voices) + len(b.Likes)
+
+	if bWeight > aWeight || (bWeight == aWeight && b.ID.Timestamp().Before(a.ID.Timestamp())) {
+		return b, a
+	}
+	return a, b
+}
+
+// FindDuplicates finds pairs of not archived songs of the band that are likely the same song.
+// Names are compared first, lyrics are downloaded only for songs with similar names.
+func (s *SongService) FindDuplicates(bandID bson.ObjectID) ([]*entity.SongDuplicate, error) {
+	songs, err := s.songRepository.FindManyNotArchivedByBandID(bandID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(songs))
+	for i, song := range songs {
+		names[i] = normalizeSongName(song.PDF.Name)
+	}
+
+	lyrics := make(map[string]string)
+	getLyrics := func(song *entity.Song) (string, bool) {
+		l, ok := lyrics[song.DriveFileID]
+		if ok {
+			return l, l != ""
+		}
+
+		text, err := s.driveFileService.GetLyrics(song.DriveFileID)
+		if err != nil {
+			log.Warn().Err(err).Str("driveFileID", song.DriveFileID).Msg("failed to get lyrics for duplicate detection")
+		}
+		l = normalizeLyrics(text)
+		lyrics[song.DriveFileID] = l
+
+		return l, l != ""
+	}
+
+	var duplicates []*entity.SongDuplicate
+	for i := range songs {
+		for j := i + 1; j < len(songs); j++ {
+			nameSim := nameSimilarity(names[i], names[j])
+			if nameSim < duplicateCandidateNameThreshold {
+				continue
+			}
+
+			var lyricsSim float32 = -1
+			if nameSim < duplicateNameThreshold {
+				l1, ok1 := getLyrics(songs[i])
+				l2, ok2 := getLyrics(songs[j])
+				if ok1 && ok2 {
+					lyricsSim = lyricsSimilarity(l1, l2)
+				}
+			}
+
+			if !isDuplicate(nameSim, lyricsSim) {
+				continue
+			}
+
+			keep, merge := songToKeep(songs[i], songs[j])
+			duplicates = append(duplicates, &entity.SongDuplicate{
+				Song:             keep,
+				Duplicate:        merge,
+				NameSimilarity:   nameSim,
+				LyricsSimilarity: lyricsSim,
+			})
+		}
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].NameSimilarity > duplicates[j].NameSimilarity
+	})
+
+	return duplicates, nil
+}
+
+// Merge moves events, voices, likes and tags of the duplicate song to the song and archives the duplicate.
+// Both songs must belong to the band.
+func (s *SongService) Merge(bandID, songID, duplicateID bson.ObjectID) (*entity.Song, error) {
+	if songID == duplicateID {
+		return nil, ErrInvalidOperation
+	}
+
+	song, err := s.songRepository.FindOneByID(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.songRepository.FindOneByID(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if song.BandID != bandID || duplicate.BandID != bandID {
+		return nil, ErrForbidden
+	}
+
+	err = s.eventRepository.ReplaceSongID(duplicate.ID, song.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.voiceRepository.MoveVoicesToSongID(duplicate.ID, song.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagsChanged := false
+	for _, tag := range duplicate.Tags {
+		if !slices.Contains(song.Tags, tag) {
+			song.Tags = append(song.Tags, tag)
+			tagsChanged = true
+		}
+	}
+	if tagsChanged {
+		song, err = s.songRepository.UpdateOne(*song)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, arrangement := range duplicate.Arrangements {
+		// Events of the duplicate keep the arrangement ID, so the clashing arrangement is renamed, not skipped.
+		arrangement.Name = uniqueArrangementName(song.Arrangements, arrangement.Name)
+		song.Arrangements = append(song.Arrangements, arrangement)
+
+		err = s.songRepository.PushArrangement(song.ID, arrangement)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, like := range duplicate.Likes {
+		err = s.songRepository.Like(song.ID, like.UserID, like.Time)
+		if err != nil {
+			return nil, err
+		}
+		err = s.songRepository.Dislike(duplicate.ID, like.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !duplicate.IsArchived {
+		_, err = s.Archive(duplicate.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.songRepository.FindOneByID(song.ID)
+}
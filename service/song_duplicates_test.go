@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestNormalizeSongName(t *testing.T) {
+	assert.Equal(t, "way maker", normalizeSongName("Way Maker (Live) - Sinach"))
+	assert.Equal(t, "велик наш бог", normalizeSongName("Велик наш Бог!  [acoustic]"))
+	assert.Equal(t, "еще", normalizeSongName("Ещё"))
+}
+
+func TestNormalizeLyrics(t *testing.T) {
+	text := "\ufeffKEY: G; BPM: 72; TIME: 4/4;\r\n" +
+		"Куплет 1:\r\n" +
+		"G   D/F#   Em  C\r\n" +
+		"Ты путь, Ты истина,\r\n" +
+		"| Am | C | G |\r\n" +
+		"Ты жизнь моя!\r\n"
+
+	assert.Equal(t, "ты путь ты истина ты жизнь моя", normalizeLyrics(text))
+}
+
+func TestIsDuplicate(t *testing.T) {
+	nameSim := nameSimilarity(normalizeSongName("Way Maker - Sinach"), normalizeSongName("Waymaker (Live)"))
+	assert.True(t, isDuplicate(nameSim, -1))
+
+	lyrics1 := normalizeLyrics("Verse:\nYou are here moving in our midst\nI worship You")
+	lyrics2 := normalizeLyrics("Куплет:\nYou are here, moving in our midst,\nI worship You!")
+	assert.True(t, isDuplicate(duplicateCandidateNameThreshold, lyricsSimilarity(lyrics1, lyrics2)))
+
+	lyrics3 := normalizeLyrics("Amazing grace how sweet the sound")
+	assert.False(t, isDuplicate(duplicateCandidateNameThreshold, lyricsSimilarity(lyrics1, lyrics3)))
+	assert.False(t, isDuplicate(duplicateCandidateNameThreshold-0.1, 1))
+}
+
+func TestSongToKeep(t *testing.T) {
+	older := &entity.Song{ID: bson.NewObjectIDFromTimestamp(bson.NewObjectID().Timestamp().AddDate(-1, 0, 0))}
+	newer := &entity.Song{ID: bson.NewObjectID()}
+
+	keep, merge := songToKeep(newer, older)
+	assert.Equal(t, older, keep)
+	assert.Equal(t, newer, merge)
+
+	newer.Likes = []*entity.Like{{UserID: 1}}
+	keep, merge = songToKeep(older, newer)
+	assert.Equal(t, newer, keep)
+	assert.Equal(t, older, merge)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// songFilterFieldAliases maps field names of the filter syntax to canonical names.
+var songFilterFieldAliases = map[string]string{
+	"key": "key", "тон": "key", "тональность": "key", "тональність": "key",
+	"bpm": "bpm", "темп": "bpm",
+	"time": "time", "размер": "time", "розмір": "time",
+	"archived": "archived", "архив": "archived", "архів": "archived",
+	"notplayed": "notplayed", "непели": "notplayed", "неспівали": "notplayed",
+}
+
+// ParseSongFilter parses filter syntax from a search query, e.g. "key:G,A bpm:120-140 time:4/4 #fast -#christmas notplayed:3m".
+// Supported fields:
+//   - key:G,A - one of the keys;
+//   - bpm:120-140, bpm:120, bpm:120-, bpm:-140 - BPM range;
+//   - time:4/4,6/8 - one of the time signatures;
+//   - #tag - has the tag, -#tag or !#tag - doesn't have the tag;
+//   - archived - only archived songs, archived:all - archived and not archived songs;
+//   - notplayed:30d, notplayed:8w, notplayed:3m, notplayed:1y, notplayed:01.02.2026 - not sung since then.
+//
+// Returns the filter, the rest of the query that is not a filter and whether any filter was found.
+func ParseSongFilter(query string, now time.Time) (entity.SongFilter, string, bool) {
+	var (
+		filter entity.SongFilter
+		rest   []string
+		found  bool
+	)
+
+	for _, token := range strings.Fields(query) {
+		if parseSongFilterToken(&filter, token, now) {
+			found = true
+		} else {
+			rest = append(rest, token)
+		}
+	}
+
+	return filter, strings.Join(rest, " "), found
+}
+
+func parseSongFilterToken(filter *entity.SongFilter, token string, now time.Time) bool {
+	for _, prefix := range []string{"-#", "!#"} {
+		if tag, ok := strings.CutPrefix(token, prefix); ok && tag != "" {
+			filter.ExcludeTags = append(filter.ExcludeTags, tag)
+			return true
+		}
+	}
+	if tag, ok := strings.CutPrefix(token, "#"); ok && tag != "" {
+		filter.Tags = append(filter.Tags, tag)
+		return true
+	}
+
+	name, value, hasValue := strings.Cut(token, ":")
+	field, ok := songFilterFieldAliases[strings.ToLower(name)]
+	if !ok {
+		return false
+	}
+
+	if field == "archived" {
+		switch strings.ToLower(value) {
+		case "", "yes", "only", "да", "так":
+			filter.Archived = entity.SongFilterArchivedOnly
+		case "all", "include", "все", "всі":
+			filter.Archived = entity.SongFilterArchivedInclude
+		case "no", "нет", "ні":
+			filter.Archived = entity.SongFilterArchivedExclude
+		default:
+			return false
+		}
+		return true
+	}
+
+	if !hasValue || value == "" {
+		return false
+	}
+
+	switch field {
+	case "key":
+		for _, key := range strings.Split(value, ",") {
+			if key = normalizeFilterKey(key); key != "" {
+				filter.Keys = append(filter.Keys, entity.Key(key))
+			}
+		}
+		return len(filter.Keys) > 0
+	case "bpm":
+		minBPM, maxBPM, ok := parseBPMRange(value)
+		if !ok {
+			return false
+		}
+		filter.MinBPM, filter.MaxBPM = minBPM, maxBPM
+		return true
+	case "time":
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.Times = append(filter.Times, t)
+			}
+		}
+		return len(filter.Times) > 0
+	case "notplayed":
+		since, ok := parseNotPlayedSince(value, now)
+		if !ok {
+			return false
+		}
+		filter.NotPlayedSince = &since
+		return true
+	}
+
+	return false
+}
+
+// normalizeFilterKey capitalizes the note, so "f#m" matches "F#m" key of the song.
+func normalizeFilterKey(key string) string {
+	key = strings.TrimSpace(key)
+	r, size := utf8.DecodeRuneInString(key)
+	if r == utf8.RuneError {
+		return ""
+	}
+	return string(unicode.ToUpper(r)) + key[size:]
+}
+
+func parseBPMRange(value string) (int, int, bool) {
+	from, to, isRange := strings.Cut(value, "-")
+	if !isRange {
+		bpm, err := strconv.Atoi(value)
+		if err != nil || bpm <= 0 {
+			return 0, 0, false
+		}
+		return bpm, bpm, true
+	}
+
+	var minBPM, maxBPM int
+	var err error
+	if from != "" {
+		minBPM, err = strconv.Atoi(from)
+		if err != nil || minBPM <= 0 {
+			return 0, 0, false
+		}
+	}
+	if to != "" {
+		maxBPM, err = strconv.Atoi(to)
+		if err != nil || maxBPM <= 0 {
+			return 0, 0, false
+		}
+	}
+	if minBPM == 0 && maxBPM == 0 {
+		return 0, 0, false
+	}
+	if maxBPM != 0 && minBPM > maxBPM {
+		minBPM, maxBPM = maxBPM, minBPM
+	}
+
+	return minBPM, maxBPM, true
+}
+
+func parseNotPlayedSince(value string, now time.Time) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006", "2006-01-02"} {
+		date, err := time.ParseInLocation(layout, value, now.Location())
+		if err == nil {
+			return date, true
+		}
+	}
+
+	unit, size := utf8.DecodeLastRuneInString(value)
+	n, err := strconv.Atoi(value[:len(value)-size])
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+
+	switch unicode.ToLower(unit) {
+	case 'd', 'д':
+		return now.AddDate(0, 0, -n), true
+	case 'w', 'н', 'т':
+		return now.AddDate(0, 0, -7*n), true
+	case 'm', 'м':
+		return now.AddDate(0, -n, 0), true
+	case 'y', 'г', 'р':
+		return now.AddDate(-n, 0, 0), true
+	}
+
+	return time.Time{}, false
+}
+
+// FindManyExtraByFilter finds band songs matching the filter and the text query if it's not empty.
+func (s *SongService) FindManyExtraByFilter(bandID bson.ObjectID, filter entity.SongFilter, query string, eventsStartDate time.Time, pageNumber int) ([]*entity.SongWithEvents, error) {
+	songIDs, err := s.searchSongIDs(bandID, query)
+	if err != nil {
+		return nil, err
+	}
+	if songIDs != nil && len(songIDs) == 0 {
+		return nil, nil
+	}
+
+	return s.songRepository.FindManyExtraByFilter(bandID, filter, songIDs, eventsStartDate, pageNumber)
+}
+
+// FilterSongs finds at most limit band songs matching the filter. If the text query is not empty,
+// only songs found by it are returned, ranked by search, otherwise songs are sorted by name.
+func (s *SongService) FilterSongs(bandID bson.ObjectID, filter entity.SongFilter, query string, limit int) ([]*entity.Song, error) {
+	songIDs, err := s.searchSongIDs(bandID, query)
+	if err != nil {
+		return nil, err
+	}
+	if songIDs != nil && len(songIDs) == 0 {
+		return nil, nil
+	}
+
+	repositoryLimit := limit
+	if songIDs != nil {
+		repositoryLimit = len(songIDs)
+	}
+
+	songs, err := s.songRepository.FindManyByFilter(bandID, filter, songIDs, repositoryLimit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if songIDs != nil {
+		slices.SortStableFunc(songs, func(a, b *entity.Song) int {
+			return slices.Index(songIDs, a.ID) - slices.Index(songIDs, b.ID)
+		})
+	}
+	if len(songs) > limit {
+		songs = songs[:limit]
+	}
+
+	return songs, nil
+}
+
+// searchSongIDs returns IDs of songs found by the text query ranked by search, or nil if the query is empty.
+func (s *SongService) searchSongIDs(bandID bson.ObjectID, query string) ([]bson.ObjectID, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+
+	results, err := s.Search(bandID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	songIDs := make([]bson.ObjectID, 0, len(results))
+	for _, result := range results {
+		songIDs = append(songIDs, result.SongID)
+	}
+
+	return songIDs, nil
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSongFilter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	t.Run("all fields", func(t *testing.T) {
+		filter, rest, found := ParseSongFilter("key:g,f#m bpm:140-120 time:4/4,6/8 #fast -#christmas !#slow notplayed:3m archived:all way maker", now)
+
+		assert.True(t, found)
+		assert.Equal(t, "way maker", rest)
+		assert.Equal(t, []entity.Key{"G", "F#m"}, filter.Keys)
+		assert.Equal(t, 120, filter.MinBPM)
+		assert.Equal(t, 140, filter.MaxBPM)
+		assert.Equal(t, []string{"4/4", "6/8"}, filter.Times)
+		assert.Equal(t, []string{"fast"}, filter.Tags)
+		assert.Equal(t, []string{"christmas", "slow"}, filter.ExcludeTags)
+		assert.Equal(t, entity.SongFilterArchivedInclude, filter.Archived)
+		assert.Equal(t, time.Date(2026, 7, 19, 12, 0, 0, 0, time.UTC), *filter.NotPlayedSince)
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		filter, rest, found := ParseSongFilter("тон:A темп:-90 архив непели:01.02.2026", now)
+
+		assert.True(t, found)
+		assert.Empty(t, rest)
+		assert.Equal(t, []entity.Key{"A"}, filter.Keys)
+		assert.Equal(t, 0, filter.MinBPM)
+		assert.Equal(t, 90, filter.MaxBPM)
+		assert.Equal(t, entity.SongFilterArchivedOnly, filter.Archived)
+		assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), *filter.NotPlayedSince)
+	})
+
+	t.Run("plain text", func(t *testing.T) {
+		_, rest, found := ParseSongFilter("Time: to worship #", now)
+
+		assert.False(t, found)
+		assert.Equal(t, "Time: to worship #", rest)
+	})
+
+	t.Run("invalid values are kept as text", func(t *testing.T) {
+		filter, rest, found := ParseSongFilter("bpm:fast notplayed:soon key:", now)
+
+		assert.False(t, found)
+		assert.Equal(t, "bpm:fast notplayed:soon key:", rest)
+		assert.Zero(t, filter.MinBPM)
+		assert.Nil(t, filter.NotPlayedSince)
+	})
+}
+
+func TestParseBPMRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max int
+		ok       bool
+	}{
+		{"120-140", 120, 140, true},
+		{"120", 120, 120, true},
+		{"120-", 120, 0, true},
+		{"-140", 0, 140, true},
+		{"-", 0, 0, false},
+		{"0", 0, 0, false},
+		{"a-b", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			minBPM, maxBPM, ok := parseBPMRange(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.min, minBPM)
+			assert.Equal(t, tt.max, maxBPM)
+		})
+	}
+}
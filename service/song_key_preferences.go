@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/joeyave/chords-transposer/transposer"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SetKeyPreference saves the key the user prefers to sing the song in.
+func (s *SongService) SetKeyPreference(songID bson.ObjectID, user *entity.User, key entity.Key) error {
+	key = entity.Key(strings.TrimSpace(string(key)))
+	if _, err := transposer.ParseKey(string(key)); err != nil {
+		return ErrInvalidOperation
+	}
+
+	return s.songRepository.SetKeyPreference(songID, &entity.KeyPreference{
+		UserID:    user.ID,
+		UserName:  user.Name,
+		Key:       key,
+		UpdatedAt: time.Now().UTC(),
+	})
+}
+
+func (s *SongService) RemoveKeyPreference(songID bson.ObjectID, userID int64) error {
+	return s.songRepository.PullKeyPreference(songID, userID)
+}
+
+// ProposeKeysForEvent proposes keys for the songs based on preferences of the event singers.
+func (s *SongService) ProposeKeysForEvent(event *entity.Event, songIDs []bson.ObjectID) ([]*entity.KeyProposal, error) {
+	if len(songIDs) == 0 {
+		return []*entity.KeyProposal{}, nil
+	}
+
+	songs, err := s.songRepository.FindManyByIDs(songIDs)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	return ProposeEventKeys(event, songs), nil
+}
+
+// ProposeEventKeys proposes for each song the key preferred by a singer of the event.
+// Memberships are checked in the order of role priority, so the lead singer's preference wins.
+func ProposeEventKeys(event *entity.Event, songs []*entity.Song) []*entity.KeyProposal {
+	var singers []int64
+	for _, membership := range event.Memberships {
+		if membership.Role != nil && membership.Role.Vocal {
+			singers = append(singers, membership.UserID)
+		}
+	}
+
+	proposals := make([]*entity.KeyProposal, 0)
+	for _, song := range songs {
+		for _, userID := range singers {
+			preference := song.GetKeyPreference(userID)
+			if preference == nil {
+				continue
+			}
+			proposals = append(proposals, &entity.KeyProposal{
+				SongID:   song.ID,
+				Key:      preference.Key,
+				UserID:   preference.UserID,
+				UserName: preference.UserName,
+			})
+			break
+		}
+	}
+
+	return proposals
+}
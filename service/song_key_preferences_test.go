@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestProposeEventKeys(t *testing.T) {
+	leadVocal := &entity.Role{Name: "🎤 Вокал", Vocal: true}
+	backVocal := &entity.Role{Name: "Бэк-вокал", Vocal: true}
+	// The name doesn't matter, only the roles marked by the admin sing.
+	guitar := &entity.Role{Name: "🎸 Гитара и вокал"}
+
+	event := &entity.Event{
+		Memberships: []*entity.Membership{
+			{UserID: 1, Role: guitar},
+			{UserID: 2, Role: leadVocal},
+			{UserID: 3, Role: backVocal},
+		},
+	}
+
+	bothSingers := &entity.Song{
+		ID: bson.NewObjectID(),
+		KeyPreferences: []*entity.KeyPreference{
+			{UserID: 3, UserName: "Olya", Key: "F"},
+			{UserID: 2, UserName: "Ivan", Key: "A"},
+		},
+	}
+	backSinger := &entity.Song{
+		ID:             bson.NewObjectID(),
+		KeyPreferences: []*entity.KeyPreference{{UserID: 3, UserName: "Olya", Key: "Em"}},
+	}
+	guitarist := &entity.Song{
+		ID:             bson.NewObjectID(),
+		KeyPreferences: []*entity.KeyPreference{{UserID: 1, UserName: "Petro", Key: "C"}},
+	}
+
+	proposals := ProposeEventKeys(event, []*entity.Song{bothSingers, backSinger, guitarist})
+
+	assert.Equal(t, []*entity.KeyProposal{
+		{SongID: bothSingers.ID, Key: "A", UserID: 2, UserName: "Ivan"},
+		{SongID: backSinger.ID, Key: "Em", UserID: 3, UserName: "Olya"},
+	}, proposals)
+
+	assert.Empty(t, ProposeEventKeys(&entity.Event{}, []*entity.Song{bothSingers}))
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hbollon/go-edlib"
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/joeyave/scala-bot/helpers"
+	"github.com/joeyave/scala-bot/repository"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/drive/v3"
+)
+
+const (
+	// Songs sung during this period are ranked higher.
+	songSearchRecentUsagePeriod  = 90 * 24 * time.Hour
+	songSearchReindexConcurrency = 4
+	// Search ranks candidates in memory, so their number is capped.
+	songSearchCandidatesLimit = 1000
+)
+
+// Weights of the fields a query word can match.
+const (
+	songSearchTitleWeight  = 3
+	songSearchInfoWeight   = 2
+	songSearchLyricsWeight = 1
+)
+
+// IndexSong updates search index of the song. Lyrics are downloaded from Drive only if the doc has changed.
+func (s *SongService) IndexSong(song *entity.Song) error {
+	existing, err := s.songSearchIndexRepository.FindOneBySongID(song.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	var lyrics string
+	if existing != nil && song.PDF.Version != 0 && existing.Version == song.PDF.Version {
+		lyrics = existing.Lyrics
+	} else {
+		text, err := s.driveFileService.GetLyrics(song.DriveFileID)
+		if err != nil {
+			return err
+		}
+		lyrics = normalizeLyrics(text)
+	}
+
+	index := buildSongSearchIndex(song, lyrics)
+	if existing != nil && sameSongSearchIndex(existing, &index) {
+		return nil
+	}
+
+	index.UpdatedAt = time.Now().UTC()
+	return s.songSearchIndexRepository.UpdateOne(index)
+}
+
+// indexSongAsync updates search index in background, so it doesn't slow down the caller.
+func (s *SongService) indexSongAsync(song *entity.Song) {
+	if song == nil {
+		return
+	}
+
+	songCopy := *song
+	go func() {
+		err := s.IndexSong(&songCopy)
+		if err != nil {
+			log.Warn().Err(err).Str("songID", songCopy.ID.Hex()).Msg("failed to index song")
+		}
+	}()
+}
+
+func (s *SongService) indexSongByIDAsync(songID bson.ObjectID) {
+	go func() {
+		song, err := s.songRepository.FindOneByID(songID)
+		if err != nil {
+			log.Warn().Err(err).Str("songID", songID.Hex()).Msg("failed to find song to index")
+			return
+		}
+
+		err = s.IndexSong(song)
+		if err != nil {
+			log.Warn().Err(err).Str("songID", songID.Hex()).Msg("failed to index song")
+		}
+	}()
+}
+
+// indexDriveFilesAsync creates songs for drive files that are not indexed yet. Songs are indexed on creation.
+func (s *SongService) indexDriveFilesAsync(driveFiles []*drive.File) {
+	if len(driveFiles) == 0 {
+		return
+	}
+
+	go func() {
+		for _, driveFile := range driveFiles {
+			_, err := s.FindOrCreateOneByDriveFile(driveFile)
+			if err != nil {
+				log.Warn().Err(err).Str("driveFileID", driveFile.Id).Msg("failed to index drive file")
+			}
+		}
+	}()
+}
+
+func (s *SongService) deleteSongSearchIndex(driveFileID string) {
+	err := s.songSearchIndexRepository.DeleteOneByDriveFileID(driveFileID)
+	if err != nil {
+		log.Warn().Err(err).Str("driveFileID", driveFileID).Msg("failed to delete song search index")
+	}
+}
+
+// ReindexBand rebuilds search index of all band songs and returns the number of indexed songs.
+func (s *SongService) ReindexBand(bandID bson.ObjectID) (int, error) {
+	songs, err := s.songRepository.FindManyByBandID(bandID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var indexed atomic.Int64
+
+	errwg := new(errgroup.Group)
+	errwg.SetLimit(songSearchReindexConcurrency)
+	for _, song := range songs {
+		errwg.Go(func() error {
+			// One broken doc shouldn't stop the whole band from being indexed.
+			err := s.IndexSong(song)
+			if err != nil {
+				log.Warn().Err(err).Str("songID", song.ID.Hex()).Msg("failed to index song")
+				return nil
+			}
+			indexed.Add(1)
+			return nil
+		})
+	}
+	_ = errwg.Wait()
+
+	return int(indexed.Load()), nil
+}
+
+// Search finds band songs in the search index. Results are ranked by how well they match the query,
+// typos are tolerated. Liked and recently sung songs are ranked higher.
+func (s *SongService) Search(bandID bson.ObjectID, query string) ([]*entity.SongSearchIndex, error) {
+	words := strings.Fields(normalizeText(query))
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	candidates, err := s.songSearchIndexRepository.FindManyByTrigrams(bandID, songSearchTrigrams(words), songSearchCandidatesLimit)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recentUsage := make(map[bson.ObjectID]int)
+	now := time.Now().UTC()
+	usages, err := s.eventRepository.GetSongUsage(bandID, now.Add(-songSearchRecentUsagePeriod), now)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to get recent song usage for search")
+	}
+	for _, usage := range usages {
+		if usage.Song != nil {
+			recentUsage[usage.Song.ID] = usage.Count
+		}
+	}
+
+	scores := make(map[bson.ObjectID]float64)
+	var results []*entity.SongSearchIndex
+	for _, candidate := range candidates {
+		score := songSearchScore(candidate, words)
+		if score == 0 {
+			continue
+		}
+
+		score += 0.5*math.Log1p(float64(candidate.Likes)) + 0.5*math.Log1p(float64(recentUsage[candidate.SongID]))
+		if candidate.IsArchived {
+			score--
+		}
+
+		scores[candidate.SongID] = score
+		results = append(results, candidate)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if scores[results[i].SongID] != scores[results[j].SongID] {
+			return scores[results[i].SongID] > scores[results[j].SongID]
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
+
+// SearchDriveFiles finds band songs in the search index and returns them as drive files, page by page.
+// Drive is searched on the first page only for files that are not indexed yet. Such files are indexed in background.
+func (s *SongService) SearchDriveFiles(band *entity.Band, query string, pageToken string) ([]*drive.File, string, error) {
+	// There is nothing to rank without the query, the band songs are listed from Drive.
+	if len(strings.Fields(normalizeText(query))) == 0 {
+		return s.driveFileService.FindSomeByFullTextAndFolderID(query, []string{band.DriveFolderID, band.ArchiveFolderID}, pageToken)
+	}
+
+	offset, _ := strconv.Atoi(pageToken)
+
+	results, err := s.Search(band.ID, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page := results[min(offset, len(results)):min(offset+helpers.SongsPageSize, len(results))]
+	driveFileIDs := make([]string, 0, len(page))
+	for _, result := range page {
+		driveFileIDs = append(driveFileIDs, result.DriveFileID)
+	}
+
+	driveFiles, err := s.driveFileService.FindManyByIDs(driveFileIDs)
+	if err != nil {
+		// Files deleted from Drive stay in the index until the next sync, the rest are still shown.
+		log.Warn().Err(err).Msg("failed to find some indexed drive files")
+	}
+	driveFiles = slices.DeleteFunc(driveFiles, func(driveFile *drive.File) bool {
+		return driveFile == nil || driveFile.Trashed
+	})
+
+	nextPageToken := ""
+	if offset+helpers.SongsPageSize < len(results) {
+		nextPageToken = strconv.Itoa(offset + helpers.SongsPageSize)
+	}
+
+	if offset > 0 || len(driveFiles) >= helpers.SongsPageSize {
+		return driveFiles, nextPageToken, nil
+	}
+
+	notIndexed, err := s.findNotIndexedDriveFiles(band, query)
+	if err != nil {
+		if len(driveFiles) > 0 {
+			log.Warn().Err(err).Msg("failed to search drive for not indexed files")
+			return driveFiles, nextPageToken, nil
+		}
+		return nil, "", err
+	}
+
+	s.indexDriveFilesAsync(notIndexed)
+
+	driveFiles = mergeSearchDriveFiles(driveFiles, notIndexed)
+	if len(driveFiles) > helpers.SongsPageSize {
+		driveFiles = driveFiles[:helpers.SongsPageSize]
+	}
+
+	return driveFiles, nextPageToken, nil
+}
+
+func (s *SongService) findNotIndexedDriveFiles(band *entity.Band, query string) ([]*drive.File, error) {
+	driveFiles, _, err := s.driveFileService.FindSomeByFullTextAndFolderID(query, []string{band.DriveFolderID, band.ArchiveFolderID}, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(driveFiles) == 0 {
+		return nil, nil
+	}
+
+	driveFileIDs := make([]string, 0, len(driveFiles))
+	for _, driveFile := range driveFiles {
+		driveFileIDs = append(driveFileIDs, driveFile.Id)
+	}
+
+	indexed, err := s.songSearchIndexRepository.FindIndexedDriveFileIDs(driveFileIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var notIndexed []*drive.File
+	for _, driveFile := range driveFiles {
+		if !indexed[driveFile.Id] {
+			notIndexed = append(notIndexed, driveFile)
+		}
+	}
+
+	return notIndexed, nil
+}
+
+// mergeSearchDriveFiles appends files from second to first skipping files that are already in first.
+func mergeSearchDriveFiles(first, second []*drive.File) []*drive.File {
+	seen := make(map[string]bool, len(first))
+	for _, driveFile := range first {
+		seen[driveFile.Id] = true
+	}
+
+	for _, driveFile := range second {
+		if !seen[driveFile.Id] {
+			seen[driveFile.Id] = true
+			first = append(first, driveFile)
+		}
+	}
+
+	return first
+}
+
+// buildSongSearchIndex builds search index of the song. Lyrics must be already normalized.
+func buildSongSearchIndex(song *entity.Song, lyrics string) entity.SongSearchIndex {
+	index := entity.SongSearchIndex{
+		SongID:      song.ID,
+		BandID:      song.BandID,
+		DriveFileID: song.DriveFileID,
+		Name:        song.PDF.Name,
+		Title:       normalizeText(song.PDF.Name),
+		Lyrics:      lyrics,
+		Key:         song.PDF.Key,
+		Likes:       len(song.Likes),
+		IsArchived:  song.IsArchived,
+		Version:     song.PDF.Version,
+	}
+
+	for _, author := range song.Authors {
+		if author = normalizeText(author); author != "" {
+			index.Authors = append(index.Authors, author)
+		}
+	}
+	for _, tag := range song.Tags {
+		if tag = normalizeText(tag); tag != "" {
+			index.Tags = append(index.Tags, tag)
+		}
+	}
+
+	words := strings.Fields(index.Title + " " + index.Lyrics + " " + strings.Join(index.Authors, " ") + " " + strings.Join(index.Tags, " "))
+	index.Trigrams = songSearchTrigrams(words)
+
+	return index
+}
+
+func sameSongSearchIndex(a, b *entity.SongSearchIndex) bool {
+	return a.SongID == b.SongID &&
+		a.BandID == b.BandID &&
+		a.DriveFileID == b.DriveFileID &&
+		a.Name == b.Name &&
+		a.Title == b.Title &&
+		a.Lyrics == b.Lyrics &&
+		slices.Equal(a.Authors, b.Authors) &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.Key == b.Key &&
+		a.Likes == b.Likes &&
+		a.IsArchived == b.IsArchived &&
+		a.Version == b.Version &&
+		slices.Equal(a.Trigrams, b.Trigrams)
+}
+
+// songSearchTrigrams returns sorted unique trigrams of the words. Words shorter than three letters are kept as is.
+// A word with a typo still shares most trigrams with the correct one.
+func songSearchTrigrams(words []string) []string {
+	set := make(map[string]bool)
+	for _, word := range words {
+		runes := []rune(word)
+		if len(runes) < 3 {
+			set[word] = true
+			continue
+		}
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = true
+		}
+	}
+
+	trigrams := make([]string, 0, len(set))
+	for trigram := range set {
+		trigrams = append(trigrams, trigram)
+	}
+	sort.Strings(trigrams)
+
+	return trigrams
+}
+
+// songSearchScore returns how well the index matches normalized query words. Every word must match something,
+// otherwise the score is zero. Whole query found in the title or lyrics gets a bonus.
+func songSearchScore(index *entity.SongSearchIndex, words []string) float64 {
+	titleWords := strings.Fields(index.Title)
+	infoWords := strings.Fields(strings.Join(index.Authors, " ") + " " + strings.Join(index.Tags, " "))
+	lyricsWords := strings.Fields(index.Lyrics)
+	key := normalizeText(string(index.Key))
+
+	score := 0.0
+	for _, word := range words {
+		best := max(
+			songSearchTitleWeight*bestWordMatch(word, titleWords),
+			songSearchInfoWeight*bestWordMatch(word, infoWords),
+			songSearchLyricsWeight*bestWordMatch(word, lyricsWords),
+		)
+		if key != "" && word == key {
+			best = max(best, songSearchInfoWeight)
+		}
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+
+	phrase := strings.Join(words, " ")
+	switch {
+	case index.Title == phrase:
+		score += 5
+	case strings.Contains(index.Title, phrase):
+		score += 3
+	}
+	if len(words) > 1 && strings.Contains(index.Lyrics, phrase) {
+		score += 2
+	}
+
+	return score
+}
+
+func bestWordMatch(word string, words []string) float64 {
+	best := 0.0
+	for _, w := range words {
+		best = max(best, wordMatch(word, w))
+		if best == 1 {
+			break
+		}
+	}
+	return best
+}
+
+// wordMatch compares a query word with a word of the song: exact match, prefix or a word with a few typos.
+func wordMatch(query, word string) float64 {
+	if query == word {
+		return 1
+	}
+
+	queryLen := len([]rune(query))
+	if queryLen >= 2 && strings.HasPrefix(word, query) {
+		return 0.8
+	}
+
+	allowedTypos := 0
+	switch {
+	case queryLen >= 8:
+		allowedTypos = 2
+	case queryLen >= 4:
+		allowedTypos = 1
+	}
+
+	wordLen := len([]rune(word))
+	if allowedTypos == 0 || wordLen < queryLen-allowedTypos || wordLen > queryLen+allowedTypos {
+		return 0
+	}
+
+	if edlib.LevenshteinDistance(query, word) <= allowedTypos {
+		return 0.6
+	}
+
+	return 0
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"google.golang.org/api/drive/v3"
+)
+
+func TestWordMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		word  string
+		want  float64
+	}{
+		{"grace", "grace", 1},
+		{"gra", "grace", 0.8},
+		{"grase", "grace", 0.6},
+		{"amazng", "amazing", 0.6},
+		{"благодать", "благодат", 0.6},
+		{"воскресение", "васкресенье", 0.6},
+		{"sun", "son", 0},
+		{"grace", "place", 0},
+		{"a", "amazing", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.word, func(t *testing.T) {
+			assert.Equal(t, tt.want, wordMatch(tt.query, tt.word))
+		})
+	}
+}
+
+func TestSongSearchTrigrams(t *testing.T) {
+	assert.Equal(t, []string{"ace", "gr", "gra", "rac"}, songSearchTrigrams([]string{"grace", "gr", "grace"}))
+}
+
+func TestBuildSongSearchIndex(t *testing.T) {
+	song := &entity.Song{
+		ID:          bson.NewObjectID(),
+		BandID:      bson.NewObjectID(),
+		DriveFileID: "doc",
+		PDF:         entity.PDF{Name: "Way Maker - Sinach", Key: "E", Version: 7},
+		Authors:     []string{"Osinachi Okoro"},
+		Tags:        []string{"Хвала!", ""},
+		Likes:       []*entity.Like{{UserID: 1}, {UserID: 2}},
+	}
+
+	index := buildSongSearchIndex(song, normalizeLyrics("KEY: E; BPM: 72; TIME: 4/4;\nVerse 1:\nE  B\nYou are here, moving in our midst"))
+
+	assert.Equal(t, "Way Maker - Sinach", index.Name)
+	assert.Equal(t, "way maker sinach", index.Title)
+	assert.Equal(t, "you are here moving in our midst", index.Lyrics)
+	assert.Equal(t, []string{"osinachi okoro"}, index.Authors)
+	assert.Equal(t, []string{"хвала"}, index.Tags)
+	assert.Equal(t, 2, index.Likes)
+	assert.Equal(t, int64(7), index.Version)
+	assert.Contains(t, index.Trigrams, "mid")
+	assert.Contains(t, index.Trigrams, "хва")
+
+	same := buildSongSearchIndex(song, index.Lyrics)
+	assert.True(t, sameSongSearchIndex(&index, &same))
+
+	song.Tags = nil
+	changed := buildSongSearchIndex(song, index.Lyrics)
+	assert.False(t, sameSongSearchIndex(&index, &changed))
+}
+
+func TestSongSearchScore(t *testing.T) {
+	wayMaker := &entity.SongSearchIndex{
+		Title:   "way maker",
+		Lyrics:  "you are here moving in our midst i worship you",
+		Authors: []string{"osinachi okoro"},
+		Key:     "E",
+	}
+	amazingGrace := &entity.SongSearchIndex{
+		Title:  "amazing grace",
+		Lyrics: "amazing grace how sweet the sound that saved a wretch like me",
+		Tags:   []string{"hymn"},
+		Key:    "G",
+	}
+
+	score := func(index *entity.SongSearchIndex, query string) float64 {
+		return songSearchScore(index, strings.Fields(normalizeText(query)))
+	}
+
+	assert.Zero(t, score(wayMaker, "amazing"))
+	assert.Zero(t, score(amazingGrace, "amazing love"), "every word must match")
+
+	assert.Positive(t, score(wayMaker, "way makr"), "typo in title")
+	assert.Positive(t, score(wayMaker, "okoro"), "author")
+	assert.Positive(t, score(amazingGrace, "hymn"), "tag")
+	assert.Positive(t, score(amazingGrace, "wretch lik"), "lyrics prefix")
+
+	assert.Greater(t, score(amazingGrace, "amazing grace"), score(amazingGrace, "grace amazing"), "phrase bonus")
+	assert.Greater(t, score(wayMaker, "way maker"), score(wayMaker, "worship"), "title is more important than lyrics")
+	assert.Greater(t, score(amazingGrace, "sweet sound"), 0.0)
+	assert.Positive(t, score(amazingGrace, "G hymn"), "key")
+	assert.Zero(t, score(wayMaker, "G"))
+}
+
+func TestMergeSearchDriveFiles(t *testing.T) {
+	merged := mergeSearchDriveFiles(
+		[]*drive.File{{Id: "1"}, {Id: "2"}},
+		[]*drive.File{{Id: "2"}, {Id: "3"}},
+	)
+
+	var ids []string
+	for _, f := range merged {
+		ids = append(ids, f.Id)
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
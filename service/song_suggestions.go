@@ -0,0 +1,191 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	// songSuggestionsHistoryPeriod is how far back events are taken into account.
+	songSuggestionsHistoryPeriod = 365 * 24 * time.Hour
+	// songSuggestionsRecencyPeriod is the time after which a song is considered fully "rested".
+	songSuggestionsRecencyPeriod = 180 * 24 * time.Hour
+	// songSuggestionsRepeatPeriod is the time around the event when the song was or will be sung anyway.
+	songSuggestionsRepeatPeriod = 14 * 24 * time.Hour
+	// songSuggestionsNotPlayedLongPeriod is the time since the last event to mention it as a reason.
+	songSuggestionsNotPlayedLongPeriod = 60 * 24 * time.Hour
+)
+
+const (
+	songSuggestionsRecencyWeight    = 0.35
+	songSuggestionsPopularityWeight = 0.2
+	songSuggestionsTagWeight        = 0.2
+	songSuggestionsKeyWeight        = 0.15
+	songSuggestionsBPMWeight        = 0.1
+)
+
+type songSuggestionContext struct {
+	at       time.Time
+	previous *entity.Song
+	tags     []string
+	// maxPopularity is the biggest likes + events count among band songs.
+	maxPopularity int
+}
+
+// SuggestSongsForEvent suggests songs to add to the setlist of the event.
+func (s *SongService) SuggestSongsForEvent(event *entity.Event, limit int) ([]*entity.SongSuggestion, error) {
+	return s.SuggestSongs(event.BandID, event.SongIDs, event.TimeUTC, nil, limit)
+}
+
+// SuggestSongs ranks band songs to add to the setlist of an event at the given time.
+// Songs that were not sung for a long time, popular songs, songs with the given tags
+// (or tags of the setlist songs if no tags given) and songs that flow well by key and tempo
+// after the last song of the setlist get higher scores. Songs from the setlist are skipped.
+func (s *SongService) SuggestSongs(bandID bson.ObjectID, setlist []bson.ObjectID, at time.Time, tags []string, limit int) ([]*entity.SongSuggestion, error) {
+	songs, err := s.songRepository.FindManyNotArchivedExtraByBandID(bandID, at.Add(-songSuggestionsHistoryPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	songsByID := make(map[bson.ObjectID]*entity.SongWithEvents, len(songs))
+	ctx := songSuggestionContext{at: at, tags: tags}
+	for _, song := range songs {
+		songsByID[song.ID] = song
+		ctx.maxPopularity = max(ctx.maxPopularity, len(song.Likes)+len(song.Events))
+	}
+
+	if len(ctx.tags) == 0 {
+		for _, songID := range setlist {
+			if song, ok := songsByID[songID]; ok {
+				for _, tag := range song.Tags {
+					if !slices.Contains(ctx.tags, tag) {
+						ctx.tags = append(ctx.tags, tag)
+					}
+				}
+			}
+		}
+	}
+
+	if len(setlist) > 0 {
+		previousID := setlist[len(setlist)-1]
+		if song, ok := songsByID[previousID]; ok {
+			ctx.previous = &song.Song
+		} else if song, err := s.songRepository.FindOneByID(previousID); err == nil {
+			ctx.previous = song
+		}
+	}
+
+	suggestions := make([]*entity.SongSuggestion, 0, len(songs))
+	for _, song := range songs {
+		if slices.Contains(setlist, song.ID) {
+			continue
+		}
+		suggestions = append(suggestions, scoreSongSuggestion(song, ctx))
+	}
+
+	slices.SortStableFunc(suggestions, func(a, b *entity.SongSuggestion) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Song.PDF.Name, b.Song.PDF.Name)
+	})
+
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
+
+func scoreSongSuggestion(song *entity.SongWithEvents, ctx songSuggestionContext) *entity.SongSuggestion {
+	suggestion := &entity.SongSuggestion{
+		Song:    &song.Song,
+		Reasons: []entity.SongSuggestionReason{},
+	}
+
+	var (
+		lastPlayed *time.Time
+		isRepeated bool
+	)
+	for _, event := range song.Events {
+		distance := event.TimeUTC.Sub(ctx.at)
+		if distance.Abs() < songSuggestionsRepeatPeriod {
+			isRepeated = true
+		}
+		if distance >= 0 {
+			continue
+		}
+		suggestion.TimesPlayed++
+		if lastPlayed == nil || event.TimeUTC.After(*lastPlayed) {
+			t := event.TimeUTC
+			lastPlayed = &t
+		}
+	}
+	suggestion.LastPlayed = lastPlayed
+
+	var recency float64
+	switch {
+	case isRepeated:
+		recency = 0
+	case lastPlayed == nil:
+		recency = 0.8
+		suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonNeverPlayed)
+	default:
+		since := ctx.at.Sub(*lastPlayed)
+		recency = math.Min(since.Hours(), songSuggestionsRecencyPeriod.Hours()) / songSuggestionsRecencyPeriod.Hours()
+		if since >= songSuggestionsNotPlayedLongPeriod {
+			suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonNotPlayedLong)
+		}
+	}
+
+	var popularity float64
+	if ctx.maxPopularity > 0 {
+		popularity = math.Log1p(float64(len(song.Likes)+len(song.Events))) / math.Log1p(float64(ctx.maxPopularity))
+		if popularity >= 0.6 {
+			suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonPopular)
+		}
+	}
+
+	tagMatch := 0.5
+	if len(ctx.tags) > 0 {
+		var matched int
+		for _, tag := range ctx.tags {
+			if slices.Contains(song.Tags, tag) {
+				matched++
+			}
+		}
+		tagMatch = float64(matched) / float64(len(ctx.tags))
+		if matched > 0 {
+			suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonTagMatch)
+		}
+	}
+
+	keyMatch, bpmMatch := 0.5, 0.5
+	if ctx.previous != nil {
+		if compatibility, ok := keyCompatibility(ctx.previous.PDF.Key, song.PDF.Key); ok {
+			keyMatch = compatibility
+			if compatibility >= 0.7 {
+				suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonKeyFlow)
+			}
+		}
+		if compatibility, ok := bpmCompatibility(ctx.previous.PDF.BPM, song.PDF.BPM); ok {
+			bpmMatch = compatibility
+			if compatibility == 1 {
+				suggestion.Reasons = append(suggestion.Reasons, entity.SongSuggestionReasonTempoFlow)
+			}
+		}
+	}
+
+	suggestion.Score = songSuggestionsRecencyWeight*recency +
+		songSuggestionsPopularityWeight*popularity +
+		songSuggestionsTagWeight*tagMatch +
+		songSuggestionsKeyWeight*keyMatch +
+		songSuggestionsBPMWeight*bpmMatch
+
+	return suggestion
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreSongSuggestion(t *testing.T) {
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	ctx := songSuggestionContext{
+		at:            at,
+		previous:      &entity.Song{PDF: entity.PDF{Key: "G", BPM: "72"}},
+		tags:          []string{"worship"},
+		maxPopularity: 10,
+	}
+
+	played := func(days ...int) []*entity.Event {
+		var events []*entity.Event
+		for _, d := range days {
+			events = append(events, &entity.Event{TimeUTC: at.AddDate(0, 0, d)})
+		}
+		return events
+	}
+
+	rested := &entity.SongWithEvents{
+		Song:   entity.Song{PDF: entity.PDF{Key: "D", BPM: "76"}, Tags: []string{"worship"}},
+		Events: played(-200, -300),
+	}
+	recent := &entity.SongWithEvents{
+		Song:   entity.Song{PDF: entity.PDF{Key: "D", BPM: "76"}, Tags: []string{"worship"}},
+		Events: played(-7, -200),
+	}
+	planned := &entity.SongWithEvents{
+		Song:   entity.Song{PDF: entity.PDF{Key: "D", BPM: "76"}, Tags: []string{"worship"}},
+		Events: played(7, -200),
+	}
+	neverPlayed := &entity.SongWithEvents{
+		Song: entity.Song{PDF: entity.PDF{Key: "F#", BPM: "140"}},
+	}
+
+	restedSuggestion := scoreSongSuggestion(rested, ctx)
+	assert.Equal(t, 2, restedSuggestion.TimesPlayed)
+	assert.Equal(t, at.AddDate(0, 0, -200), *restedSuggestion.LastPlayed)
+	assert.ElementsMatch(t, []entity.SongSuggestionReason{
+		entity.SongSuggestionReasonNotPlayedLong,
+		entity.SongSuggestionReasonTagMatch,
+		entity.SongSuggestionReasonKeyFlow,
+		entity.SongSuggestionReasonTempoFlow,
+	}, restedSuggestion.Reasons)
+
+	assert.Greater(t, restedSuggestion.Score, scoreSongSuggestion(recent, ctx).Score)
+	assert.Greater(t, restedSuggestion.Score, scoreSongSuggestion(planned, ctx).Score, "song is already planned for the next week")
+	assert.Equal(t, 1, scoreSongSuggestion(planned, ctx).TimesPlayed)
+
+	neverPlayedSuggestion := scoreSongSuggestion(neverPlayed, ctx)
+	assert.Nil(t, neverPlayedSuggestion.LastPlayed)
+	assert.Equal(t, []entity.SongSuggestionReason{entity.SongSuggestionReasonNeverPlayed}, neverPlayedSuggestion.Reasons)
+	assert.Greater(t, restedSuggestion.Score, neverPlayedSuggestion.Score)
+}
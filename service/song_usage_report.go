@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var songUsageReportHeader = []string{"Song", "CCLI Number", "Authors", "Copyright", "Times Used", "First Used", "Last Used"}
+
+// GetUsageReport returns songs performed by the band in [from, to) sorted by the number of performances.
+func (s *SongService) GetUsageReport(bandID bson.ObjectID, from, to time.Time) ([]*entity.SongUsage, error) {
+	return s.eventRepository.GetSongUsage(bandID, from.UTC(), to.UTC())
+}
+
+// WriteUsageReportCSV writes song usage as CSV suitable for licensing reports.
+// Dates are formatted in the given location.
+func WriteUsageReportCSV(w io.Writer, usages []*entity.SongUsage, loc *time.Location) error {
+	// BOM makes Excel open the file as UTF-8.
+	_, err := io.WriteString(w, "\ufeff")
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+
+	err = writer.Write(songUsageReportHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, usage := range usages {
+		if usage.Song == nil {
+			continue
+		}
+
+		err = writer.Write([]string{
+			usage.Song.PDF.Name,
+			usage.Song.CCLINumber,
+			strings.Join(usage.Song.Authors, ", "),
+			usage.Song.Copyright,
+			strconv.Itoa(usage.Count),
+			usage.FirstTime.In(loc).Format("2006-01-02"),
+			usage.LastTime.In(loc).Format("2006-01-02"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/joeyave/scala-bot/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteUsageReportCSV(t *testing.T) {
+	loc := time.FixedZone("EET", 2*60*60)
+
+	usages := []*entity.SongUsage{
+		{
+			Song: &entity.Song{
+				PDF:        entity.PDF{Name: "How Great Is Our God"},
+				Authors:    []string{"Chris Tomlin", "Jesse Reeves"},
+				Copyright:  "2004 worshiptogether.com songs",
+				CCLINumber: "4348399",
+			},
+			Count:     3,
+			FirstTime: time.Date(2026, 1, 4, 23, 0, 0, 0, time.UTC),
+			LastTime:  time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			Song:      &entity.Song{PDF: entity.PDF{Name: "Слава, \"Алилуя\""}},
+			Count:     1,
+			FirstTime: time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC),
+			LastTime:  time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{Count: 5},
+	}
+
+	var buf bytes.Buffer
+	err := WriteUsageReportCSV(&buf, usages, loc)
+	assert.NoError(t, err)
+
+	expected := "\ufeff" +
+		"Song,CCLI Number,Authors,Copyright,Times Used,First Used,Last Used\n" +
+		"How Great Is Our God,4348399,\"Chris Tomlin, Jesse Reeves\",2004 worshiptogether.com songs,3,2026-01-05,2026-03-01\n" +
+		"\"Слава, \"\"Алилуя\"\"\",,,,1,2026-02-01,2026-02-01\n"
+	assert.Equal(t, expected, buf.String())
+}
@@ -1,6 +1,10 @@
 package service
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/joeyave/scala-bot/entity"
 	"github.com/joeyave/scala-bot/repository"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -24,6 +28,91 @@ func (s *VoiceService) FindOneByFileID(fileID string) (*entity.Voice, error) {
 	return s.voiceRepository.FindOneByFileID(fileID)
 }
 
+func (s *VoiceService) FindOneByAnyFileID(fileID string) (*entity.Voice, error) {
+	return s.voiceRepository.FindOneByAnyFileID(fileID)
+}
+
+// FindManyBySongID returns voices of the song in their order.
+func (s *VoiceService) FindManyBySongID(songID bson.ObjectID) ([]*entity.Voice, error) {
+	voices, err := s.voiceRepository.FindManyBySongID(songID)
+	if err != nil {
+		return nil, err
+	}
+	entity.SortVoices(voices)
+	return voices, nil
+}
+
+// Move moves the voice up (negative shift) or down (positive shift) in the order of the song voices.
+func (s *VoiceService) Move(ID bson.ObjectID, shift int) error {
+	voice, err := s.voiceRepository.FindOneByID(ID)
+	if err != nil {
+		return err
+	}
+
+	voices, err := s.FindManyBySongID(voice.SongID)
+	if err != nil {
+		return err
+	}
+
+	from := slices.IndexFunc(voices, func(v *entity.Voice) bool { return v.ID == ID })
+	to := from + shift
+	if from == -1 || to < 0 || to >= len(voices) {
+		return nil
+	}
+	moved := voices[from]
+	voices = slices.Insert(slices.Delete(voices, from, from+1), to, moved)
+
+	// Voices without the manual order get it, so the order is the same as shown.
+	for i, v := range voices {
+		if v.Position == i {
+			continue
+		}
+		if err := s.voiceRepository.UpdatePosition(v.ID, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddSegment saves the named part of the voice recording for the whole band.
+func (s *VoiceService) AddSegment(voiceID bson.ObjectID, segment entity.VoiceSegment) (*entity.Voice, error) {
+	voice, err := s.voiceRepository.FindOneByID(voiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	segment.Name = strings.TrimSpace(segment.Name)
+	if segment.Name == "" {
+		return nil, fmt.Errorf("%w: segment name is empty", ErrInvalidOperation)
+	}
+	if segment.Start < 0 || segment.End <= segment.Start {
+		return nil, fmt.Errorf("%w: segment ends before it starts", ErrInvalidOperation)
+	}
+	if voice.Duration > 0 && segment.Start >= voice.Duration*1000 {
+		return nil, fmt.Errorf("%w: segment starts after the end of the recording", ErrInvalidOperation)
+	}
+
+	segment.ID = bson.NewObjectID()
+	return s.voiceRepository.PushSegment(voiceID, segment)
+}
+
+func (s *VoiceService) DeleteSegment(voiceID, segmentID bson.ObjectID) (*entity.Voice, error) {
+	return s.voiceRepository.PullSegment(voiceID, segmentID)
+}
+
+// SaveAnalysis saves the key and the tempo detected from the recording without touching the rest of the voice.
+func (s *VoiceService) SaveAnalysis(voiceID bson.ObjectID, key entity.Key, bpm int) error {
+	return s.voiceRepository.UpdateDetected(voiceID, key, bpm)
+}
+
+// UpdateDetails changes the part, the role and the description of the voice.
+func (s *VoiceService) UpdateDetails(voiceID bson.ObjectID, part entity.VoicePart, roleID bson.ObjectID, description string) error {
+	if part != "" && !slices.Contains(entity.VoiceParts, part) {
+		return fmt.Errorf("%w: unknown voice part %q", ErrInvalidOperation, part)
+	}
+	return s.voiceRepository.UpdateDetails(voiceID, part, roleID, strings.TrimSpace(description))
+}
+
 func (s *VoiceService) UpdateOne(voice entity.Voice) (*entity.Voice, error) {
 	return s.voiceRepository.UpdateOne(voice)
 }
@@ -72,4 +72,42 @@ const (
 
 	JoinRequestApprove
 	JoinRequestDecline
+
+	SongMergeConfirm
+	SongMerge
+
+	EventSongSuggestions
+	EventSongSuggestionAdd
+
+	EventSetlistFlow
+	EventSetlistFlowReorder
+
+	SongKeyPreference
+	SongKeyPreferenceSet
+
+	SongChartViews
+	SongChartView
+
+	EventSlides
+	EventSlidesExport
+
+	AudioJobCancel
+	EventVoices
+	VoiceTransposeToEventKey
+	SongVoiceMove
+	EventMyVoices
+	SongVoicesMix
+	SongVoicesMixRun
+	SongClickTrack
+	SongClickTrackRun
+	VoiceSegments
+	VoiceSegment
+	VoiceSegmentCreateAskForStart
+	VoiceSegmentCreate
+	VoiceSegmentRun
+	VoiceSegmentDelete
+	AudioSettings
+	BandRoles
+	SongVoiceEditAskForPart
+	SongVoiceEdit
 )
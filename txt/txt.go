@@ -97,6 +97,10 @@ var locales = map[string]map[string]string{
 		"ru": "🔖",
 		"uk": "🔖",
 	},
+	"button.songFilter": {
+		"ru": "🎛",
+		"uk": "🎛",
+	},
 	"button.globalSearch": {
 		"ru": "🔎 Искать во всех группах",
 		"uk": "🔎 Шукати у всіх групах",
@@ -250,6 +254,10 @@ var locales = map[string]map[string]string{
 		"ru": "Продолжить",
 		"uk": "Продовжити",
 	},
+	"button.continueWithChanges": {
+		"ru": "Продолжить (%s)",
+		"uk": "Продовжити (%s)",
+	},
 	"button.qualitatively": {
 		"ru": "Качественно, но долго",
 		"uk": "Якісно, але довго",
@@ -371,6 +379,102 @@ var locales = map[string]map[string]string{
 		"ru": "На сколько полутонов транспонировать этот аудио файл?",
 		"uk": "На скільки півтонів транспонувати цей аудіо файл?",
 	},
+	"text.sendTempo": {
+		"ru": "Для репетиции можно замедлить или ускорить запись.",
+		"uk": "Для репетиції можна сповільнити або пришвидшити запис.",
+	},
+	"text.sendTempoBPM": {
+		"ru": "Темп песни — %s BPM. Для репетиции можно замедлить или ускорить запись.",
+		"uk": "Темп пісні — %s BPM. Для репетиції можна сповільнити або пришвидшити запис.",
+	},
+	"text.audioAnalyzing": {
+		"ru": "🔎 Определяю тональность и темп…",
+		"uk": "🔎 Визначаю тональність і темп…",
+	},
+	"text.audioDetectedKey": {
+		"ru": "Тональность записи: %s.",
+		"uk": "Тональність запису: %s.",
+	},
+	"text.audioDetectedBPM": {
+		"ru": "Темп записи: ~%d BPM.",
+		"uk": "Темп запису: ~%d BPM.",
+	},
+	"text.audioSuggestedShift": {
+		"ru": "Чтобы попасть в тональность %s, транспонируйте на %s.",
+		"uk": "Щоб потрапити в тональність %s, транспонуйте на %s.",
+	},
+	"text.audioSameKey": {
+		"ru": "Запись уже в нужной тональности (%s).",
+		"uk": "Запис уже в потрібній тональності (%s).",
+	},
+	"text.chooseVoicePart": {
+		"ru": "Какая это партия?",
+		"uk": "Яка це партія?",
+	},
+	"text.chooseVoiceRole": {
+		"ru": "Для какой роли в группе эта запись?",
+		"uk": "Для якої ролі в гурті цей запис?",
+	},
+	"text.sendVoiceDescription": {
+		"ru": "Добавьте описание, например, на что обратить внимание при разучивании.",
+		"uk": "Додайте опис, наприклад, на що звернути увагу під час розучування.",
+	},
+	"text.voicePart": {
+		"ru": "Партия: %s",
+		"uk": "Партія: %s",
+	},
+	"text.voiceRole": {
+		"ru": "Роль: %s",
+		"uk": "Роль: %s",
+	},
+	"text.voiceDuration": {
+		"ru": "Длительность: %s",
+		"uk": "Тривалість: %s",
+	},
+	"text.voiceUploader": {
+		"ru": "Загрузил(а): %s",
+		"uk": "Завантажив(ла): %s",
+	},
+	"text.voiceCreatedAt": {
+		"ru": "Добавлено: %s",
+		"uk": "Додано: %s",
+	},
+	"text.myVoices": {
+		"ru": "Ваши партии для события <b>%s</b>:",
+		"uk": "Ваші партії для події <b>%s</b>:",
+	},
+	"text.noMyVoices": {
+		"ru": "Для ваших ролей в этом событии пока нет записанных партий.",
+		"uk": "Для ваших ролей у цій події поки немає записаних партій.",
+	},
+	"text.noMyUpcomingEvents": {
+		"ru": "Вы не участвуете в предстоящих событиях.",
+		"uk": "Ви не берете участі в майбутніх подіях.",
+	},
+	"voicePart.soprano": {
+		"ru": "Сопрано",
+		"uk": "Сопрано",
+	},
+	"voicePart.alto": {
+		"ru": "Альт",
+		"uk": "Альт",
+	},
+	"voicePart.tenor": {
+		"ru": "Тенор",
+		"uk": "Тенор",
+	},
+	"voicePart.bass": {
+		"ru": "Бас",
+		"uk": "Бас",
+	},
+	"voicePart.lead": {
+		"ru": "Соло",
+		"uk": "Соло",
+	},
+	"voicePart.harmony": {
+		"ru": "Бэк-вокал",
+		"uk": "Бек-вокал",
+	},
 	"text.sendVoiceName": {
 		"ru": "Отправь мне название этой партии.",
 		"uk": "Відправ мені назву цієї партії.",
@@ -425,6 +529,10 @@ var locales = map[string]map[string]string{
 		"ru": "Произошла ошибка.",
 		"uk": "Сталася помилка.",
 	},
+	"text.outdatedButton": {
+		"ru": "Эта кнопка устарела. Открой меню заново.",
+		"uk": "Ця кнопка застаріла. Відкрий меню заново.",
+	},
 	"text.roleIndex": {
 		"ru": "Роли выводятся в определенном порядке. После какой роли должна быть эта роль?",
 		"uk": "Ролі виводяться у заданому порядку. Після якої ролі має бути ця роль?",
@@ -433,6 +541,10 @@ var locales = map[string]map[string]string{
 		"ru": "В папке на Google Диске нет документов. Проверь, что сервисный email %s добавлен в эту папку как редактор.",
 		"uk": "У папці Google Диск немає документів. Перевір, що сервісний email %s додано в цю папку як редактора.",
 	},
+	"text.voiceUpdated": {
+		"ru": "Партия изменена.",
+		"uk": "Партію змінено.",
+	},
 	"text.added": {
 		"ru": "Добавлено.",
 		"uk": "Додано.",
@@ -607,6 +719,122 @@ var locales = map[string]map[string]string{
 		"ru": "Начинаю...",
 		"uk": "Починаю...",
 	},
+	"text.chooseVoiceToTranspose": {
+		"ru": "Партии песен, тональность которых изменена для этого события. Выберите партию, чтобы транспонировать её:",
+		"uk": "Партії пісень, тональність яких змінена для цієї події. Оберіть партію, щоб транспонувати її:",
+	},
+	"text.noEventKeyVoices": {
+		"ru": "В этом событии нет партий песен с измененной тональностью.",
+		"uk": "У цій події немає партій пісень зі зміненою тональністю.",
+	},
+	"text.voiceAlreadyInEventKey": {
+		"ru": "Партия уже в тональности события (%s).",
+		"uk": "Партія вже в тональності події (%s).",
+	},
+	"text.audioTransposingToKey": {
+		"ru": "Транспонирую «%s» в %s...",
+		"uk": "Транспоную «%s» в %s...",
+	},
+	"text.audioMixer": {
+		"ru": "Микшер партий. Нажми на партию, чтобы изменить её громкость или выключить, и на ⬅️ / ➡️, чтобы сдвинуть её влево или вправо. Включенные партии сведутся в один трек для репетиции.",
+		"uk": "Мікшер партій. Натисни на партію, щоб змінити її гучність або вимкнути, і на ⬅️ / ➡️, щоб зсунути її ліворуч або праворуч. Увімкнені партії зведуться в один трек для репетиції.",
+	},
+	"text.audioMixEventKey": {
+		"ru": "Тональность на %s: <b>%s</b>.",
+		"uk": "Тональність на %s: <b>%s</b>.",
+	},
+	"text.audioMixing": {
+		"ru": "Свожу партии...",
+		"uk": "Зводжу партії...",
+	},
+	"text.audioMixEmpty": {
+		"ru": "Включи хотя бы одну партию.",
+		"uk": "Увімкни хоча б одну партію.",
+	},
+	"text.audioMixTitle": {
+		"ru": "Микс",
+		"uk": "Мікс",
+	},
+	"text.clickTrack": {
+		"ru": "Метроном: %g BPM, %d долей в такте. Выбери длину отсчёта и партию, поверх которой наложить метроном. Партия начнётся после отсчёта.",
+		"uk": "Метроном: %g BPM, %d долей у такті. Обери довжину відліку і партію, поверх якої накласти метроном. Партія почнеться після відліку.",
+	},
+	"text.clickTrackNoBPM": {
+		"ru": "Сначала укажи BPM песни.",
+		"uk": "Спочатку вкажи BPM пісні.",
+	},
+	"text.clickTrackTitle": {
+		"ru": "Метроном %g BPM",
+		"uk": "Метроном %g BPM",
+	},
+	"text.clickTrackCaption": {
+		"ru": "🥁 %g BPM · %d долей · отсчёт %d такт.",
+		"uk": "🥁 %g BPM · %d долей · відлік %d такт.",
+	},
+	"text.voiceSegments": {
+		"ru": "Сохраненные фрагменты записи. Они видны всей группе. Выбери фрагмент, чтобы вырезать его или повторить несколько раз:",
+		"uk": "Збережені фрагменти запису. Їх бачить уся група. Обери фрагмент, щоб вирізати його або повторити кілька разів:",
+	},
+	"text.noVoiceSegments": {
+		"ru": "У записи пока нет фрагментов. Отметь начало и конец, например бриджа, чтобы учить его отдельно.",
+		"uk": "У запису поки немає фрагментів. Познач початок і кінець, наприклад бріджу, щоб вчити його окремо.",
+	},
+	"text.chooseVoiceSegmentLoops": {
+		"ru": "Сколько раз повторить фрагмент?",
+		"uk": "Скільки разів повторити фрагмент?",
+	},
+	"text.sendVoiceSegmentStart": {
+		"ru": "Отправь начало фрагмента, например <code>2:10</code>:",
+		"uk": "Надішли початок фрагмента, наприклад <code>2:10</code>:",
+	},
+	"text.sendVoiceSegmentEnd": {
+		"ru": "Отправь конец фрагмента, например <code>2:45</code>:",
+		"uk": "Надішли кінець фрагмента, наприклад <code>2:45</code>:",
+	},
+	"text.sendVoiceSegmentName": {
+		"ru": "Отправь название фрагмента, например «Бридж»:",
+		"uk": "Надішли назву фрагмента, наприклад «Брідж»:",
+	},
+	"text.badTimestamp": {
+		"ru": "Не понимаю время. Отправь его в виде минуты:секунды, например 2:10, и в пределах записи.",
+		"uk": "Не розумію час. Надішли його у вигляді хвилини:секунди, наприклад 2:10, і в межах запису.",
+	},
+	"text.badVoiceSegmentEnd": {
+		"ru": "Конец фрагмента должен быть позже начала. Отправь его в виде минуты:секунды, например 2:45.",
+		"uk": "Кінець фрагмента має бути пізніше за початок. Надішли його у вигляді хвилини:секунди, наприклад 2:45.",
+	},
+	"text.voiceSegmentNotFound": {
+		"ru": "Фрагмент уже удален.",
+		"uk": "Фрагмент вже видалено.",
+	},
+	"text.voiceSegmentDeleted": {
+		"ru": "Фрагмент удален.",
+		"uk": "Фрагмент видалено.",
+	},
+	"text.voiceSegmentTooLong": {
+		"ru": "Слишком длинный результат. Он должен быть не длиннее %s.",
+		"uk": "Задовгий результат. Він має бути не довшим за %s.",
+	},
+	"text.audioTrimming": {
+		"ru": "Вырезаю фрагмент...",
+		"uk": "Вирізаю фрагмент...",
+	},
+	"text.audioEncoding": {
+		"ru": "Сохраняю в нужном формате...",
+		"uk": "Зберігаю в потрібному форматі...",
+	},
+	"text.audioOutput": {
+		"ru": "Формат результата: %s. Изменить: /audio",
+		"uk": "Формат результату: %s. Змінити: /audio",
+	},
+	"text.audioSettings": {
+		"ru": "В каком формате присылать обработанные аудио?\n\nВыравнивание громкости приводит записи к одному уровню по стандарту EBU R128.\n\nГолосовые сообщения остаются голосовыми. Чтобы получить их файлом в выбранном формате, нажми «%s» после отправки голосового.",
+		"uk": "У якому форматі надсилати оброблені аудіо?\n\nВирівнювання гучності приводить записи до одного рівня за стандартом EBU R128.\n\nГолосові повідомлення залишаються голосовими. Щоб отримати їх файлом у вибраному форматі, натисни «%s» після надсилання голосового.",
+	},
+	"text.audioFormatOriginal": {
+		"ru": "как у оригинала",
+		"uk": "як в оригіналу",
+	},
 	"text.audioQueuePosition": {
 		"ru": "Позиция в очереди: %d",
 		"uk": "Позиція в черзі: %d",
@@ -631,6 +859,338 @@ var locales = map[string]map[string]string{
 		"ru": "Обрабатываю... %s",
 		"uk": "Обробляю... %s",
 	},
+	"text.audioCanceled": {
+		"ru": "Обработка отменена.",
+		"uk": "Обробку скасовано.",
+	},
+	"text.audioJobForbidden": {
+		"ru": "Отменить обработку может только тот, кто её запустил.",
+		"uk": "Скасувати обробку може лише той, хто її запустив.",
+	},
+	"text.audioJobNotCancelable": {
+		"ru": "Обработка уже завершена.",
+		"uk": "Обробку вже завершено.",
+	},
+	"text.audioJobsLimit": {
+		"ru": "Можно обрабатывать не больше %d аудио одновременно. Дождитесь окончания или отмените одно из них.",
+		"uk": "Можна обробляти не більше %d аудіо одночасно. Дочекайтеся завершення або скасуйте одне з них.",
+	},
+	"text.bandRoles": {
+		"ru": "Отметь роли, участники которых поют. Их предпочтительные тональности предлагаются для событий.",
+		"uk": "Познач ролі, учасники яких співають. Їхні бажані тональності пропонуються для подій.",
+	},
+	"text.bandRolesInsufficientRights": {
+		"ru": "Настраивать роли может только администратор группы.",
+		"uk": "Налаштовувати ролі може лише адміністратор групи.",
+	},
+	"text.songDuplicatesInsufficientRights": {
+		"ru": "Искать и объединять дубликаты песен может только администратор группы.",
+		"uk": "Шукати та об'єднувати дублікати пісень може лише адміністратор групи.",
+	},
+	"text.songDuplicatesSearching": {
+		"ru": "Ищу дубликаты песен, это может занять некоторое время...",
+		"uk": "Шукаю дублікати пісень, це може зайняти деякий час...",
+	},
+	"text.songDuplicatesNotFound": {
+		"ru": "Дубликаты песен не найдены.",
+		"uk": "Дублікати пісень не знайдено.",
+	},
+	"text.songDuplicatesFound": {
+		"ru": "Похожие песни. Выбери номер пары, чтобы объединить её:",
+		"uk": "Схожі пісні. Обери номер пари, щоб об'єднати її:",
+	},
+	"text.songDuplicatesLyrics": {
+		"ru": "текст",
+		"uk": "текст",
+	},
+	"text.songMergeConfirm": {
+		"ru": "Оставить <b>%s</b> и объединить с ней <b>%s</b>?\n\nСобрания, партии, лайки и теги будут перенесены, а <b>%s</b> будет заархивирована.",
+		"uk": "Залишити <b>%s</b> та об'єднати з нею <b>%s</b>?\n\nЗаходи, партії, вподобання та теги буде перенесено, а <b>%s</b> буде заархівовано.",
+	},
+	"text.songMergeInvalid": {
+		"ru": "Эти песни нельзя объединить.",
+		"uk": "Ці пісні не можна об'єднати.",
+	},
+	"text.songMerged": {
+		"ru": "Песни объединены в <b>%s</b>.",
+		"uk": "Пісні об'єднано в <b>%s</b>.",
+	},
+	"button.songMergeSwap": {
+		"ru": "🔄 Оставить другую",
+		"uk": "🔄 Залишити іншу",
+	},
+	"text.songUsageReport": {
+		"ru": "Отчёт об использовании песен за %s.\nПесен: %d, исполнений: %d.",
+		"uk": "Звіт про використання пісень за %s.\nПісень: %d, виконань: %d.",
+	},
+	"text.songUsageReportEmpty": {
+		"ru": "За период %s не исполнялось ни одной песни.",
+		"uk": "За період %s не виконувалося жодної пісні.",
+	},
+	"text.songUsageReportUsage": {
+		"ru": "Используй <code>/report</code> для отчёта за последние полгода или <code>/report 01.01.2026 30.06.2026</code> для отчёта за период.",
+		"uk": "Використовуй <code>/report</code> для звіту за останні пів року або <code>/report 01.01.2026 30.06.2026</code> для звіту за період.",
+	},
+	"text.songSearchReindexInsufficientRights": {
+		"ru": "Обновлять поисковый индекс может только администратор группы.",
+		"uk": "Оновлювати пошуковий індекс може лише адміністратор групи.",
+	},
+	"text.songSearchReindexing": {
+		"ru": "Обновляю поисковый индекс песен, это может занять некоторое время...",
+		"uk": "Оновлюю пошуковий індекс пісень, це може зайняти деякий час...",
+	},
+	"text.songSearchReindexed": {
+		"ru": "Поисковый индекс обновлён. Проиндексировано песен: %d.",
+		"uk": "Пошуковий індекс оновлено. Проіндексовано пісень: %d.",
+	},
+	"text.songFilterHelp": {
+		"ru": "Напиши фильтр, например: <code>key:G,A bpm:120-140 #быстрые -#рождество</code>\n\n<code>key:G,A</code> — тональности\n<code>bpm:120-140</code> — темп\n<code>time:4/4</code> — размер\n<code>#тег</code> — с тегом, <code>-#тег</code> — без тега\n<code>notplayed:3m</code> — не пели 3 месяца (<code>d</code>, <code>w</code>, <code>m</code>, <code>y</code> или дата <code>01.02.2026</code>)\n<code>archived</code> — только архив, <code>archived:all</code> — вместе с архивом\n\nОстальные слова ищутся в названии и тексте песни. Этот же фильтр работает в инлайн-режиме.",
+		"uk": "Напиши фільтр, наприклад: <code>key:G,A bpm:120-140 #швидкі -#різдво</code>\n\n<code>key:G,A</code> — тональності\n<code>bpm:120-140</code> — темп\n<code>time:4/4</code> — розмір\n<code>#тег</code> — з тегом, <code>-#тег</code> — без тегу\n<code>notplayed:3m</code> — не співали 3 місяці (<code>d</code>, <code>w</code>, <code>m</code>, <code>y</code> або дата <code>01.02.2026</code>)\n<code>archived</code> — лише архів, <code>archived:all</code> — разом з архівом\n\nІнші слова шукаються в назві та тексті пісні. Цей самий фільтр працює в інлайн-режимі.",
+	},
+	"button.songSuggestions": {
+		"ru": "💡 Предложить песни",
+		"uk": "💡 Запропонувати пісні",
+	},
+	"text.songSuggestions": {
+		"ru": "💡 Песни, которые можно добавить в список:",
+		"uk": "💡 Пісні, які можна додати до списку:",
+	},
+	"text.noSongSuggestions": {
+		"ru": "Нет песен, которые можно предложить.",
+		"uk": "Немає пісень, які можна запропонувати.",
+	},
+	"text.songSuggestionAdded": {
+		"ru": "Песня добавлена в список!",
+		"uk": "Пісня додана до списку!",
+	},
+	"text.songSuggestionReason.neverPlayed": {
+		"ru": "не пели за год",
+		"uk": "не співали за рік",
+	},
+	"text.songSuggestionReason.notPlayedLong": {
+		"ru": "давно не пели",
+		"uk": "давно не співали",
+	},
+	"text.songSuggestionReason.popular": {
+		"ru": "популярная",
+		"uk": "популярна",
+	},
+	"text.songSuggestionReason.tagMatch": {
+		"ru": "подходит по тегам",
+		"uk": "підходить за тегами",
+	},
+	"text.songSuggestionReason.keyFlow": {
+		"ru": "подходит по тональности",
+		"uk": "підходить за тональністю",
+	},
+	"text.songSuggestionReason.tempoFlow": {
+		"ru": "подходит по темпу",
+		"uk": "підходить за темпом",
+	},
+	"button.setlistFlow": {
+		"ru": "🎼 Переходы",
+		"uk": "🎼 Переходи",
+	},
+	"button.applySetlistOrder": {
+		"ru": "🔀 Применить порядок",
+		"uk": "🔀 Застосувати порядок",
+	},
+	"text.setlistDuration": {
+		"ru": "⏱ Длительность: ~%d мин.",
+		"uk": "⏱ Тривалість: ~%d хв.",
+	},
+	"text.setlistFlow": {
+		"ru": "🎼 Переходы между песнями:",
+		"uk": "🎼 Переходи між піснями:",
+	},
+	"text.setlistFlowEmpty": {
+		"ru": "В списке меньше двух песен.",
+		"uk": "У списку менше двох пісень.",
+	},
+	"text.setlistFlowSmooth": {
+		"ru": "Переходы между песнями плавные 👍",
+		"uk": "Переходи між піснями плавні 👍",
+	},
+	"text.setlistFlowKeyJump": {
+		"ru": "резкая смена тональности %s → %s",
+		"uk": "різка зміна тональності %s → %s",
+	},
+	"text.setlistFlowSuggestedKey": {
+		"ru": "можно спеть в %s",
+		"uk": "можна заспівати в %s",
+	},
+	"text.setlistFlowTempoJump": {
+		"ru": "резкая смена темпа %s → %s",
+		"uk": "різка зміна темпу %s → %s",
+	},
+	"text.setlistFlowSuggestedOrder": {
+		"ru": "🔀 Более плавный порядок:",
+		"uk": "🔀 Плавніший порядок:",
+	},
+	"text.setlistFlowUnknownDuration": {
+		"ru": "Длительность неизвестна у песен: %d.",
+		"uk": "Тривалість невідома у пісень: %d.",
+	},
+	"text.setlistOrderApplied": {
+		"ru": "Порядок песен изменён!",
+		"uk": "Порядок пісень змінено!",
+	},
+	"button.keyPreferences": {
+		"ru": "🎤 Моя тональность",
+		"uk": "🎤 Моя тональність",
+	},
+	"button.removeKeyPreference": {
+		"ru": "🗑 Удалить мою тональность",
+		"uk": "🗑 Видалити мою тональність",
+	},
+	"text.chooseKeyPreference": {
+		"ru": "В какой тональности тебе удобно петь эту песню?",
+		"uk": "У якій тональності тобі зручно співати цю пісню?",
+	},
+	"text.keyPreferenceSaved": {
+		"ru": "Тональность %s сохранена!",
+		"uk": "Тональність %s збережено!",
+	},
+	"text.keyPreferenceRemoved": {
+		"ru": "Тональность удалена.",
+		"uk": "Тональність видалено.",
+	},
+	"button.chartViews": {
+		"ru": "🎸 Каподастр / Цифры",
+		"uk": "🎸 Каподастр / Цифри",
+	},
+	"button.capo": {
+		"ru": "Капо %d (%s)",
+		"uk": "Капо %d (%s)",
+	},
+	"button.nashville": {
+		"ru": "🔢 Нэшвилл (цифры)",
+		"uk": "🔢 Нешвіл (цифри)",
+	},
+	"text.chooseChartView": {
+		"ru": "Выбери, в каком виде прислать аккорды. Документ песни не изменится.",
+		"uk": "Обери, у якому вигляді надіслати акорди. Документ пісні не зміниться.",
+	},
+	"text.chartViewUnknownKey": {
+		"ru": "Сначала укажи тональность песни.",
+		"uk": "Спочатку вкажи тональність пісні.",
+	},
+	"text.capoCaption": {
+		"ru": "Тональность %s, каподастр на %d ладу — играй аккорды %s.",
+		"uk": "Тональність %s, каподастр на %d ладу — грай акорди %s.",
+	},
+	"text.nashvilleCaption": {
+		"ru": "Нэшвиллская система, 1 = %s.",
+		"uk": "Нешвільська система, 1 = %s.",
+	},
+	"button.eventVoices": {
+		"ru": "🎧 Партии",
+		"uk": "🎧 Партії",
+	},
+	"button.transposeToEventKey": {
+		"ru": "🎧 В тональность события (%s)",
+		"uk": "🎧 У тональність події (%s)",
+	},
+	"button.myVoices": {
+		"ru": "🎤 Мои партии",
+		"uk": "🎤 Мої партії",
+	},
+	"button.voiceMixer": {
+		"ru": "🎚 Микшер",
+		"uk": "🎚 Мікшер",
+	},
+	"button.mix": {
+		"ru": "🎧 Свести",
+		"uk": "🎧 Звести",
+	},
+	"button.reset": {
+		"ru": "↺ Сбросить",
+		"uk": "↺ Скинути",
+	},
+	"button.countIn": {
+		"ru": "Отсчёт: %d",
+		"uk": "Відлік: %d",
+	},
+	"button.clickOnly": {
+		"ru": "Только метроном",
+		"uk": "Лише метроном",
+	},
+	"button.makeClickTrack": {
+		"ru": "🥁 Сделать метроном",
+		"uk": "🥁 Зробити метроном",
+	},
+	"button.voiceSegments": {
+		"ru": "✂️ Фрагменты (%d)",
+		"uk": "✂️ Фрагменти (%d)",
+	},
+	"button.addVoiceSegment": {
+		"ru": "➕ Добавить фрагмент",
+		"uk": "➕ Додати фрагмент",
+	},
+	"button.audioFormatOriginal": {
+		"ru": "Как у оригинала",
+		"uk": "Як в оригіналу",
+	},
+	"button.normalizeLoudness": {
+		"ru": "🔊 Выравнивать громкость",
+		"uk": "🔊 Вирівнювати гучність",
+	},
+	"button.sendAsAudioFile": {
+		"ru": "🎵 Прислать файлом",
+		"uk": "🎵 Надіслати файлом",
+	},
+	"button.slides": {
+		"ru": "🖥 Слайды для проектора",
+		"uk": "🖥 Слайди для проектора",
+	},
+	"button.slidesOpenLyrics": {
+		"ru": "OpenLyrics (OpenLP)",
+		"uk": "OpenLyrics (OpenLP)",
+	},
+	"button.slidesText": {
+		"ru": "Текст (ProPresenter)",
+		"uk": "Текст (ProPresenter)",
+	},
+	"button.slidesPDF": {
+		"ru": "PDF",
+		"uk": "PDF",
+	},
+	"text.chooseSlidesFormat": {
+		"ru": "🖥 В каком формате выгрузить слайды?\n\nOpenLyrics импортируется в OpenLP, текстовые файлы — в ProPresenter (один слайд на абзац). PDF можно сразу показывать на проекторе.",
+		"uk": "🖥 У якому форматі вивантажити слайди?\n\nOpenLyrics імпортується в OpenLP, текстові файли — у ProPresenter (один слайд на абзац). PDF можна одразу показувати на проекторі.",
+	},
+	"text.slidesCaption": {
+		"ru": "Слайды: %s. Песен: %d.",
+		"uk": "Слайди: %s. Пісень: %d.",
+	},
+	"text.songImportInsufficientRights": {
+		"ru": "Импортировать папку Google Drive может только администратор группы.",
+		"uk": "Імпортувати папку Google Drive може лише адміністратор групи.",
+	},
+	"text.songImportStarted": {
+		"ru": "📥 Импортирую песни из папки Google Drive группы...",
+		"uk": "📥 Імпортую пісні з папки Google Drive групи...",
+	},
+	"text.songImportAlreadyRunning": {
+		"ru": "Импорт папки уже идёт, прогресс обновляется в этом сообщении.",
+		"uk": "Імпорт папки вже триває, прогрес оновлюється в цьому повідомленні.",
+	},
+	"text.songImportProgress": {
+		"ru": "📥 Импортирую песни из папки Google Drive группы...\n\nОбработано документов: %d\nНовых песен: %d\nОшибок: %d",
+		"uk": "📥 Імпортую пісні з папки Google Drive групи...\n\nОброблено документів: %d\nНових пісень: %d\nПомилок: %d",
+	},
+	"text.songImportDone": {
+		"ru": "✅ Импорт завершён.\n\nОбработано документов: %d\nНовых песен: %d\nОшибок: %d",
+		"uk": "✅ Імпорт завершено.\n\nОброблено документів: %d\nНових пісень: %d\nПомилок: %d",
+	},
+	"text.songImportFailedFiles": {
+		"ru": "Не удалось импортировать:",
+		"uk": "Не вдалося імпортувати:",
+	},
+	"text.songImportFailed": {
+		"ru": "❌ Импорт остановлен из-за ошибки, его можно продолжить командой /import.\n\nОбработано документов: %d\nНовых песен: %d\nОшибок: %d",
+		"uk": "❌ Імпорт зупинено через помилку, його можна продовжити командою /import.\n\nОброблено документів: %d\nНових пісень: %d\nПомилок: %d",
+	},
 	"button.approve": {
 		"ru": "✅ Принять",
 		"uk": "✅ Прийняти",
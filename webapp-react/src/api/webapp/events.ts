@@ -1,6 +1,12 @@
 import { doReqWebappApi } from "@/api/webapp/doReq.ts";
-import { RespEventData, RespEventFreqNames } from "@/api/webapp/typesResp.ts";
 import {
+  RespEventData,
+  RespEventFreqNames,
+  RespEventKeyProposals,
+  RespSetlistFlow,
+} from "@/api/webapp/typesResp.ts";
+import {
+  ReqBodySetlistFlow,
   ReqBodyUpdateEvent,
   ReqQueryParamsUpdateEvent,
 } from "@/api/webapp/typesReq.ts";
@@ -75,3 +81,39 @@ export async function updateEvent(
 
   return;
 }
+
+export async function analyzeSetlistFlow(
+  body: ReqBodySetlistFlow,
+): Promise<RespSetlistFlow | null> {
+  const { data, err } = await doReqWebappApi<RespSetlistFlow>(
+    `/api/events/setlist-flow`,
+    "POST",
+    undefined,
+    { Accept: "application/json" },
+    body,
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
+
+export async function getEventKeyProposals(
+  eventId: string,
+  songIds: string[],
+): Promise<RespEventKeyProposals | null> {
+  const { data, err } = await doReqWebappApi<RespEventKeyProposals>(
+    `/api/events/${eventId}/key-proposals`,
+    "GET",
+    { songIds: songIds.join(",") },
+    { Accept: "application/json" },
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
@@ -138,6 +138,7 @@ export const mockStatisticsData: RespStatistics = {
   bandName: "Worship Band",
   currentDate: "2026-03-25",
   defaultFromDate: "2025-09-25",
+  audioCache: { entries: 12, hits: 37, size: 58720256 },
   roles: [
     { id: "role-1", name: "Vocalist" },
     { id: "role-2", name: "Guitarist" },
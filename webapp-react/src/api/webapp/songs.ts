@@ -1,10 +1,54 @@
 import { doReqWebappApi } from "@/api/webapp/doReq.ts";
 import {
   RespSong,
+  RespSongChartView,
   RespSongData,
+  RespSongFilter,
   RespSongLyrics,
+  RespSongSuggestions,
 } from "@/api/webapp/typesResp.ts";
-import { ReqBodyUpdateSong, ReqQueryParamsUpdateSong } from "./typesReq.ts";
+import {
+  ReqBodyArrangement,
+  ReqBodyUpdateSong,
+  ReqQueryParamsFilterSongs,
+  ReqQueryParamsSongChartView,
+  ReqQueryParamsSongSuggestions,
+  ReqQueryParamsUpdateSong,
+} from "./typesReq.ts";
+
+export async function filterSongs(
+  params: ReqQueryParamsFilterSongs,
+): Promise<RespSongFilter | null> {
+  const { data, err } = await doReqWebappApi<RespSongFilter>(
+    `/api/songs/filter`,
+    "GET",
+    params,
+    { Accept: "application/json" },
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
+
+export async function getSongSuggestions(
+  params: ReqQueryParamsSongSuggestions,
+): Promise<RespSongSuggestions | null> {
+  const { data, err } = await doReqWebappApi<RespSongSuggestions>(
+    `/api/songs/suggestions`,
+    "GET",
+    params,
+    { Accept: "application/json" },
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
 
 export async function getSongData(
   songId: string,
@@ -58,6 +102,24 @@ export async function getSongLyrics(
   return data;
 }
 
+export async function getSongChartView(
+  songId: string,
+  params: ReqQueryParamsSongChartView,
+): Promise<RespSongChartView | null> {
+  const { data, err } = await doReqWebappApi<RespSongChartView>(
+    `/api/songs/${songId}/chart`,
+    "GET",
+    params,
+    { Accept: "application/json" },
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
+
 export async function updateSong(
   songId: string,
   queryParams: ReqQueryParamsUpdateSong,
@@ -109,3 +171,69 @@ export async function downloadSongPdf(songId: string): Promise<Blob | null> {
 
   return data;
 }
+
+export function getSongUsageReportUrl(bandId: string, fromDate?: string): string {
+  const url = new URL("/api/songs/usage-report", window.location.origin);
+  url.searchParams.set("bandId", bandId);
+  if (fromDate) {
+    url.searchParams.set("from", fromDate);
+  }
+  return url.toString();
+}
+
+export async function createArrangement(
+  songId: string,
+  body: ReqBodyArrangement,
+): Promise<RespSong | null> {
+  const { data, err } = await doReqWebappApi<RespSong>(
+    `/api/songs/${songId}/arrangements`,
+    "POST",
+    undefined,
+    { Accept: "application/json" },
+    body,
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
+
+export async function updateArrangement(
+  songId: string,
+  arrangementId: string,
+  body: ReqBodyArrangement,
+): Promise<RespSong | null> {
+  const { data, err } = await doReqWebappApi<RespSong>(
+    `/api/songs/${songId}/arrangements/${arrangementId}/edit`,
+    "POST",
+    undefined,
+    { Accept: "application/json" },
+    body,
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
+
+export async function deleteArrangement(
+  songId: string,
+  arrangementId: string,
+): Promise<RespSong | null> {
+  const { data, err } = await doReqWebappApi<RespSong>(
+    `/api/songs/${songId}/arrangements/${arrangementId}`,
+    "DELETE",
+    undefined,
+    { Accept: "application/json" },
+  );
+
+  if (err) {
+    throw err;
+  }
+
+  return data;
+}
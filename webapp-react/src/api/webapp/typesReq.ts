@@ -1,3 +1,5 @@
+import { DriveStyleProfile, SlideRules } from "@/api/webapp/typesResp.ts";
+
 export interface ReqQueryParamsUpdateSong {
   messageId: string;
   chatId: string;
@@ -11,6 +13,14 @@ export interface ReqBodyUpdateSong {
   time?: string;
   tags?: string[];
   transposeSection?: string;
+  authors?: string[];
+  copyright?: string;
+  ccliNumber?: string;
+  language?: string;
+  durationSec?: number;
+  originalKey?: string;
+  roadMap?: string;
+  writeCreditsToDoc?: boolean;
 }
 
 export interface ReqQueryParamsUpdateEvent {
@@ -21,7 +31,18 @@ export interface ReqQueryParamsUpdateEvent {
 
 export interface SongOverride {
   songId: string;
-  eventKey: string;
+  eventKey?: string;
+  arrangementId?: string;
+  roadMap?: string;
+}
+
+export interface ReqBodyArrangement {
+  name: string;
+  structure?: string;
+  key?: string;
+  bpm?: string;
+  time?: string;
+  driveFileId?: string;
 }
 
 export interface ReqBodyUpdateEvent {
@@ -33,6 +54,11 @@ export interface ReqBodyUpdateEvent {
   notes?: string;
 }
 
+export interface ReqBodySetlistFlow {
+  songIds: string[];
+  songOverrides?: SongOverride[];
+}
+
 export interface ReqBodySettingsBand {
   name: string;
   driveFolderId?: string;
@@ -45,8 +71,37 @@ export interface ReqBodySettingsBandPatch {
   driveFolderId?: string;
   driveFolderUrl?: string;
   timezone?: string;
+  slideRules?: SlideRules;
+  driveStyle?: DriveStyleProfile;
 }
 
 export interface ReqBodySettingsMemberRole {
   isAdmin: boolean;
 }
+
+export interface ReqQueryParamsFilterSongs {
+  bandId: string;
+  q?: string; // Filter syntax, e.g. "key:G bpm:120-140 #fast"
+  keys?: string; // Comma separated
+  minBpm?: string;
+  maxBpm?: string;
+  times?: string;
+  tags?: string;
+  excludeTags?: string;
+  archived?: "only" | "include";
+  notPlayedSince?: string; // YYYY-MM-DD
+  limit?: string;
+}
+
+export interface ReqQueryParamsSongChartView {
+  capo?: string; // Capo fret, 1-7
+  nashville?: string; // "true" to render Nashville numbers
+}
+
+export interface ReqQueryParamsSongSuggestions {
+  bandId: string;
+  songIds?: string; // Comma separated, in setlist order
+  date?: string; // YYYY-MM-DD
+  tags?: string; // Comma separated
+  limit?: string;
+}
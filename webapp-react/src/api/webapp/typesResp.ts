@@ -14,9 +14,27 @@ interface Song {
   band: Band;
   pdf: Pdf;
   tags: string[];
+  authors?: string[];
+  copyright?: string;
+  ccliNumber?: string;
+  language?: string;
+  durationSec?: number;
+  originalKey?: string;
+  arrangements?: SongArrangement[];
+  roadMap?: string;
   isArchived: boolean;
 }
 
+export interface SongArrangement {
+  id: string;
+  name: string;
+  structure?: string;
+  key?: string;
+  bpm?: string;
+  time?: string;
+  driveFileId?: string;
+}
+
 interface Band {
   id: string;
   name: string;
@@ -37,6 +55,80 @@ interface Pdf {
   webViewLink: string;
 }
 
+export interface SongFilter {
+  keys?: string[];
+  minBpm?: number;
+  maxBpm?: number;
+  times?: string[];
+  tags?: string[];
+  excludeTags?: string[];
+  archived?: "only" | "include";
+  notPlayedSince?: string;
+}
+
+export interface RespSongFilter {
+  songs: Song[];
+  filter: SongFilter;
+}
+
+export type SongSuggestionReason =
+  | "neverPlayed"
+  | "notPlayedLong"
+  | "popular"
+  | "tagMatch"
+  | "keyFlow"
+  | "tempoFlow";
+
+export interface SongSuggestion {
+  song: Song;
+  score: number;
+  lastPlayed?: string;
+  timesPlayed: number;
+  reasons: SongSuggestionReason[];
+}
+
+export interface RespSongSuggestions {
+  suggestions: SongSuggestion[];
+}
+
+export type SetlistTransitionIssue = "keyJump" | "tempoJump";
+
+export interface SetlistTransition {
+  from: number; // Index of the song, the next song is from + 1
+  fromKey: string;
+  toKey: string;
+  fromBpm: string;
+  toBpm: string;
+  score: number;
+  issues: SetlistTransitionIssue[];
+  suggestedKey?: string;
+}
+
+export interface SetlistFlow {
+  songs: Song[];
+  transitions: SetlistTransition[];
+  score: number;
+  suggestedOrder?: number[];
+  suggestedScore?: number;
+  durationSec: number;
+  songsWithoutDuration: number;
+}
+
+export interface RespSetlistFlow {
+  flow: SetlistFlow;
+}
+
+export interface KeyProposal {
+  songId: string;
+  key: string;
+  userId: number;
+  userName: string;
+}
+
+export interface RespEventKeyProposals {
+  proposals: KeyProposal[];
+}
+
 export interface RespSongLyrics {
   lyricsHtml: string;
   sectionsNumber: number;
@@ -49,6 +141,14 @@ export interface RespSongLyrics {
   };
 }
 
+export interface RespSongChartView {
+  lyricsHtml: string;
+  key: string;
+  chartKey: string;
+  capo: number;
+  nashville: boolean;
+}
+
 export interface RespTags {
   tags: string[];
 }
@@ -67,6 +167,13 @@ export interface RespStatistics {
   defaultFromDate: string;
   roles: StatisticsRole[];
   users: StatisticsUser[];
+  audioCache?: StatisticsAudioCache;
+}
+
+export interface StatisticsAudioCache {
+  entries: number;
+  hits: number;
+  size: number;
 }
 
 export interface StatisticsUser {
@@ -91,7 +198,9 @@ export interface StatisticsRole {
 // SetlistItem represents a song in the setlist with optional event-specific overrides
 export interface SongOverride {
   songId: string;
-  eventKey: string;
+  eventKey?: string;
+  arrangementId?: string;
+  roadMap?: string;
 }
 
 export interface Event {
@@ -141,12 +250,27 @@ export interface SettingsBand {
   archiveFolderId: string;
   tempFolderId: string;
   timezone: string;
+  slideRules: SlideRules;
+  driveStyle: DriveStyleProfile;
   isMember: boolean;
   isActive: boolean;
   isAdmin: boolean;
   hasPendingJoinRequest: boolean;
 }
 
+// SlideRules are rules of splitting lyrics into projection slides.
+export interface SlideRules {
+  maxLines: number;
+  ignoreBlankLines: boolean;
+  uppercase: boolean;
+}
+
+// DriveStyleProfile overrides the Drive doc style config file for a band.
+export interface DriveStyleProfile {
+  preset: string;
+  yaml: string;
+}
+
 export interface SettingsMember {
   id: number;
   name: string;
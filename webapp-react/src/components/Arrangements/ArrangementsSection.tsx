@@ -0,0 +1,130 @@
+import { createArrangement, deleteArrangement } from "@/api/webapp/songs.ts";
+import { SongArrangement } from "@/api/webapp/typesResp.ts";
+import { logger } from "@/helpers/logger";
+import { TrashIcon } from "@heroicons/react/20/solid";
+import {
+  Button,
+  Cell,
+  IconButton,
+  Input,
+  Section,
+} from "@telegram-apps/telegram-ui";
+import { hapticFeedback } from "@tma.js/sdk-react";
+import { Notify } from "notiflix";
+import { useState } from "react";
+import { useTranslation } from "react-i18next";
+
+interface ArrangementsSectionProps {
+  songId: string;
+  arrangements: SongArrangement[];
+  onChange: () => void;
+}
+
+const emptyArrangement = { name: "", structure: "", key: "", bpm: "" };
+
+export function ArrangementsSection({
+  songId,
+  arrangements,
+  onChange,
+}: ArrangementsSectionProps) {
+  const { t } = useTranslation();
+
+  const [form, setForm] = useState(emptyArrangement);
+  const [isSaving, setIsSaving] = useState(false);
+
+  const handleAdd = async () => {
+    hapticFeedback.impactOccurred("light");
+    setIsSaving(true);
+    try {
+      await createArrangement(songId, form);
+      setForm(emptyArrangement);
+      onChange();
+    } catch (err) {
+      logger.error("Failed to create arrangement", { error: err });
+      Notify.failure(t("arrangementSaveError"));
+    } finally {
+      setIsSaving(false);
+    }
+  };
+
+  const handleDelete = async (arrangement: SongArrangement) => {
+    hapticFeedback.impactOccurred("light");
+    try {
+      await deleteArrangement(songId, arrangement.id);
+      onChange();
+    } catch (err) {
+      logger.error("Failed to delete arrangement", { error: err });
+      Notify.failure(t("arrangementDeleteError"));
+    }
+  };
+
+  return (
+    <Section header={t("arrangements")} footer={t("arrangementsHint")}>
+      {arrangements.map((arrangement) => (
+        <Cell
+          key={arrangement.id}
+          subtitle={[arrangement.key, arrangement.bpm, arrangement.structure]
+            .filter(Boolean)
+            .join(", ")}
+          after={
+            <IconButton
+              mode="outline"
+              size="s"
+              onClick={() => handleDelete(arrangement)}
+            >
+              <TrashIcon className="h-5 w-5 text-[var(--tg-theme-destructive-text-color)]" />
+            </IconButton>
+          }
+        >
+          {arrangement.name}
+        </Cell>
+      ))}
+
+      <Input
+        placeholder={t("arrangementNamePlaceholder")}
+        value={form.name}
+        onChange={(e) => setForm((prev) => ({ ...prev, name: e.target.value }))}
+      />
+      <Input
+        placeholder={t("arrangementStructurePlaceholder")}
+        value={form.structure}
+        onChange={(e) =>
+          setForm((prev) => ({ ...prev, structure: e.target.value }))
+        }
+      />
+      <div className="flex flex-row items-center gap-2">
+        <div className="flex-1">
+          <Input
+            placeholder={t("keyPlaceholder")}
+            value={form.key}
+            onChange={(e) =>
+              setForm((prev) => ({ ...prev, key: e.target.value }))
+            }
+          />
+        </div>
+        <div className="flex-1">
+          <Input
+            placeholder={t("bpmPlaceholder")}
+            value={form.bpm}
+            inputMode="decimal"
+            onChange={(e) =>
+              setForm((prev) => ({ ...prev, bpm: e.target.value }))
+            }
+          />
+        </div>
+      </div>
+      <div className="p-2">
+        <Button
+          mode="bezeled"
+          size="s"
+          stretched
+          disabled={!form.name.trim() || isSaving}
+          loading={isSaving}
+          onClick={handleAdd}
+        >
+          {t("arrangementAdd")}
+        </Button>
+      </div>
+    </Section>
+  );
+}
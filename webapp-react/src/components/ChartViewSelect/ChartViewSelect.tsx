@@ -0,0 +1,53 @@
+import { ReqQueryParamsSongChartView } from "@/api/webapp/typesReq.ts";
+import { Select, SelectProps } from "@telegram-apps/telegram-ui";
+import React, { ChangeEvent } from "react";
+import { useTranslation } from "react-i18next";
+
+export const maxCapo = 7;
+
+export const chartViewNashville = "nashville";
+
+// chartViewQueryParams converts the selected view ("", "nashville" or capo fret) to request params.
+export function chartViewQueryParams(
+  view: string,
+): ReqQueryParamsSongChartView {
+  if (view === chartViewNashville) {
+    return { nashville: "true" };
+  }
+  return { capo: view };
+}
+
+interface ChartViewSelectProps
+  extends Omit<SelectProps, "onChange" | "children"> {
+  value: string;
+  onChange: (v: string) => void;
+}
+
+export const ChartViewSelect: React.FC<ChartViewSelectProps> = ({
+  value,
+  onChange,
+  ...restProps
+}) => {
+  const { t } = useTranslation();
+
+  const handleChange = (e: ChangeEvent<HTMLSelectElement>) => {
+    onChange(e.target.value);
+  };
+
+  return (
+    <Select
+      header={t("chartView")}
+      value={value}
+      onChange={handleChange}
+      {...restProps}
+    >
+      <option value="">{t("chartViewOriginal")}</option>
+      {Array.from({ length: maxCapo }, (_, i) => i + 1).map((capo) => (
+        <option key={capo} value={String(capo)}>
+          {t("chartViewCapo", { capo })}
+        </option>
+      ))}
+      <option value={chartViewNashville}>{t("chartViewNashville")}</option>
+    </Select>
+  );
+};
@@ -197,6 +197,8 @@ export default function Search({
                               key: data.song.pdf.key,
                               bpm: data.song.pdf.bpm,
                               time: data.song.pdf.time,
+                              arrangements: data.song.arrangements,
+                              roadMap: data.song.roadMap,
                             };
 
                             onSelectSong?.(song);
@@ -1,6 +1,7 @@
 import SetlistSongDisplay, {
   SetlistSong,
 } from "@/components/Setlist/SetlistSong.tsx";
+import { KeyProposal } from "@/api/webapp/typesResp.ts";
 import { Song } from "@/pages/EventPage/util/types.ts";
 import { Section } from "@telegram-apps/telegram-ui";
 import { useTranslation } from "react-i18next";
@@ -30,9 +31,20 @@ interface SetlistProps {
   onRemove: (song: Song) => void;
   onReorder: (newSetlist: Song[]) => void;
   onKeyChange?: (song: Song, newKey: string) => void;
+  onArrangementChange?: (song: Song, arrangementId: string) => void;
+  onRoadMapChange?: (song: Song, roadMap: string) => void;
+  keyProposals?: KeyProposal[];
 }
 
-export function Setlist({ items, onRemove, onReorder, onKeyChange }: SetlistProps) {
+export function Setlist({
+  items,
+  onRemove,
+  onReorder,
+  onKeyChange,
+  onArrangementChange,
+  onRoadMapChange,
+  keyProposals,
+}: SetlistProps) {
   const { t } = useTranslation();
 
   const [activeSong, setActiveSong] = useState<Song | null>(null);
@@ -105,7 +117,15 @@ export function Setlist({ items, onRemove, onReorder, onKeyChange }: SetlistProp
         <SortableContext items={items} strategy={verticalListSortingStrategy}>
           <Section footer={items.length > 0 && t("setlistFooter")}>
             {items.map((song: Song) => (
-              <SetlistSong key={song.id} song={song} onRemove={onRemove} onKeyChange={onKeyChange} />
+              <SetlistSong
+                key={song.id}
+                song={song}
+                onRemove={onRemove}
+                onKeyChange={onKeyChange}
+                onArrangementChange={onArrangementChange}
+                onRoadMapChange={onRoadMapChange}
+                keyProposal={keyProposals?.find((p) => p.songId === song.id)}
+              />
             ))}
           </Section>
         </SortableContext>
@@ -0,0 +1,144 @@
+import { analyzeSetlistFlow } from "@/api/webapp/events.ts";
+import { SetlistTransition } from "@/api/webapp/typesResp.ts";
+import { Song } from "@/pages/EventPage/util/types.ts";
+import { useQuery } from "@tanstack/react-query";
+import { Button, Cell, Section } from "@telegram-apps/telegram-ui";
+import { useTranslation } from "react-i18next";
+
+interface SetlistFlowProps {
+  songs: Song[];
+  onReorder: (newSetlist: Song[]) => void;
+  onKeyChange?: (song: Song, newKey: string) => void;
+}
+
+export function SetlistFlow({ songs, onReorder, onKeyChange }: SetlistFlowProps) {
+  const { t } = useTranslation();
+
+  const body = {
+    songIds: songs.map((song) => song.id),
+    songOverrides: songs
+      .filter((song) => song.eventKey || song.arrangementId)
+      .map((song) => ({
+        songId: song.id,
+        eventKey: song.eventKey,
+        arrangementId: song.arrangementId,
+      })),
+  };
+
+  const flowQuery = useQuery({
+    queryKey: ["setlistFlow", body],
+    queryFn: async () => {
+      const data = await analyzeSetlistFlow(body);
+      if (!data) {
+        throw new Error("Failed to analyze setlist.");
+      }
+      return data;
+    },
+    enabled: songs.length > 0,
+  });
+
+  const flow = flowQuery.data?.flow;
+  // Songs may be missing in the response if they were deleted.
+  if (!flow || flow.songs.length !== songs.length) {
+    return null;
+  }
+
+  const issues = flow.transitions.filter(
+    (transition) => transition.issues.length > 0,
+  );
+
+  const durationMin = Math.round(flow.durationSec / 60);
+
+  const transitionText = (transition: SetlistTransition) => {
+    const texts: string[] = [];
+    if (transition.issues.includes("keyJump")) {
+      texts.push(
+        t("setlistFlowKeyJump", {
+          from: transition.fromKey,
+          to: transition.toKey,
+        }),
+      );
+    }
+    if (transition.issues.includes("tempoJump")) {
+      texts.push(
+        t("setlistFlowTempoJump", {
+          from: transition.fromBpm,
+          to: transition.toBpm,
+        }),
+      );
+    }
+    return texts.join(", ");
+  };
+
+  return (
+    <Section
+      header={t("setlistFlow")}
+      footer={
+        flow.durationSec > 0 &&
+        (flow.songsWithoutDuration > 0
+          ? t("setlistFlowDurationPartial", {
+              minutes: durationMin,
+              unknown: flow.songsWithoutDuration,
+            })
+          : t("setlistFlowDuration", { minutes: durationMin }))
+      }
+    >
+      {issues.length === 0 && flow.transitions.length > 0 && (
+        <Cell multiline>{t("setlistFlowSmooth")}</Cell>
+      )}
+
+      {issues.map((transition) => {
+        const toSong = songs[transition.from + 1];
+        return (
+          <Cell
+            key={transition.from}
+            multiline
+            subtitle={transitionText(transition)}
+            after={
+              transition.suggestedKey &&
+              onKeyChange && (
+                <Button
+                  mode="plain"
+                  size="s"
+                  onClick={() =>
+                    onKeyChange(toSong, transition.suggestedKey as string)
+                  }
+                >
+                  {t("setlistFlowUseKey", { key: transition.suggestedKey })}
+                </Button>
+              )
+            }
+          >
+            {`${songs[transition.from].name} → ${toSong.name}`}
+          </Cell>
+        );
+      })}
+
+      {flow.suggestedOrder && (
+        <Cell
+          multiline
+          subtitle={flow.suggestedOrder
+            .map((index) => songs[index].name)
+            .join(" → ")}
+          after={
+            <Button
+              mode="plain"
+              size="s"
+              onClick={() =>
+                onReorder(
+                  (flow.suggestedOrder as number[]).map(
+                    (index) => songs[index],
+                  ),
+                )
+              }
+            >
+              {t("setlistFlowApplyOrder")}
+            </Button>
+          }
+        >
+          {t("setlistFlowSuggestedOrder")}
+        </Cell>
+      )}
+    </Section>
+  );
+}
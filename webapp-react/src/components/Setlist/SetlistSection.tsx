@@ -1,5 +1,8 @@
 import Search from "@/components/Setlist/Search.tsx";
 import { Setlist } from "@/components/Setlist/Setlist.tsx";
+import { SetlistFlow } from "@/components/Setlist/SetlistFlow.tsx";
+import { Suggestions } from "@/components/Setlist/Suggestions.tsx";
+import { KeyProposal } from "@/api/webapp/typesResp.ts";
 import { Song } from "@/pages/EventPage/util/types.ts";
 import { hapticFeedback } from "@tma.js/sdk-react";
 import { Notify } from "notiflix";
@@ -12,8 +15,13 @@ interface SetlistSectionProps {
   onRemove: (song: Song) => void;
   onReorder: (newSetlist: Song[]) => void;
   onKeyChange?: (song: Song, newKey: string) => void;
+  onArrangementChange?: (song: Song, arrangementId: string) => void;
+  onRoadMapChange?: (song: Song, roadMap: string) => void;
   driveFolderId: string;
   archiveFolderId?: string | null;
+  bandId: string;
+  date: string;
+  keyProposals?: KeyProposal[];
 }
 
 export function SetlistSection({
@@ -22,8 +30,13 @@ export function SetlistSection({
   onRemove,
   onReorder,
   onKeyChange,
+  onArrangementChange,
+  onRoadMapChange,
   driveFolderId,
   archiveFolderId,
+  bandId,
+  date,
+  keyProposals,
 }: SetlistSectionProps) {
   const { t } = useTranslation();
 
@@ -51,7 +64,26 @@ export function SetlistSection({
           onSelectSong={handleSelectSong}
         />
       </div>
-      <Setlist items={songs} onRemove={onRemove} onReorder={onReorder} onKeyChange={onKeyChange} />
+      <Setlist
+        items={songs}
+        onRemove={onRemove}
+        onReorder={onReorder}
+        onKeyChange={onKeyChange}
+        onArrangementChange={onArrangementChange}
+        onRoadMapChange={onRoadMapChange}
+        keyProposals={keyProposals}
+      />
+      <SetlistFlow
+        songs={songs}
+        onReorder={onReorder}
+        onKeyChange={onKeyChange}
+      />
+      <Suggestions
+        bandId={bandId}
+        date={date}
+        songs={songs}
+        onSelectSong={handleSelectSong}
+      />
     </div>
   );
 }
@@ -1,4 +1,5 @@
 import { allValidKeys, keyGroups } from "@/components/KeyInput/KeyInput.tsx";
+import { KeyProposal } from "@/api/webapp/typesResp.ts";
 import { Song } from "@/pages/EventPage/util/types.ts";
 import { useSortable } from "@dnd-kit/sortable";
 import { CSS } from "@dnd-kit/utilities";
@@ -6,91 +7,174 @@ import { TrashIcon } from "@heroicons/react/20/solid";
 import { IconButton } from "@telegram-apps/telegram-ui";
 import { hapticFeedback } from "@tma.js/sdk-react";
 import React, { ChangeEvent } from "react";
+import { useTranslation } from "react-i18next";
 import "./Setlist.css";
 
 interface SetlistSongDisplayProps extends React.HTMLAttributes<HTMLDivElement> {
   song: Song;
   onRemove?: (song: Song) => void;
   onKeyChange?: (song: Song, newKey: string) => void;
+  onArrangementChange?: (song: Song, arrangementId: string) => void;
+  onRoadMapChange?: (song: Song, roadMap: string) => void;
+  keyProposal?: KeyProposal;
   isOverlay?: boolean;
 }
 
 const SetlistSongDisplay = React.forwardRef<
   HTMLDivElement,
   SetlistSongDisplayProps
->(({ song, onRemove, onKeyChange, isOverlay, ...props }, ref) => {
-  // Get the effective key (eventKey overrides original key)
-  const effectiveKey = song.eventKey || song.key;
-  const hasKeyOverride = song.eventKey && song.eventKey !== song.key;
+>(
+  (
+    {
+      song,
+      onRemove,
+      onKeyChange,
+      onArrangementChange,
+      onRoadMapChange,
+      keyProposal,
+      isOverlay,
+      ...props
+    },
+    ref,
+  ) => {
+    const { t } = useTranslation();
 
-  const handleKeyChange = (e: ChangeEvent<HTMLSelectElement>) => {
-    e.stopPropagation();
-    hapticFeedback.impactOccurred("light");
-    onKeyChange?.(song, e.target.value);
-  };
+    // Chosen arrangement overrides key, BPM and time of the song.
+    const arrangement = song.arrangements?.find(
+      (a) => a.id === song.arrangementId,
+    );
+    const baseKey = arrangement?.key || song.key;
+    const bpm = arrangement?.bpm || song.bpm;
+    const time = arrangement?.time || song.time;
+    const defaultRoadMap = arrangement?.structure || song.roadMap || "";
 
-  return (
-    <div
-      className={`relative mb-1 flex items-center justify-between rounded-xl bg-[var(--tg-theme-section-bg-color)] px-4 py-3 ${isOverlay ? "z-10 cursor-grabbing shadow-lg" : "cursor-grab"} `}
-      ref={ref}
-      {...props}
-    >
-      <div className="mr-2 min-w-0 flex-1">
-        <div className="mb-2 truncate font-[family-name:var(--tgui--font-family)] text-[length:var(--tgui--text--font_size)] leading-[var(--tgui--text--line_height)] font-[var(--tgui--font_weight--accent3)] text-[var(--tg-theme-text-color,#000)]">
-          {song.name}
-        </div>
-        <div className="flex items-center truncate font-[family-name:var(--tgui--font-family)] text-[length:var(--tgui--subheadline2--font_size)] leading-[var(--tgui--subheadline2--line_height)] font-[var(--tgui--font_weight--accent3)] text-[var(--tg-theme-hint-color)]">
-          {/* Key selector using native select */}
-          <select
-            value={effectiveKey}
-            onChange={handleKeyChange}
+    // Get the effective key (eventKey overrides original key)
+    const effectiveKey = song.eventKey || baseKey;
+    const hasKeyOverride = song.eventKey && song.eventKey !== baseKey;
+
+    const handleArrangementChange = (e: ChangeEvent<HTMLSelectElement>) => {
+      e.stopPropagation();
+      hapticFeedback.impactOccurred("light");
+      onArrangementChange?.(song, e.target.value);
+    };
+
+    const handleKeyChange = (e: ChangeEvent<HTMLSelectElement>) => {
+      e.stopPropagation();
+      hapticFeedback.impactOccurred("light");
+      onKeyChange?.(song, e.target.value);
+    };
+
+    return (
+      <div
+        className={`relative mb-1 flex items-center justify-between rounded-xl bg-[var(--tg-theme-section-bg-color)] px-4 py-3 ${isOverlay ? "z-10 cursor-grabbing shadow-lg" : "cursor-grab"} `}
+        ref={ref}
+        {...props}
+      >
+        <div className="mr-2 min-w-0 flex-1">
+          <div className="mb-2 truncate font-[family-name:var(--tgui--font-family)] text-[length:var(--tgui--text--font_size)] leading-[var(--tgui--text--line_height)] font-[var(--tgui--font_weight--accent3)] text-[var(--tg-theme-text-color,#000)]">
+            {song.name}
+          </div>
+          <div className="flex items-center truncate font-[family-name:var(--tgui--font-family)] text-[length:var(--tgui--subheadline2--font_size)] leading-[var(--tgui--subheadline2--line_height)] font-[var(--tgui--font_weight--accent3)] text-[var(--tg-theme-hint-color)]">
+            {/* Key selector using native select */}
+            <select
+              value={effectiveKey}
+              onChange={handleKeyChange}
+              onClick={(e) => e.stopPropagation()}
+              onPointerDown={(e) => e.stopPropagation()}
+              onTouchStart={(e) => e.stopPropagation()}
+              onMouseDown={(e) => e.stopPropagation()}
+              className={`mr-2 w-16 cursor-pointer appearance-none rounded-lg bg-[length:12px] bg-[right_4px_center] bg-no-repeat py-0.5 pl-2 text-base font-semibold outline-none focus:outline-none ${
+                hasKeyOverride
+                  ? "bg-[var(--tg-theme-secondary-bg-color)] bg-[url('data:image/svg+xml;charset=UTF-8,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%20viewBox%3D%220%200%2016%2016%22%20fill%3D%22white%22%3E%3Cpath%20fill-rule%3D%22evenodd%22%20d%3D%22M4.22%206.22a.75.75%200%200%201%201.06%200L8%208.94l2.72-2.72a.75.75%200%201%201%201.06%201.06l-3.25%203.25a.75.75%200%200%201-1.06%200L4.22%207.28a.75.75%200%200%201%200-1.06Z%22%20clip-rule%3D%22evenodd%22%2F%3E%3C%2Fsvg%3E')] text-[var(--tg-theme-text-color)]"
+                  : "bg-[var(--tg-theme-secondary-bg-color)] bg-[url('data:image/svg+xml;charset=UTF-8,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%20viewBox%3D%220%200%2016%2016%22%20fill%3D%22white%22%3E%3Cpath%20fill-rule%3D%22evenodd%22%20d%3D%22M4.22%206.22a.75.75%200%200%201%201.06%200L8%208.94l2.72-2.72a.75.75%200%201%201%201.06%201.06l-3.25%203.25a.75.75%200%200%201-1.06%200L4.22%207.28a.75.75%200%200%201%200-1.06Z%22%20clip-rule%3D%22evenodd%22%2F%3E%3C%2Fsvg%3E')] text-[var(--tg-theme-hint-color)]"
+              }`}
+            >
+              {/* Show current value if not in valid keys */}
+              {!allValidKeys.includes(effectiveKey) && (
+                <option value={effectiveKey}>{effectiveKey}</option>
+              )}
+
+              {keyGroups
+                .filter((group) => group.id !== "nashville")
+                .map((group) => (
+                  <optgroup key={group.id} label={group.label}>
+                    {group.options.map((option) => (
+                      <option key={option.value} value={option.value}>
+                        {option.label}
+                      </option>
+                    ))}
+                  </optgroup>
+                ))}
+            </select>
+            <span>
+              {bpm || "?"}, {time || "?"}
+            </span>
+            {keyProposal && keyProposal.key !== effectiveKey && (
+              <button
+                type="button"
+                onClick={(e) => {
+                  e.stopPropagation();
+                  hapticFeedback.impactOccurred("light");
+                  onKeyChange?.(song, keyProposal.key);
+                }}
+                onPointerDown={(e) => e.stopPropagation()}
+                onTouchStart={(e) => e.stopPropagation()}
+                onMouseDown={(e) => e.stopPropagation()}
+                className="ml-2 cursor-pointer truncate rounded-lg bg-[var(--tg-theme-secondary-bg-color)] px-2 py-0.5 text-[var(--tg-theme-accent-text-color)]"
+              >
+                {t("keyProposal", {
+                  name: keyProposal.userName,
+                  key: keyProposal.key,
+                })}
+              </button>
+            )}
+            {song.arrangements && song.arrangements.length > 0 && (
+              <select
+                value={song.arrangementId ?? ""}
+                onChange={handleArrangementChange}
+                onClick={(e) => e.stopPropagation()}
+                onPointerDown={(e) => e.stopPropagation()}
+                onTouchStart={(e) => e.stopPropagation()}
+                onMouseDown={(e) => e.stopPropagation()}
+                className="ml-2 max-w-32 cursor-pointer truncate rounded-lg bg-[var(--tg-theme-secondary-bg-color)] px-2 py-0.5 outline-none focus:outline-none"
+              >
+                <option value="">{t("arrangementDefault")}</option>
+                {song.arrangements.map((a) => (
+                  <option key={a.id} value={a.id}>
+                    {a.name}
+                  </option>
+                ))}
+              </select>
+            )}
+          </div>
+          <input
+            value={song.eventRoadMap ?? ""}
+            placeholder={defaultRoadMap || t("roadMapPlaceholder")}
+            onChange={(e) => onRoadMapChange?.(song, e.target.value)}
             onClick={(e) => e.stopPropagation()}
             onPointerDown={(e) => e.stopPropagation()}
             onTouchStart={(e) => e.stopPropagation()}
             onMouseDown={(e) => e.stopPropagation()}
-            className={`mr-2 w-16 cursor-pointer appearance-none rounded-lg bg-[length:12px] bg-[right_4px_center] bg-no-repeat py-0.5 pl-2 text-base font-semibold outline-none focus:outline-none ${
-              hasKeyOverride
-                ? "bg-[var(--tg-theme-secondary-bg-color)] bg-[url('data:image/svg+xml;charset=UTF-8,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%20viewBox%3D%220%200%2016%2016%22%20fill%3D%22white%22%3E%3Cpath%20fill-rule%3D%22evenodd%22%20d%3D%22M4.22%206.22a.75.75%200%200%201%201.06%200L8%208.94l2.72-2.72a.75.75%200%201%201%201.06%201.06l-3.25%203.25a.75.75%200%200%201-1.06%200L4.22%207.28a.75.75%200%200%201%200-1.06Z%22%20clip-rule%3D%22evenodd%22%2F%3E%3C%2Fsvg%3E')] text-[var(--tg-theme-text-color)]"
-                : "bg-[var(--tg-theme-secondary-bg-color)] bg-[url('data:image/svg+xml;charset=UTF-8,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%20viewBox%3D%220%200%2016%2016%22%20fill%3D%22white%22%3E%3Cpath%20fill-rule%3D%22evenodd%22%20d%3D%22M4.22%206.22a.75.75%200%200%201%201.06%200L8%208.94l2.72-2.72a.75.75%200%201%201%201.06%201.06l-3.25%203.25a.75.75%200%200%201-1.06%200L4.22%207.28a.75.75%200%200%201%200-1.06Z%22%20clip-rule%3D%22evenodd%22%2F%3E%3C%2Fsvg%3E')] text-[var(--tg-theme-hint-color)]"
-            }`}
-          >
-            {/* Show current value if not in valid keys */}
-            {!allValidKeys.includes(effectiveKey) && (
-              <option value={effectiveKey}>{effectiveKey}</option>
-            )}
-
-            {keyGroups
-              .filter((group) => group.id !== "nashville")
-              .map((group) => (
-                <optgroup key={group.id} label={group.label}>
-                  {group.options.map((option) => (
-                    <option key={option.value} value={option.value}>
-                      {option.label}
-                    </option>
-                  ))}
-                </optgroup>
-              ))}
-          </select>
-          <span>
-            {song.bpm || "?"}, {song.time || "?"}
-          </span>
+            onKeyDown={(e) => e.stopPropagation()}
+            className="mt-2 w-full rounded-lg bg-[var(--tg-theme-secondary-bg-color)] px-2 py-0.5 font-[family-name:var(--tgui--font-family)] text-[length:var(--tgui--subheadline2--font_size)] text-[var(--tg-theme-text-color)] outline-none placeholder:text-[var(--tg-theme-hint-color)] focus:outline-none"
+          />
         </div>
-      </div>
 
-      <IconButton
-        onClick={(e) => {
-          hapticFeedback.impactOccurred("light");
-          e.stopPropagation();
-          onRemove?.(song);
-        }}
-        mode="outline"
-        size="s"
-      >
-        <TrashIcon className="h-5 w-5 text-[var(--tg-theme-destructive-text-color)]" />
-      </IconButton>
-    </div>
-  );
-});
+        <IconButton
+          onClick={(e) => {
+            hapticFeedback.impactOccurred("light");
+            e.stopPropagation();
+            onRemove?.(song);
+          }}
+          mode="outline"
+          size="s"
+        >
+          <TrashIcon className="h-5 w-5 text-[var(--tg-theme-destructive-text-color)]" />
+        </IconButton>
+      </div>
+    );
+  },
+);
 
 export default SetlistSongDisplay;
 
@@ -100,9 +184,19 @@ interface SetlistSongProps {
   song: Song;
   onRemove: (id: Song) => void;
   onKeyChange?: (song: Song, newKey: string) => void;
+  onArrangementChange?: (song: Song, arrangementId: string) => void;
+  onRoadMapChange?: (song: Song, roadMap: string) => void;
+  keyProposal?: KeyProposal;
 }
 
-export function SetlistSong({ song, onRemove, onKeyChange }: SetlistSongProps) {
+export function SetlistSong({
+  song,
+  onRemove,
+  onKeyChange,
+  onArrangementChange,
+  onRoadMapChange,
+  keyProposal,
+}: SetlistSongProps) {
   const {
     attributes,
     listeners,
@@ -125,6 +219,9 @@ export function SetlistSong({ song, onRemove, onKeyChange }: SetlistSongProps) {
       song={song}
       onRemove={onRemove}
       onKeyChange={onKeyChange}
+      onArrangementChange={onArrangementChange}
+      onRoadMapChange={onRoadMapChange}
+      keyProposal={keyProposal}
       {...attributes}
       {...listeners}
     />
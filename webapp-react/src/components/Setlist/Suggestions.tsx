@@ -0,0 +1,102 @@
+import { getSongSuggestions } from "@/api/webapp/songs.ts";
+import {
+  SongSuggestion,
+  SongSuggestionReason,
+} from "@/api/webapp/typesResp.ts";
+import { Song } from "@/pages/EventPage/util/types.ts";
+import { PlusIcon } from "@heroicons/react/20/solid";
+import { useQuery } from "@tanstack/react-query";
+import { Cell, IconButton, Section } from "@telegram-apps/telegram-ui";
+import { useTranslation } from "react-i18next";
+
+interface SuggestionsProps {
+  bandId: string;
+  date: string; // YYYY-MM-DD or datetime-local value
+  songs: Song[];
+  onSelectSong: (song: Song) => void;
+}
+
+const SUGGESTIONS_LIMIT = 5;
+
+const reasonKeys: Record<SongSuggestionReason, string> = {
+  neverPlayed: "suggestionReasonNeverPlayed",
+  notPlayedLong: "suggestionReasonNotPlayedLong",
+  popular: "suggestionReasonPopular",
+  tagMatch: "suggestionReasonTagMatch",
+  keyFlow: "suggestionReasonKeyFlow",
+  tempoFlow: "suggestionReasonTempoFlow",
+};
+
+export function Suggestions({
+  bandId,
+  date,
+  songs,
+  onSelectSong,
+}: SuggestionsProps) {
+  const { t } = useTranslation();
+
+  const songIds = songs.map((song) => song.id).join(",");
+  const day = date.slice(0, 10);
+
+  const suggestionsQuery = useQuery({
+    queryKey: ["songSuggestions", bandId, songIds, day],
+    queryFn: async () => {
+      const data = await getSongSuggestions({
+        bandId,
+        songIds,
+        date: day || undefined,
+        limit: String(SUGGESTIONS_LIMIT),
+      });
+      if (!data) {
+        throw new Error("Failed to get song suggestions.");
+      }
+      return data;
+    },
+  });
+
+  const suggestions = suggestionsQuery.data?.suggestions ?? [];
+  if (suggestions.length === 0) {
+    return null;
+  }
+
+  const toSong = (suggestion: SongSuggestion): Song => ({
+    id: suggestion.song.id,
+    name: suggestion.song.pdf.name,
+    key: suggestion.song.pdf.key,
+    bpm: suggestion.song.pdf.bpm,
+    time: suggestion.song.pdf.time,
+    arrangements: suggestion.song.arrangements,
+    roadMap: suggestion.song.roadMap,
+  });
+
+  return (
+    <Section header={t("suggestions")} footer={t("suggestionsFooter")}>
+      {suggestions.map((suggestion) => (
+        <Cell
+          key={suggestion.song.id}
+          subtitle={[
+            [suggestion.song.pdf.key, suggestion.song.pdf.bpm]
+              .filter(Boolean)
+              .join(", "),
+            ...suggestion.reasons.map((reason) =>
+              t(reasonKeys[reason]),
+            ),
+          ]
+            .filter(Boolean)
+            .join(" · ")}
+          after={
+            <IconButton
+              mode="plain"
+              size="s"
+              onClick={() => onSelectSong(toSong(suggestion))}
+            >
+              <PlusIcon className="h-5 w-5 text-[var(--tg-theme-accent-text-color)]" />
+            </IconButton>
+          }
+        >
+          {suggestion.song.pdf.name}
+        </Cell>
+      ))}
+    </Section>
+  );
+}
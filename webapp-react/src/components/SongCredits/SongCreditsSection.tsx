@@ -0,0 +1,83 @@
+import {
+  isCcliNumberValid,
+  isDurationValid,
+} from "@/pages/SongPage/util/formValidation.ts";
+import { SongForm } from "@/pages/SongPage/util/types.ts";
+import { Cell, Input, Section, Switch } from "@telegram-apps/telegram-ui";
+import { useTranslation } from "react-i18next";
+
+interface SongCreditsSectionProps {
+  formData: SongForm;
+  onChange: (patch: Partial<SongForm>) => void;
+}
+
+export function SongCreditsSection({
+  formData,
+  onChange,
+}: SongCreditsSectionProps) {
+  const { t } = useTranslation();
+
+  return (
+    <Section header={t("songCredits")} footer={t("songCreditsHint")}>
+      <Input
+        placeholder={t("authorsPlaceholder")}
+        value={formData.authors}
+        onChange={(e) => onChange({ authors: e.target.value })}
+      />
+      <Input
+        placeholder={t("copyrightPlaceholder")}
+        value={formData.copyright}
+        onChange={(e) => onChange({ copyright: e.target.value })}
+      />
+      <div className="flex flex-row items-center gap-2">
+        <div className="flex-1">
+          <Input
+            placeholder={t("ccliNumberPlaceholder")}
+            value={formData.ccliNumber}
+            inputMode="numeric"
+            status={
+              !isCcliNumberValid(formData.ccliNumber) ? "error" : undefined
+            }
+            onChange={(e) => onChange({ ccliNumber: e.target.value })}
+          />
+        </div>
+        <div className="flex-1">
+          <Input
+            placeholder={t("languagePlaceholder")}
+            value={formData.language}
+            onChange={(e) => onChange({ language: e.target.value })}
+          />
+        </div>
+      </div>
+      <div className="flex flex-row items-center gap-2">
+        <div className="flex-1">
+          <Input
+            placeholder={t("durationPlaceholder")}
+            value={formData.duration}
+            status={!isDurationValid(formData.duration) ? "error" : undefined}
+            onChange={(e) => onChange({ duration: e.target.value })}
+          />
+        </div>
+        <div className="flex-1">
+          <Input
+            placeholder={t("originalKeyPlaceholder")}
+            value={formData.originalKey}
+            onChange={(e) => onChange({ originalKey: e.target.value })}
+          />
+        </div>
+      </div>
+      <Cell
+        Component="label"
+        multiline
+        after={
+          <Switch
+            checked={formData.writeCreditsToDoc}
+            onChange={(e) => onChange({ writeCreditsToDoc: e.target.checked })}
+          />
+        }
+      >
+        {t("writeCreditsToDoc")}
+      </Cell>
+    </Section>
+  );
+}
@@ -85,10 +85,15 @@ const CreateEventPage: FC = () => {
         timezone: bandTimezone,
         songIds: formData.setlist.map((song) => song.id),
         songOverrides: formData.setlist
-          .filter((song) => song.eventKey)
+          .filter(
+            (song) =>
+              song.eventKey || song.arrangementId || song.eventRoadMap,
+          )
           .map((song) => ({
             songId: song.id,
             eventKey: song.eventKey,
+            arrangementId: song.arrangementId,
+            roadMap: song.eventRoadMap,
           })),
         notes: formData.notes,
       }),
@@ -153,6 +158,8 @@ const CreateEventPage: FC = () => {
             <SetlistSection
               driveFolderId={driveFolderId}
               archiveFolderId={archiveFolderId}
+              bandId={bandId}
+              date={formData.date}
               songs={formData.setlist}
               onAddSong={(song) => {
                 setFormData((prev) => ({
@@ -185,6 +192,26 @@ const CreateEventPage: FC = () => {
                   ),
                 }));
               }}
+              onArrangementChange={(song, arrangementId) => {
+                setFormData((prev) => ({
+                  ...prev,
+                  setlist: prev.setlist.map((s) =>
+                    s.id === song.id
+                      ? { ...s, arrangementId: arrangementId || undefined }
+                      : s,
+                  ),
+                }));
+              }}
+              onRoadMapChange={(song, roadMap) => {
+                setFormData((prev) => ({
+                  ...prev,
+                  setlist: prev.setlist.map((s) =>
+                    s.id === song.id
+                      ? { ...s, eventRoadMap: roadMap || undefined }
+                      : s,
+                  ),
+                }));
+              }}
             />
 
             <AutosizeTextarea
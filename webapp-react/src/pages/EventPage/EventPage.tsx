@@ -1,6 +1,7 @@
 import {
   getEventData,
   getEventFreqNames,
+  getEventKeyProposals,
   updateEvent,
 } from "@/api/webapp/events.ts";
 import { ReqBodyUpdateEvent } from "@/api/webapp/typesReq.ts";
@@ -17,7 +18,11 @@ import {
 import { getLocalDateTimeString } from "@/pages/EventPage/util/helpers.ts";
 import { EventForm, Song } from "@/pages/EventPage/util/types.ts";
 import { CalendarIcon } from "@heroicons/react/20/solid";
-import { useMutation, useSuspenseQuery } from "@tanstack/react-query";
+import {
+  useMutation,
+  useQuery,
+  useSuspenseQuery,
+} from "@tanstack/react-query";
 import {
   IconButton,
   Input,
@@ -123,11 +128,10 @@ const EventPage: FC = () => {
     //   bandTimezone,
     // ).split("T")[0],
     setlist: queryEventRes.data.event.songs.map((song) => {
-      // Get eventKey from songOverrides if available
-      const songOverrides = queryEventRes.data.event.songOverrides;
-      const eventKey = songOverrides?.find(
+      // Get eventKey and arrangement from songOverrides if available
+      const songOverride = queryEventRes.data.event.songOverrides?.find(
         (o) => o.songId === song.id,
-      )?.eventKey;
+      );
 
       const s: Song = {
         id: song.id,
@@ -135,7 +139,11 @@ const EventPage: FC = () => {
         key: song.pdf.key,
         bpm: song.pdf.bpm,
         time: song.pdf.time,
-        eventKey: eventKey, // Populate from songOverrides
+        eventKey: songOverride?.eventKey || undefined, // Populate from songOverrides
+        arrangements: song.arrangements,
+        arrangementId: songOverride?.arrangementId,
+        roadMap: song.roadMap,
+        eventRoadMap: songOverride?.roadMap,
       };
       return s;
     }),
@@ -147,6 +155,55 @@ const EventPage: FC = () => {
 
   const [formData, setFormData] = useState<EventForm>(initFormData);
 
+  // Keys preferred by singers of the event.
+  const setlistSongIds = formData.setlist.map((song) => song.id);
+  const queryKeyProposalsRes = useQuery({
+    queryKey: ["eventKeyProposals", eventId, setlistSongIds],
+    queryFn: async () => {
+      const data = await getEventKeyProposals(eventId, setlistSongIds);
+      if (!data) {
+        throw new Error("Failed to get key proposals");
+      }
+      return data;
+    },
+    enabled: setlistSongIds.length > 0,
+  });
+
+  const handleAddSong = useCallback(
+    async (song: Song) => {
+      setFormData((prev) => ({
+        ...prev,
+        setlist: [...prev.setlist, song],
+      }));
+
+      // Propose the key of the singer for the new song.
+      try {
+        const data = await getEventKeyProposals(eventId, [song.id]);
+        const proposal = data?.proposals[0];
+        if (!proposal || proposal.key === song.key) {
+          return;
+        }
+        setFormData((prev) => ({
+          ...prev,
+          setlist: prev.setlist.map((s) =>
+            s.id === song.id && !s.eventKey
+              ? { ...s, eventKey: proposal.key }
+              : s,
+          ),
+        }));
+        Notify.info(
+          t("keyProposalApplied", {
+            name: proposal.userName,
+            key: proposal.key,
+          }),
+        );
+      } catch (err) {
+        logger.error("Failed to get key proposals", { error: err });
+      }
+    },
+    [eventId, t],
+  );
+
   useEffect(() => {
     postEvent("web_app_expand");
     postEvent("web_app_setup_swipe_behavior", { allow_vertical_swipe: false });
@@ -174,10 +231,14 @@ const EventPage: FC = () => {
       timezone: bandTimezone,
       songIds: formData.setlist.map((song) => song.id),
       songOverrides: formData.setlist
-        .filter((song) => song.eventKey)
+        .filter(
+          (song) => song.eventKey || song.arrangementId || song.eventRoadMap,
+        )
         .map((song) => ({
           songId: song.id,
-          eventKey: song.eventKey!,
+          eventKey: song.eventKey,
+          arrangementId: song.arrangementId,
+          roadMap: song.eventRoadMap,
         })),
       notes: formData.notes,
       messageId: messageId,
@@ -271,13 +332,11 @@ const EventPage: FC = () => {
             <SetlistSection
               driveFolderId={queryEventRes.data.event.band.driveFolderId}
               archiveFolderId={queryEventRes.data.event.band.archiveFolderId}
+              bandId={queryEventRes.data.event.bandId}
+              date={formData.date}
               songs={formData.setlist}
-              onAddSong={(song) => {
-                setFormData((prev) => ({
-                  ...prev,
-                  setlist: [...prev.setlist, song],
-                }));
-              }}
+              keyProposals={queryKeyProposalsRes.data?.proposals}
+              onAddSong={handleAddSong}
               onRemove={(songToRemove) => {
                 setFormData((prev) => ({
                   ...prev,
@@ -303,6 +362,26 @@ const EventPage: FC = () => {
                   ),
                 }));
               }}
+              onArrangementChange={(song, arrangementId) => {
+                setFormData((prev) => ({
+                  ...prev,
+                  setlist: prev.setlist.map((s) =>
+                    s.id === song.id
+                      ? { ...s, arrangementId: arrangementId || undefined }
+                      : s,
+                  ),
+                }));
+              }}
+              onRoadMapChange={(song, roadMap) => {
+                setFormData((prev) => ({
+                  ...prev,
+                  setlist: prev.setlist.map((s) =>
+                    s.id === song.id
+                      ? { ...s, eventRoadMap: roadMap || undefined }
+                      : s,
+                  ),
+                }));
+              }}
             />
 
             <AutosizeTextarea
@@ -5,6 +5,8 @@ export interface EventForm {
   notes: string;
 }
 
+import { SongArrangement } from "@/api/webapp/typesResp.ts";
+
 export interface Song {
   id: string;
   name: string;
@@ -12,4 +14,8 @@ export interface Song {
   bpm: string;
   time: string;
   eventKey?: string; // Key override for this event (if different from original)
+  arrangements?: SongArrangement[];
+  arrangementId?: string; // Arrangement chosen for this event
+  roadMap?: string; // Default order of sections
+  eventRoadMap?: string; // Order of sections for this event
 }
@@ -5,7 +5,12 @@ import {
   updateSettingsBand,
   updateSettingsBandMember,
 } from "@/api/webapp/settings.ts";
-import { SettingsBand, SettingsMember } from "@/api/webapp/typesResp.ts";
+import {
+  DriveStyleProfile,
+  SettingsBand,
+  SettingsMember,
+  SlideRules,
+} from "@/api/webapp/typesResp.ts";
 import { ContextMenu } from "@/components/ContextMenu.tsx";
 import { DriveAccessNotice } from "@/components/DriveAccessNotice.tsx";
 import { Page } from "@/components/Page.tsx";
@@ -14,6 +19,14 @@ import {
   SettingsBandForm,
   SettingsBandFormState,
 } from "@/pages/SettingsPage/SettingsBandForm.tsx";
+import {
+  Field,
+  Input,
+  Label,
+  Select,
+  Switch,
+  Textarea,
+} from "@headlessui/react";
 import {
   useMutation,
   useQuery,
@@ -22,7 +35,7 @@ import {
 } from "@tanstack/react-query";
 import { hapticFeedback, postEvent } from "@tma.js/sdk-react";
 import type { TFunction } from "i18next";
-import { FC, ReactNode, useEffect, useMemo } from "react";
+import { FC, ReactNode, useEffect, useMemo, useState } from "react";
 import { ThreeDots } from "react-bootstrap-icons";
 import { useTranslation } from "react-i18next";
 import { useParams } from "react-router";
@@ -140,12 +153,224 @@ const BandSettingsPageContent: FC<{ bandId: string }> = ({ bandId }) => {
         </SectionBlock>
         <DriveAccessNotice />
 
+        <SettingsSlideRulesSection key={`slides-${band.id}`} band={band} />
+
+        <SettingsDriveStyleSection key={`style-${band.id}`} band={band} />
+
         <SettingsMembersSection band={band} />
       </main>
     </Page>
   );
 };
 
+// maxSlideLines must match entity.MaxSlideMaxLines.
+const maxSlideLines = 12;
+
+function SettingsSlideRulesSection({ band }: { band: SettingsBand }) {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+  const [rules, setRules] = useState<SlideRules>(band.slideRules);
+
+  const slideRulesMutation = useMutation({
+    mutationFn: (slideRules: SlideRules) =>
+      updateSettingsBand(band.id, { slideRules }),
+    onSuccess: async () => {
+      hapticFeedback.notificationOccurred("success");
+      await Promise.all([
+        queryClient.invalidateQueries({ queryKey: ["settings", "me"] }),
+        queryClient.invalidateQueries({ queryKey: ["settings", "bands"] }),
+      ]);
+    },
+    onError: (err: any) => {
+      hapticFeedback.notificationOccurred("error");
+      tgAlert(err?.message || t("settingsUpdateBandError"));
+    },
+  });
+
+  const isDirty =
+    rules.maxLines !== band.slideRules.maxLines ||
+    rules.ignoreBlankLines !== band.slideRules.ignoreBlankLines ||
+    rules.uppercase !== band.slideRules.uppercase;
+
+  const isValid =
+    Number.isInteger(rules.maxLines) &&
+    rules.maxLines >= 1 &&
+    rules.maxLines <= maxSlideLines;
+
+  return (
+    <SectionBlock title={t("settingsSlides")}>
+      <div className="grid gap-3 rounded-2xl bg-[var(--tg-theme-section-bg-color,#ffffff)] p-4">
+        <div className="text-sm text-[var(--tg-theme-hint-color,#8e8e93)]">
+          {t("settingsSlidesDescription")}
+        </div>
+        <Field className="grid gap-1.5">
+          <Label className="px-1 text-sm font-semibold text-[var(--tg-theme-hint-color,#8e8e93)]">
+            {t("settingsSlidesMaxLines", { max: maxSlideLines })}
+          </Label>
+          <Input
+            type="number"
+            inputMode="numeric"
+            min={1}
+            max={maxSlideLines}
+            value={Number.isNaN(rules.maxLines) ? "" : rules.maxLines}
+            className="h-12 w-full rounded-xl border border-black/[0.06] bg-[var(--tg-theme-section-bg-color,#ffffff)] px-4 text-base text-[var(--tg-theme-text-color,#000000)] outline-none focus:border-[var(--tg-theme-link-color,#2481cc)]"
+            onChange={(e) =>
+              setRules((current) => ({
+                ...current,
+                maxLines: parseInt(e.target.value, 10),
+              }))
+            }
+          />
+        </Field>
+        <SlideRuleSwitch
+          label={t("settingsSlidesIgnoreBlankLines")}
+          checked={rules.ignoreBlankLines}
+          onChange={(ignoreBlankLines) =>
+            setRules((current) => ({ ...current, ignoreBlankLines }))
+          }
+        />
+        <SlideRuleSwitch
+          label={t("settingsSlidesUppercase")}
+          checked={rules.uppercase}
+          onChange={(uppercase) =>
+            setRules((current) => ({ ...current, uppercase }))
+          }
+        />
+        {isDirty && (
+          <button
+            type="button"
+            disabled={!isValid || slideRulesMutation.isPending}
+            className="h-12 w-full rounded-xl bg-[var(--tg-theme-button-color,#2481cc)] px-4 text-base font-semibold text-[var(--tg-theme-button-text-color,#ffffff)] active:opacity-75 disabled:opacity-60"
+            onClick={() => slideRulesMutation.mutate(rules)}
+          >
+            {slideRulesMutation.isPending ? "..." : t("settingsSave")}
+          </button>
+        )}
+      </div>
+    </SectionBlock>
+  );
+}
+
+// driveStylePresets must match service.DriveStylePresets.
+const driveStylePresets = ["large_print", "compact", "dark_chords"];
+
+// maxDriveStyleYAMLLength must match service.MaxDriveStyleYAMLLength.
+const maxDriveStyleYAMLLength = 4096;
+
+function SettingsDriveStyleSection({ band }: { band: SettingsBand }) {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+  const [driveStyle, setDriveStyle] = useState<DriveStyleProfile>(
+    band.driveStyle,
+  );
+
+  const driveStyleMutation = useMutation({
+    mutationFn: (driveStyle: DriveStyleProfile) =>
+      updateSettingsBand(band.id, { driveStyle }),
+    onSuccess: async () => {
+      hapticFeedback.notificationOccurred("success");
+      await Promise.all([
+        queryClient.invalidateQueries({ queryKey: ["settings", "me"] }),
+        queryClient.invalidateQueries({ queryKey: ["settings", "bands"] }),
+      ]);
+    },
+    onError: (err: any) => {
+      hapticFeedback.notificationOccurred("error");
+      tgAlert(err?.message || t("settingsUpdateBandError"));
+    },
+  });
+
+  const isDirty =
+    driveStyle.preset !== band.driveStyle.preset ||
+    driveStyle.yaml !== band.driveStyle.yaml;
+
+  return (
+    <SectionBlock title={t("settingsDriveStyle")}>
+      <div className="grid gap-3 rounded-2xl bg-[var(--tg-theme-section-bg-color,#ffffff)] p-4">
+        <div className="text-sm text-[var(--tg-theme-hint-color,#8e8e93)]">
+          {t("settingsDriveStyleDescription")}
+        </div>
+        <Field className="grid gap-1.5">
+          <Label className="px-1 text-sm font-semibold text-[var(--tg-theme-hint-color,#8e8e93)]">
+            {t("settingsDriveStylePreset")}
+          </Label>
+          <Select
+            value={driveStyle.preset}
+            className="h-12 w-full rounded-xl border border-black/[0.06] bg-[var(--tg-theme-section-bg-color,#ffffff)] px-4 text-base text-[var(--tg-theme-text-color,#000000)] outline-none focus:border-[var(--tg-theme-link-color,#2481cc)]"
+            onChange={(e) =>
+              setDriveStyle((current) => ({
+                ...current,
+                preset: e.target.value,
+              }))
+            }
+          >
+            <option value="">{t("settingsDriveStylePresetNone")}</option>
+            {driveStylePresets.map((preset) => (
+              <option key={preset} value={preset}>
+                {t(`settingsDriveStylePreset_${preset}`)}
+              </option>
+            ))}
+          </Select>
+        </Field>
+        <Field className="grid gap-1.5">
+          <Label className="px-1 text-sm font-semibold text-[var(--tg-theme-hint-color,#8e8e93)]">
+            {t("settingsDriveStyleYAML")}
+          </Label>
+          <Textarea
+            rows={6}
+            maxLength={maxDriveStyleYAMLLength}
+            spellCheck={false}
+            value={driveStyle.yaml}
+            placeholder={"chords:\n  primary_color: \"#CC0000\""}
+            className="w-full rounded-xl border border-black/[0.06] bg-[var(--tg-theme-section-bg-color,#ffffff)] px-4 py-3 font-mono text-sm text-[var(--tg-theme-text-color,#000000)] outline-none focus:border-[var(--tg-theme-link-color,#2481cc)]"
+            onChange={(e) =>
+              setDriveStyle((current) => ({
+                ...current,
+                yaml: e.target.value,
+              }))
+            }
+          />
+        </Field>
+        {isDirty && (
+          <button
+            type="button"
+            disabled={driveStyleMutation.isPending}
+            className="h-12 w-full rounded-xl bg-[var(--tg-theme-button-color,#2481cc)] px-4 text-base font-semibold text-[var(--tg-theme-button-text-color,#ffffff)] active:opacity-75 disabled:opacity-60"
+            onClick={() => driveStyleMutation.mutate(driveStyle)}
+          >
+            {driveStyleMutation.isPending ? "..." : t("settingsSave")}
+          </button>
+        )}
+      </div>
+    </SectionBlock>
+  );
+}
+
+function SlideRuleSwitch({
+  label,
+  checked,
+  onChange,
+}: {
+  label: string;
+  checked: boolean;
+  onChange: (checked: boolean) => void;
+}) {
+  return (
+    <Field className="flex items-center justify-between gap-3 px-1">
+      <Label className="text-base text-[var(--tg-theme-text-color,#000000)]">
+        {label}
+      </Label>
+      <Switch
+        checked={checked}
+        onChange={onChange}
+        className="group relative inline-flex h-7 w-12 shrink-0 items-center rounded-full bg-black/[0.12] transition data-checked:bg-[var(--tg-theme-button-color,#2481cc)]"
+      >
+        <span className="inline-block h-5 w-5 translate-x-1 rounded-full bg-white transition group-data-checked:translate-x-6" />
+      </Switch>
+    </Field>
+  );
+}
+
 function SettingsMembersSection({ band }: { band: SettingsBand }) {
   const { t } = useTranslation();
   const queryClient = useQueryClient();
@@ -86,12 +86,8 @@ const SongConfirmationPage: FC = () => {
 
     await mutateSong(
       {
+        ...st.formData,
         songId: songId,
-        name: st.formData.name,
-        key: st.formData.key,
-        bpm: st.formData.bpm,
-        time: st.formData.time,
-        tags: st.formData.tags,
         transposeSection: sectionNumber,
         messageId: messageId,
         chatId: chatId,
@@ -119,11 +115,7 @@ const SongConfirmationPage: FC = () => {
   }, [
     mutateSong,
     songId,
-    st.formData.name,
-    st.formData.key,
-    st.formData.bpm,
-    st.formData.time,
-    st.formData.tags,
+    st.formData,
     sectionNumber,
     messageId,
     chatId,
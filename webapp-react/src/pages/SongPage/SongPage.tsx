@@ -1,17 +1,24 @@
 import {
   formatSong,
+  getSongChartView,
   getSongData,
   getSongLyrics,
   updateSong,
 } from "@/api/webapp/songs.ts";
 import { ReqBodyUpdateSong } from "@/api/webapp/typesReq.ts";
+import { ArrangementsSection } from "@/components/Arrangements/ArrangementsSection.tsx";
 import { BPMInput, formatBpm } from "@/components/BPMInput/BPMInput.tsx";
+import {
+  ChartViewSelect,
+  chartViewQueryParams,
+} from "@/components/ChartViewSelect/ChartViewSelect.tsx";
 import {
   EditableTitle,
   formatTitle,
 } from "@/components/EditableTitle/EditableTitle.tsx";
 import { formatKey, KeyInput } from "@/components/KeyInput/KeyInput.tsx";
 import { Page } from "@/components/Page.tsx";
+import { SongCreditsSection } from "@/components/SongCredits/SongCreditsSection.tsx";
 import { TagsInput } from "@/components/TagsInput/TagsInput.tsx";
 import {
   formatTimeSignature,
@@ -20,11 +27,14 @@ import {
 import { logger } from "@/helpers/logger";
 import { setMainButton } from "@/helpers/mainButton.ts";
 import {
+  formatDuration,
   isBpmValid,
   isFormChanged,
   isFormValid,
   isNameValid,
   isTimeSignatureValid,
+  parseDuration,
+  splitAuthors,
 } from "@/pages/SongPage/util/formValidation.ts";
 import { transposeAllText } from "@/pages/SongPage/util/transpose.ts";
 import { SongForm, StateSongData } from "@/pages/SongPage/util/types.ts";
@@ -39,13 +49,8 @@ import { FileEarmarkTextFill, Magic } from "react-bootstrap-icons";
 import { useTranslation } from "react-i18next";
 import { useNavigate, useParams, useSearchParams } from "react-router";
 
-interface SongMutationData {
+interface SongMutationData extends SongForm {
   songId: string;
-  name: string;
-  key: string;
-  bpm: string;
-  time: string;
-  tags: string[];
   transposeSection?: string;
   messageId: string;
   chatId: string;
@@ -68,6 +73,13 @@ export function useSongMutation() {
         time: d.time || "?",
         tags: d.tags,
         transposeSection: d.transposeSection,
+        authors: splitAuthors(d.authors),
+        copyright: d.copyright,
+        ccliNumber: d.ccliNumber,
+        language: d.language,
+        durationSec: parseDuration(d.duration),
+        originalKey: d.originalKey,
+        writeCreditsToDoc: d.writeCreditsToDoc,
       };
 
       return await updateSong(d.songId, queryParams, body);
@@ -76,6 +88,16 @@ export function useSongMutation() {
   return mutateSong;
 }
 
+const emptySongCredits = {
+  authors: "",
+  copyright: "",
+  ccliNumber: "",
+  language: "",
+  duration: "",
+  originalKey: "",
+  writeCreditsToDoc: false,
+};
+
 const SongPage: FC = () => {
   const { t } = useTranslation();
 
@@ -94,6 +116,7 @@ const SongPage: FC = () => {
     bpm: "",
     time: "",
     tags: [],
+    ...emptySongCredits,
   });
 
   // Form data state.
@@ -103,10 +126,13 @@ const SongPage: FC = () => {
     bpm: "",
     time: "",
     tags: [],
+    ...emptySongCredits,
   });
 
   const [transposedLyricsHtml, setTransposedLyricsHtml] = useState<string>("");
   const [transpositionError, setTranspositionError] = useState<boolean>(false);
+  // Chart view ("" for the doc as is, capo fret or "nashville") is rendered by the server and never saved.
+  const [chartView, setChartView] = useState<string>("");
   const formDataRef = useRef(formData);
   const initialFormDataRef = useRef(initialFormData);
   const appliedLyricsMetadataAtRef = useRef<number>(0);
@@ -143,6 +169,21 @@ const SongPage: FC = () => {
       return data;
     },
   });
+  const querySongChartViewRes = useQuery({
+    queryKey: ["songChartView", songId, chartView],
+    queryFn: async () => {
+      const data = await getSongChartView(
+        songId,
+        chartViewQueryParams(chartView),
+      );
+      if (!data) {
+        throw new Error("Song chart view is empty.");
+      }
+      return data;
+    },
+    enabled: chartView !== "",
+  });
+
   const { refetch: refetchSongData } = querySongDataRes;
   const { refetch: refetchSongLyrics } = querySongLyricsRes;
 
@@ -184,6 +225,13 @@ const SongPage: FC = () => {
       bpm: formatBpm(songData.song.pdf.bpm),
       time: formatTimeSignature(songData.song.pdf.time),
       tags: songData.song.tags || [],
+      authors: (songData.song.authors || []).join(", "),
+      copyright: songData.song.copyright || "",
+      ccliNumber: songData.song.ccliNumber || "",
+      language: songData.song.language || "",
+      duration: formatDuration(songData.song.durationSec),
+      originalKey: songData.song.originalKey || "",
+      writeCreditsToDoc: false,
     };
 
     setInitialFormData(initFormData);
@@ -212,11 +260,11 @@ const SongPage: FC = () => {
 
     const refreshedMetadata = querySongLyricsRes.data.metadata;
     const refreshedInitFormData: SongForm = {
+      ...initialFormDataRef.current,
       name: formatTitle(refreshedMetadata.name),
       key: formatKey(refreshedMetadata.key),
       bpm: formatBpm(refreshedMetadata.bpm),
       time: formatTimeSignature(refreshedMetadata.time),
-      tags: initialFormDataRef.current.tags || [],
     };
 
     setInitialFormData(refreshedInitFormData);
@@ -242,6 +290,7 @@ const SongPage: FC = () => {
 
   const handleKeyChange = (newKey: string) => {
     setFormData((prev: SongForm) => ({ ...prev, key: newKey }));
+    setChartView("");
 
     if (!querySongLyricsRes.isSuccess) {
       return;
@@ -292,12 +341,8 @@ const SongPage: FC = () => {
     }
     await mutateSong(
       {
+        ...formData,
         songId: songId,
-        name: formData.name,
-        key: formData.key,
-        bpm: formData.bpm,
-        time: formData.time,
-        tags: formData.tags,
         transposeSection: undefined,
         messageId: messageId,
         chatId: chatId,
@@ -519,7 +564,27 @@ const SongPage: FC = () => {
           </div>
         </div>
 
-        <Section className={"sect"}>
+        <SongCreditsSection
+          formData={formData}
+          onChange={(patch) => setFormData((prev) => ({ ...prev, ...patch }))}
+        />
+
+        <ArrangementsSection
+          songId={songId}
+          arrangements={querySongDataRes.data.song.arrangements ?? []}
+          onChange={() => querySongDataRes.refetch()}
+        />
+
+        <ChartViewSelect
+          value={chartView}
+          disabled={
+            formData.key !== initialFormData.key ||
+            !querySongLyricsRes.isSuccess
+          }
+          onChange={setChartView}
+        />
+
+        <Section className={"sect"} footer={chartView && t("chartViewHint")}>
           <Text>
             <div className="p-4 font-mono text-base/6 whitespace-pre-wrap">
               {
@@ -531,6 +596,16 @@ const SongPage: FC = () => {
                   <>{t("errorLoadingLyrics")}</>
                 ) : transpositionError ? (
                   <>{t("errorTransposingLyrics")}</>
+                ) : chartView && querySongChartViewRes.isLoading ? (
+                  <>{t("loadingLyrics")}</>
+                ) : chartView && querySongChartViewRes.isError ? (
+                  <>{t("errorLoadingChartView")}</>
+                ) : chartView && querySongChartViewRes.data ? (
+                  <div
+                    dangerouslySetInnerHTML={{
+                      __html: querySongChartViewRes.data.lyricsHtml,
+                    }}
+                  />
                 ) : (
                   <div
                     // className={e("lyrics")}
@@ -1,6 +1,13 @@
 import { describe, expect, it } from "vitest";
 
-import { isBpmValid } from "./formValidation";
+import {
+  formatDuration,
+  isBpmValid,
+  isDurationValid,
+  isFormChanged,
+  parseDuration,
+} from "./formValidation";
+import { SongForm } from "./types";
 
 describe("isBpmValid", () => {
   it("should return true for empty string", () => {
@@ -55,3 +62,47 @@ describe("isBpmValid", () => {
     expect(isBpmValid("70.")).toBe(false);
   });
 });
+
+describe("duration", () => {
+  it("should accept empty and m:ss durations", () => {
+    expect(isDurationValid("")).toBe(true);
+    expect(isDurationValid("4:05")).toBe(true);
+    expect(isDurationValid("4:5")).toBe(false);
+    expect(isDurationValid("4:65")).toBe(false);
+  });
+
+  it("should format and parse seconds", () => {
+    expect(formatDuration(245)).toBe("4:05");
+    expect(formatDuration(0)).toBe("");
+    expect(parseDuration("4:05")).toBe(245);
+    expect(parseDuration("")).toBe(0);
+  });
+});
+
+describe("isFormChanged", () => {
+  const form: SongForm = {
+    name: "Song",
+    key: "C",
+    bpm: "70",
+    time: "4/4",
+    tags: ["a", "b"],
+    authors: "",
+    copyright: "",
+    ccliNumber: "",
+    language: "",
+    duration: "",
+    originalKey: "",
+    writeCreditsToDoc: false,
+  };
+
+  it("should ignore tags order", () => {
+    expect(isFormChanged({ ...form, tags: ["b", "a"] }, form)).toBe(false);
+  });
+
+  it("should detect changed credits", () => {
+    expect(isFormChanged({ ...form, ccliNumber: "123" }, form)).toBe(true);
+    expect(isFormChanged({ ...form, writeCreditsToDoc: true }, form)).toBe(
+      true,
+    );
+  });
+});
@@ -20,6 +20,37 @@ export const isTimeSignatureValid = (time: string): boolean => {
   return /^\d{1,2}\/\d{1,2}$/.test(time);
 };
 
+export const isCcliNumberValid = (ccliNumber: string): boolean => {
+  if (ccliNumber.length === 0) return true; // Empty is considered valid (optional field)
+  return /^\d{1,10}$/.test(ccliNumber.trim());
+};
+
+export const isDurationValid = (duration: string): boolean => {
+  if (duration.length === 0) return true; // Empty is considered valid (optional field)
+  return /^\d{1,3}:[0-5]\d$/.test(duration.trim());
+};
+
+// Formats seconds as m:ss, empty for unknown duration.
+export const formatDuration = (durationSec?: number): string => {
+  if (!durationSec || durationSec <= 0) return "";
+  const seconds = durationSec % 60;
+  return `${Math.floor(durationSec / 60)}:${seconds.toString().padStart(2, "0")}`;
+};
+
+// Parses m:ss into seconds, 0 for empty duration.
+export const parseDuration = (duration: string): number => {
+  const [minutes, seconds] = duration.trim().split(":");
+  if (!minutes || !seconds) return 0;
+  return parseInt(minutes, 10) * 60 + parseInt(seconds, 10);
+};
+
+export const splitAuthors = (authors: string): string[] => {
+  return authors
+    .split(",")
+    .map((author) => author.trim())
+    .filter(Boolean);
+};
+
 export function isFormChanged(
   formData: SongForm,
   initialFormData: SongForm,
@@ -56,6 +87,8 @@ export function isFormValid(
     isNameValid(formData.name) &&
     isBpmValid(formData.bpm) &&
     isTimeSignatureValid(formData.time) &&
+    isCcliNumberValid(formData.ccliNumber) &&
+    isDurationValid(formData.duration) &&
     !transpositionError
   );
 }
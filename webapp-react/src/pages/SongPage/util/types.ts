@@ -4,6 +4,13 @@ export interface SongForm {
   bpm: string;
   time: string;
   tags: string[];
+  authors: string; // Comma separated.
+  copyright: string;
+  ccliNumber: string;
+  language: string;
+  duration: string; // m:ss.
+  originalKey: string;
+  writeCreditsToDoc: boolean;
 }
 
 export interface StateSongData {
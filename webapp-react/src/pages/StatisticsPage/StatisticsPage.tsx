@@ -1,3 +1,4 @@
+import { getSongUsageReportUrl } from "@/api/webapp/songs.ts";
 import { getStatistics } from "@/api/webapp/statistics.ts";
 import { StatisticsRole } from "@/api/webapp/typesResp.ts";
 import { Page } from "@/components/Page.tsx";
@@ -16,7 +17,7 @@ import {
   Section,
   Spinner,
 } from "@telegram-apps/telegram-ui";
-import { hapticFeedback, postEvent } from "@tma.js/sdk-react";
+import { hapticFeedback, openLink, postEvent } from "@tma.js/sdk-react";
 import {
   FC,
   ReactNode,
@@ -378,6 +379,43 @@ const StatisticsPage: FC = () => {
                     </p>
                   </div>
                 </div>
+
+                {query.data.audioCache && query.data.audioCache.entries > 0 && (
+                  <div className="grid grid-cols-3 gap-3 border-t border-[color:var(--tg-theme-section-separator-color)] pt-3">
+                    <div className="flex h-full flex-col justify-between gap-2">
+                      <p className="text-xs font-semibold tracking-[0.08em] text-[var(--tg-theme-hint-color)] uppercase">
+                        {t("statisticsAudioCacheEntries")}
+                      </p>
+                      <p className="font-roboto-mono text-xl leading-none">
+                        {query.data.audioCache.entries}
+                      </p>
+                    </div>
+
+                    <div className="flex h-full flex-col justify-between gap-2 border-l border-[color:var(--tg-theme-section-separator-color)] pl-3">
+                      <p className="text-xs font-semibold tracking-[0.08em] text-[var(--tg-theme-hint-color)] uppercase">
+                        {t("statisticsAudioCacheHits")}
+                      </p>
+                      <p className="font-roboto-mono text-xl leading-none">
+                        {query.data.audioCache.hits}
+                      </p>
+                    </div>
+
+                    <div className="flex h-full flex-col justify-between gap-2 border-l border-[color:var(--tg-theme-section-separator-color)] pl-3">
+                      <p className="text-xs font-semibold tracking-[0.08em] text-[var(--tg-theme-hint-color)] uppercase">
+                        {t("statisticsAudioCacheSize")}
+                      </p>
+                      <p className="font-roboto-mono text-xl leading-none">
+                        {t("statisticsAudioCacheSizeValue", {
+                          size: (
+                            query.data.audioCache.size /
+                            1024 /
+                            1024
+                          ).toFixed(1),
+                        })}
+                      </p>
+                    </div>
+                  </div>
+                )}
               </div>
             </Section>
 
@@ -490,8 +528,20 @@ const StatisticsPage: FC = () => {
                   </Accordion>
                 </div>
 
-                {hasActiveFilters ? (
-                  <div className="flex justify-start md:justify-end">
+                <div className="flex flex-wrap justify-start gap-2 md:justify-end">
+                  <Button
+                    mode="outline"
+                    size="s"
+                    onClick={() => {
+                      hapticFeedback.impactOccurred("light");
+                      openLink(
+                        getSongUsageReportUrl(bandId, effectiveFromDate),
+                      );
+                    }}
+                  >
+                    {t("statisticsSongUsageReport")}
+                  </Button>
+                  {hasActiveFilters ? (
                     <Button
                       mode="outline"
                       size="s"
@@ -507,8 +557,8 @@ const StatisticsPage: FC = () => {
                     >
                       {t("statisticsClearFilters")}
                     </Button>
-                  </div>
-                ) : null}
+                  ) : null}
+                </div>
               </div>
             </Section>
 